@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/Southclaws/samp-servers-api/server"
 	"github.com/Southclaws/samp-servers-api/types"
 )
@@ -14,23 +16,24 @@ var app *server.App
 
 func TestMain(m *testing.M) {
 	config := types.Config{
-		Bind:            "localhost:8080",
-		MongoHost:       "localhost",
-		MongoPort:       "27017",
-		MongoName:       "samplist",
-		MongoUser:       "root",
-		MongoPass:       "",
-		MongoCollection: "servers",
-		QueryInterval:   time.Hour, // don't query during tests
-		MaxFailedQuery:  0,
-		VerifyByHost:    false,
-		LegacyList:      false,
+		Bind:             "localhost:8080",
+		MongoHost:        "localhost",
+		MongoPort:        "27017",
+		MongoName:        "samplist",
+		MongoUser:        "root",
+		MongoPass:        "",
+		MongoCollection:  "servers",
+		QueryInterval:    time.Hour, // don't query during tests
+		MaxFailedQuery:   0,
+		VerifyByHost:     false,
+		SkipVerifyPosted: true, // the servers posted in tests don't exist
+		LegacyList:       false,
 	}
 
 	fmt.Println("initialising announce-backend testing mode", config)
 
 	var err error
-	app, err = server.Initialise(config)
+	app, err = server.Initialise(config, prometheus.NewRegistry())
 	if err != nil {
 		panic(err)
 	}
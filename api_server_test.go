@@ -137,13 +137,16 @@ func TestAPI_ServerGet(t *testing.T) {
 	}{
 		{"valid", args{"ss.southcla.ws"}, types.Server{
 			Core: types.ServerCore{
-				Address:    "ss.southcla.ws",
+				Address:    "ss.southcla.ws:7777",
 				Hostname:   "Scavenge and Survive Official",
 				Players:    4,
 				MaxPlayers: 32,
 				Gamemode:   "Scavenge & Survive by Southclaws",
 				Language:   "English",
 				Password:   false,
+
+				HostnameClean: "Scavenge and Survive Official",
+				Languages:     []string{"English"},
 			},
 			Rules:       map[string]string{"mapname": "San Androcalypse"},
 			Description: "Scavenge and Survive is a very fun server!",
@@ -161,3 +164,79 @@ func TestAPI_ServerGet(t *testing.T) {
 		})
 	}
 }
+
+func TestAPI_ServerDelete(t *testing.T) {
+	server := types.Server{
+		Core: types.ServerCore{
+			Address:    "s5.example.com",
+			Hostname:   "test server 5",
+			Players:    1,
+			MaxPlayers: 10,
+			Gamemode:   "Grand Larceny",
+			Language:   "English",
+		},
+	}
+	resp, err := resty.SetDebug(false).R().SetBody(server).Post("http://localhost:8080/v2/server")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode())
+
+	tests := []struct {
+		name       string
+		address    string
+		wantStatus int
+	}{
+		{"valid", "s5.example.com", 204},
+		{"not found", "s5.example.com", 404},
+		{"invalid", "http://s5.example.com", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := resty.SetDebug(false).R().Delete(fmt.Sprintf("http://localhost:8080/v2/server/%s", tt.address))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode())
+		})
+	}
+}
+
+func TestAPI_ServerPostInvalid(t *testing.T) {
+	server := types.Server{
+		Core: types.ServerCore{
+			Address:    "s6.example.com",
+			Hostname:   "",
+			Players:    1,
+			MaxPlayers: 10,
+			Gamemode:   "Grand Larceny",
+			Language:   "English",
+		},
+	}
+	resp, err := resty.SetDebug(false).R().SetBody(server).Post("http://localhost:8080/v2/server")
+	assert.NoError(t, err)
+	assert.Equal(t, 422, resp.StatusCode())
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+	resp, err = resty.SetDebug(false).R().Get("http://localhost:8080/v2/server/s6.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode())
+}
+
+func TestAPI_ServerBulk(t *testing.T) {
+	servers := []types.Server{
+		{Core: types.ServerCore{Address: "s7.example.com", Hostname: "test server 7", MaxPlayers: 10, Gamemode: "Grand Larceny", Language: "English"}},
+		{Core: types.ServerCore{Address: "s8.example.com", Hostname: "", MaxPlayers: 10, Gamemode: "Grand Larceny", Language: "English"}},
+		{Core: types.ServerCore{Address: "s9.example.com", Hostname: "test server 9", MaxPlayers: 10, Gamemode: "rivershell", Language: "Polish"}},
+	}
+
+	result := types.BulkResult{}
+	resp, err := resty.SetDebug(false).R().SetBody(servers).SetResult(&result).Post("http://localhost:8080/v2/servers")
+	assert.NoError(t, err)
+	assert.Equal(t, 207, resp.StatusCode())
+	assert.Equal(t, types.BulkResult{
+		Accepted: 2,
+		Rejected: []types.BulkRejection{{Index: 1, Errors: []string{"hostname is empty"}}},
+	}, result)
+
+	for _, address := range []string{"s7.example.com", "s8.example.com", "s9.example.com"} {
+		_, err = resty.SetDebug(false).R().Delete(fmt.Sprintf("http://localhost:8080/v2/server/%s", address))
+		assert.NoError(t, err)
+	}
+}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	// loads environment variables from .env
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/kelseyhightower/envconfig"
@@ -20,11 +25,23 @@ func main() {
 
 	config.Version = version
 
-	app, err := server.Initialise(config)
+	app, err := server.Initialise(config, nil)
 	if err != nil {
 		panic(err)
 	}
 
-	err = app.Start()
-	panic(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	err = app.Run(ctx, config.Bind)
+	if err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,144 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// PartialErrors is returned by QueryAll when the info query succeeded but some of the others failed,
+// there's one PartialError for each opcode that failed in the order the opcodes were sent.
+type PartialErrors []PartialError
+
+func (e PartialErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Opcodes returns the opcodes that failed
+func (e PartialErrors) Opcodes() (opcodes []Opcode) {
+	for _, err := range e {
+		opcodes = append(opcodes, err.Opcode)
+	}
+	return
+}
+
+// IsPartial reports whether a query failed after the info query succeeded, in which case the
+// server it returned still has up to date core information. It recognises the errors of both
+// QueryServer and QueryAll.
+func IsPartial(err error) bool {
+	// errors.Cause can't be used since it goes straight past PartialError to what it wraps
+	for err != nil {
+		switch err.(type) {
+		case PartialError, PartialErrors:
+			return true
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return false
+		}
+		err = causer.Cause()
+	}
+	return false
+}
+
+// QueryAll sends the info, rules and players queries to the server at the given address all at
+// once and assembles the same Server as QueryServer from their responses, which saves a couple of
+// round trips. The server is only returned with an error if the info query failed, when the rules
+// or players queries fail the rest of the server is still returned along with PartialErrors naming
+// each opcode that failed. Like QueryServer, the player list of a server with too many players to
+// list is left empty rather than being an error. A handshake is done before anything else is sent
+// when the options ask for one.
+func QueryAll(ctx context.Context, address string, opts QueryOptions) (server types.Server, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	opts = opts.withDefaults()
+
+	err = handshake(ctx, addr, opts)
+	if err != nil {
+		return
+	}
+
+	// the other queries are abandoned if the info query fails since the server can't be returned
+	// without it, and the players query is abandoned if the server has too many players to list
+	ctx, cancel := context.WithCancel(ctx)
+	playersCtx, cancelPlayers := context.WithCancel(ctx)
+	defer cancelPlayers()
+
+	var (
+		wg         sync.WaitGroup
+		rules      map[string]string
+		rawRules   map[string][]byte
+		rulesErr   error
+		players    []string
+		playersErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		rules, rawRules, rulesErr = queryRules(ctx, addr, opts)
+	}()
+	go func() {
+		defer wg.Done()
+		players, playersErr = queryPlayers(playersCtx, addr, opts)
+	}()
+	defer wg.Wait()
+	defer cancel()
+
+	response, rtt, err := sendQuery(ctx, addr, Info, opts)
+	if err != nil {
+		return
+	}
+	var raw types.RawStrings
+	server.Core, raw, err = parseInfo(response, opts.Encoding)
+	if err != nil {
+		return
+	}
+	server.Core.Address = address
+	server.Core.HostnameClean = types.CleanHostname(server.Core.Hostname)
+	server.Core.Languages = types.NormalizeLanguage(server.Core.Language)
+	if server.Core.Players > maxListedPlayers {
+		cancelPlayers()
+	}
+	server.Ping = int(measurePing(ctx, addr, opts, rtt) / time.Millisecond)
+	wg.Wait()
+
+	var partial PartialErrors
+	if rulesErr != nil {
+		partial = append(partial, PartialError{Opcode: Rules, Err: rulesErr})
+	} else {
+		server.Rules = rules
+		raw.Rules = rawRules
+		if version, ok := rules["version"]; ok {
+			server.Core.Version = version
+		}
+		server.OpenMP = types.DetectOpenMP(rules)
+	}
+	if raw.Hostname != nil || raw.Gamemode != nil || raw.Language != nil || raw.Rules != nil {
+		server.Raw = &raw
+	}
+
+	// servers with too many players don't answer the players query, which isn't worth reporting
+	if server.Core.Players <= maxListedPlayers {
+		if playersErr == nil {
+			setPlayerList(&server, players)
+		} else if errors.Cause(playersErr) != ErrPlayerListUnavailable {
+			partial = append(partial, PartialError{Opcode: Players, Err: playersErr})
+		}
+	}
+
+	if partial != nil {
+		err = partial
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestQueryAll(t *testing.T) {
+	info := infoPayload(false, 4, 32, "{FF0000}Scavenge and Survive {FFFFFF}Official", "Scavenge & Survive by Southclaws", "English")
+	full := infoPayload(false, 150, 500, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", "English")
+	rules := rulesPayload("mapname", "San Androcalypse", "version", "0.3.7-R2")
+	players := playersPayload("Southclaws", "Y_Less")
+	wantServer := types.Server{
+		Core: types.ServerCore{
+			Hostname:      "{FF0000}Scavenge and Survive {FFFFFF}Official",
+			HostnameClean: "Scavenge and Survive Official",
+			Players:       4,
+			MaxPlayers:    32,
+			Gamemode:      "Scavenge & Survive by Southclaws",
+			Language:      "English",
+			Languages:     []string{"English"},
+			Version:       "0.3.7-R2",
+		},
+		Rules:      map[string]string{"mapname": "San Androcalypse", "version": "0.3.7-R2"},
+		PlayerList: []string{"Southclaws", "Y_Less"},
+	}
+	unlisted := wantServer
+	unlisted.PlayerList = nil
+	noRules := types.Server{Core: wantServer.Core, PlayerList: wantServer.PlayerList}
+	noRules.Core.Version = ""
+	crowded := unlisted
+	crowded.Core.Hostname = "Scavenge and Survive Official"
+	crowded.Core.Players = 150
+	crowded.Core.MaxPlayers = 500
+	fast := QueryOptions{Timeout: time.Millisecond * 50, Retries: 3}
+	tests := []struct {
+		name        string
+		drop        int
+		responses   map[Opcode][]byte
+		wantServer  types.Server
+		wantErr     bool
+		wantFailed  []Opcode
+		wantElapsed time.Duration
+	}{
+		{"valid", 0, map[Opcode][]byte{Info: info, Rules: rules, Players: players}, wantServer, false, nil, time.Second},
+		{"valid retried", 2, map[Opcode][]byte{Info: info, Rules: rules, Players: players}, wantServer, false, nil, time.Second},
+		{"valid player list unavailable", 0, map[Opcode][]byte{Info: info, Rules: rules, Players: {}}, unlisted, false, nil, time.Second},
+		{"valid too many players to list", 0, map[Opcode][]byte{Info: full, Rules: rules}, crowded, false, nil, time.Millisecond * 100},
+		{"partial no rules", 0, map[Opcode][]byte{Info: info, Players: players}, noRules, true, []Opcode{Rules}, time.Second},
+		{"partial no players", 0, map[Opcode][]byte{Info: info, Rules: rules}, unlisted, true, []Opcode{Players}, time.Second},
+		{"partial no rules or players", 0, map[Opcode][]byte{Info: info}, types.Server{Core: noRules.Core}, true, []Opcode{Rules, Players}, time.Second},
+		{"invalid no info", 0, map[Opcode][]byte{Rules: rules, Players: players}, types.Server{}, true, nil, time.Millisecond * 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, stop := fakeServer(t, tt.drop, tt.responses)
+			defer stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			start := time.Now()
+			gotServer, err := QueryAll(ctx, address, fast)
+			assert.True(t, time.Since(start) < tt.wantElapsed, "took %s", time.Since(start))
+			if !tt.wantErr {
+				assert.NoError(t, err)
+			} else if tt.wantFailed == nil {
+				assert.Error(t, err)
+				assert.False(t, IsPartial(err))
+				return
+			} else {
+				partial, ok := err.(PartialErrors)
+				assert.True(t, ok)
+				assert.Equal(t, tt.wantFailed, partial.Opcodes())
+			}
+
+			// ping depends on the machine running the test, and is usually zero on loopback
+			assert.True(t, gotServer.Ping >= 0)
+			gotServer.Ping = 0
+
+			tt.wantServer.Core.Address = address
+			assert.Equal(t, tt.wantServer, gotServer)
+		})
+	}
+}
+
+func TestIsPartial(t *testing.T) {
+	partial := PartialError{Opcode: Rules, Err: timeoutError{}}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"partial", partial, true},
+		{"partial wrapped", errors.Wrap(partial, "failed to query server"), true},
+		{"partial errors", PartialErrors{partial, {Opcode: Players, Err: timeoutError{}}}, true},
+		{"timeout", timeoutError{}, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsPartial(tt.err))
+		})
+	}
+}
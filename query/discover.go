@@ -0,0 +1,56 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// discoverWorkers is how many ports are probed at once, it's kept low so that a Querier's per host
+// rate limit doesn't leave probes waiting long enough for their turn that they time out
+const discoverWorkers = 8
+
+// Discover sends an info query to every port of an IP from one port to another inclusive and
+// returns the servers that responded, ordered by port. Each port is only sent a single packet
+// since most of them won't have anything listening, and ports that don't respond are left out.
+// It's only an error if the context ends before every port has been probed, in which case the
+// servers found so far are returned along with it.
+func Discover(ctx context.Context, ip string, from, to int, opts QueryOptions) (cores []types.ServerCore, err error) {
+	opts = opts.withDefaults()
+	opts.Retries = 1
+
+	found := make([]*types.ServerCore, to-from+1)
+	ports := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < discoverWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range ports {
+				core, err := QueryInfo(ctx, net.JoinHostPort(ip, strconv.Itoa(port)), opts)
+				if err == nil {
+					found[port-from] = &core
+				}
+			}
+		}()
+	}
+
+	for port := from; port <= to && ctx.Err() == nil; port++ {
+		select {
+		case ports <- port:
+		case <-ctx.Done():
+		}
+	}
+	close(ports)
+	wg.Wait()
+
+	for _, core := range found {
+		if core != nil {
+			cores = append(cores, *core)
+		}
+	}
+	return cores, ctx.Err()
+}
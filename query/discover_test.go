@@ -0,0 +1,46 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	info := infoPayload(false, 4, 32, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", "English")
+
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{Info: info})
+	defer stop()
+	_, p, err := net.SplitHostPort(address)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(p)
+	require.NoError(t, err)
+
+	cores, err := Discover(context.Background(), "127.0.0.1", port-3, port+3, QueryOptions{Timeout: time.Millisecond * 50})
+	assert.NoError(t, err)
+	require.Len(t, cores, 1)
+	assert.Equal(t, address, cores[0].Address)
+	assert.Equal(t, "Scavenge and Survive Official", cores[0].Hostname)
+}
+
+func TestDiscover_ContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	// a querier's socket isn't connected so closed ports are silent rather than refusing the query,
+	// nothing answers and the deadline passes long before the range has been probed
+	q, err := NewQuerier()
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	start := time.Now()
+	cores, err := Discover(ctx, "127.0.0.1", 20000, 20099, QueryOptions{Timeout: time.Millisecond * 40, Querier: q})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Empty(t, cores)
+	assert.True(t, time.Since(start) < time.Second)
+}
@@ -0,0 +1,49 @@
+package query
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// DefaultEncoding is used to decode strings in responses when QueryOptions.Encoding is nil. SA:MP
+// doesn't specify an encoding but Windows-1251 is by far the most common one that isn't ASCII,
+// since the largest non-English communities are Cyrillic.
+var DefaultEncoding encoding.Encoding = charmap.Windows1251
+
+// EncodingByName returns the encoding for a name such as "windows-1251" or "latin1", an empty name
+// returns DefaultEncoding.
+func EncodingByName(name string) (enc encoding.Encoding, err error) {
+	switch strings.ToLower(strings.Replace(name, "_", "-", -1)) {
+	case "":
+		return DefaultEncoding, nil
+	case "windows-1251", "cp1251":
+		return charmap.Windows1251, nil
+	case "windows-1252", "cp1252":
+		return charmap.Windows1252, nil
+	case "latin1", "latin-1", "iso-8859-1":
+		return charmap.ISO8859_1, nil
+	case "utf8", "utf-8":
+		return encoding.Nop, nil
+	}
+	return nil, errors.Errorf("unknown encoding '%s'", name)
+}
+
+// decodeString converts a string from a response to UTF-8. Strings that are already valid UTF-8,
+// which includes plain ASCII, are used as-is and anything else is transcoded from enc. transcoded
+// is true if the result differs from the raw bytes.
+func decodeString(raw []byte, enc encoding.Encoding) (s string, transcoded bool) {
+	if utf8.Valid(raw) {
+		return string(raw), false
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(raw)
+	if err != nil {
+		decoded = raw
+	}
+	// the encoding may not cover every byte, anything left over is replaced rather than stored
+	return strings.ToValidUTF8(string(decoded), string(utf8.RuneError)), true
+}
@@ -0,0 +1,58 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestDecodeString(t *testing.T) {
+	tests := []struct {
+		name           string
+		raw            string
+		enc            encoding.Encoding
+		want           string
+		wantTranscoded bool
+	}{
+		{"ascii", "Grand Larceny", charmap.Windows1251, "Grand Larceny", false},
+		{"utf-8", "Привет", charmap.Windows1251, "Привет", false},
+		{"windows-1251", privet, charmap.Windows1251, "Привет", true},
+		{"latin1", "Caf\xe9", charmap.ISO8859_1, "Café", true},
+		{"nop replaces invalid", "Caf\xe9", encoding.Nop, "Caf�", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotTranscoded := decodeString([]byte(tt.raw), tt.enc)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantTranscoded, gotTranscoded)
+		})
+	}
+}
+
+func TestEncodingByName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    encoding.Encoding
+		wantErr bool
+	}{
+		{"", DefaultEncoding, false},
+		{"Windows-1251", charmap.Windows1251, false},
+		{"windows_1252", charmap.Windows1252, false},
+		{"latin1", charmap.ISO8859_1, false},
+		{"utf-8", encoding.Nop, false},
+		{"ebcdic", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodingByName(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
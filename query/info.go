@@ -0,0 +1,89 @@
+package query
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// ErrPartialInfo is returned, wrapped, when an 'i' response ends before all of its strings. Some
+// modified servers only send their player counts and leave off the hostname, gamemode or language,
+// the fields before the end are still filled in so callers can use them if they're all they need.
+var ErrPartialInfo = errors.New("partial info response")
+
+// parseInfo decodes the payload of an 'i' response. The payload consists of the password byte,
+// the player count and player limit as 2 byte integers followed by the hostname, gamemode and
+// language as strings prefixed with a 4 byte length. The strings are decoded with enc unless they're
+// already valid UTF-8, raw holds the original bytes of any that weren't. A response that ends
+// cleanly between fields after the player limit is partial, see ErrPartialInfo, but one that ends
+// part way through a field is still invalid.
+func parseInfo(payload []byte, enc encoding.Encoding) (core types.ServerCore, raw types.RawStrings, err error) {
+	r := reader{buf: payload, opcode: Info}
+
+	password, err := r.uint8()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read password")
+		return
+	}
+	core.Password = password == 1
+
+	players, err := r.uint16()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read players")
+		return
+	}
+	core.Players = int(players)
+
+	maxPlayers, err := r.uint16()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read max players")
+		return
+	}
+	core.MaxPlayers = int(maxPlayers)
+
+	if r.remaining() == 0 {
+		err = errors.Wrap(ErrPartialInfo, "response ends before the hostname")
+		return
+	}
+	hostname, err := r.string32()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read hostname")
+		return
+	}
+	core.Hostname, raw.Hostname = decodeField(hostname, enc)
+
+	if r.remaining() == 0 {
+		err = errors.Wrap(ErrPartialInfo, "response ends before the gamemode")
+		return
+	}
+	gamemode, err := r.string32()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read gamemode")
+		return
+	}
+	core.Gamemode, raw.Gamemode = decodeField(gamemode, enc)
+
+	if r.remaining() == 0 {
+		err = errors.Wrap(ErrPartialInfo, "response ends before the language")
+		return
+	}
+	language, err := r.string32()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read language")
+		return
+	}
+	core.Language, raw.Language = decodeField(language, enc)
+
+	return
+}
+
+// decodeField decodes a string from a response, returning a copy of the raw bytes if it needed
+// transcoding
+func decodeField(b []byte, enc encoding.Encoding) (s string, raw []byte) {
+	s, transcoded := decodeString(b, enc)
+	if transcoded {
+		raw = append([]byte{}, b...)
+	}
+	return
+}
@@ -0,0 +1,84 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// privet is "Привет" encoded in Windows-1251
+const privet = "\xcf\xf0\xe8\xe2\xe5\xf2"
+
+func TestParseInfo(t *testing.T) {
+	full := infoPayload(true, 948, 1000, "test server 3", "Grand Larceny", "English")
+	tests := []struct {
+		name     string
+		payload  []byte
+		wantCore types.ServerCore
+		wantRaw  types.RawStrings
+		wantErr  bool
+	}{
+		{"valid", full, types.ServerCore{
+			Hostname:   "test server 3",
+			Players:    948,
+			MaxPlayers: 1000,
+			Gamemode:   "Grand Larceny",
+			Language:   "English",
+			Password:   true,
+		}, types.RawStrings{}, false},
+		{"valid empty strings", infoPayload(false, 0, 50, "", "", ""), types.ServerCore{
+			MaxPlayers: 50,
+		}, types.RawStrings{}, false},
+		{"valid windows-1251", infoPayload(false, 4, 50, privet+" RP", "Roleplay", "Русский"), types.ServerCore{
+			Hostname:   "Привет RP",
+			Players:    4,
+			MaxPlayers: 50,
+			Gamemode:   "Roleplay",
+			Language:   "Русский",
+		}, types.RawStrings{Hostname: []byte(privet + " RP")}, false},
+		{"invalid empty", []byte{}, types.ServerCore{}, types.RawStrings{}, true},
+		{"invalid truncated string", full[:len(full)-3], types.ServerCore{}, types.RawStrings{}, true},
+		{"invalid string length", append(append([]byte{}, full[:5]...), 0xff, 0xff, 0xff, 0xff), types.ServerCore{}, types.RawStrings{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCore, gotRaw, err := parseInfo(tt.payload, DefaultEncoding)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantCore, gotCore)
+				assert.Equal(t, tt.wantRaw, gotRaw)
+			}
+		})
+	}
+}
+
+func TestParseInfo_Partial(t *testing.T) {
+	full := infoPayload(false, 12, 50, "test server 3", "Grand Larceny", "English")
+	counts := full[:5]
+	tests := []struct {
+		name     string
+		payload  []byte
+		wantCore types.ServerCore
+	}{
+		{"player counts only", counts, types.ServerCore{Players: 12, MaxPlayers: 50}},
+		{"no gamemode or language", full[:5+4+len("test server 3")], types.ServerCore{Hostname: "test server 3", Players: 12, MaxPlayers: 50}},
+		{"no language", full[:len(full)-4-len("English")], types.ServerCore{Hostname: "test server 3", Players: 12, MaxPlayers: 50, Gamemode: "Grand Larceny"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCore, _, err := parseInfo(tt.payload, DefaultEncoding)
+			assert.Equal(t, ErrPartialInfo, errors.Cause(err))
+			assert.Equal(t, tt.wantCore, gotCore)
+		})
+	}
+
+	// ending part way through the player counts is still invalid
+	_, _, err := parseInfo(counts[:4], DefaultEncoding)
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrPartialInfo, errors.Cause(err))
+}
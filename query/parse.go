@@ -0,0 +1,46 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// The reasons a response can fail to parse, these are the reason labels of the parse failure
+// metrics so they must stay the same
+const (
+	// ReasonBadMagic is a response that doesn't start with an echo of the request header
+	ReasonBadMagic = "bad_magic"
+	// ReasonTruncatedString is a string that's shorter than its length prefix says it is
+	ReasonTruncatedString = "truncated_string"
+	// ReasonLengthOverflow is a response that ends before one of its fields, usually because a count
+	// claims there are more entries than were actually sent
+	ReasonLengthOverflow = "length_overflow"
+)
+
+// parseError is returned when a response to a query can't be parsed, it records the opcode of the
+// query and why it failed so misbehaving servers can be told apart from ones that just didn't
+// respond.
+type parseError struct {
+	opcode byte
+	reason string
+	msg    string
+}
+
+func (e parseError) Error() string {
+	return e.msg
+}
+
+func newParseError(opcode byte, reason, format string, args ...interface{}) error {
+	return parseError{opcode: opcode, reason: reason, msg: fmt.Sprintf(format, args...)}
+}
+
+// ParseFailure reports whether a query failed because the response couldn't be parsed, along with
+// the opcode of the query and one of the Reason constants describing what was wrong with it.
+func ParseFailure(err error) (opcode Opcode, reason string, ok bool) {
+	perr, ok := errors.Cause(err).(parseError)
+	if !ok {
+		return 0, "", false
+	}
+	return Opcode(perr.opcode), perr.reason, true
+}
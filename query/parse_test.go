@@ -0,0 +1,52 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFailure(t *testing.T) {
+	info := infoPayload(false, 4, 32, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", "English")
+	request := []byte("SAMP\x7f\x00\x00\x01\x61\x1er")
+
+	tests := []struct {
+		name       string
+		parse      func() error
+		wantOpcode Opcode
+		wantReason string
+		wantOk     bool
+	}{
+		{"bad magic", func() error {
+			_, err := checkHeader(request, []byte("SAMQ\x7f\x00\x00\x01\x61\x1er"))
+			return err
+		}, Rules, ReasonBadMagic, true},
+		{"short header", func() error {
+			_, err := checkHeader(request, []byte("SAMP"))
+			return err
+		}, Rules, ReasonBadMagic, true},
+		{"truncated string", func() error {
+			_, _, err := parseInfo(info[:len(info)-3], DefaultEncoding)
+			return err
+		}, Info, ReasonTruncatedString, true},
+		{"length overflow", func() error {
+			_, _, err := parseRules(rulesPayload("version", "0.3.7-R2")[:2], DefaultEncoding)
+			return err
+		}, Rules, ReasonLengthOverflow, true},
+		{"not a parse failure", func() error {
+			return errors.Wrap(timeoutError{}, "server did not respond")
+		}, 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.parse()
+			assert.Error(t, err)
+
+			opcode, reason, ok := ParseFailure(PartialError{Opcode: Rules, Err: err})
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantOpcode, opcode)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrChallengeMismatch is returned when the responses to a ping query never echoed back the
+// challenge it was sent with, which means they didn't come from the server being queried
+var ErrChallengeMismatch = errors.New("response challenge does not match request")
+
+// challengeLength is the length of the random challenge that follows the header of ping requests
+const challengeLength = 4
+
+// newChallenge returns a random challenge for a ping request. It's generated with crypto/rand since
+// a challenge that could be predicted could be echoed by someone spoofing the server's address.
+func newChallenge() (challenge []byte, err error) {
+	challenge = make([]byte, challengeLength)
+	_, err = rand.Read(challenge)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate challenge")
+	}
+	return
+}
+
+// requestKeyLength is how much of a request a response has to echo back to answer it, the header
+// and the challenge of ping requests. The opcode is the last byte of the header.
+func requestKeyLength(packet []byte) int {
+	if len(packet) >= headerLength+challengeLength && Opcode(packet[headerLength-1]) == Ping {
+		return headerLength + challengeLength
+	}
+	return headerLength
+}
+
+// checkChallenge ensures a ping response echoes the challenge of the request, the header has
+// already been checked
+func checkChallenge(request, response []byte) (payload []byte, err error) {
+	if len(response) < len(request) || !bytes.Equal(request[headerLength:], response[headerLength:len(request)]) {
+		return nil, ErrChallengeMismatch
+	}
+	return response[len(request):], nil
+}
+
+// QueryPing sends a ping query to the server at the given address and returns the round-trip time.
+// The request carries a random challenge which the server echoes back, responses that echo any
+// other challenge are discarded as spoofed and the query fails with ErrChallengeMismatch if nothing
+// else arrives. Through a Querier they're dropped like any other response that nothing is waiting
+// for, since they might be late responses to earlier pings sent from the same socket.
+func QueryPing(ctx context.Context, address string, opts QueryOptions) (ping time.Duration, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	_, ping, err = sendQuery(ctx, addr, Ping, opts.withDefaults())
+	return
+}
@@ -0,0 +1,163 @@
+package query
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spoofingServer answers pings with a response echoing the wrong challenge, followed by the real
+// response if honest is set
+func spoofingServer(t *testing.T, honest bool) (address string, stop func()) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			spoofed := append([]byte{}, buf[:n]...)
+			spoofed[n-1] ^= 0xff
+			conn.WriteToUDP(spoofed, from) // nolint:errcheck
+			if honest {
+				conn.WriteToUDP(buf[:n], from) // nolint:errcheck
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() } // nolint:errcheck
+}
+
+func TestBuildRequest_Challenge(t *testing.T) {
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 7777}
+
+	info, err := buildRequest(addr, Info)
+	require.NoError(t, err)
+	assert.Len(t, info, headerLength)
+
+	first, err := buildRequest(addr, Ping)
+	require.NoError(t, err)
+	second, err := buildRequest(addr, Ping)
+	require.NoError(t, err)
+	assert.Len(t, first, headerLength+challengeLength)
+	assert.Equal(t, first[:headerLength], second[:headerLength])
+	assert.NotEqual(t, first, second)
+
+	assert.Equal(t, headerLength, requestKeyLength(info))
+	assert.Equal(t, headerLength+challengeLength, requestKeyLength(first))
+}
+
+func TestCheckHeader_Challenge(t *testing.T) {
+	request := []byte("SAMP\x7f\x00\x00\x01\x61\x1ep\x01\x02\x03\x04")
+	tests := []struct {
+		name     string
+		response []byte
+		wantErr  error
+	}{
+		{"echoed", []byte("SAMP\x7f\x00\x00\x01\x61\x1ep\x01\x02\x03\x04"), nil},
+		{"wrong challenge", []byte("SAMP\x7f\x00\x00\x01\x61\x1ep\x01\x02\x03\x05"), ErrChallengeMismatch},
+		{"no challenge", []byte("SAMP\x7f\x00\x00\x01\x61\x1ep"), ErrChallengeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := checkHeader(request, tt.response)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Empty(t, payload)
+			}
+		})
+	}
+}
+
+func TestQueryPing(t *testing.T) {
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{Ping: {}})
+	defer stop()
+
+	ping, err := QueryPing(context.Background(), address, QueryOptions{Timeout: time.Millisecond * 200})
+	assert.NoError(t, err)
+	assert.True(t, ping > 0)
+}
+
+func TestQueryPing_Spoofed(t *testing.T) {
+	opts := QueryOptions{Timeout: time.Millisecond * 50, Retries: 2}
+
+	// the spoofed response arrives first but the real one is read on the retry
+	address, stop := spoofingServer(t, true)
+	defer stop()
+	_, err := QueryPing(context.Background(), address, opts)
+	assert.NoError(t, err)
+
+	address, stop = spoofingServer(t, false)
+	defer stop()
+	_, err = QueryPing(context.Background(), address, opts)
+	assert.Equal(t, ErrChallengeMismatch, errors.Cause(err))
+}
+
+func TestQuerier_PingSpoofed(t *testing.T) {
+	q, err := NewQuerier()
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	opts := QueryOptions{Timeout: time.Millisecond * 50, Retries: 2, Querier: q}
+
+	address, stop := spoofingServer(t, true)
+	defer stop()
+	_, err = QueryPing(context.Background(), address, opts)
+	assert.NoError(t, err)
+
+	// the spoofed responses are dropped so the ping times out
+	address, stop = spoofingServer(t, false)
+	defer stop()
+	_, err = QueryPing(context.Background(), address, opts)
+	assert.True(t, IsTimeout(err))
+	assert.Zero(t, pendingQueries(q))
+}
+
+func TestQueryInfo_Handshake(t *testing.T) {
+	info := infoPayload(false, 4, 32, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", "English")
+	q, err := NewQuerier()
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	for _, querier := range []*Querier{nil, q} {
+		opts := QueryOptions{Timeout: time.Millisecond * 50, Retries: 1, Querier: querier, Handshake: true}
+
+		address, stop := fakeServer(t, 0, map[Opcode][]byte{Ping: {}, Info: info})
+		core, err := QueryInfo(context.Background(), address, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, "Scavenge and Survive Official", core.Hostname)
+		server, err := QueryServer(context.Background(), address, opts)
+		assert.Equal(t, "Scavenge and Survive Official", server.Core.Hostname)
+		assert.True(t, IsPartial(err)) // no rules, which doesn't matter here
+		stop()
+
+		// a server that doesn't answer pings isn't trusted, even though it answered the info query
+		address, stop = fakeServer(t, 0, map[Opcode][]byte{Info: info})
+		_, err = QueryInfo(context.Background(), address, opts)
+		assert.True(t, IsTimeout(err))
+		_, err = QueryServer(context.Background(), address, opts)
+		assert.True(t, IsTimeout(err))
+		_, err = QueryAll(context.Background(), address, opts)
+		assert.True(t, IsTimeout(err))
+		opts.Handshake = false
+		_, err = QueryInfo(context.Background(), address, opts)
+		assert.NoError(t, err)
+		stop()
+	}
+
+	address, stop := spoofingServer(t, false)
+	defer stop()
+	opts := QueryOptions{Timeout: time.Millisecond * 50, Retries: 1, Handshake: true}
+	_, err = QueryInfo(context.Background(), address, opts)
+	assert.Equal(t, ErrChallengeMismatch, errors.Cause(err))
+	_, err = QueryAll(context.Background(), address, opts)
+	assert.Equal(t, ErrChallengeMismatch, errors.Cause(err))
+}
@@ -0,0 +1,129 @@
+package query
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// ErrPlayerListUnavailable is returned when a server does not provide a client list. SA:MP servers
+// refuse to list clients when there are more than 100 players online, instead they either do not
+// respond at all or respond with an empty or truncated list.
+var ErrPlayerListUnavailable = errors.New("player list unavailable")
+
+// maxListedPlayers is the player count above which servers no longer respond to the 'c' and 'd'
+// opcodes
+const maxListedPlayers = 100
+
+// suspectDuplicates is how many duplicate names a player list needs before the server is suspected
+// of faking players. SA:MP doesn't let two players join with the same name so any duplicate is odd,
+// but a single one is given the benefit of the doubt.
+const suspectDuplicates = 2
+
+// dedupePlayers removes repeated names from a player list, keeping the first of each in order, and
+// returns the number removed. Servers that inflate their player count with bots tend to give them
+// the same few names.
+func dedupePlayers(players []string) (deduped []string, duplicates int) {
+	if players == nil {
+		return nil, 0
+	}
+	seen := make(map[string]struct{}, len(players))
+	deduped = make([]string, 0, len(players))
+	for _, name := range players {
+		if _, ok := seen[name]; ok {
+			duplicates++
+			continue
+		}
+		seen[name] = struct{}{}
+		deduped = append(deduped, name)
+	}
+	return
+}
+
+// setPlayerList sets the player list of a server with duplicates removed and flags the server if
+// there were suspiciously many
+func setPlayerList(server *types.Server, players []string) {
+	var duplicates int
+	server.PlayerList, duplicates = dedupePlayers(players)
+	server.SuspectFakePlayers = duplicates >= suspectDuplicates
+}
+
+// parsePlayers decodes the payload of a 'c' response. The payload consists of a 2 byte player
+// count followed by each player's name, prefixed with a 1 byte length, and their 4 byte score.
+func parsePlayers(payload []byte) (players []string, err error) {
+	r := reader{buf: payload, opcode: Players}
+
+	count, err := r.uint16()
+	if err != nil {
+		return nil, ErrPlayerListUnavailable
+	}
+
+	players = make([]string, 0, count)
+	for i := 0; i < int(count); i++ {
+		var name []byte
+
+		name, err = r.string8()
+		if err != nil {
+			return nil, errors.Wrapf(ErrPlayerListUnavailable, "truncated at player %d", i)
+		}
+
+		_, err = r.uint32() // score, not stored since it's arbitrary and gamemode-specific
+		if err != nil {
+			return nil, errors.Wrapf(ErrPlayerListUnavailable, "truncated at score of player %d", i)
+		}
+
+		players = append(players, string(name))
+	}
+
+	return
+}
+
+// parseDetailedPlayers decodes the payload of a 'd' response. The payload consists of a 2 byte player
+// count followed by each player's 1 byte id, their name prefixed with a 1 byte length, their 4 byte
+// score and their 4 byte ping.
+func parseDetailedPlayers(payload []byte) (players []types.PlayerDetail, err error) {
+	r := reader{buf: payload, opcode: DetailedPlayers}
+
+	count, err := r.uint16()
+	if err != nil {
+		return nil, ErrPlayerListUnavailable
+	}
+
+	players = make([]types.PlayerDetail, 0, count)
+	for i := 0; i < int(count); i++ {
+		var (
+			id          uint8
+			name        []byte
+			score, ping uint32
+		)
+
+		id, err = r.uint8()
+		if err != nil {
+			return nil, errors.Wrapf(ErrPlayerListUnavailable, "truncated at id of player %d", i)
+		}
+
+		name, err = r.string8()
+		if err != nil {
+			return nil, errors.Wrapf(ErrPlayerListUnavailable, "truncated at player %d", i)
+		}
+
+		score, err = r.uint32()
+		if err != nil {
+			return nil, errors.Wrapf(ErrPlayerListUnavailable, "truncated at score of player %d", i)
+		}
+
+		ping, err = r.uint32()
+		if err != nil {
+			return nil, errors.Wrapf(ErrPlayerListUnavailable, "truncated at ping of player %d", i)
+		}
+
+		players = append(players, types.PlayerDetail{
+			ID:    int(id),
+			Name:  string(name),
+			Score: int(int32(score)), // scores are signed, gamemodes commonly use negative ones
+			Ping:  int(ping),
+		})
+	}
+
+	return
+}
@@ -0,0 +1,125 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func playersPayload(names ...string) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(len(names))) // nolint:errcheck
+	for i, name := range names {
+		buf.WriteByte(byte(len(name)))
+		buf.WriteString(name)
+		binary.Write(buf, binary.LittleEndian, uint32(i*10)) // nolint:errcheck
+	}
+	return buf.Bytes()
+}
+
+func TestParsePlayers(t *testing.T) {
+	full := playersPayload("Southclaws", "Y_Less", "Zeex")
+	tests := []struct {
+		name            string
+		payload         []byte
+		wantPlayers     []string
+		wantUnavailable bool
+	}{
+		{"valid", full, []string{"Southclaws", "Y_Less", "Zeex"}, false},
+		{"valid zero players", playersPayload(), []string{}, false},
+		{"unavailable empty", []byte{}, nil, true},
+		{"unavailable truncated name", full[:len(full)-6], nil, true},
+		{"unavailable truncated score", full[:len(full)-2], nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPlayers, err := parsePlayers(tt.payload)
+			if tt.wantUnavailable {
+				assert.Equal(t, ErrPlayerListUnavailable, errors.Cause(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantPlayers, gotPlayers)
+			}
+		})
+	}
+}
+
+func TestDedupePlayers(t *testing.T) {
+	tests := []struct {
+		name           string
+		players        []string
+		wantPlayers    []string
+		wantDuplicates int
+		wantSuspect    bool
+	}{
+		{"none", nil, nil, 0, false},
+		{"empty", []string{}, []string{}, 0, false},
+		{"unique", []string{"Southclaws", "Y_Less", "Zeex"}, []string{"Southclaws", "Y_Less", "Zeex"}, 0, false},
+		{"one duplicate", []string{"Southclaws", "Y_Less", "Southclaws"}, []string{"Southclaws", "Y_Less"}, 1, false},
+		{"bots", []string{"Bot", "Southclaws", "Bot", "Y_Less", "Bot", "Zeex", "Y_Less"}, []string{"Bot", "Southclaws", "Y_Less", "Zeex"}, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPlayers, gotDuplicates := dedupePlayers(tt.players)
+			assert.Equal(t, tt.wantPlayers, gotPlayers)
+			assert.Equal(t, tt.wantDuplicates, gotDuplicates)
+
+			var server types.Server
+			setPlayerList(&server, tt.players)
+			assert.Equal(t, tt.wantPlayers, server.PlayerList)
+			assert.Equal(t, tt.wantSuspect, server.SuspectFakePlayers)
+		})
+	}
+}
+
+func detailedPlayersPayload(players ...types.PlayerDetail) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(len(players))) // nolint:errcheck
+	for _, player := range players {
+		buf.WriteByte(byte(player.ID))
+		buf.WriteByte(byte(len(player.Name)))
+		buf.WriteString(player.Name)
+		binary.Write(buf, binary.LittleEndian, int32(player.Score)) // nolint:errcheck
+		binary.Write(buf, binary.LittleEndian, uint32(player.Ping)) // nolint:errcheck
+	}
+	return buf.Bytes()
+}
+
+func TestParseDetailedPlayers(t *testing.T) {
+	players := []types.PlayerDetail{
+		{ID: 0, Name: "Southclaws", Score: 1337, Ping: 48},
+		{ID: 3, Name: "Y_Less", Score: -20, Ping: 120},
+		{ID: 255, Name: "Zeex", Score: 0, Ping: 65535},
+	}
+	full := detailedPlayersPayload(players...)
+	tests := []struct {
+		name            string
+		payload         []byte
+		wantPlayers     []types.PlayerDetail
+		wantUnavailable bool
+	}{
+		{"valid", full, players, false},
+		{"valid zero players", detailedPlayersPayload(), []types.PlayerDetail{}, false},
+		{"unavailable empty", []byte{}, nil, true},
+		{"unavailable truncated id", full[:2], nil, true},
+		{"unavailable truncated name", full[:len(full)-10], nil, true},
+		{"unavailable truncated score", full[:len(full)-6], nil, true},
+		{"unavailable truncated ping", full[:len(full)-2], nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPlayers, err := parseDetailedPlayers(tt.payload)
+			if tt.wantUnavailable {
+				assert.Equal(t, ErrPlayerListUnavailable, errors.Cause(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantPlayers, gotPlayers)
+			}
+		})
+	}
+}
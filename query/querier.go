@@ -0,0 +1,334 @@
+package query
+
+import (
+	"context"
+	"hash/fnv"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQuerierClosed is returned by queries sent through a Querier after it has been closed
+var ErrQuerierClosed = errors.New("querier closed")
+
+// ErrTooManyQueries is returned by queries sent through a Querier whose context ran out while they
+// were waiting for a chance to be sent, see WithMaxConcurrentQueries
+var ErrTooManyQueries = errors.New("too many queries in flight")
+
+// Querier sends queries from a single UDP socket that's shared between every query instead of
+// dialling a new socket for each one, which saves a handful of syscalls per query when polling many
+// servers. Since the socket isn't connected to any particular server, responses are matched to the
+// queries waiting on them by the address they came from and the header they echo back. A Querier is
+// safe for concurrent use and is used by setting it in QueryOptions. On busy deployments the one
+// socket can be split into several, see WithSocketShards.
+type Querier struct {
+	shards    []*querierShard
+	done      chan struct{}
+	once      sync.Once
+	limiter   *hostLimiter
+	slots     chan struct{}
+	transport Transport
+}
+
+// querierShard is one of the sockets of a Querier along with the queries waiting for a response on
+// it, each shard has a read loop of its own
+type querierShard struct {
+	conn *net.UDPConn
+
+	mu      sync.Mutex
+	pending map[pendingKey][]chan []byte
+}
+
+// pendingKey identifies the responses a query is waiting for, the header includes the opcode so
+// different queries of the same server don't receive each other's responses, along with the
+// challenge of ping requests so that pings only accept responses to themselves
+type pendingKey struct {
+	from   string
+	header string
+}
+
+// QuerierOption configures a Querier
+type QuerierOption func(*Querier)
+
+// WithPerHostRate limits the packets sent to each host to rps per second, counting every query of
+// every server on the host along with retries. Packets over the limit wait their turn instead of
+// failing, unless they'd have to wait more than a couple of seconds or longer than their context
+// allows, in which case they fail with a timeout. A rate of zero or less leaves packets unlimited.
+func WithPerHostRate(rps float64) QuerierOption {
+	return func(q *Querier) {
+		if rps > 0 {
+			q.limiter = newHostLimiter(rps)
+		} else {
+			q.limiter = nil
+		}
+	}
+}
+
+// WithMaxConcurrentQueries limits the queries in flight at once to n, across every server. A query
+// over the limit waits for another to finish for as long as its context allows and then fails with
+// ErrTooManyQueries, so queries without a deadline always wait their turn. RCON commands sent with
+// the Querier in their options count towards the limit too since each of them opens a socket of
+// its own. A limit of zero or less leaves queries unlimited.
+func WithMaxConcurrentQueries(n int) QuerierOption {
+	return func(q *Querier) {
+		if n > 0 {
+			q.slots = make(chan struct{}, n)
+		} else {
+			q.slots = nil
+		}
+	}
+}
+
+// WithSocketShards spreads queries across n sockets instead of one, each with its own read loop and
+// kernel buffers, so a single socket doesn't become the bottleneck when polling many servers at
+// once. Servers are assigned a socket by a hash of their address so every query of a server is sent
+// from, and answered on, the same one. A count of one or less uses a single socket.
+func WithSocketShards(n int) QuerierOption {
+	return func(q *Querier) {
+		if n < 1 {
+			n = 1
+		}
+		q.shards = make([]*querierShard, n)
+	}
+}
+
+// WithTransport sets the transport queries are sent over unless their options name one of their
+// own, TransportTCP is only worth setting for deployments whose servers are all behind a TCP proxy.
+// Queries over TCP don't use the shared sockets, each dials a connection that's closed as soon as
+// the response is read, but they count towards the rate and concurrency limits all the same.
+func WithTransport(transport Transport) QuerierOption {
+	return func(q *Querier) {
+		q.transport = transport
+	}
+}
+
+// NewQuerier binds a UDP socket to a random local port, or one per shard, and starts reading
+// responses from it, the sockets stay open until Close is called.
+func NewQuerier(opts ...QuerierOption) (q *Querier, err error) {
+	q = &Querier{
+		shards:    make([]*querierShard, 1),
+		done:      make(chan struct{}),
+		transport: TransportUDP,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	for i := range q.shards {
+		conn, err := net.ListenUDP("udp4", nil)
+		if err != nil {
+			for _, shard := range q.shards[:i] {
+				shard.conn.Close() // nolint:errcheck
+			}
+			return nil, errors.Wrap(err, "failed to bind query socket")
+		}
+		q.shards[i] = &querierShard{
+			conn:    conn,
+			pending: make(map[pendingKey][]chan []byte),
+		}
+	}
+	for _, shard := range q.shards {
+		go q.read(shard)
+	}
+	return q, nil
+}
+
+// Close closes the sockets, any queries still waiting for a response fail with ErrQuerierClosed
+func (q *Querier) Close() (err error) {
+	q.once.Do(func() {
+		close(q.done)
+		for _, shard := range q.shards {
+			if closeErr := shard.conn.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return
+}
+
+// shard returns the shard that queries of an address are sent from
+func (q *Querier) shard(addr *net.UDPAddr) *querierShard {
+	if len(q.shards) == 1 {
+		return q.shards[0]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(addr.String())) // nolint:errcheck
+	return q.shards[h.Sum32()%uint32(len(q.shards))]
+}
+
+// Query sends a query packet with the specified opcode to the address and returns the response with
+// the header stripped off, the packet is re-sent in the same way as the package level queries.
+func (q *Querier) Query(ctx context.Context, address string, opcode Opcode, opts QueryOptions) (response []byte, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	response, _, err = q.send(ctx, addr, opcode, opts.withDefaults())
+	return
+}
+
+func (q *Querier) send(ctx context.Context, addr *net.UDPAddr, opcode Opcode, opts QueryOptions) (response []byte, rtt time.Duration, err error) {
+	request, err := buildRequest(addr, opcode)
+	if err != nil {
+		return
+	}
+
+	release, err := q.acquire(ctx)
+	if err != nil {
+		return
+	}
+	defer release()
+	if opts.Transport == TransportTCP || opts.Transport == 0 && q.transport == TransportTCP {
+		return sendTCP(ctx, addr, request, opts, func(ctx context.Context) error {
+			if q.limiter == nil {
+				return nil
+			}
+			return q.limiter.wait(ctx, addr.IP.String())
+		}, q.done)
+	}
+	shard := q.shard(addr)
+	key := pendingKey{from: addr.String(), header: string(request)}
+
+	for attempt := 0; attempt < opts.Retries; attempt++ {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		if q.limiter != nil {
+			err = q.limiter.wait(ctx, addr.IP.String())
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		responses := shard.wait(key)
+
+		sent := time.Now()
+		_, err = shard.conn.WriteToUDP(request, addr)
+		if err != nil {
+			shard.cancel(key, responses)
+			select {
+			case <-q.done:
+				err = ErrQuerierClosed
+			default:
+				err = errors.Wrap(err, "failed to write request")
+			}
+			return
+		}
+
+		timer := time.NewTimer(opts.Timeout)
+		select {
+		case response = <-responses:
+			timer.Stop()
+			return response[len(request):], time.Since(sent), nil
+		case <-timer.C:
+			shard.cancel(key, responses)
+			err = timeoutError{}
+		case <-ctx.Done():
+			timer.Stop()
+			shard.cancel(key, responses)
+			return nil, 0, ctx.Err()
+		case <-q.done:
+			timer.Stop()
+			return nil, 0, ErrQuerierClosed
+		}
+	}
+
+	err = errors.Wrapf(err, "server %s did not respond after %d attempts", addr, opts.Retries)
+	return
+}
+
+// acquire waits until there are fewer queries in flight than the limit, the returned func must be
+// called once the query is finished
+func (q *Querier) acquire(ctx context.Context) (release func(), err error) {
+	if q.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case q.slots <- struct{}{}:
+		return func() { <-q.slots }, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, ErrTooManyQueries
+		}
+		return nil, ctx.Err()
+	case <-q.done:
+		return nil, ErrQuerierClosed
+	}
+}
+
+// wait registers a query as waiting for a response, the response is sent on the returned channel
+func (shard *querierShard) wait(key pendingKey) chan []byte {
+	responses := make(chan []byte, 1)
+
+	shard.mu.Lock()
+	shard.pending[key] = append(shard.pending[key], responses)
+	shard.mu.Unlock()
+
+	return responses
+}
+
+// cancel stops a query from waiting for a response
+func (shard *querierShard) cancel(key pendingKey, responses chan []byte) {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	waiting := shard.pending[key]
+	for i, ch := range waiting {
+		if ch == responses {
+			waiting = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+	if len(waiting) == 0 {
+		delete(shard.pending, key)
+	} else {
+		shard.pending[key] = waiting
+	}
+}
+
+// read hands each response on a shard's socket to the queries waiting on it until the socket is
+// closed. Every query of the same server and opcode sends an identical request so one response
+// satisfies all of them, except for pings which each have a challenge of their own. The rest are
+// dropped since nothing is waiting for them, including pings that echo the wrong challenge.
+func (q *Querier) read(shard *querierShard) {
+	buf := make([]byte, maxResponseLength)
+	for {
+		n, from, err := shard.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-q.done:
+				return
+			default:
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				continue
+			}
+			q.Close() // nolint:errcheck
+			return
+		}
+		if n < headerLength {
+			continue
+		}
+
+		key := pendingKey{from: from.String(), header: string(buf[:requestKeyLength(buf[:n])])}
+
+		shard.mu.Lock()
+		waiting := shard.pending[key]
+		delete(shard.pending, key)
+		shard.mu.Unlock()
+
+		for _, responses := range waiting {
+			responses <- append([]byte(nil), buf[:n]...)
+		}
+	}
+}
+
+// timeoutError is what an attempt fails with when no response arrives in time, it's a net.Error in
+// the same way as a read deadline passing on a connected socket so IsTimeout recognises it
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,244 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingQueries counts the queries waiting for a response across every shard of a Querier
+func pendingQueries(q *Querier) (n int) {
+	for _, shard := range q.shards {
+		shard.mu.Lock()
+		n += len(shard.pending)
+		shard.mu.Unlock()
+	}
+	return
+}
+
+func TestQuerier(t *testing.T) {
+	for _, shards := range []int{1, 3} {
+		t.Run(fmt.Sprintf("%d shards", shards), func(t *testing.T) {
+			testQuerier(t, shards)
+		})
+	}
+}
+
+func testQuerier(t *testing.T, shards int) {
+	q, err := NewQuerier(WithSocketShards(shards))
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+	assert.Len(t, q.shards, shards)
+
+	// several servers queried at once through the same socket each get their own responses
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		hostname := fmt.Sprintf("server %d", i)
+		address, stop := fakeServer(t, i%2, map[Opcode][]byte{
+			Info:  infoPayload(false, uint16(i), 32, hostname, "rivershell", "English"),
+			Rules: rulesPayload("version", "0.3.7-R2"),
+		})
+		defer stop()
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			opts := QueryOptions{Timeout: time.Millisecond * 50, Querier: q}
+			core, err := QueryInfo(ctx, address, opts)
+			assert.NoError(t, err)
+			assert.Equal(t, hostname, core.Hostname)
+			assert.Equal(t, i, core.Players)
+
+			rules, err := QueryRules(ctx, address, opts)
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]string{"version": "0.3.7-R2"}, rules)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Zero(t, pendingQueries(q))
+}
+
+func TestQuerier_Timeout(t *testing.T) {
+	q, err := NewQuerier()
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{})
+	defer stop()
+
+	_, err = q.Query(context.Background(), address, Info, QueryOptions{Timeout: time.Millisecond * 20, Retries: 2})
+	assert.True(t, IsTimeout(err))
+
+	assert.Zero(t, pendingQueries(q))
+}
+
+func TestQuerier_Close(t *testing.T) {
+	q, err := NewQuerier()
+	require.NoError(t, err)
+
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{})
+	defer stop()
+
+	errs := make(chan error)
+	go func() {
+		_, err := q.Query(context.Background(), address, Info, QueryOptions{Timeout: time.Second})
+		errs <- err
+	}()
+
+	time.Sleep(time.Millisecond * 20)
+	assert.NoError(t, q.Close())
+	assert.Equal(t, ErrQuerierClosed, <-errs)
+
+	_, err = q.Query(context.Background(), address, Info, QueryOptions{Timeout: time.Second})
+	assert.Equal(t, ErrQuerierClosed, err)
+}
+
+func BenchmarkQueryInfo(b *testing.B) {
+	address, stop := fakeServer(b, 0, map[Opcode][]byte{
+		Info: infoPayload(false, 4, 32, "Stunt Paradise", "rivershell", "Polish"),
+	})
+	defer stop()
+
+	q, err := NewQuerier()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer q.Close() // nolint:errcheck
+
+	for _, querier := range []*Querier{nil, q} {
+		name := "dialled"
+		if querier != nil {
+			name = "shared"
+		}
+		b.Run(name, func(b *testing.B) {
+			opts := QueryOptions{Querier: querier}
+			for i := 0; i < b.N; i++ {
+				_, err := QueryInfo(context.Background(), address, opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkQuerier_Shards polls many servers at once through a single socket and through several
+func BenchmarkQuerier_Shards(b *testing.B) {
+	var addresses []string
+	for i := 0; i < 32; i++ {
+		address, stop := fakeServer(b, 0, map[Opcode][]byte{
+			Info: infoPayload(false, 4, 32, "Stunt Paradise", "rivershell", "Polish"),
+		})
+		defer stop()
+		addresses = append(addresses, address)
+	}
+
+	for _, shards := range []int{1, 4} {
+		b.Run(fmt.Sprintf("%d sockets", shards), func(b *testing.B) {
+			q, err := NewQuerier(WithSocketShards(shards))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer q.Close() // nolint:errcheck
+
+			opts := QueryOptions{Querier: q}
+			var next uint32
+			b.SetParallelism(8)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					address := addresses[atomic.AddUint32(&next, 1)%uint32(len(addresses))]
+					_, err := QueryInfo(context.Background(), address, opts)
+					if err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestQuerier_MaxConcurrentQueries(t *testing.T) {
+	q, err := NewQuerier(WithMaxConcurrentQueries(1))
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{
+		Info: infoPayload(false, 4, 32, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", "English"),
+	})
+	defer stop()
+
+	// another query holds the only slot so this one gives up once its context runs out
+	release, err := q.acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	_, err = q.Query(ctx, address, Info, QueryOptions{Timeout: time.Millisecond * 50})
+	assert.Equal(t, ErrTooManyQueries, err)
+
+	// but waits its turn as long as its context allows
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		release()
+	}()
+	_, err = q.Query(context.Background(), address, Info, QueryOptions{Timeout: time.Millisecond * 50})
+	assert.NoError(t, err)
+	assert.Empty(t, q.slots)
+}
+
+func TestQuerier_PingSamples(t *testing.T) {
+	q, err := NewQuerier()
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	// records where each info query came from, every one should come from the Querier's socket
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close() // nolint:errcheck
+	var (
+		mu      sync.Mutex
+		senders []string
+	)
+	info := infoPayload(false, 500, 1000, "Full Server", "rivershell", "English") // too many to list
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			payload := rulesPayload()
+			if Opcode(buf[headerLength-1]) == Info {
+				mu.Lock()
+				senders = append(senders, strconv.Itoa(from.Port))
+				mu.Unlock()
+				payload = info
+			}
+			conn.WriteToUDP(append(append([]byte{}, buf[:n]...), payload...), from) // nolint:errcheck
+		}
+	}()
+
+	_, err = QueryServer(context.Background(), conn.LocalAddr().String(), QueryOptions{Timeout: time.Millisecond * 100, Retries: 3, Querier: q})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, senders, 3) // the info query and a ping sample for each further retry
+	for _, sender := range senders {
+		assert.Equal(t, strconv.Itoa(q.shards[0].conn.LocalAddr().(*net.UDPAddr).Port), sender)
+	}
+}
@@ -0,0 +1,476 @@
+// Package query implements the SA:MP server query protocol. Queries are small UDP packets that
+// consist of a "SAMP" magic header, the IPv4 address and port of the target server and a single
+// opcode byte that determines what information the server responds with.
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// Opcode represents a query packet type from the SA:MP set
+type Opcode byte
+
+const (
+	// Info is the 'i' opcode, it returns the basic server information
+	Info Opcode = 'i'
+	// Rules is the 'r' opcode, it returns the server's rules such as the map name and version
+	Rules Opcode = 'r'
+	// Players is the 'c' opcode, it returns the list of connected clients and their scores
+	Players Opcode = 'c'
+	// DetailedPlayers is the 'd' opcode, it returns the list of connected clients along with their
+	// ids, scores and pings
+	DetailedPlayers Opcode = 'd'
+	// RCONCommand is the 'x' opcode, it runs a console command on the server and returns its output
+	RCONCommand Opcode = 'x'
+	// Ping is the 'p' opcode, the request ends with a random challenge that the server echoes back
+	// and nothing else, so it's the cheapest query and the only one whose response can be matched
+	// to the request that caused it
+	Ping Opcode = 'p'
+)
+
+// ParseOpcode returns the opcode named by a single character, only the opcodes that this package
+// knows how to send are accepted
+func ParseOpcode(s string) (opcode Opcode, err error) {
+	if len(s) == 1 {
+		switch opcode = Opcode(s[0]); opcode {
+		case Info, Rules, Players, DetailedPlayers:
+			return opcode, nil
+		}
+	}
+	return 0, errors.Errorf("unknown opcode '%s', must be one of i r c d", s)
+}
+
+// headerLength is the length of the "SAMP" magic, address, port and opcode that prefix every
+// request and that servers echo back at the start of every response.
+const headerLength = 11
+
+// maxResponseLength is the size of the buffer responses are read into, the most a UDP datagram can
+// hold. The detailed player list of a full server with long names is several kilobytes and a
+// datagram that doesn't fit is silently truncated.
+const maxResponseLength = 64 << 10
+
+// QueryOptions controls how long to wait for a response and how many times to send a packet
+type QueryOptions struct {
+	Timeout  time.Duration     // time to wait for a response to each packet
+	Retries  int               // amount of times a packet is sent before giving up
+	Encoding encoding.Encoding // encoding of strings that aren't valid UTF-8, defaults to DefaultEncoding
+	Querier  *Querier          // shared socket to send queries from, each query dials its own when nil
+
+	// Transport is how queries are carried to the server, when it's zero they're sent over the
+	// transport of the Querier or over UDP if there isn't one. RCON commands are always sent over UDP.
+	Transport Transport
+
+	// QueryPort is the port queries are sent to when the server answers them on a different port to
+	// the game port in its address, results are still reported under the address as given
+	QueryPort int
+
+	// Handshake makes QueryServer, QueryAll and QueryInfo ping the server before the info query, the info
+	// response is only trusted if the server echoed the ping's challenge. Without it anyone can
+	// make an address that isn't running a server look like it is by spoofing its responses.
+	Handshake bool
+}
+
+// DefaultQueryOptions are used for any QueryOptions fields that are left zero
+var DefaultQueryOptions = QueryOptions{
+	Timeout: time.Second * 2,
+	Retries: 3,
+}
+
+// resolve looks up the address queries of a server are sent to
+func resolve(address string, opts QueryOptions) (addr *net.UDPAddr, err error) {
+	addr, err = net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve address")
+	}
+	if opts.QueryPort != 0 {
+		addr.Port = opts.QueryPort
+	}
+	return
+}
+
+func (opts QueryOptions) withDefaults() QueryOptions {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultQueryOptions.Timeout
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultQueryOptions.Retries
+	}
+	if opts.Encoding == nil {
+		opts.Encoding = DefaultEncoding
+	}
+	return opts
+}
+
+// PartialError is returned by QueryServer when the info query succeeded but a subsequent query
+// failed. The returned Server still contains everything that was received before the failure.
+type PartialError struct {
+	Opcode Opcode
+	Err    error
+}
+
+func (e PartialError) Error() string {
+	return errors.Wrapf(e.Err, "failed to query '%c'", e.Opcode).Error()
+}
+
+// Cause returns the underlying error for use with errors.Cause
+func (e PartialError) Cause() error { return e.Err }
+
+// IsTimeout reports whether a query failed because the server didn't respond in time, either every
+// attempt timed out or the context deadline passed first.
+func IsTimeout(err error) bool {
+	cause := errors.Cause(err)
+	if cause == context.DeadlineExceeded {
+		return true
+	}
+	netErr, ok := cause.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// QueryServer performs an info query followed by rules and players queries against the server at
+// the given address and returns a Server with the core fields, ping, rules and player list populated. If
+// the rules or players query fails, the server is still returned along with a PartialError. Servers
+// that do not provide a player list are returned with an empty list and no error.
+func QueryServer(ctx context.Context, address string, opts QueryOptions) (server types.Server, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	opts = opts.withDefaults()
+
+	err = handshake(ctx, addr, opts)
+	if err != nil {
+		return
+	}
+	response, rtt, err := sendQuery(ctx, addr, Info, opts)
+	if err != nil {
+		return
+	}
+
+	var raw types.RawStrings
+	server.Core, raw, err = parseInfo(response, opts.Encoding)
+	if err != nil {
+		return
+	}
+	server.Core.Address = address
+	server.Core.HostnameClean = types.CleanHostname(server.Core.Hostname)
+	server.Core.Languages = types.NormalizeLanguage(server.Core.Language)
+	server.Ping = int(measurePing(ctx, addr, opts, rtt) / time.Millisecond)
+	if raw.Hostname != nil || raw.Gamemode != nil || raw.Language != nil {
+		server.Raw = &raw
+	}
+
+	server.Rules, raw.Rules, err = queryRules(ctx, addr, opts)
+	if err != nil {
+		err = PartialError{Opcode: Rules, Err: err}
+		return
+	}
+	if raw.Rules != nil {
+		server.Raw = &raw
+	}
+
+	if version, ok := server.Rules["version"]; ok {
+		server.Core.Version = version
+	}
+	server.OpenMP = types.DetectOpenMP(server.Rules)
+
+	if server.Core.Players > maxListedPlayers {
+		return
+	}
+	players, err := queryPlayers(ctx, addr, opts)
+	setPlayerList(&server, players)
+	if errors.Cause(err) == ErrPlayerListUnavailable {
+		err = nil
+	} else if err != nil {
+		err = PartialError{Opcode: Players, Err: err}
+	}
+
+	return
+}
+
+// QueryInfo performs only an info query against the server at the given address, this is the
+// cheapest way to check whether a server is online and how many players it has, a ping is sent
+// first when the options ask for a handshake. If the response
+// was partial the fields it did contain are returned along with an error wrapping ErrPartialInfo.
+func QueryInfo(ctx context.Context, address string, opts QueryOptions) (core types.ServerCore, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+
+	opts = opts.withDefaults()
+
+	err = handshake(ctx, addr, opts)
+	if err != nil {
+		return
+	}
+	response, _, err := sendQuery(ctx, addr, Info, opts)
+	if err != nil {
+		return
+	}
+
+	core, _, err = parseInfo(response, opts.Encoding)
+	if err != nil && errors.Cause(err) != ErrPartialInfo {
+		return
+	}
+	core.Address = address
+	core.HostnameClean = types.CleanHostname(core.Hostname)
+	core.Languages = types.NormalizeLanguage(core.Language)
+
+	return
+}
+
+// handshake pings the server if the options ask for it, the ping's challenge can only be echoed by
+// whoever is really at the address so a spoofed server fails with ErrChallengeMismatch
+func handshake(ctx context.Context, addr *net.UDPAddr, opts QueryOptions) error {
+	if !opts.Handshake {
+		return nil
+	}
+	_, _, err := sendQuery(ctx, addr, Ping, opts)
+	if err != nil {
+		return errors.Wrap(err, "handshake failed")
+	}
+	return nil
+}
+
+// measurePing sends further info packets to the server in order to take a ping sample for each
+// allowed retry and returns the median of the samples, including the one from the initial query.
+// Packets that are lost are simply ignored since the server has already responded once. They're
+// sent with the same options as the query, so through its Querier and subject to its limits.
+func measurePing(ctx context.Context, addr *net.UDPAddr, opts QueryOptions, first time.Duration) time.Duration {
+	samples := []time.Duration{first}
+	single := opts
+	single.Retries = 1
+	for i := 1; i < opts.Retries; i++ {
+		_, rtt, err := sendQuery(ctx, addr, Info, single)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, rtt)
+	}
+	return median(samples)
+}
+
+func median(samples []time.Duration) time.Duration {
+	sorted := append([]time.Duration{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// QueryRules performs a rules query against the server at the given address
+func QueryRules(ctx context.Context, address string, opts QueryOptions) (rules map[string]string, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	opts = opts.withDefaults()
+	rules, _, err = queryRules(ctx, addr, opts)
+	return
+}
+
+// QueryPlayers performs a client list query against the server at the given address and returns
+// the names of the players, ErrPlayerListUnavailable is returned if the server does not list them.
+func QueryPlayers(ctx context.Context, address string, opts QueryOptions) (players []string, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	return queryPlayers(ctx, addr, opts.withDefaults())
+}
+
+func queryPlayers(ctx context.Context, addr *net.UDPAddr, opts QueryOptions) (players []string, err error) {
+	response, _, err := sendQuery(ctx, addr, Players, opts)
+	if err != nil {
+		return
+	}
+	return parsePlayers(response)
+}
+
+// QueryDetailedPlayers performs a detailed client list query against the server at the given
+// address. Servers don't list clients when there are too many players online so an info query is
+// sent first, ErrPlayerListUnavailable is returned without querying the list if there are too many
+// or if the server does not list them.
+func QueryDetailedPlayers(ctx context.Context, address string, opts QueryOptions) (players []types.PlayerDetail, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	opts = opts.withDefaults()
+
+	response, _, err := sendQuery(ctx, addr, Info, opts)
+	if err != nil {
+		return
+	}
+	core, _, err := parseInfo(response, opts.Encoding)
+	if err != nil && errors.Cause(err) != ErrPartialInfo { // only the player count is needed
+		return
+	}
+	if core.Players > maxListedPlayers {
+		return nil, ErrPlayerListUnavailable
+	}
+
+	response, _, err = sendQuery(ctx, addr, DetailedPlayers, opts)
+	if err != nil {
+		return
+	}
+	return parseDetailedPlayers(response)
+}
+
+// QueryRaw sends a single query packet with the specified opcode to the server at the given address
+// and returns the response payload as-is, without parsing it, for inspecting nonstandard responses
+func QueryRaw(ctx context.Context, address string, opcode Opcode, opts QueryOptions) (payload []byte, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	payload, _, err = sendQuery(ctx, addr, opcode, opts.withDefaults())
+	return
+}
+
+func queryRules(ctx context.Context, addr *net.UDPAddr, opts QueryOptions) (rules map[string]string, raw map[string][]byte, err error) {
+	response, _, err := sendQuery(ctx, addr, Rules, opts)
+	if err != nil {
+		return
+	}
+	return parseRules(response, opts.Encoding)
+}
+
+// sendQuery writes a query packet with the specified opcode to the address and returns the raw
+// response with the header stripped off along with the round-trip time of the successful attempt.
+// The packet is re-sent if no response arrives within the timeout, up to the amount of retries, or
+// until the context is cancelled. The query is sent through the Querier in the options if there is
+// one, otherwise a socket is dialled just for this query, or a connection when it's sent over TCP.
+func sendQuery(ctx context.Context, addr *net.UDPAddr, opcode Opcode, opts QueryOptions) (response []byte, rtt time.Duration, err error) {
+	if opts.Querier != nil {
+		return opts.Querier.send(ctx, addr, opcode, opts)
+	}
+
+	request, err := buildRequest(addr, opcode)
+	if err != nil {
+		return
+	}
+	if opts.transport() == TransportTCP {
+		return sendTCP(ctx, addr, request, opts, nil, nil)
+	}
+
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		err = errors.Wrap(err, "failed to dial")
+		return
+	}
+	defer conn.Close()
+
+	buf := make([]byte, maxResponseLength)
+	mismatched := false
+	for attempt := 0; attempt < opts.Retries; attempt++ {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+
+		deadline := time.Now().Add(opts.Timeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		err = conn.SetReadDeadline(deadline)
+		if err != nil {
+			err = errors.Wrap(err, "failed to set read deadline")
+			return
+		}
+
+		sent := time.Now()
+		_, err = conn.Write(request)
+		if err != nil {
+			err = errors.Wrap(err, "failed to write request")
+			return
+		}
+
+		var n int
+		n, err = conn.Read(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				continue
+			}
+			err = errors.Wrap(err, "failed to read response")
+			return
+		}
+
+		rtt = time.Since(sent)
+
+		response, err = checkHeader(request, buf[:n])
+		if err == ErrChallengeMismatch {
+			// spoofed, the challenge stays the same so the real response can still answer a retry
+			mismatched = true
+			continue
+		}
+		return
+	}
+
+	if mismatched {
+		err = ErrChallengeMismatch
+	}
+	err = errors.Wrapf(err, "server %s did not respond after %d attempts", addr, opts.Retries)
+	return
+}
+
+// buildRequest creates the query packet for an address and opcode, ping requests are given a new
+// challenge each time
+func buildRequest(addr *net.UDPAddr, opcode Opcode) (request []byte, err error) {
+	ip := addr.IP.To4()
+	if ip == nil {
+		err = errors.Errorf("address '%s' is not an IPv4 address", addr.IP)
+		return
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, headerLength))
+	buf.WriteString("SAMP")
+	buf.Write(ip)
+	err = binary.Write(buf, binary.LittleEndian, uint16(addr.Port))
+	if err != nil {
+		return
+	}
+	buf.WriteByte(byte(opcode))
+
+	if opcode == Ping {
+		challenge, err := newChallenge()
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(challenge)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// checkHeader ensures the response starts with the same header that was sent in the request and
+// returns the remainder of the response. The opcode is the last byte of the header. Ping responses
+// must echo the challenge too, ErrChallengeMismatch is returned if they don't.
+func checkHeader(request, response []byte) (payload []byte, err error) {
+	opcode := request[headerLength-1]
+	if len(response) < headerLength {
+		err = newParseError(opcode, ReasonBadMagic, "response of %d bytes is too short to contain a header", len(response))
+		return
+	}
+	if !bytes.Equal(request[:headerLength], response[:headerLength]) {
+		err = newParseError(opcode, ReasonBadMagic, "response header %q does not match request header %q",
+			response[:headerLength], request[:headerLength])
+		return
+	}
+	if Opcode(opcode) == Ping {
+		return checkChallenge(request, response)
+	}
+	return response[headerLength:], nil
+}
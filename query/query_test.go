@@ -0,0 +1,342 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// fakeServer listens on a random local port and answers queries using the responses map, keyed by
+// opcode. Responses are written after an echo of the request header, like a real server. The first
+// `drop` packets received are ignored in order to simulate packet loss.
+func fakeServer(t testing.TB, drop int, responses map[Opcode][]byte) (address string, stop func()) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if n < headerLength {
+				continue
+			}
+			if drop > 0 {
+				drop--
+				continue
+			}
+			payload, ok := responses[Opcode(buf[headerLength-1])]
+			if !ok {
+				continue
+			}
+			conn.WriteToUDP(append(append([]byte{}, buf[:n]...), payload...), from) // nolint:errcheck
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() } // nolint:errcheck
+}
+
+func string32(s string) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+func infoPayload(password bool, players, maxPlayers uint16, hostname, gamemode, language string) []byte {
+	buf := new(bytes.Buffer)
+	if password {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	binary.Write(buf, binary.LittleEndian, players)    // nolint:errcheck
+	binary.Write(buf, binary.LittleEndian, maxPlayers) // nolint:errcheck
+	buf.Write(string32(hostname))
+	buf.Write(string32(gamemode))
+	buf.Write(string32(language))
+	return buf.Bytes()
+}
+
+func TestQueryServer(t *testing.T) {
+	info := infoPayload(false, 4, 32, "{FF0000}Scavenge and Survive {FFFFFF}Official", "Scavenge & Survive by Southclaws", "English")
+	rules := rulesPayload("mapname", "San Androcalypse", "version", "0.3.7-R2")
+	players := playersPayload("Southclaws", "Y_Less")
+	wantServer := types.Server{
+		Core: types.ServerCore{
+			Hostname:      "{FF0000}Scavenge and Survive {FFFFFF}Official",
+			HostnameClean: "Scavenge and Survive Official",
+			Players:       4,
+			MaxPlayers:    32,
+			Gamemode:      "Scavenge & Survive by Southclaws",
+			Language:      "English",
+			Languages:     []string{"English"},
+			Password:      false,
+			Version:       "0.3.7-R2",
+		},
+		Rules:      map[string]string{"mapname": "San Androcalypse", "version": "0.3.7-R2"},
+		PlayerList: []string{"Southclaws", "Y_Less"},
+	}
+	unlisted := wantServer
+	unlisted.PlayerList = nil
+	partialServer := types.Server{Core: wantServer.Core}
+	partialServer.Core.Version = ""
+	fast := QueryOptions{Timeout: time.Millisecond * 50, Retries: 3}
+	tests := []struct {
+		name        string
+		drop        int
+		responses   map[Opcode][]byte
+		opts        QueryOptions
+		wantServer  types.Server
+		wantErr     bool
+		wantPartial bool
+	}{
+		{"valid", 0, map[Opcode][]byte{Info: info, Rules: rules, Players: players}, QueryOptions{}, wantServer, false, false},
+		{"valid retried", 2, map[Opcode][]byte{Info: info, Rules: rules, Players: players}, fast, wantServer, false, false},
+		{"valid player list unavailable", 0, map[Opcode][]byte{Info: info, Rules: rules, Players: {}}, fast, unlisted, false, false},
+		{"partial no rules", 0, map[Opcode][]byte{Info: info}, fast, partialServer, true, true},
+		{"partial no players", 0, map[Opcode][]byte{Info: info, Rules: rules}, fast, unlisted, true, true},
+		{"invalid retries exhausted", 3, map[Opcode][]byte{Info: info, Rules: rules, Players: players}, fast, types.Server{}, true, false},
+		{"invalid no response", 0, map[Opcode][]byte{}, QueryOptions{Timeout: time.Millisecond * 50, Retries: 1}, types.Server{}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, stop := fakeServer(t, tt.drop, tt.responses)
+			defer stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			gotServer, err := QueryServer(ctx, address, tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				_, partial := err.(PartialError)
+				assert.Equal(t, tt.wantPartial, partial)
+			} else {
+				assert.NoError(t, err)
+			}
+			if !tt.wantErr || tt.wantPartial {
+				// ping depends on the machine running the test, and is usually zero on loopback
+				assert.True(t, gotServer.Ping >= 0)
+				gotServer.Ping = 0
+
+				tt.wantServer.Core.Address = address
+				assert.Equal(t, tt.wantServer, gotServer)
+			}
+		})
+	}
+}
+
+func TestQueryInfo(t *testing.T) {
+	info := infoPayload(true, 50, 50, "Stunt Paradise", "rivershell", "Polish")
+
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{Info: info})
+	defer stop()
+
+	gotCore, err := QueryInfo(context.Background(), address, QueryOptions{Timeout: time.Millisecond * 50})
+	assert.NoError(t, err)
+	assert.Equal(t, types.ServerCore{
+		Address:       address,
+		Hostname:      "Stunt Paradise",
+		HostnameClean: "Stunt Paradise",
+		Players:       50,
+		MaxPlayers:    50,
+		Gamemode:      "rivershell",
+		Language:      "Polish",
+		Languages:     []string{"Polish"},
+		Password:      true,
+	}, gotCore)
+}
+
+func TestQueryInfo_QueryPort(t *testing.T) {
+	info := infoPayload(false, 4, 32, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", "English")
+
+	queryAddress, stop := fakeServer(t, 0, map[Opcode][]byte{Info: info})
+	defer stop()
+	_, port, err := net.SplitHostPort(queryAddress)
+	require.NoError(t, err)
+	queryPort, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	// nothing is listening on the game port so the query only succeeds if it's sent to the query port
+	gotCore, err := QueryInfo(context.Background(), "127.0.0.1:7777", QueryOptions{Timeout: time.Millisecond * 50, QueryPort: queryPort})
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:7777", gotCore.Address)
+	assert.Equal(t, "Scavenge and Survive Official", gotCore.Hostname)
+}
+
+func TestQueryDetailedPlayers(t *testing.T) {
+	players := []types.PlayerDetail{{ID: 1, Name: "Southclaws", Score: 10, Ping: 30}}
+	tests := []struct {
+		name            string
+		responses       map[Opcode][]byte
+		wantPlayers     []types.PlayerDetail
+		wantUnavailable bool
+	}{
+		{"valid", map[Opcode][]byte{
+			Info:            infoPayload(false, 1, 50, "Stunt Paradise", "rivershell", "Polish"),
+			DetailedPlayers: detailedPlayersPayload(players...),
+		}, players, false},
+		{"too many players", map[Opcode][]byte{
+			Info: infoPayload(false, 101, 200, "Stunt Paradise", "rivershell", "Polish"),
+		}, nil, true},
+		{"unavailable", map[Opcode][]byte{
+			Info:            infoPayload(false, 1, 50, "Stunt Paradise", "rivershell", "Polish"),
+			DetailedPlayers: {},
+		}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, stop := fakeServer(t, 0, tt.responses)
+			defer stop()
+
+			gotPlayers, err := QueryDetailedPlayers(context.Background(), address, QueryOptions{Timeout: time.Millisecond * 50})
+			if tt.wantUnavailable {
+				assert.Equal(t, ErrPlayerListUnavailable, errors.Cause(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantPlayers, gotPlayers)
+			}
+		})
+	}
+}
+
+func TestQueryDetailedPlayers_Full(t *testing.T) {
+	players := make([]types.PlayerDetail, maxListedPlayers)
+	for i := range players {
+		players[i] = types.PlayerDetail{ID: i, Name: fmt.Sprintf("%024d", i), Score: i, Ping: 30}
+	}
+	payload := detailedPlayersPayload(players...)
+	require.True(t, len(payload) > 2048)
+
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{
+		Info:            infoPayload(false, maxListedPlayers, maxListedPlayers, "Stunt Paradise", "rivershell", "Polish"),
+		DetailedPlayers: payload,
+	})
+	defer stop()
+
+	q, err := NewQuerier()
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	for _, querier := range []*Querier{nil, q} {
+		got, err := QueryDetailedPlayers(context.Background(), address, QueryOptions{Timeout: time.Millisecond * 200, Querier: querier})
+		assert.NoError(t, err)
+		assert.Equal(t, players, got)
+	}
+}
+
+func TestQueryRaw(t *testing.T) {
+	players := playersPayload("Southclaws")
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{Players: players})
+	defer stop()
+
+	payload, err := QueryRaw(context.Background(), address, Players, QueryOptions{Timeout: time.Millisecond * 50})
+	assert.NoError(t, err)
+	assert.Equal(t, players, payload)
+}
+
+func TestParseOpcode(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Opcode
+		wantErr bool
+	}{
+		{"info", "i", Info, false},
+		{"rules", "r", Rules, false},
+		{"players", "c", Players, false},
+		{"detailed players", "d", DetailedPlayers, false},
+		{"ping", "p", 0, true},
+		{"empty", "", 0, true},
+		{"too long", "ir", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOpcode(tt.s)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []time.Duration
+		want    time.Duration
+	}{
+		{"single", []time.Duration{time.Millisecond * 40}, time.Millisecond * 40},
+		{"odd", []time.Duration{time.Millisecond * 90, time.Millisecond * 20, time.Millisecond * 40}, time.Millisecond * 40},
+		{"even", []time.Duration{time.Millisecond * 50, time.Millisecond * 20, time.Millisecond * 30, time.Millisecond * 500}, time.Millisecond * 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, median(tt.samples))
+		})
+	}
+}
+
+func TestCheckHeader(t *testing.T) {
+	request := []byte("SAMP\x7f\x00\x00\x01\x61\x1ei")
+	tests := []struct {
+		name        string
+		response    []byte
+		wantPayload []byte
+		wantErr     bool
+	}{
+		{"valid", []byte("SAMP\x7f\x00\x00\x01\x61\x1eipayload"), []byte("payload"), false},
+		{"valid empty", []byte("SAMP\x7f\x00\x00\x01\x61\x1ei"), []byte{}, false},
+		{"invalid magic", []byte("PMAS\x7f\x00\x00\x01\x61\x1eipayload"), nil, true},
+		{"invalid opcode", []byte("SAMP\x7f\x00\x00\x01\x61\x1erpayload"), nil, true},
+		{"invalid short", []byte("SAMP"), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPayload, err := checkHeader(request, tt.response)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantPayload, gotPayload)
+			}
+		})
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"deadline", context.DeadlineExceeded, true},
+		{"read timeout", errors.Wrap(timeoutError{}, "server did not respond after 3 attempts"), true},
+		{"partial timeout", PartialError{Opcode: Rules, Err: timeoutError{}}, true},
+		{"refused", errors.New("connection refused"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTimeout(tt.err))
+		})
+	}
+}
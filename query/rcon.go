@@ -0,0 +1,155 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidRCONPassword is returned when a server rejects the password of an RCON command
+var ErrInvalidRCONPassword = errors.New("invalid rcon password")
+
+// invalidRCONPassword is what servers respond with instead of running a command with the wrong
+// password
+const invalidRCONPassword = "Invalid RCON password."
+
+// rconLineTimeout is how long to wait for each line of output after the first. Servers send every
+// line of a command's output as soon as it's run, so a short gap means the output is complete.
+const rconLineTimeout = time.Millisecond * 250
+
+// RCON runs a command on the server at the given address with the 'x' opcode and returns each line
+// of its output. Unlike other queries, the packet is only sent once since commands aren't safe to
+// repeat and it's always sent from its own socket since the output spans several packets, although
+// it still counts towards the concurrency limit of the Querier in the options if there is one.
+// Servers don't respond at all to commands that have no output so an empty output is
+// returned if nothing arrives within the timeout. ErrInvalidRCONPassword is returned if the server
+// rejects the password. The password is never included in any returned error.
+func RCON(ctx context.Context, address, password, command string, opts QueryOptions) (output []string, err error) {
+	addr, err := resolve(address, opts)
+	if err != nil {
+		return
+	}
+	opts = opts.withDefaults()
+
+	request, err := buildRCONRequest(addr, password, command)
+	if err != nil {
+		return
+	}
+
+	if opts.Querier != nil {
+		var release func()
+		release, err = opts.Querier.acquire(ctx)
+		if err != nil {
+			return
+		}
+		defer release()
+	}
+
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		err = errors.Wrap(err, "failed to dial")
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Write(request)
+	if err != nil {
+		err = errors.Wrap(err, "failed to write request")
+		return
+	}
+
+	output = []string{}
+	buf := make([]byte, 2048)
+	wait := opts.Timeout
+	for {
+		deadline := time.Now().Add(wait)
+		ctxDeadline, limited := ctx.Deadline()
+		if limited && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		} else {
+			limited = false
+		}
+		err = conn.SetReadDeadline(deadline)
+		if err != nil {
+			err = errors.Wrap(err, "failed to set read deadline")
+			return
+		}
+
+		var n int
+		n, err = conn.Read(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				// the context running out is reported, only the output coming to an end isn't. The
+				// read deadline can pass a moment before the context notices so it's not consulted.
+				if limited && len(output) == 0 {
+					return nil, context.DeadlineExceeded
+				}
+				return output, nil
+			}
+			err = errors.Wrap(err, "failed to read response")
+			return
+		}
+
+		var payload []byte
+		payload, err = checkHeader(request, buf[:n])
+		if err != nil {
+			return
+		}
+		var line string
+		line, err = parseRCONLine(payload)
+		if err != nil {
+			return
+		}
+		if strings.EqualFold(line, invalidRCONPassword) {
+			return nil, ErrInvalidRCONPassword
+		}
+		output = append(output, line)
+
+		wait = rconLineTimeout
+	}
+}
+
+// buildRCONRequest creates an 'x' packet, which is the usual header followed by the password and
+// the command, each prefixed with a 2 byte length
+func buildRCONRequest(addr *net.UDPAddr, password, command string) (request []byte, err error) {
+	if command == "" {
+		return nil, errors.New("rcon command is empty")
+	}
+	if len(password) > math.MaxUint16 {
+		return nil, errors.New("rcon password is too long")
+	}
+	if len(command) > math.MaxUint16 {
+		return nil, errors.New("rcon command is too long")
+	}
+
+	header, err := buildRequest(addr, RCONCommand)
+	if err != nil {
+		return
+	}
+
+	buf := bytes.NewBuffer(header)
+	binary.Write(buf, binary.LittleEndian, uint16(len(password))) // nolint:errcheck
+	buf.WriteString(password)
+	binary.Write(buf, binary.LittleEndian, uint16(len(command))) // nolint:errcheck
+	buf.WriteString(command)
+
+	return buf.Bytes(), nil
+}
+
+// parseRCONLine decodes the payload of an 'x' response, each response is a single line of output
+// prefixed with a 2 byte length
+func parseRCONLine(payload []byte) (line string, err error) {
+	r := reader{buf: payload, opcode: RCONCommand}
+	b, err := r.string16()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read rcon output")
+		return
+	}
+	return string(b), nil
+}
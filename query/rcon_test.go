@@ -0,0 +1,113 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rconLine(line string) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(len(line))) // nolint:errcheck
+	buf.WriteString(line)
+	return buf.Bytes()
+}
+
+// fakeRCONServer answers 'x' packets with each line of output in its own packet if the password
+// is correct, or with the invalid password response if it's not
+func fakeRCONServer(t *testing.T, password string, output ...string) (address string, stop func()) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			r := reader{buf: buf[headerLength:n]}
+			got, err := r.string16()
+			if err != nil {
+				continue
+			}
+			header := append([]byte{}, buf[:headerLength]...)
+
+			lines := output
+			if string(got) != password {
+				lines = []string{invalidRCONPassword}
+			}
+			for _, line := range lines {
+				conn.WriteToUDP(append(append([]byte{}, header...), rconLine(line)...), from) // nolint:errcheck
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() } // nolint:errcheck
+}
+
+func TestRCON(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		output     []string
+		wantOutput []string
+		wantErr    error
+	}{
+		{"valid", "changeme", []string{"ID\tName\tPing\tIP", "0\tSouthclaws\t48\t127.0.0.1"}, []string{"ID\tName\tPing\tIP", "0\tSouthclaws\t48\t127.0.0.1"}, nil},
+		{"valid no output", "changeme", nil, []string{}, nil},
+		{"invalid password", "wrong", []string{"never sent"}, nil, ErrInvalidRCONPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, stop := fakeRCONServer(t, "changeme", tt.output...)
+			defer stop()
+
+			gotOutput, err := RCON(context.Background(), address, tt.password, "players", QueryOptions{Timeout: time.Millisecond * 100})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantOutput, gotOutput)
+		})
+	}
+}
+
+func TestRCON_ContextDeadline(t *testing.T) {
+	address, stop := fakeRCONServer(t, "changeme")
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	_, err := RCON(ctx, address, "changeme", "players", QueryOptions{Timeout: time.Second})
+	assert.True(t, IsTimeout(err))
+}
+
+func TestBuildRCONRequest(t *testing.T) {
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 7777}
+	tests := []struct {
+		name        string
+		password    string
+		command     string
+		wantRequest []byte
+		wantErr     bool
+	}{
+		{"valid", "pw", "gmx", []byte("SAMP\x7f\x00\x00\x01\x61\x1ex\x02\x00pw\x03\x00gmx"), false},
+		{"invalid empty command", "pw", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRequest, err := buildRCONRequest(addr, tt.password, tt.command)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantRequest, gotRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package query
+
+import (
+	"encoding/binary"
+)
+
+// reader consumes little-endian integers and length-prefixed strings from a response payload,
+// returning an error instead of panicking when the payload is shorter than it claims to be. The
+// errors are parseErrors for the opcode of the response.
+type reader struct {
+	buf    []byte
+	pos    int
+	opcode Opcode
+}
+
+func (r *reader) remaining() int {
+	return len(r.buf) - r.pos
+}
+
+func (r *reader) bytes(n int) (b []byte, err error) {
+	if n < 0 || n > r.remaining() {
+		err = newParseError(byte(r.opcode), ReasonLengthOverflow, "cannot read %d bytes at offset %d, only %d remaining", n, r.pos, r.remaining())
+		return
+	}
+	b = r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return
+}
+
+func (r *reader) uint8() (v uint8, err error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return
+	}
+	return b[0], nil
+}
+
+func (r *reader) uint16() (v uint16, err error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *reader) uint32() (v uint32, err error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// string8 reads a string prefixed with a 1 byte length
+func (r *reader) string8() (s []byte, err error) {
+	n, err := r.uint8()
+	if err != nil {
+		return
+	}
+	if int(n) > r.remaining() {
+		err = newParseError(byte(r.opcode), ReasonTruncatedString, "string length %d exceeds remaining %d bytes", n, r.remaining())
+		return
+	}
+	return r.bytes(int(n))
+}
+
+// string16 reads a string prefixed with a 2 byte length
+func (r *reader) string16() (s []byte, err error) {
+	n, err := r.uint16()
+	if err != nil {
+		return
+	}
+	if int(n) > r.remaining() {
+		err = newParseError(byte(r.opcode), ReasonTruncatedString, "string length %d exceeds remaining %d bytes", n, r.remaining())
+		return
+	}
+	return r.bytes(int(n))
+}
+
+// string32 reads a string prefixed with a 4 byte length
+func (r *reader) string32() (s []byte, err error) {
+	n, err := r.uint32()
+	if err != nil {
+		return
+	}
+	if int64(n) > int64(r.remaining()) {
+		err = newParseError(byte(r.opcode), ReasonTruncatedString, "string length %d exceeds remaining %d bytes", n, r.remaining())
+		return
+	}
+	return r.bytes(int(n))
+}
@@ -0,0 +1,49 @@
+package query
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+)
+
+// parseRules decodes the payload of an 'r' response. The payload consists of a 2 byte rule count
+// followed by pairs of rule names and values, each of which are prefixed with a 1 byte length.
+// Names and values are decoded the same way as in parseInfo, raw holds the original bytes of any
+// values that needed transcoding, keyed by the decoded name.
+func parseRules(payload []byte, enc encoding.Encoding) (rules map[string]string, raw map[string][]byte, err error) {
+	r := reader{buf: payload, opcode: Rules}
+
+	count, err := r.uint16()
+	if err != nil {
+		err = errors.Wrap(err, "failed to read rule count")
+		return
+	}
+
+	rules = make(map[string]string, count)
+	for i := 0; i < int(count); i++ {
+		var name, value []byte
+
+		name, err = r.string8()
+		if err != nil {
+			err = errors.Wrapf(err, "failed to read name of rule %d", i)
+			return nil, nil, err
+		}
+
+		value, err = r.string8()
+		if err != nil {
+			err = errors.Wrapf(err, "failed to read value of rule '%s'", name)
+			return nil, nil, err
+		}
+
+		key, _ := decodeString(name, enc)
+		decoded, rawValue := decodeField(value, enc)
+		rules[key] = decoded
+		if rawValue != nil {
+			if raw == nil {
+				raw = make(map[string][]byte)
+			}
+			raw[key] = rawValue
+		}
+	}
+
+	return
+}
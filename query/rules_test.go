@@ -0,0 +1,59 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rulesPayload(rules ...string) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(len(rules)/2)) // nolint:errcheck
+	for _, s := range rules {
+		buf.WriteByte(byte(len(s)))
+		buf.WriteString(s)
+	}
+	return buf.Bytes()
+}
+
+func TestParseRules(t *testing.T) {
+	full := rulesPayload("mapname", "San Andreas", "version", "0.3.7-R2", "weather", "10", "worldtime", "10:00")
+	tests := []struct {
+		name      string
+		payload   []byte
+		wantRules map[string]string
+		wantRaw   map[string][]byte
+		wantErr   bool
+	}{
+		{"valid", full, map[string]string{
+			"mapname":   "San Andreas",
+			"version":   "0.3.7-R2",
+			"weather":   "10",
+			"worldtime": "10:00",
+		}, nil, false},
+		{"valid empty value", rulesPayload("weburl", ""), map[string]string{"weburl": ""}, nil, false},
+		{"valid zero rules", rulesPayload(), map[string]string{}, nil, false},
+		{"valid windows-1251", rulesPayload("mapname", privet, "weburl", "samp.ru"), map[string]string{
+			"mapname": "Привет",
+			"weburl":  "samp.ru",
+		}, map[string][]byte{"mapname": []byte(privet)}, false},
+		{"invalid empty", []byte{}, nil, nil, true},
+		{"invalid truncated", full[:len(full)-2], nil, nil, true},
+		{"invalid length", []byte{1, 0, 0xff, 'a'}, nil, nil, true},
+		{"invalid count", []byte{2, 0, 1, 'a', 1, 'b'}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRules, gotRaw, err := parseRules(tt.payload, DefaultEncoding)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantRules, gotRules)
+				assert.Equal(t, tt.wantRaw, gotRaw)
+			}
+		})
+	}
+}
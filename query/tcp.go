@@ -0,0 +1,156 @@
+package query
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Transport is how query packets are carried between the API and a server
+type Transport int
+
+const (
+	// TransportUDP sends each packet as a datagram, which is how SA:MP servers answer queries
+	TransportUDP Transport = iota + 1
+	// TransportTCP sends packets over a TCP connection, for servers behind firewalls that block UDP
+	// and proxy queries over TCP instead. The packets are the same as over UDP but since a stream has
+	// no packet boundaries each one is prefixed with its length as a little-endian uint16.
+	TransportTCP
+)
+
+// transport returns the transport a query is sent over, the transport in the options takes
+// precedence over the one of the Querier so individual servers can be queried differently
+func (opts QueryOptions) transport() Transport {
+	if opts.Transport != 0 {
+		return opts.Transport
+	}
+	if opts.Querier != nil {
+		return opts.Querier.transport
+	}
+	return TransportUDP
+}
+
+// sendTCP sends a query over TCP and returns the response with the header stripped off. Each attempt
+// dials a connection of its own which is closed once it's over, so an attempt that timed out can't
+// leave a late response behind for the next one to read. Only attempts that time out are retried,
+// a connection being refused or reset is the server's answer rather than a lost packet. The wait
+// func is called before each attempt and done cancels an attempt when closed, either may be nil.
+func sendTCP(ctx context.Context, addr *net.UDPAddr, request []byte, opts QueryOptions, wait func(context.Context) error, done <-chan struct{}) (response []byte, rtt time.Duration, err error) {
+	for attempt := 0; attempt < opts.Retries; attempt++ {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		if wait != nil {
+			err = wait(ctx)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		response, rtt, err = attemptTCP(ctx, addr.String(), request, opts.Timeout, done)
+		if err == nil {
+			return
+		}
+		select {
+		case <-done:
+			return nil, 0, ErrQuerierClosed
+		default:
+		}
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		if !IsTimeout(err) {
+			return nil, 0, err
+		}
+	}
+
+	err = errors.Wrapf(err, "server %s did not respond after %d attempts", addr, opts.Retries)
+	return
+}
+
+// attemptTCP connects to the server, writes the request and reads back one response. The timeout
+// covers the whole exchange, including setting up the connection.
+func attemptTCP(ctx context.Context, address string, request []byte, timeout time.Duration, done <-chan struct{}) (response []byte, rtt time.Duration, err error) {
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		err = errors.Wrap(err, "failed to dial")
+		return
+	}
+	defer conn.Close()
+
+	// the deadline doesn't notice the context being cancelled or the Querier being closed, closing
+	// the connection unblocks whichever read or write is in progress when either happens
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		case <-finished:
+			return
+		}
+		conn.Close() // nolint:errcheck
+	}()
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		err = errors.Wrap(err, "failed to set deadline")
+		return
+	}
+
+	sent := time.Now()
+	err = writeFrame(conn, request)
+	if err != nil {
+		err = errors.Wrap(err, "failed to write request")
+		return
+	}
+
+	packet, err := readFrame(conn)
+	if err != nil {
+		err = errors.Wrap(err, "failed to read response")
+		return
+	}
+	rtt = time.Since(sent)
+
+	response, err = checkHeader(request, packet)
+	return
+}
+
+// writeFrame writes a packet to a stream prefixed with its length
+func writeFrame(w io.Writer, packet []byte) (err error) {
+	if len(packet) > math.MaxUint16 {
+		return errors.Errorf("packet of %d bytes is too long to frame", len(packet))
+	}
+	frame := make([]byte, 2, 2+len(packet))
+	binary.LittleEndian.PutUint16(frame, uint16(len(packet)))
+	_, err = w.Write(append(frame, packet...))
+	return
+}
+
+// readFrame reads one length prefixed packet from a stream. Unlike a datagram a frame may arrive
+// split across several reads, so exactly as many bytes as the prefix says are read, and a stream
+// that ends part way through a frame is an error rather than a short packet.
+func readFrame(r io.Reader) (packet []byte, err error) {
+	var length [2]byte
+	_, err = io.ReadFull(r, length[:])
+	if err != nil {
+		return
+	}
+	packet = make([]byte, binary.LittleEndian.Uint16(length[:]))
+	_, err = io.ReadFull(r, packet)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
@@ -0,0 +1,182 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTCPServer answers queries framed over TCP in the same way as fakeServer answers datagrams,
+// the first drop connections are held open without a response. Responses are written in two halves
+// so readers have to put frames back together.
+func fakeTCPServer(t testing.TB, drop int32, responses map[Opcode][]byte) (address string, connections func() int32, stop func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accepted int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if atomic.AddInt32(&accepted, 1) <= drop {
+				defer conn.Close() // nolint:errcheck
+				continue
+			}
+			go func() {
+				defer conn.Close() // nolint:errcheck
+				for {
+					request, err := readFrame(conn)
+					if err != nil || len(request) < headerLength {
+						return
+					}
+					payload, ok := responses[Opcode(request[headerLength-1])]
+					if !ok {
+						continue
+					}
+					frame := new(bytes.Buffer)
+					writeFrame(frame, append(request, payload...)) // nolint:errcheck
+					half := frame.Len() / 2
+					conn.Write(frame.Bytes()[:half]) // nolint:errcheck
+					time.Sleep(time.Millisecond)
+					conn.Write(frame.Bytes()[half:]) // nolint:errcheck
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(),
+		func() int32 { return atomic.LoadInt32(&accepted) },
+		func() { listener.Close() } // nolint:errcheck
+}
+
+func TestFrame(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeFrame(buf, []byte("SAMPpacket")))
+	require.NoError(t, writeFrame(buf, []byte{}))
+	assert.Equal(t, []byte{10, 0}, buf.Bytes()[:2])
+
+	r := iotest.OneByteReader(buf)
+	packet, err := readFrame(r)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("SAMPpacket"), packet)
+	packet, err = readFrame(r)
+	assert.NoError(t, err)
+	assert.Empty(t, packet)
+	_, err = readFrame(r)
+	assert.Equal(t, io.EOF, err)
+
+	_, err = readFrame(bytes.NewReader([]byte{10, 0, 'S', 'A'}))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	assert.Error(t, writeFrame(buf, make([]byte, 1<<16)))
+}
+
+func TestQueryInfo_TCP(t *testing.T) {
+	address, connections, stop := fakeTCPServer(t, 0, map[Opcode][]byte{
+		Info:  infoPayload(false, 4, 32, "Stunt Paradise", "rivershell", "Polish"),
+		Rules: rulesPayload("version", "0.3.7-R2"),
+	})
+	defer stop()
+
+	opts := QueryOptions{Timeout: time.Millisecond * 200, Transport: TransportTCP}
+	core, err := QueryInfo(context.Background(), address, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Stunt Paradise", core.Hostname)
+	assert.Equal(t, 4, core.Players)
+
+	rules, err := QueryRules(context.Background(), address, opts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"version": "0.3.7-R2"}, rules)
+
+	// each query is a connection of its own
+	assert.Equal(t, int32(2), connections())
+}
+
+func TestQueryInfo_TCPTimeout(t *testing.T) {
+	address, connections, stop := fakeTCPServer(t, 1, map[Opcode][]byte{
+		Info: infoPayload(false, 4, 32, "Stunt Paradise", "rivershell", "Polish"),
+	})
+	defer stop()
+
+	// the first connection never answers, the retry dials a second one which does
+	opts := QueryOptions{Timeout: time.Millisecond * 50, Retries: 2, Transport: TransportTCP}
+	core, err := QueryInfo(context.Background(), address, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Stunt Paradise", core.Hostname)
+	assert.Equal(t, int32(2), connections())
+
+	address, _, stop = fakeTCPServer(t, 2, map[Opcode][]byte{})
+	defer stop()
+	_, err = QueryInfo(context.Background(), address, opts)
+	assert.True(t, IsTimeout(err))
+}
+
+func TestQueryInfo_TCPRefused(t *testing.T) {
+	address, _, stop := fakeTCPServer(t, 0, map[Opcode][]byte{})
+	stop()
+
+	// a refused connection isn't retried since the server did answer
+	start := time.Now()
+	_, err := QueryInfo(context.Background(), address, QueryOptions{Timeout: time.Second, Retries: 3, Transport: TransportTCP})
+	assert.Error(t, err)
+	assert.False(t, IsTimeout(err))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestQuerier_Transport(t *testing.T) {
+	q, err := NewQuerier(WithTransport(TransportTCP))
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	tcpAddress, connections, stop := fakeTCPServer(t, 0, map[Opcode][]byte{
+		Info: infoPayload(false, 4, 32, "over tcp", "rivershell", "Polish"),
+	})
+	defer stop()
+	udpAddress, stop := fakeServer(t, 0, map[Opcode][]byte{
+		Info: infoPayload(false, 4, 32, "over udp", "rivershell", "Polish"),
+	})
+	defer stop()
+
+	core, err := QueryInfo(context.Background(), tcpAddress, QueryOptions{Timeout: time.Millisecond * 200, Querier: q})
+	require.NoError(t, err)
+	assert.Equal(t, "over tcp", core.Hostname)
+	assert.Equal(t, int32(1), connections())
+
+	// the transport of a server's options takes precedence over the Querier's
+	core, err = QueryInfo(context.Background(), udpAddress, QueryOptions{Timeout: time.Millisecond * 200, Querier: q, Transport: TransportUDP})
+	require.NoError(t, err)
+	assert.Equal(t, "over udp", core.Hostname)
+	assert.Zero(t, pendingQueries(q))
+}
+
+func TestQuerier_TransportClose(t *testing.T) {
+	q, err := NewQuerier(WithTransport(TransportTCP))
+	require.NoError(t, err)
+
+	address, connections, stop := fakeTCPServer(t, 1, map[Opcode][]byte{})
+	defer stop()
+
+	errs := make(chan error)
+	go func() {
+		_, err := q.Query(context.Background(), address, Info, QueryOptions{Timeout: time.Second})
+		errs <- err
+	}()
+
+	for connections() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.NoError(t, q.Close())
+	assert.Equal(t, ErrQuerierClosed, <-errs)
+}
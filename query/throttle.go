@@ -0,0 +1,91 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxThrottleWait is the longest a packet waits for its turn to be sent to a host, a packet that
+// would have to wait longer fails with a timeout instead of queueing up behind the others.
+const maxThrottleWait = time.Second * 2
+
+// hostLimiter spaces out the packets sent to each host so that no more than a set amount are sent
+// per second, regardless of how many queries are being made of it at once. Each packet reserves
+// the next free slot for its host and waits until then.
+type hostLimiter struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	next      map[string]time.Time
+	lastEvict time.Time
+}
+
+func newHostLimiter(rps float64) *hostLimiter {
+	return &hostLimiter{
+		interval: time.Duration(float64(time.Second) / rps),
+		now:      time.Now,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// wait blocks until a packet may be sent to the host. If the wait would be longer than
+// maxThrottleWait or would outlast the context, the slot isn't reserved and a timeout is returned
+// straight away.
+func (l *hostLimiter) wait(ctx context.Context, host string) (err error) {
+	now := l.now()
+	wait, ok := l.reserve(ctx, host, now)
+	if !ok {
+		return errors.Wrapf(timeoutError{}, "too many queries to %s", host)
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *hostLimiter) reserve(ctx context.Context, host string, now time.Time) (wait time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastEvict) > time.Minute {
+		l.evict(now)
+		l.lastEvict = now
+	}
+
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	wait = slot.Sub(now)
+	if wait > maxThrottleWait {
+		return 0, false
+	}
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline && slot.After(deadline) {
+		return 0, false
+	}
+
+	l.next[host] = slot.Add(l.interval)
+	return wait, true
+}
+
+// evict removes hosts whose next slot has already passed, they're equivalent to a host that hasn't
+// been sent anything yet and would otherwise accumulate forever.
+func (l *hostLimiter) evict(now time.Time) {
+	for host, next := range l.next {
+		if next.Before(now) {
+			delete(l.next, host)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostLimiter_reserve(t *testing.T) {
+	l := newHostLimiter(2)
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// packets to the same host are spaced half a second apart, other hosts aren't affected
+	for _, want := range []time.Duration{0, time.Millisecond * 500, time.Second, time.Millisecond * 1500, time.Second * 2} {
+		wait, ok := l.reserve(context.Background(), "1.2.3.4", now)
+		assert.True(t, ok)
+		assert.Equal(t, want, wait)
+	}
+	wait, ok := l.reserve(context.Background(), "5.6.7.8", now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), wait)
+
+	// too long a wait fails without using up a slot
+	_, ok = l.reserve(context.Background(), "1.2.3.4", now)
+	assert.False(t, ok)
+	wait, ok = l.reserve(context.Background(), "1.2.3.4", now.Add(time.Millisecond*500))
+	assert.True(t, ok)
+	assert.Equal(t, time.Second*2, wait)
+
+	// as does a wait that would outlast the context
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(time.Millisecond*100))
+	defer cancel()
+	_, ok = l.reserve(ctx, "5.6.7.8", now)
+	assert.False(t, ok)
+
+	// hosts are forgotten once their next slot has passed
+	l.evict(now.Add(time.Minute))
+	assert.Empty(t, l.next)
+}
+
+func TestQuerier_PerHostRate(t *testing.T) {
+	q, err := NewQuerier(WithPerHostRate(20))
+	require.NoError(t, err)
+	defer q.Close() // nolint:errcheck
+
+	address, stop := fakeServer(t, 0, map[Opcode][]byte{
+		Info: infoPayload(false, 4, 32, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", "English"),
+	})
+	defer stop()
+
+	started := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err = q.Query(context.Background(), address, Info, QueryOptions{Timeout: time.Millisecond * 50})
+		require.NoError(t, err)
+	}
+	assert.True(t, time.Since(started) >= time.Millisecond*100, "queries were not spaced out")
+}
@@ -0,0 +1,47 @@
+package query
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// VerifyServer performs an info query against the address of the server and checks that the server
+// exists and that its hostname and gamemode resemble the claimed values. The comparison is loose
+// since hostnames often contain colour codes or change slightly between restarts, it's only meant
+// to catch servers being registered by someone who doesn't control them. The query is sent to the
+// server's query port if it has one and always starts with a handshake, otherwise the live values
+// could be spoofed just as easily as the claimed ones.
+func VerifyServer(ctx context.Context, server types.Server, opts QueryOptions) (err error) {
+	if server.QueryPort != 0 {
+		opts.QueryPort = server.QueryPort
+	}
+	opts.Handshake = true
+	live, err := QueryInfo(ctx, server.Core.Address, opts)
+	if err != nil {
+		return errors.Wrap(err, "server did not respond to query")
+	}
+
+	if !resembles(server.Core.Hostname, live.Hostname) {
+		return errors.Errorf("claimed hostname '%s' does not match live hostname '%s'", server.Core.Hostname, live.Hostname)
+	}
+	if !resembles(server.Core.Gamemode, live.Gamemode) {
+		return errors.Errorf("claimed gamemode '%s' does not match live gamemode '%s'", server.Core.Gamemode, live.Gamemode)
+	}
+
+	return nil
+}
+
+// resembles reports whether two strings are the same ignoring case and surrounding whitespace, or
+// whether one contains the other.
+func resembles(claimed, live string) bool {
+	claimed = strings.ToLower(strings.TrimSpace(claimed))
+	live = strings.ToLower(strings.TrimSpace(live))
+	if claimed == "" || live == "" {
+		return claimed == live
+	}
+	return strings.Contains(live, claimed) || strings.Contains(claimed, live)
+}
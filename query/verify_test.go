@@ -0,0 +1,47 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestVerifyServer(t *testing.T) {
+	info := infoPayload(false, 4, 32, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", "English")
+	fast := QueryOptions{Timeout: time.Millisecond * 50, Retries: 1}
+	tests := []struct {
+		name      string
+		responses map[Opcode][]byte
+		hostname  string
+		gamemode  string
+		wantErr   bool
+	}{
+		{"valid", map[Opcode][]byte{Ping: {}, Info: info}, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", false},
+		{"valid loose", map[Opcode][]byte{Ping: {}, Info: info}, " scavenge and survive ", "Scavenge & Survive", false},
+		{"invalid hostname", map[Opcode][]byte{Ping: {}, Info: info}, "Totally Real Roleplay", "Scavenge & Survive by Southclaws", true},
+		{"invalid gamemode", map[Opcode][]byte{Ping: {}, Info: info}, "Scavenge and Survive Official", "Grand Larceny", true},
+		{"invalid no response", map[Opcode][]byte{}, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", true},
+		{"invalid no ping", map[Opcode][]byte{Info: info}, "Scavenge and Survive Official", "Scavenge & Survive by Southclaws", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, stop := fakeServer(t, 0, tt.responses)
+			defer stop()
+
+			err := VerifyServer(context.Background(), types.Server{Core: types.ServerCore{
+				Address:  address,
+				Hostname: tt.hostname,
+				Gamemode: tt.gamemode,
+			}}, fast)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -13,8 +13,8 @@ type metrics struct {
 	QueryTime prometheus.Summary
 }
 
-// newMetricsRecorder initialises a new metrics recorder
-func newMetricsRecorder() (m *metrics) {
+// newMetricsRecorder initialises a new metrics recorder and registers it with reg
+func newMetricsRecorder(reg prometheus.Registerer) (m *metrics) {
 	m = &metrics{
 		Errors: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: "samplist",
@@ -59,7 +59,7 @@ func newMetricsRecorder() (m *metrics) {
 			Help:      "The length of queries in seconds",
 		}),
 	}
-	prometheus.MustRegister(
+	reg.MustRegister(
 		m.Errors,
 		m.Queries,
 		m.Successes,
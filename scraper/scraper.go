@@ -4,22 +4,24 @@ import (
 	"context"
 	"time"
 
-	"github.com/Southclaws/go-samp-query"
 	"github.com/Southclaws/tickerpool"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/syncmap"
 
+	"github.com/Southclaws/samp-servers-api/query"
 	"github.com/Southclaws/samp-servers-api/types"
 )
 
 // Config contains parameters to tweak the scraper performance
 type Config struct {
-	QueryInterval    time.Duration      // interval between query attempts
-	MaxFailed        int                // maximum number of failed query attempts before removing address
-	QueryFunction    QueryFunction      // function for querying servers
-	OnRequestArchive func(string)       // called to archive an address
-	OnRequestRemove  func(string)       // called to remove an address
-	OnRequestUpdate  func(types.Server) // called to update an address
+	QueryInterval    time.Duration         // interval between query attempts
+	MaxFailed        int                   // maximum number of failed query attempts before removing address
+	QueryFunction    QueryFunction         // function for querying servers
+	OnRequestArchive func(string)          // called to archive an address
+	OnRequestRemove  func(string)          // called to remove an address
+	OnRequestUpdate  func(types.Server)    // called to update an address
+	Registerer       prometheus.Registerer // metrics registry, defaults to the global registry
 }
 
 // Scraper crawls through a list of server addresses and gathers information about them via the
@@ -34,15 +36,19 @@ type Scraper struct {
 }
 
 // QueryFunction represents a function capable of retreiving server information via the server API
-type QueryFunction func(context.Context, string, bool) (sampquery.Server, error)
+type QueryFunction func(context.Context, string) (types.Server, error)
 
 // New sets up the query daemon and starts the background processes
 func New(ctx context.Context, initial []string, config Config) (daemon *Scraper, err error) {
+	if config.Registerer == nil {
+		config.Registerer = prometheus.DefaultRegisterer
+	}
+
 	daemon = &Scraper{
 		config:         config,
 		ctx:            ctx,
 		failedAttempts: &syncmap.Map{},
-		metrics:        newMetricsRecorder(),
+		metrics:        newMetricsRecorder(config.Registerer),
 	}
 
 	daemon.active, err = tickerpool.NewTickerPool(config.QueryInterval)
@@ -95,6 +101,18 @@ func (daemon *Scraper) Remove(address string) {
 	}
 }
 
+// Forget removes an address from the query rotation without requesting its removal from storage,
+// for when the server has already been removed by other means.
+func (daemon *Scraper) Forget(address string) {
+	daemon.failedAttempts.Delete(address)
+	if daemon.active.Exists(address) {
+		daemon.active.Remove(address)
+	}
+	if daemon.failed.Exists(address) {
+		daemon.failed.Remove(address)
+	}
+}
+
 // addFailed marks a server as "inactive" and queries it less often
 func (daemon *Scraper) addFailed(address string) {
 	daemon.failedAttempts.Delete(address)
@@ -126,7 +144,12 @@ func (daemon *Scraper) query(address string) (remove bool, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	serverData, err := daemon.config.QueryFunction(ctx, address, true)
+	server, err := daemon.config.QueryFunction(ctx, address)
+	if query.IsPartial(err) {
+		// a partial response still contains the core information so it is stored rather than
+		// being discarded as a failure
+		err = nil
+	}
 	if err != nil {
 		if hasFailed {
 			if attempts > daemon.config.MaxFailed {
@@ -144,19 +167,6 @@ func (daemon *Scraper) query(address string) (remove bool, err error) {
 	}
 	daemon.removeFailed(address)
 
-	server := types.Server{
-		Core: types.ServerCore{
-			Address:    serverData.Address,
-			Hostname:   serverData.Hostname,
-			Players:    serverData.Players,
-			MaxPlayers: serverData.MaxPlayers,
-			Gamemode:   serverData.Gamemode,
-			Language:   serverData.Language,
-			Password:   serverData.Password,
-		},
-		Rules: serverData.Rules,
-	}
-
 	if server.Core.Players > server.Core.MaxPlayers {
 		return true, nil
 	}
@@ -164,10 +174,6 @@ func (daemon *Scraper) query(address string) (remove bool, err error) {
 		return true, nil
 	}
 
-	version, ok := serverData.Rules["version"]
-	if ok {
-		server.Core.Version = version
-	}
 	daemon.config.OnRequestUpdate(server)
 
 	return false, nil
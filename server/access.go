@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Southclaws/samp-servers-api/server/realip"
+)
+
+// appGroup is the route group name of the routes that don't belong to an API version, such as the
+// health checks and metrics
+const appGroup = "app"
+
+// RequestLogger returns a middleware that writes an access log entry at info level for every
+// request with its method, path, status, duration and remote IP.
+func RequestLogger(logger *zap.Logger) func(http.Handler) http.Handler {
+	return requestLogger(logger, zapcore.InfoLevel)
+}
+
+// requestLogger is RequestLogger with the level of the entries
+func requestLogger(logger *zap.Logger, level zapcore.Level) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+
+			next.ServeHTTP(sw, r)
+
+			entry := logger.Check(level, "request")
+			if entry == nil {
+				return
+			}
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", sw.Status()),
+				zap.Duration("duration", time.Since(start)),
+				zap.String("ip", realip.ClientIP(r)),
+			}
+			if route := mux.CurrentRoute(r); route != nil && route.GetName() != "" {
+				fields = append(fields, zap.String("route", route.GetName()))
+			}
+			entry.Write(fields...)
+		})
+	}
+}
+
+// accessLogLevels parses the configured access log level of each route group, groups that aren't
+// configured are logged at info level
+func accessLogLevels(configured map[string]string) (levels map[string]zapcore.Level, err error) {
+	levels = make(map[string]zapcore.Level, len(configured))
+	for group, name := range configured {
+		var level zapcore.Level
+		err = level.UnmarshalText([]byte(name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid access log level for route group '%s'", group)
+		}
+		levels[group] = level
+	}
+	return
+}
+
+// accessLog wraps a handler with a request logger at the level configured for its route group
+func (app *App) accessLog(group string, handler http.Handler) http.Handler {
+	level, ok := app.accessLevels[group]
+	if !ok {
+		level = zapcore.InfoLevel
+	}
+	return requestLogger(app.logger, level)(handler)
+}
+
+// statusWriter records the status code of a response. It passes through flushes for streamed
+// responses and hijacking for WebSockets, a hijacked connection is recorded as switching protocols.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusWriter) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusWriter) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusWriter) Flush() {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && s.status == 0 {
+		s.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Status returns the recorded status code, a handler that never wrote anything responded with 200
+func (s *statusWriter) Status() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
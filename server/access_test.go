@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	router := mux.NewRouter()
+	router.Methods("GET").Path("/teapot").Name("teapot").Handler(RequestLogger(zap.New(core))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})))
+	router.Methods("GET").Path("/ok").Handler(RequestLogger(zap.New(core))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok")) // nolint:errcheck
+		})))
+
+	for _, target := range []string{"/teapot", "/ok"} {
+		r := httptest.NewRequest("GET", target, nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "GET", fields["method"])
+	assert.Equal(t, "/teapot", fields["path"])
+	assert.Equal(t, int64(http.StatusTeapot), fields["status"])
+	assert.Equal(t, "10.0.0.1", fields["ip"])
+	assert.Equal(t, "teapot", fields["route"])
+	assert.Contains(t, fields, "duration")
+
+	fields = entries[1].ContextMap()
+	assert.Equal(t, int64(http.StatusOK), fields["status"])
+	assert.NotContains(t, fields, "route")
+}
+
+func TestRequestLogger_Level(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	handler := requestLogger(zap.New(core), zapcore.DebugLevel)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, 0, logs.Len())
+}
+
+func TestAccessLogLevels(t *testing.T) {
+	levels, err := accessLogLevels(map[string]string{"app": "debug", "v2": "warn"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]zapcore.Level{"app": zapcore.DebugLevel, "v2": zapcore.WarnLevel}, levels)
+
+	_, err = accessLogLevels(map[string]string{"v2": "loud"})
+	assert.Error(t, err)
+}
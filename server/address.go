@@ -0,0 +1,44 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// CanonicalizeAddress returns the address that a server is stored under, which is its IPv4 address
+// and port. Hostnames are resolved so servers submitted under both a hostname and an IP address end
+// up as a single record.
+func (app *App) CanonicalizeAddress(address string) (string, error) {
+	canonical, others, errs := types.AddressFromStringStrict(address, true)
+	if errs != nil {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return "", errors.Errorf("failed to canonicalize address '%s': %s", address, strings.Join(messages, ", "))
+	}
+	if others != nil {
+		app.logger.Info("hostname has several IPv4 addresses, using the first",
+			zap.String("address", address),
+			zap.String("using", canonical),
+			zap.Strings("others", others))
+	}
+	return canonical, nil
+}
+
+// canonicalAddress is CanonicalizeAddress for storing servers, if the address can't be resolved it's
+// used as-is rather than losing the server
+func (app *App) canonicalAddress(address string) string {
+	canonical, err := app.CanonicalizeAddress(address)
+	if err != nil {
+		app.logger.Debug("failed to canonicalize address",
+			zap.Error(err),
+			zap.String("address", address))
+		return address
+	}
+	return canonical
+}
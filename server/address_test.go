@@ -0,0 +1,35 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestApp_CanonicalizeAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    string
+		wantErr bool
+	}{
+		{"ip", "1.2.3.4:7777", "1.2.3.4:7777", false},
+		{"default port", "samp://1.2.3.4", "1.2.3.4:7777", false},
+		{"ipv6", "[::1]:7777", "", true},
+		{"invalid", "1.2.3.4:80", "", true},
+	}
+	app := NewApp(nil, zap.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := app.CanonicalizeAddress(tt.address)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.address, app.canonicalAddress(tt.address))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// APIKeyAuth returns a middleware that requires requests which modify the index to have an
+// `Authorization: Bearer <key>` header containing one of the valid keys, anything else is rejected
+// with a 401. Reads are left public so only POST, PUT, PATCH and DELETE requests are checked.
+func APIKeyAuth(validKeys ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if mutating(r) && !validKey(validKeys, bearerToken(r)) {
+				unauthorized(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAPIKey returns a middleware that rejects every request without one of the valid keys in
+// the same way as APIKeyAuth, regardless of its method. It's for routes that must never be public,
+// so when no keys are configured every request is rejected.
+func RequireAPIKey(validKeys ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !validKey(validKeys, bearerToken(r)) {
+				unauthorized(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+func mutating(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// AdminOnly returns a middleware that only passes through requests with an
+// `Authorization: Bearer <key>` header containing one of the admin keys, anything else is rejected
+// with a 403. When no keys are configured, every request is rejected.
+func AdminOnly(keys []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !validKey(keys, bearerToken(r)) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bearerToken returns the token of a bearer Authorization header or an empty string if there isn't one
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	const prefix = "bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
+
+// validKey compares the key against every valid key in constant time so that the response time
+// doesn't reveal how much of a key was correct
+func validKey(keys []string, key string) (valid bool) {
+	if key == "" {
+		return false
+	}
+	for _, k := range keys {
+		if k != "" && subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	handler := APIKeyAuth("first", "second")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantStatus    int
+	}{
+		{"get is public", "GET", "", http.StatusOK},
+		{"head is public", "HEAD", "Bearer wrong", http.StatusOK},
+		{"post", "POST", "Bearer first", http.StatusOK},
+		{"delete", "DELETE", "Bearer second", http.StatusOK},
+		{"post missing", "POST", "", http.StatusUnauthorized},
+		{"put wrong key", "PUT", "Bearer third", http.StatusUnauthorized},
+		{"delete empty key", "DELETE", "Bearer ", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/v2/server/127.0.0.1:7777", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAdminOnly(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		authorization string
+		wantStatus    int
+	}{
+		{"valid", []string{"first", "second"}, "Bearer second", http.StatusOK},
+		{"lowercase scheme", []string{"first"}, "bearer first", http.StatusOK},
+		{"wrong key", []string{"first"}, "Bearer firs", http.StatusForbidden},
+		{"missing", []string{"first"}, "", http.StatusForbidden},
+		{"not bearer", []string{"first"}, "Basic first", http.StatusForbidden},
+		{"no keys configured", nil, "Bearer ", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := AdminOnly(tt.keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			r := httptest.NewRequest("PUT", "/v2/server/127.0.0.1:7777/feature", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		method        string
+		authorization string
+		wantStatus    int
+	}{
+		{"valid", []string{"first", "second"}, "POST", "Bearer second", http.StatusOK},
+		{"get needs a key too", []string{"first"}, "GET", "", http.StatusUnauthorized},
+		{"wrong key", []string{"first"}, "POST", "Bearer third", http.StatusUnauthorized},
+		{"no keys configured", nil, "POST", "Bearer first", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireAPIKey(tt.keys...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			r := httptest.NewRequest(tt.method, "/v2/server/127.0.0.1:7777/rcon", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
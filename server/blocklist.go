@@ -0,0 +1,46 @@
+package server
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+)
+
+// IsBlocked reports whether a server address is on the blocklist, either by itself or by its IP or
+// a range containing it. A blocklist that fails to load is logged and treated as empty so an outage
+// of the store doesn't stop the rest of the index being updated.
+func (app *App) IsBlocked(address string) bool {
+	blocked, err := storage.IsBlocked(app.db, address)
+	if err != nil {
+		app.logger.Error("failed to check blocklist",
+			zap.Error(err),
+			zap.String("address", address))
+		return false
+	}
+	return blocked
+}
+
+// unblocked filters the blocked addresses out of a list of addresses, the blocklist is loaded once
+// for the whole list
+func (app *App) unblocked(addresses []string) []string {
+	blocklist, err := app.db.GetBlocklist()
+	if err != nil {
+		app.logger.Error("failed to load blocklist",
+			zap.Error(err))
+		return addresses
+	}
+	if len(blocklist) == 0 {
+		return addresses
+	}
+
+	result := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if _, blocked := blocklist.Blocks(address); blocked {
+			app.logger.Debug("skipping blocked server",
+				zap.String("address", address))
+			continue
+		}
+		result = append(result, address)
+	}
+	return result
+}
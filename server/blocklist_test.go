@@ -0,0 +1,28 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestApp_IsBlocked(t *testing.T) {
+	db := storage.NewMemoryStore()
+	app := NewApp(db, zap.NewNop())
+
+	addresses := []string{"203.0.113.1:7777", "198.51.100.1:7777", "198.51.100.1:7778"}
+	assert.Equal(t, addresses, app.unblocked(addresses))
+
+	require.NoError(t, db.AddBlock(types.Block{Address: "203.0.113.0/24"}))
+	require.NoError(t, db.AddBlock(types.Block{Address: "198.51.100.1:7778"}))
+
+	assert.True(t, app.IsBlocked("203.0.113.1:7777"))
+	assert.True(t, app.IsBlocked("198.51.100.1:7778"))
+	assert.False(t, app.IsBlocked("198.51.100.1:7777"))
+	assert.Equal(t, []string{"198.51.100.1:7777"}, app.unblocked(addresses))
+}
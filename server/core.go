@@ -4,14 +4,19 @@ import (
 	"context"
 	"net/http"
 	"path"
+	"time"
 
-	"github.com/Southclaws/go-samp-query"
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/text/encoding"
 
+	"github.com/Southclaws/samp-servers-api/query"
 	"github.com/Southclaws/samp-servers-api/scraper"
+	"github.com/Southclaws/samp-servers-api/server/realip"
 	"github.com/Southclaws/samp-servers-api/server/v2"
 	"github.com/Southclaws/samp-servers-api/storage"
 	"github.com/Southclaws/samp-servers-api/types"
@@ -22,31 +27,200 @@ type App struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	config     types.Config
-	db         *storage.Manager
+	db         storage.Store
 	qd         *scraper.Scraper
 	handlers   map[string]types.RouteHandler
 	httpServer *http.Server
 	metrics    *metrics
+	geo        *geoLocator
+	updates    *updateHub
+	queries    *queryCache
+	encoding   encoding.Encoding
+	querier    *query.Querier
+	logger     *zap.Logger
+	openapi    []byte
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+
+	accessLevels  map[string]zapcore.Level
+	parseFailures *parseFailureLog
+
+	// polling is held by whichever poll cycle is running so manual and scheduled polls never overlap
+	polling  chan struct{}
+	pollJobs *pollJobs
 }
 
-// Initialise sets up a database connection, binds all the routes and prepares for Start
-func Initialise(config types.Config) (app *App, err error) {
-	logger.Debug("initialising samp-servers-api with debug logging", zap.Any("config", config))
+// Option configures an App created by NewApp
+type Option func(*App)
+
+// WithConfig sets the config of an App, it's used as-is so any defaults must already be filled in
+func WithConfig(config types.Config) Option {
+	return func(app *App) {
+		app.config = config
+	}
+}
 
+// WithRegistry sets the registry metrics are registered with and exposed from, if it's nil then the
+// global Prometheus registry is used. Without this option each App gets a registry of its own so any
+// number of them can exist at once.
+func WithRegistry(registry *prometheus.Registry) Option {
+	return func(app *App) {
+		if registry == nil {
+			app.registerer = prometheus.DefaultRegisterer
+			app.gatherer = prometheus.DefaultGatherer
+			return
+		}
+		app.registerer = registry
+		app.gatherer = registry
+	}
+}
+
+// NewApp creates an App that stores servers in store and logs to logger, the background workers
+// aren't started and no routes are bound so it's mostly useful for tests and for embedding parts of
+// the API. If logger is nil, the package logger is used.
+func NewApp(store storage.Store, logger *zap.Logger, opts ...Option) (app *App) {
+	registry := prometheus.NewRegistry()
 	app = &App{
-		config:  config,
-		metrics: newMetricsRecorder(),
+		db:         store,
+		logger:     logger,
+		registerer: registry,
+		gatherer:   registry,
+	}
+	if app.logger == nil {
+		app.logger = defaultLogger
+	}
+	for _, opt := range opts {
+		opt(app)
 	}
+
 	app.ctx, app.cancel = context.WithCancel(context.Background())
+	app.metrics = newMetricsRecorder(app.registerer)
+	app.updates = newUpdateHub()
+	app.queries = newQueryCache(app.config.LiveCacheTTL, app.liveQuery)
+	app.parseFailures = newParseFailureLog(parseFailureLogSize)
+	app.polling = make(chan struct{}, 1)
+	app.pollJobs = newPollJobs()
+	return
+}
+
+// Initialise sets up a database connection, binds all the routes and prepares for Start. Metrics are
+// registered with and exposed from registry, if it's nil then the global Prometheus registry is used.
+func Initialise(config types.Config, registry *prometheus.Registry) (app *App, err error) {
+	defaultLogger.Debug("initialising samp-servers-api with debug logging", zap.Any("config", config.Redacted()))
 
-	app.db, err = storage.New(storage.Config{
-		MongoHost:       config.MongoHost,
-		MongoPort:       config.MongoPort,
-		MongoName:       config.MongoName,
-		MongoUser:       config.MongoUser,
-		MongoPass:       config.MongoPass,
-		MongoCollection: config.MongoCollection,
-	})
+	if config.GzipMinLength == 0 {
+		config.GzipMinLength = 1400 // roughly a single packet
+	}
+	if len(config.CorsOrigins) == 0 {
+		config.CorsOrigins = []string{"*"}
+	}
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = time.Second * 30
+	}
+	if config.LiveRateLimit == 0 {
+		config.LiveRateLimit = 0.2 // one live query every five seconds
+	}
+	if config.LiveRateBurst < 1 {
+		config.LiveRateBurst = 3
+	}
+	if config.LiveTimeout == 0 {
+		config.LiveTimeout = time.Second * 5
+	}
+	if config.LiveCacheTTL == 0 {
+		config.LiveCacheTTL = time.Second * 10
+	}
+	if config.DiscoverTimeout == 0 {
+		config.DiscoverTimeout = time.Second * 30
+	}
+	if config.TrendingMinPlayers == 0 {
+		config.TrendingMinPlayers = 10
+	}
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = time.Second * 30 // a negative timeout disables it
+	}
+	if config.HistoryRawRetention == 0 {
+		config.HistoryRawRetention = time.Hour * 24 * 7
+	}
+	if config.HistoryRetention == 0 {
+		config.HistoryRetention = time.Hour * 24 * 90
+	}
+	if config.DeadGracePeriod == 0 {
+		config.DeadGracePeriod = time.Hour * 24 * 7
+	}
+	if config.QueryHostRate == 0 {
+		config.QueryHostRate = 10 // a negative rate disables the limit
+	}
+	if config.MaxConcurrentQueries == 0 {
+		config.MaxConcurrentQueries = 256 // a negative limit disables it
+	}
+	if config.MongoConnectAttempts == 0 {
+		config.MongoConnectAttempts = 5 // roughly a minute of backoff before giving up
+	}
+	if config.IdempotencyTTL == 0 {
+		config.IdempotencyTTL = time.Hour * 24
+	}
+	if config.OfflineAfter == 0 {
+		// a server is considered offline once it has missed a few queries in a row
+		config.OfflineAfter = config.QueryInterval * 3
+	}
+	for i, address := range config.TCPQueryServers {
+		config.TCPQueryServers[i], err = types.NormalizeAddress(address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid TCP query server '%s'", address)
+		}
+	}
+
+	var db storage.Store
+	switch config.Storage {
+	case "", "mongo":
+		db, err = storage.New(storage.Config{
+			MongoHost:            config.MongoHost,
+			MongoPort:            config.MongoPort,
+			MongoURI:             config.MongoURI,
+			MongoName:            config.MongoName,
+			MongoUser:            config.MongoUser,
+			MongoPass:            config.MongoPass,
+			MongoCollection:      config.MongoCollection,
+			MongoTimeout:         config.MongoTimeout,
+			MongoPoolSize:        config.MongoPoolSize,
+			MongoConnectAttempts: config.MongoConnectAttempts,
+		})
+		if err != nil {
+			return
+		}
+	case "memory":
+		defaultLogger.Warn("using in-memory storage, servers will not be persisted")
+		db = storage.NewMemoryStore()
+	default:
+		return nil, errors.Errorf("unknown storage backend '%s', must be 'mongo' or 'memory'", config.Storage)
+	}
+
+	app = NewApp(db, defaultLogger, WithConfig(config), WithRegistry(registry))
+
+	app.encoding, err = query.EncodingByName(config.QueryEncoding)
+	if err != nil {
+		return
+	}
+
+	app.querier, err = query.NewQuerier(
+		query.WithPerHostRate(config.QueryHostRate),
+		query.WithMaxConcurrentQueries(config.MaxConcurrentQueries),
+		query.WithSocketShards(config.QuerySockets))
+	if err != nil {
+		return
+	}
+
+	proxies, err := realip.ParseProxies(config.TrustProxy, config.TrustedProxies)
+	if err != nil {
+		return
+	}
+
+	app.accessLevels, err = accessLogLevels(config.AccessLogLevels)
+	if err != nil {
+		return
+	}
+
+	app.geo, err = newGeoLocator(config.GeoipDatabase)
 	if err != nil {
 		return
 	}
@@ -63,41 +237,112 @@ func Initialise(config types.Config) (app *App, err error) {
 		scraper.Config{
 			QueryInterval:    config.QueryInterval,
 			MaxFailed:        config.MaxFailedQuery,
-			QueryFunction:    sampquery.GetServerInfo,
+			QueryFunction:    app.queryServer,
 			OnRequestArchive: app.onRequestArchive,
 			OnRequestRemove:  app.onRequestRemove,
 			OnRequestUpdate:  app.onRequestUpdate,
+			Registerer:       app.registerer,
 		})
 	if err != nil {
 		return
 	}
 
+	if config.PollInterval > 0 {
+		// Periodically re-check every stored server, including ones that have dropped out of the
+		// scraper's rotation, so their player counts and online status don't go stale.
+		go app.StartPoller(app.ctx, config.PollInterval)
+		go app.StartHistoryCompaction(app.ctx, historyBucket)
+	}
+
+	go app.StartReaper(app.ctx, reapInterval)
+
 	if config.LegacyList {
 		// Start a periodic query against the SA:MP official internet list (if it's even online...)
 		go app.LegacyListQuery()
 	}
 
 	app.handlers = map[string]types.RouteHandler{
-		"v2": v2.Init(app.db, app.qd, config),
+		"v2": v2.Init(app.db, app.qd, config, v2.Deps{
+			Locate:       app.locateAddress,
+			Canonicalize: app.canonicalAddress,
+			Query:        app.cachedQuery,
+			RCON:         app.RCON,
+			Discover:     app.Discover,
+			Nearby:       app.Nearby,
+			Patch:        app.PatchServer,
+			Trending:     app.Trending,
+			QueryOptions: app.queryOptionsFor,
+			Querier:      app.querier,
+			Logger:       app.logger,
+		}),
 		// "v3": v3.Init(app.db, app.qd, config),
 	}
 
+	app.openapi, err = openAPI(config.Version, app.handlers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate openapi spec")
+	}
+
+	// admin keys are accepted as API keys too so admins don't need a second key for the admin routes
+	apiKeys := append(append([]string{}, config.APIKeys...), config.AdminKeys...)
+
+	// shared between every route so a key is only ever processed once at a time
+	maxBody := config.MaxBodySize
+	if maxBody <= 0 {
+		maxBody = v2.DefaultMaxBodySize
+	}
+	idempotent := Idempotency(app.logger, app.db, config.IdempotencyTTL, maxBody)
+
 	router := mux.NewRouter().StrictSlash(true)
-	router.Handle("/metrics", promhttp.Handler())
+	router.Path("/metrics").Name("metrics").
+		Handler(app.accessLog(appGroup, promhttp.HandlerFor(app.gatherer, promhttp.HandlerOpts{})))
+	router.Methods("GET").Path("/metrics/parse-failures").Name("parseFailures").
+		Handler(app.accessLog(appGroup, http.HandlerFunc(app.ParseFailures)))
+	router.Methods("GET").Path("/healthz").Name("healthz").Handler(app.accessLog(appGroup, http.HandlerFunc(app.Healthz)))
+	router.Methods("GET").Path("/readyz").Name("readyz").Handler(app.accessLog(appGroup, http.HandlerFunc(app.Readyz)))
+	router.Methods("GET").Path("/openapi.json").Name("openapi").Handler(app.accessLog(appGroup, http.HandlerFunc(app.OpenAPI)))
+	router.Methods("GET").Path("/ws").Name("updates").Handler(app.accessLog(appGroup, http.HandlerFunc(app.Updates)))
+	router.Methods("POST").Path("/admin/poll").Name("pollTrigger").
+		Handler(app.accessLog(appGroup, AdminOnly(config.AdminKeys)(http.HandlerFunc(app.PollTrigger))))
+	router.Methods("GET").Path("/admin/poll/{id}").Name("pollStatus").
+		Handler(app.accessLog(appGroup, AdminOnly(config.AdminKeys)(http.HandlerFunc(app.PollStatus))))
 	for name, handler := range app.handlers {
 		routes := handler.Routes()
 
-		logger.Debug("loaded handler",
+		app.logger.Debug("loaded handler",
 			zap.String("name", name),
 			zap.Int("routes", len(routes)))
 
 		for _, route := range routes {
+			var routeHandler http.Handler = route.Handler
+			if route.Method == http.MethodPost && !route.Sensitive {
+				routeHandler = idempotent(routeHandler)
+			}
+			if route.Limited {
+				routeHandler = RateLimitAll(config.LiveRateLimit, config.LiveRateBurst)(routeHandler)
+			}
+			if route.Admin {
+				routeHandler = AdminOnly(config.AdminKeys)(routeHandler)
+			}
+			if route.KeyRequired {
+				routeHandler = RequireAPIKey(apiKeys...)(routeHandler)
+			}
+			// outermost so a request that times out still finishes in the background and an
+			// idempotent one records its real response for the client's retry
+			timeout := config.RequestTimeout
+			if route.Timeout != 0 {
+				timeout = route.Timeout
+			}
+			if timeout > 0 {
+				routeHandler = Timeout(timeout)(routeHandler)
+			}
+
 			router.Methods(route.Method).
 				Path(path.Join("/", name, route.Path)).
 				Name(route.Name).
-				Handler(route.Handler)
+				Handler(app.accessLog(name, app.metrics.instrument(route.Name, routeHandler)))
 
-			logger.Debug("registered handler route",
+			app.logger.Debug("registered handler route",
 				zap.String("name", route.Name),
 				zap.String("method", route.Method),
 				zap.String("path", path.Join(name, route.Path)))
@@ -106,23 +351,78 @@ func Initialise(config types.Config) (app *App, err error) {
 		router.Methods("GET").
 			Path(path.Join("/", name, "docs")).
 			Name("docs").
-			Handler(app.docsWrapper(handler))
+			Handler(app.accessLog(name, app.docsWrapper(handler)))
+	}
+
+	var handler http.Handler = Gzip(config.GzipMinLength)(router)
+	if len(config.APIKeys) > 0 {
+		handler = APIKeyAuth(apiKeys...)(handler)
+	}
+	if config.RateLimit > 0 {
+		burst := config.RateLimitBurst
+		if burst < 1 {
+			burst = 1
+		}
+		handler = RateLimit(config.RateLimit, burst)(handler)
 	}
 
 	app.httpServer = &http.Server{
-		Addr: app.config.Bind,
-		Handler: handlers.CORS(
-			handlers.AllowedHeaders([]string{"X-Requested-With"}),
-			handlers.AllowedOrigins([]string{"*"}),
-			handlers.AllowedMethods([]string{"HEAD", "GET", "POST", "PUT", "OPTIONS"}),
-		)(router),
+		Addr:    app.config.Bind,
+		Handler: Recover(app.logger)(proxies.Middleware(CORS(app.logger, config.CorsOrigins)(handler))),
 	}
 
 	return app, nil
 }
 
-// Start begins listening for requests and blocks until fatal error
+// Start begins listening for requests on the configured bind address and blocks until fatal error
 func (app *App) Start() error {
+	return app.Run(context.Background(), app.config.Bind)
+}
+
+// Run begins listening for requests on addr and blocks until either a fatal error occurs or the
+// context is cancelled. If SelfTestAddress is set, that server is queried first to check queries
+// work, see SelfTest, leaving it empty skips the test for environments with no outside access. On
+// cancellation, the HTTP server stops accepting connections and waits up to ShutdownTimeout for
+// in-flight requests to complete before the background workers are stopped and the database
+// connection and query socket are closed.
+func (app *App) Run(ctx context.Context, addr string) (err error) {
+	defer app.db.Close()
+	defer app.querier.Close() // nolint:errcheck
 	defer app.cancel()
-	return app.httpServer.ListenAndServe()
+
+	app.httpServer.Addr = addr
+
+	if app.config.SelfTestAddress != "" {
+		// a failure is only a warning since the test server itself may be down
+		if err := app.SelfTest(app.config.SelfTestAddress); err != nil {
+			app.logger.Warn("query self-test failed, servers will appear offline if outbound UDP is blocked",
+				zap.Error(err))
+		} else {
+			app.logger.Info("query self-test passed",
+				zap.String("address", app.config.SelfTestAddress))
+		}
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- app.httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err = <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	app.logger.Info("shutting down", zap.Duration("timeout", app.config.ShutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.config.ShutdownTimeout)
+	defer cancel()
+
+	err = app.httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		return errors.Wrap(err, "failed to shut down cleanly")
+	}
+
+	return nil
 }
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestNewApp(t *testing.T) {
+	logger := zap.NewNop()
+	config := types.Config{DeadGracePeriod: 1}
+
+	// each app registers its metrics with a registry of its own so they don't collide
+	first := NewApp(storage.NewMemoryStore(), logger, WithConfig(config))
+	defer first.cancel()
+	second := NewApp(storage.NewMemoryStore(), nil)
+	defer second.cancel()
+
+	assert.Equal(t, logger, first.logger)
+	assert.Equal(t, config, first.config)
+	assert.NotEqual(t, first.registerer, second.registerer)
+	assert.Equal(t, defaultLogger, second.logger)
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/handlers"
+	"go.uber.org/zap"
+)
+
+// CORS returns a middleware that allows browsers to call the API from any of the allowed origins.
+// An origin of "*" allows every origin, which is fine for a public read-only API but makes writes
+// possible from any page so a warning is logged to logger when it's used.
+func CORS(logger *zap.Logger, allowedOrigins []string) func(http.Handler) http.Handler {
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			logger.Warn("CORS is allowing requests from any origin, set an explicit list of origins in production")
+			break
+		}
+	}
+
+	return handlers.CORS(
+		handlers.AllowedOrigins(allowedOrigins),
+		handlers.AllowedMethods([]string{"HEAD", "GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+		handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "If-None-Match", "Authorization", "Idempotency-Key", "X-Samplist-Secret"}),
+		handlers.ExposedHeaders([]string{"Retry-After", "ETag", "Idempotent-Replayed"}),
+	)
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCORS(t *testing.T) {
+	handler := CORS(zap.NewNop(), []string{"https://samp-servers.net"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		preflight  string
+		headers    string
+		wantOrigin string
+	}{
+		{"allowed", "GET", "https://samp-servers.net", "", "", "https://samp-servers.net"},
+		{"allowed preflight", "OPTIONS", "https://samp-servers.net", "DELETE", "", "https://samp-servers.net"},
+		{"idempotency key", "OPTIONS", "https://samp-servers.net", "POST", "Content-Type, Idempotency-Key", "https://samp-servers.net"},
+		{"webhook secret", "OPTIONS", "https://samp-servers.net", "DELETE", "X-Samplist-Secret", "https://samp-servers.net"},
+		{"unknown header", "OPTIONS", "https://samp-servers.net", "POST", "X-Unknown", ""},
+		{"disallowed", "GET", "https://example.com", "", "", ""},
+		{"disallowed preflight", "OPTIONS", "https://example.com", "POST", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/v2/servers", nil)
+			r.Header.Set("Origin", tt.origin)
+			if tt.preflight != "" {
+				r.Header.Set("Access-Control-Request-Method", tt.preflight)
+			}
+			if tt.headers != "" {
+				r.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			if tt.wantOrigin != "" && tt.preflight == "" {
+				assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Idempotent-Replayed")
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"context"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// Discover probes every port of an IP from one port to another inclusive with an info query and
+// returns the servers that responded, ordered by port. The probes go through the shared Querier so
+// they count towards the same per host and concurrency limits as every other query. The range
+// isn't checked so it should already have been validated with types.Discover, and the whole
+// discovery is given DiscoverTimeout to complete.
+func (app *App) Discover(ctx context.Context, ip string, from, to int) (servers []types.Server, err error) {
+	ctx, cancel := context.WithTimeout(ctx, app.config.DiscoverTimeout)
+	defer cancel()
+
+	cores, err := query.Discover(ctx, ip, from, to, app.queryOptions())
+	for _, core := range cores {
+		servers = append(servers, types.Server{Core: core})
+	}
+	return
+}
@@ -68,6 +68,7 @@ func docsForRoute(version string, route types.Route, w io.Writer) {
 		Route:   route,
 		Version: version,
 	}
+	obj.Path = pathParameter.ReplaceAllString(route.Path, "{$1}")
 
 	if route.Params != nil {
 		tmp := types.ServerListParams{}
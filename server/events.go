@@ -1,32 +1,80 @@
 package server
 
 import (
-	"github.com/Southclaws/samp-servers-api/types"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
 )
 
+func (app *App) queryServer(ctx context.Context, address string) (types.Server, error) {
+	opts := app.queryOptionsFor(address)
+	opts.Handshake = true // what's stored has to have come from the server itself
+	server, err := query.QueryServer(ctx, address, opts)
+	server.QueryPort = opts.QueryPort
+	app.recordParseFailure(address, err)
+	return server, err
+}
+
+func (app *App) queryOptions() query.QueryOptions {
+	return query.QueryOptions{
+		Timeout:  app.config.QueryTimeout,
+		Retries:  app.config.QueryRetries,
+		Encoding: app.encoding,
+		Querier:  app.querier,
+	}
+}
+
+// queryOptionsFor returns the options for querying a particular server, which are sent to its query
+// port if it's stored with one and over TCP if it's one of the TCPQueryServers
+func (app *App) queryOptionsFor(address string) query.QueryOptions {
+	opts := app.queryOptions()
+	for _, tcp := range app.config.TCPQueryServers {
+		if tcp == address {
+			opts.Transport = query.TransportTCP
+			break
+		}
+	}
+	port, err := app.db.GetQueryPort(address)
+	if err != nil && err != storage.ErrNotFound {
+		app.logger.Error("failed to get query port",
+			zap.Error(err),
+			zap.String("address", address))
+	}
+	opts.QueryPort = port
+	return opts
+}
+
 func (app *App) onRequestArchive(address string) {
-	logger.Debug("archiving server",
+	app.logger.Debug("archiving server",
 		zap.String("address", address))
 
 	err := app.db.ArchiveServer(address)
 	if err != nil {
-		logger.Error("failed to archive server",
+		app.logger.Error("failed to archive server",
 			zap.Error(err),
 			zap.String("address", address))
 		return
 	}
+	app.serverChanged(address, 0, false)
 
 	app.updateIndexMetrics()
 }
 
+// onRequestRemove marks a server that the scraper has given up on as dead rather than deleting it,
+// the reaper removes it once it's been dead for longer than the grace period.
 func (app *App) onRequestRemove(address string) {
-	logger.Debug("removing server",
+	app.logger.Debug("marking server dead",
 		zap.String("address", address))
 
-	err := app.db.RemoveServer(address)
+	err := app.db.MarkDead(address, time.Now())
 	if err != nil {
-		logger.Error("failed to remove server",
+		app.logger.Error("failed to mark server dead",
 			zap.Error(err),
 			zap.String("address", address))
 		return
@@ -36,38 +84,96 @@ func (app *App) onRequestRemove(address string) {
 }
 
 func (app *App) onRequestUpdate(server types.Server) {
-	logger.Debug("updating server",
+	if app.IsBlocked(server.Core.Address) {
+		app.logger.Debug("not updating blocked server",
+			zap.String("address", server.Core.Address))
+		if app.qd != nil {
+			app.qd.Forget(server.Core.Address)
+		}
+		return
+	}
+	if errs := server.Core.Allowed(app.config.AllowedGamemodes, app.config.AllowedLanguages); errs != nil {
+		app.logger.Debug("not updating server that isn't allowed",
+			zap.String("address", server.Core.Address),
+			zap.Errors("reasons", errs))
+		if app.qd != nil {
+			app.qd.Forget(server.Core.Address)
+		}
+		return
+	}
+
+	app.logger.Debug("updating server",
 		zap.String("address", server.Core.Address))
 
-	err := app.db.UpsertServer(server)
+	server.MarkSeen(time.Now())
+	server.Country = app.locateAddress(server.Core.Address)
+
+	_, err := app.UpsertIfChanged(server)
 	if err != nil {
-		logger.Error("failed to upsert server",
+		app.logger.Error("failed to upsert server",
 			zap.Error(err),
 			zap.String("address", server.Core.Address))
 		return
 	}
+	app.serverChanged(server.Core.Address, server.Core.Players, true)
 
 	app.updateIndexMetrics()
 }
 
+// UpsertIfChanged stores a server under its canonical address unless nothing about it has changed
+// since it was last stored, in which case only when it was last seen and its ping are updated. Most
+// servers are idle most of the time so this saves rewriting nearly every server on every query. The
+// comparison is by content hash, see types.Server.Hash, so the stored server isn't loaded first.
+// Servers that haven't been marked seen are marked seen now.
+func (app *App) UpsertIfChanged(server types.Server) (changed bool, err error) {
+	if server.LastSeen == nil {
+		server.MarkSeen(time.Now())
+	}
+	canonical := app.canonicalAddress(server.Core.Address)
+	server.ContentHash = server.Hash()
+
+	touched, err := app.db.TouchServer(canonical, server.ContentHash, *server.LastSeen, server.Ping)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to touch server")
+	}
+	if touched {
+		app.metrics.Upserts.WithLabelValues("unchanged").Inc()
+		return false, nil
+	}
+
+	err = storage.UpsertCanonical(app.db, &server, canonical)
+	if err != nil {
+		return false, err
+	}
+	app.metrics.Upserts.WithLabelValues("changed").Inc()
+	return true, nil
+}
+
 func (app *App) updateIndexMetrics() {
 	c, err := app.db.GetActiveServers()
 	if err != nil {
-		logger.Error("failed to get active servers metric",
+		app.logger.Error("failed to get active servers metric",
 			zap.Error(err))
 	}
 	app.metrics.Active.Set(float64(c))
 
 	c, err = app.db.GetInactiveServers()
 	if err != nil {
-		logger.Error("failed to get inactive servers metric",
+		app.logger.Error("failed to get inactive servers metric",
 			zap.Error(err))
 	}
 	app.metrics.Inactive.Set(float64(c))
 
+	c, err = app.db.GetOnlineServers()
+	if err != nil {
+		app.logger.Error("failed to get online servers metric",
+			zap.Error(err))
+	}
+	app.metrics.Online.Set(float64(c))
+
 	c, err = app.db.GetTotalPlayers()
 	if err != nil {
-		logger.Error("failed to get total players metric",
+		app.logger.Error("failed to get total players metric",
 			zap.Error(err))
 	}
 	app.metrics.Players.Set(float64(c))
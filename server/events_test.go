@@ -0,0 +1,65 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestApp_UpsertIfChanged(t *testing.T) {
+	db := storage.NewMemoryStore()
+	app := NewApp(db, zap.NewNop())
+
+	server := types.Server{Core: types.ServerCore{Address: "1.2.3.4:7777", Hostname: "idle", Players: 2}, Ping: 40}
+	changed, err := app.UpsertIfChanged(server)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	seen := time.Now().Add(time.Minute)
+	idle := server
+	idle.MarkSeen(seen)
+	idle.Ping = 45
+	changed, err = app.UpsertIfChanged(idle)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	got, err := db.GetServer("1.2.3.4:7777")
+	require.NoError(t, err)
+	assert.Equal(t, seen, *got.LastSeen)
+	assert.Equal(t, 45, got.Ping)
+
+	busy := idle
+	busy.Core.Players = 3
+	changed, err = app.UpsertIfChanged(busy)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	got, err = db.GetServer("1.2.3.4:7777")
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.Core.Players)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(app.metrics.Upserts.WithLabelValues("changed")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(app.metrics.Upserts.WithLabelValues("unchanged")))
+}
+
+func TestApp_queryOptionsFor(t *testing.T) {
+	db := storage.NewMemoryStore()
+	app := NewApp(db, zap.NewNop(), WithConfig(types.Config{TCPQueryServers: []string{"1.2.3.4:7777"}}))
+	require.NoError(t, db.UpsertServer(types.Server{Core: types.ServerCore{Address: "5.6.7.8:7777"}, QueryPort: 7778}))
+
+	opts := app.queryOptionsFor("1.2.3.4:7777")
+	assert.Equal(t, query.TransportTCP, opts.Transport)
+	assert.Zero(t, opts.QueryPort)
+
+	opts = app.queryOptionsFor("5.6.7.8:7777")
+	assert.Zero(t, opts.Transport)
+	assert.Equal(t, 7778, opts.QueryPort)
+}
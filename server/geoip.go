@@ -0,0 +1,125 @@
+package server
+
+import (
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/syncmap"
+)
+
+// geoLocator looks up the country of IP addresses from a GeoLite2 database and caches the results.
+// The continent of each country that's been looked up is kept too since servers only store their
+// country.
+type geoLocator struct {
+	db         *geoip2.Reader
+	cache      *syncmap.Map
+	continents *syncmap.Map
+}
+
+func newGeoLocator(path string) (g *geoLocator, err error) {
+	g = &geoLocator{cache: &syncmap.Map{}, continents: &syncmap.Map{}}
+	if path == "" {
+		return
+	}
+
+	g.db, err = geoip2.Open(path)
+	if err != nil {
+		err = errors.Wrap(err, "failed to open geoip database")
+	}
+	return
+}
+
+// GeoLocate returns the ISO country code for an IP address. If no GeoIP database is configured, an
+// empty country is returned without an error. Results are cached forever, which is fine for server
+// IPs since there's only so many of them, but IPs given by clients must use locateClient instead.
+func (app *App) GeoLocate(ip string) (country string, err error) {
+	if app.geo == nil || app.geo.db == nil {
+		return
+	}
+
+	if cached, ok := app.geo.cache.Load(ip); ok {
+		return cached.(string), nil
+	}
+
+	country, err = app.lookupCountry(ip)
+	if err != nil {
+		return
+	}
+	app.geo.cache.Store(ip, country)
+	return
+}
+
+// locateClient is GeoLocate without the cache, since clients can pick any IP they like they could
+// otherwise grow it without limit. Database lookups are cheap enough to do on every request.
+func (app *App) locateClient(ip string) (country string, err error) {
+	if app.geo == nil || app.geo.db == nil {
+		return
+	}
+	return app.lookupCountry(ip)
+}
+
+// lookupCountry looks up the country of an IP in the database and remembers the continent it's on
+func (app *App) lookupCountry(ip string) (country string, err error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		err = errors.Errorf("'%s' is not an IP address", ip)
+		return
+	}
+
+	record, err := app.geo.db.Country(parsed)
+	if err != nil {
+		err = errors.Wrap(err, "failed to look up country")
+		return
+	}
+
+	country = record.Country.IsoCode
+	if country != "" && record.Continent.Code != "" {
+		app.geo.continents.Store(country, record.Continent.Code)
+	}
+	return
+}
+
+// continentOf returns the continent code of a country, if any address in it has been looked up
+func (app *App) continentOf(country string) (continent string) {
+	if app.geo == nil || country == "" {
+		return
+	}
+	if cached, ok := app.geo.continents.Load(country); ok {
+		return cached.(string)
+	}
+	return
+}
+
+// locateAddress returns the country of a server address, the host is resolved first if it's not an
+// IP address. Failures are logged and result in an empty country since it's purely informational.
+func (app *App) locateAddress(address string) (country string) {
+	if app.geo == nil || app.geo.db == nil {
+		return
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	if net.ParseIP(host) == nil {
+		ips, err := net.LookupIP(host)
+		if err != nil || len(ips) == 0 {
+			app.logger.Debug("failed to resolve host for geolocation",
+				zap.Error(err),
+				zap.String("address", address))
+			return
+		}
+		host = ips[0].String()
+	}
+
+	country, err = app.GeoLocate(host)
+	if err != nil {
+		app.logger.Debug("failed to geolocate server",
+			zap.Error(err),
+			zap.String("address", address))
+	}
+	return
+}
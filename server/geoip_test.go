@@ -0,0 +1,30 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestApp_GeoLocate_Unconfigured(t *testing.T) {
+	geo, err := newGeoLocator("")
+	assert.NoError(t, err)
+
+	app := NewApp(nil, zap.NewNop())
+	app.geo = geo
+
+	country, err := app.GeoLocate("93.119.25.177")
+	assert.NoError(t, err)
+	assert.Equal(t, "", country)
+	assert.Equal(t, "", app.locateAddress("93.119.25.177:7777"))
+
+	country, err = app.locateClient("93.119.25.177")
+	assert.NoError(t, err)
+	assert.Equal(t, "", country)
+}
+
+func TestNewGeoLocator_Missing(t *testing.T) {
+	_, err := newGeoLocator("/nonexistent/GeoLite2-Country.mmdb")
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Gzip returns a middleware that compresses responses for clients that accept gzip. Responses are
+// buffered until they reach minLength bytes, if the response ends before that it's written as-is
+// since compressing small payloads costs more than it saves. Responses that set their own
+// Content-Encoding and requests to upgrade the connection are passed through untouched.
+func Gzip(minLength int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// upgraded connections need the original writer in order to hijack it
+			if r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipWriter{ResponseWriter: w, minLength: minLength, status: http.StatusOK}
+			defer gw.close()
+
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip checks an Accept-Encoding header for gzip, ignoring it if it's been given a q of zero
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		if strings.TrimSpace(fields[0]) != "gzip" {
+			continue
+		}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter holds back the status and body of a response until it knows whether the response is
+// large enough to compress. Once decided, writes go straight to either the gzip stream or the
+// underlying writer so streamed responses are not held in memory.
+type gzipWriter struct {
+	http.ResponseWriter
+	minLength int
+
+	status      int
+	buf         []byte
+	decided     bool
+	wroteHeader bool
+	gz          *gzip.Writer
+}
+
+func (g *gzipWriter) WriteHeader(status int) {
+	if g.wroteHeader {
+		return
+	}
+	g.status = status
+	if g.decided {
+		g.writeHeader()
+	}
+}
+
+func (g *gzipWriter) Write(b []byte) (int, error) {
+	if g.decided {
+		if !g.wroteHeader {
+			g.writeHeader()
+		}
+		if g.gz != nil {
+			return g.gz.Write(b)
+		}
+		return g.ResponseWriter.Write(b)
+	}
+
+	g.buf = append(g.buf, b...)
+	if len(g.buf) >= g.minLength {
+		err := g.decide(true)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends whatever has been written so far, a response that's flushed before reaching the
+// minimum length is sent uncompressed since it's presumably being streamed in small pieces.
+func (g *gzipWriter) Flush() {
+	if !g.decided {
+		if err := g.decide(false); err != nil {
+			return
+		}
+	}
+	if g.gz != nil {
+		g.gz.Flush() // nolint:errcheck
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// decide commits to either compressing or not and writes out anything that was buffered
+func (g *gzipWriter) decide(compress bool) (err error) {
+	g.decided = true
+
+	if compress && g.Header().Get("Content-Encoding") == "" {
+		g.Header().Set("Content-Encoding", "gzip")
+		g.Header().Del("Content-Length")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+
+	g.writeHeader()
+
+	if len(g.buf) > 0 {
+		if g.gz != nil {
+			_, err = g.gz.Write(g.buf)
+		} else {
+			_, err = g.ResponseWriter.Write(g.buf)
+		}
+		g.buf = nil
+	}
+	return
+}
+
+func (g *gzipWriter) writeHeader() {
+	g.wroteHeader = true
+	g.ResponseWriter.WriteHeader(g.status)
+}
+
+func (g *gzipWriter) close() {
+	if !g.decided {
+		g.decide(false) // nolint:errcheck
+	}
+	if g.gz != nil {
+		g.gz.Close() // nolint:errcheck
+	}
+}
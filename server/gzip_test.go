@@ -0,0 +1,77 @@
+package server
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzip(t *testing.T) {
+	large := strings.Repeat(`{"ip":"127.0.0.1:7777","hn":"test server"},`, 100)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           []string
+		flush          bool
+		wantEncoding   string
+	}{
+		{"large", "gzip, deflate", []string{large}, false, "gzip"},
+		{"large streamed", "gzip", []string{large[:100], large[100:]}, false, "gzip"},
+		{"small", "gzip", []string{`{"ip":"127.0.0.1:7777"}`}, false, ""},
+		{"not accepted", "deflate", []string{large}, false, ""},
+		{"refused", "gzip;q=0", []string{large}, false, ""},
+		{"flushed early", "gzip", []string{"[", large}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Gzip(1000)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				for _, chunk := range tt.body {
+					w.Write([]byte(chunk)) // nolint:errcheck
+					if tt.flush {
+						w.(http.Flusher).Flush()
+					}
+				}
+			}))
+
+			r := httptest.NewRequest("GET", "/v2/servers", nil)
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, tt.wantEncoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			body := w.Body.Bytes()
+			if tt.wantEncoding == "gzip" {
+				gz, err := gzip.NewReader(w.Body)
+				assert.NoError(t, err)
+				body, err = ioutil.ReadAll(gz)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, strings.Join(tt.body, ""), string(body))
+		})
+	}
+}
+
+func TestGzip_NoContent(t *testing.T) {
+	handler := Gzip(1000)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	r := httptest.NewRequest("DELETE", "/v2/server/s1.example.com", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, 0, w.Body.Len())
+}
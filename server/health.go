@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// readyTimeout bounds how long a readiness check waits on dependencies so a hung database fails the
+// probe instead of hanging it
+var readyTimeout = time.Second * 2
+
+// healthResponse is the body of both health endpoints, Failed maps each unavailable dependency to
+// the reason it failed and is omitted when everything is fine
+type healthResponse struct {
+	Status string            `json:"status"`
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// Healthz is the liveness probe, it always succeeds as long as the process is able to serve requests
+func (app *App) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Readyz is the readiness probe, it only succeeds when the database is reachable within readyTimeout
+func (app *App) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	err := app.db.Ping(ctx)
+	if err != nil {
+		app.logger.Warn("readiness check failed", zap.Error(err))
+		writeHealth(w, http.StatusServiceUnavailable, healthResponse{
+			Status: "unavailable",
+			Failed: map[string]string{"database": err.Error()},
+		})
+		return
+	}
+
+	writeHealth(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+func writeHealth(w http.ResponseWriter, status int, body healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) // nolint:errcheck
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+)
+
+// hungStore never answers a ping until it's given up on
+type hungStore struct {
+	storage.Store
+}
+
+func (hungStore) Ping(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestApp_Healthz(t *testing.T) {
+	app := NewApp(hungStore{}, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	app.Healthz(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestApp_Readyz(t *testing.T) {
+	readyTimeout = time.Millisecond * 10
+
+	tests := []struct {
+		name       string
+		db         storage.Store
+		wantStatus int
+		wantBody   string
+	}{
+		{"ready", storage.NewMemoryStore(), http.StatusOK, `{"status":"ok"}`},
+		{"hung", hungStore{}, http.StatusServiceUnavailable, `{"status":"unavailable","failed":{"database":"context deadline exceeded"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApp(tt.db, zap.NewNop())
+
+			w := httptest.NewRecorder()
+			app.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// historyBucket is the interval that samples older than HistoryRawRetention are averaged over
+const historyBucket = time.Hour
+
+// StartHistoryCompaction keeps the amount of stored player samples bounded by periodically averaging
+// samples older than HistoryRawRetention into hourly ones and removing any older than
+// HistoryRetention. StartHistoryCompaction blocks until the context is cancelled.
+func (app *App) StartHistoryCompaction(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		app.compactHistory(time.Now())
+	}
+}
+
+func (app *App) compactHistory(now time.Time) {
+	err := app.db.DownsampleSamples(now.Add(-app.config.HistoryRawRetention), historyBucket)
+	if err != nil {
+		app.logger.Error("failed to downsample player history",
+			zap.Error(err))
+	}
+
+	err = app.db.RemoveSamples(now.Add(-app.config.HistoryRetention))
+	if err != nil {
+		app.logger.Error("failed to remove old player history",
+			zap.Error(err))
+	}
+}
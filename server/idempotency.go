@@ -0,0 +1,163 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/server/realip"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// maxIdempotencyKeyLength is the longest Idempotency-Key header that's accepted, keys are meant to be
+// something like a UUID so anything longer is almost certainly a mistake
+const maxIdempotencyKeyLength = 255
+
+// Idempotency returns a middleware that makes POST requests safe to retry. When a request has an
+// `Idempotency-Key` header its response is stored, and a repeat of the request with the same key
+// within ttl is answered with the stored response, marked with an `Idempotent-Replayed` header,
+// instead of being processed again. Server errors aren't stored so those requests can be retried.
+// Keys belong to whoever sent them, see idempotencyKey, so one client can't be replayed another's
+// response. A key that's reused for a different route or body is rejected with a 422 and a repeat
+// that arrives while the original is still being processed is rejected with a 409. Bodies larger
+// than maxBody are passed straight through for the handler to reject. Failures to look up or store
+// a response are logged to logger.
+func Idempotency(logger *zap.Logger, db storage.Store, ttl time.Duration, maxBody int64) func(http.Handler) http.Handler {
+	var (
+		mu         sync.Mutex
+		processing = make(map[string]struct{})
+	)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLength)+" characters", http.StatusBadRequest)
+				return
+			}
+
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBody+1))
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			if int64(len(body)) > maxBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			bodyHash := sha256.Sum256(body)
+			key = idempotencyKey(r, key)
+
+			mu.Lock()
+			if _, ok := processing[key]; ok {
+				mu.Unlock()
+				http.Error(w, "a request with this Idempotency-Key is already being processed", http.StatusConflict)
+				return
+			}
+			processing[key] = struct{}{}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				delete(processing, key)
+				mu.Unlock()
+			}()
+
+			now := time.Now()
+			stored, err := db.GetIdempotentResponse(key)
+			if err == nil && now.Sub(stored.Created) < ttl {
+				if stored.Method != r.Method || stored.Path != r.URL.Path || stored.BodyHash != hex.EncodeToString(bodyHash[:]) {
+					http.Error(w, "Idempotency-Key has already been used for a different request", http.StatusUnprocessableEntity)
+					return
+				}
+				replay(w, stored)
+				return
+			} else if err != nil && err != storage.ErrNotFound {
+				logger.Error("failed to look up idempotency key",
+					zap.Error(err))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+
+			err = db.SetIdempotentResponse(types.IdempotentResponse{
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				BodyHash:    hex.EncodeToString(bodyHash[:]),
+				Status:      recorder.status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+				Created:     now,
+			})
+			if err != nil {
+				logger.Error("failed to store idempotent response",
+					zap.Error(err),
+					zap.String("path", r.URL.Path))
+			}
+		})
+	}
+}
+
+// idempotencyKey namespaces an Idempotency-Key by the caller, their API key if they sent one and
+// otherwise their IP. It's hashed so the API keys don't end up in the database.
+func idempotencyKey(r *http.Request, key string) string {
+	caller := "ip " + realip.ClientIP(r)
+	if token := bearerToken(r); token != "" {
+		caller = "key " + token
+	}
+	sum := sha256.Sum256([]byte(caller + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// replay writes a stored response in place of processing its request again
+func replay(w http.ResponseWriter, response types.IdempotentResponse) {
+	if response.ContentType != "" {
+		w.Header().Set("Content-Type", response.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(response.Status)
+	w.Write(response.Body) // nolint:errcheck
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
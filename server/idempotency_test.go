@@ -0,0 +1,147 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestIdempotency(t *testing.T) {
+	db := storage.NewMemoryStore()
+	require.NoError(t, db.SetIdempotentResponse(types.IdempotentResponse{
+		Key:     idempotencyKey(httptest.NewRequest("POST", "/v2/server", nil), "expired"),
+		Method:  "POST",
+		Path:    "/v2/server",
+		Status:  http.StatusCreated,
+		Body:    []byte("stale"),
+		Created: time.Now().Add(-time.Hour * 25),
+	}))
+
+	calls := 0
+	handler := Idempotency(zap.NewNop(), db, time.Hour*24, 16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/v2/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("call " + strconv.Itoa(calls))) // nolint:errcheck
+	}))
+
+	// each step runs against the same handler and store, in order
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		key          string
+		body         string
+		caller       string // an API key, or an IP if it has a dot
+		wantStatus   int
+		wantBody     string
+		wantReplayed bool
+	}{
+		{"no key", "POST", "/v2/server", "", "{}", "", http.StatusCreated, "call 1", false},
+		{"no key again", "POST", "/v2/server", "", "{}", "", http.StatusCreated, "call 2", false},
+		{"first", "POST", "/v2/server", "a", "{}", "", http.StatusCreated, "call 3", false},
+		{"retry", "POST", "/v2/server", "a", "{}", "", http.StatusCreated, "call 3", true},
+		{"different key", "POST", "/v2/server", "b", "{}", "", http.StatusCreated, "call 4", false},
+		{"different route", "POST", "/v2/servers", "a", "{}", "", http.StatusUnprocessableEntity, "Idempotency-Key has already been used for a different request\n", false},
+		{"different body", "POST", "/v2/server", "a", `{"a":1}`, "", http.StatusUnprocessableEntity, "Idempotency-Key has already been used for a different request\n", false},
+		{"different ip", "POST", "/v2/server", "a", "{}", "203.0.113.1", http.StatusCreated, "call 5", false},
+		{"api key", "POST", "/v2/server", "a", "{}", "secret", http.StatusCreated, "call 6", false},
+		{"api key retry", "POST", "/v2/server", "a", "{}", "secret", http.StatusCreated, "call 6", true},
+		{"other api key", "POST", "/v2/server", "a", "{}", "other", http.StatusCreated, "call 7", false},
+		{"not a post", "GET", "/v2/server", "a", "{}", "", http.StatusCreated, "call 8", false},
+		{"expired", "POST", "/v2/server", "expired", "", "", http.StatusCreated, "call 9", false},
+		{"expired retry", "POST", "/v2/server", "expired", "", "", http.StatusCreated, "call 9", true},
+		{"server error", "POST", "/v2/fail", "c", "{}", "", http.StatusBadGateway, "", false},
+		{"server error retry", "POST", "/v2/fail", "c", "{}", "", http.StatusBadGateway, "", false},
+		{"long key", "POST", "/v2/server", strings.Repeat("k", 256), "{}", "", http.StatusBadRequest, "Idempotency-Key must be at most 255 characters\n", false},
+		{"too large", "POST", "/v2/server", "d", strings.Repeat("a", 17), "", http.StatusCreated, "call 12", false},
+		{"too large retry", "POST", "/v2/server", "d", strings.Repeat("a", 17), "", http.StatusCreated, "call 13", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.key != "" {
+				r.Header.Set("Idempotency-Key", tt.key)
+			}
+			if strings.Contains(tt.caller, ".") {
+				r.RemoteAddr = tt.caller + ":1234"
+			} else if tt.caller != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.caller)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			if tt.wantReplayed {
+				assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+				assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+			} else {
+				assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+	assert.Equal(t, 13, calls)
+}
+
+func TestIdempotency_Processing(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := Idempotency(zap.NewNop(), storage.NewMemoryStore(), time.Hour, 1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	done := make(chan int)
+	go func() {
+		r := httptest.NewRequest("POST", "/v2/server", nil)
+		r.Header.Set("Idempotency-Key", "a")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		done <- w.Code
+	}()
+	<-started
+
+	r := httptest.NewRequest("POST", "/v2/server", nil)
+	r.Header.Set("Idempotency-Key", "a")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+}
+
+func TestApp_reapIdempotencyKeys(t *testing.T) {
+	db := storage.NewMemoryStore()
+	app := NewApp(db, zap.NewNop(), WithConfig(types.Config{IdempotencyTTL: time.Hour * 24}))
+
+	now := time.Date(2018, 1, 10, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, db.SetIdempotentResponse(types.IdempotentResponse{Key: "old", Created: now.Add(-time.Hour * 25)}))
+	require.NoError(t, db.SetIdempotentResponse(types.IdempotentResponse{Key: "new", Created: now.Add(-time.Hour)}))
+
+	app.reapIdempotencyKeys(now)
+
+	_, err := db.GetIdempotentResponse("old")
+	assert.Equal(t, storage.ErrNotFound, err)
+	_, err = db.GetIdempotentResponse("new")
+	assert.NoError(t, err)
+}
@@ -15,7 +15,7 @@ import (
 func (app *App) LegacyListQuery() {
 	err := app.getMasterlist()
 	if err != nil {
-		logger.Error("failed to get lists.sa-mp.com",
+		app.logger.Error("failed to get lists.sa-mp.com",
 			zap.Error(err))
 	}
 
@@ -23,7 +23,7 @@ func (app *App) LegacyListQuery() {
 	for range ticker.C {
 		err = app.getMasterlist()
 		if err != nil {
-			logger.Error("failed to get lists.sa-mp.com",
+			app.logger.Error("failed to get lists.sa-mp.com",
 				zap.Error(err))
 		}
 	}
@@ -48,13 +48,17 @@ func (app *App) getMasterlist() (err error) {
 			return
 		}
 
-		logger.Debug("adding server from legacy masterlist",
+		if app.IsBlocked(address) {
+			continue
+		}
+
+		app.logger.Debug("adding server from legacy masterlist",
 			zap.String("address", address))
 
 		app.qd.Add(address)
 		count++
 	}
-	logger.Debug("added servers from masterlist", zap.Int("servers", count))
+	app.logger.Debug("added servers from masterlist", zap.Int("servers", count))
 
 	return
 }
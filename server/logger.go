@@ -7,7 +7,10 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-var logger *zap.Logger
+// defaultLogger is the logger of Apps created by Initialise or without a logger of their own.
+//
+// Deprecated: it's only kept as a fallback for one release, pass a logger to NewApp instead.
+var defaultLogger *zap.Logger
 
 func init() {
 	var config zap.Config
@@ -32,7 +35,7 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
-	logger = _logger.With(
+	defaultLogger = _logger.With(
 		zap.String("@version", os.Getenv("GIT_HASH")),
 		zap.Namespace("@fields"),
 	)
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"time"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// GetMany returns the stored servers at many addresses at once keyed by each address as it was
+// given, addresses without a server map to nil. Each server is marked online or offline by when it
+// was last seen.
+func (app *App) GetMany(addresses []string) (servers map[string]*types.Server, err error) {
+	servers, err = storage.GetMany(app.db, addresses)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, server := range servers {
+		if server != nil {
+			server.CheckOnline(now, app.config.OfflineAfter)
+		}
+	}
+	return
+}
@@ -1,16 +1,27 @@
 package server
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
 
 // metrics stores rates and guages for monitoring
 type metrics struct {
 	Active   prometheus.Gauge
 	Inactive prometheus.Gauge
+	Online   prometheus.Gauge
 	Players  prometheus.Gauge
+	Polls    *prometheus.CounterVec
+	Requests *prometheus.CounterVec
+
+	ParseFailures *prometheus.CounterVec
+	Upserts       *prometheus.CounterVec
 }
 
-// newMetricsRecorder initialises a new metrics recorder
-func newMetricsRecorder() (m *metrics) {
+// newMetricsRecorder initialises a new metrics recorder and registers it with reg
+func newMetricsRecorder(reg prometheus.Registerer) (m *metrics) {
 	m = &metrics{
 		Active: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: "samplist",
@@ -24,17 +35,57 @@ func newMetricsRecorder() (m *metrics) {
 			Name:      "inactive",
 			Help:      "Total servers that are offline but being given a grace-period to come back online.",
 		}),
+		Online: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "samplist",
+			Subsystem: "index",
+			Name:      "online",
+			Help:      "Total active servers that responded to their most recent query.",
+		}),
 		Players: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: "samplist",
 			Subsystem: "index",
 			Name:      "players",
 			Help:      "Total players across all servers",
 		}),
+		Polls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "samplist",
+			Subsystem: "poller",
+			Name:      "queries",
+			Help:      "Total poller queries by result.",
+		}, []string{"result"}),
+		Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "samplist",
+			Subsystem: "http",
+			Name:      "requests",
+			Help:      "Total HTTP requests by route and status code.",
+		}, []string{"route", "code"}),
+		ParseFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "samplist",
+			Subsystem: "query",
+			Name:      "parse_failures",
+			Help:      "Total responses that could not be parsed by opcode and reason.",
+		}, []string{"opcode", "reason"}),
+		Upserts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "samplist",
+			Subsystem: "index",
+			Name:      "upserts",
+			Help:      "Total queried servers stored by whether they had changed, unchanged ones are only marked seen.",
+		}, []string{"result"}),
 	}
-	prometheus.MustRegister(
+	reg.MustRegister(
 		m.Active,
 		m.Inactive,
+		m.Online,
 		m.Players,
+		m.Polls,
+		m.Requests,
+		m.ParseFailures,
+		m.Upserts,
 	)
 	return m
 }
+
+// instrument wraps a route handler so each response is counted against the route name
+func (m *metrics) instrument(route string, handler http.Handler) http.Handler {
+	return promhttp.InstrumentHandlerCounter(m.Requests.MustCurryWith(prometheus.Labels{"route": route}), handler)
+}
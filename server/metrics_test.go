@@ -0,0 +1,28 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Instrument(t *testing.T) {
+	m := newMetricsRecorder(prometheus.NewRegistry())
+
+	ok := m.instrument("serverGet", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	notFound := m.instrument("serverGet", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	for i := 0; i < 3; i++ {
+		ok.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v2/server/s1.example.com", nil))
+	}
+	notFound.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v2/server/s2.example.com", nil))
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(m.Requests.WithLabelValues("serverGet", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.Requests.WithLabelValues("serverGet", "404")))
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// Nearby returns the listed servers ordered by how close they're likely to be to a client, those in
+// the client's country first, then those on the same continent and then the rest, each group sorted
+// by players. The GeoIP database only goes as far as countries so that's as fine as the distance
+// gets. If the client can't be located the servers are only sorted by players.
+func (app *App) Nearby(clientIP string) (servers []types.Server, err error) {
+	err = app.db.StreamServers(types.ServerListParams{}, func(server types.Server) error {
+		servers = append(servers, server)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load servers")
+	}
+
+	country, err := app.locateClient(clientIP)
+	if err != nil {
+		app.logger.Debug("failed to geolocate client for nearby servers",
+			zap.Error(err),
+			zap.String("ip", clientIP))
+		return servers, nil
+	}
+
+	sortNearby(servers, country, app.continentOf)
+	return servers, nil
+}
+
+// sortNearby moves the servers in a country to the front followed by those on the same continent,
+// the order within each group is left as it was. Nothing is moved if the country is unknown.
+func sortNearby(servers []types.Server, country string, continentOf func(country string) string) {
+	if country == "" {
+		return
+	}
+	continent := continentOf(country)
+
+	distance := func(server types.Server) int {
+		switch {
+		case server.Country == country:
+			return 0
+		case continent != "" && continentOf(server.Country) == continent:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(servers, func(i, j int) bool {
+		return distance(servers[i]) < distance(servers[j])
+	})
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestSortNearby(t *testing.T) {
+	continents := map[string]string{"PL": "EU", "DE": "EU", "RU": "EU", "US": "NA", "BR": "SA"}
+	continentOf := func(country string) string { return continents[country] }
+
+	servers := func(countries ...string) (servers []types.Server) {
+		for _, country := range countries {
+			servers = append(servers, types.Server{Country: country})
+		}
+		return
+	}
+	tests := []struct {
+		name    string
+		servers []types.Server
+		country string
+		want    []types.Server
+	}{
+		{"country then continent", servers("US", "DE", "PL", "", "RU", "PL"), "PL", servers("PL", "PL", "DE", "RU", "US", "")},
+		{"unknown continent", servers("US", "BR", "XX", "BR"), "XX", servers("XX", "US", "BR", "BR")},
+		{"unknown country", servers("US", "DE", "PL"), "", servers("US", "DE", "PL")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortNearby(tt.servers, tt.country, continentOf)
+			assert.Equal(t, tt.want, tt.servers)
+		})
+	}
+}
+
+func TestApp_Nearby_Unlocated(t *testing.T) {
+	db := storage.NewMemoryStore()
+	for _, server := range []types.Server{
+		{Core: types.ServerCore{Address: "a.example.com:7777", Players: 5}, Country: "PL"},
+		{Core: types.ServerCore{Address: "b.example.com:7777", Players: 20}, Country: "US"},
+		{Core: types.ServerCore{Address: "c.example.com:7777", Players: 10}, Country: "PL"},
+	} {
+		require.NoError(t, db.UpsertServer(server))
+	}
+	geo, err := newGeoLocator("")
+	require.NoError(t, err)
+	app := NewApp(db, zap.NewNop())
+	app.geo = geo
+
+	servers, err := app.Nearby("93.119.25.177")
+	assert.NoError(t, err)
+	var addresses []string
+	for _, server := range servers {
+		addresses = append(addresses, server.Core.Address)
+	}
+	assert.Equal(t, []string{"b.example.com:7777", "c.example.com:7777", "a.example.com:7777"}, addresses)
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// openAPISpec is an OpenAPI 3 document, only the parts of the specification the API needs are
+// included
+type openAPISpec struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       openAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components openAPIComponents                      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Description string                     `json:"description,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// pathParameter matches a mux path variable, along with the pattern it's restricted to if any
+var pathParameter = regexp.MustCompile(`{([^}:]+)(?::[^}]*)?}`)
+
+// openAPI generates an OpenAPI 3 spec describing the routes of every handler. The request and
+// response schemas are derived from the json tags of the example types each route accepts and
+// returns, so the spec stays in step with the types without being maintained by hand. Named structs
+// such as the server are described once under the components and referred to from each route.
+func openAPI(version string, handlers map[string]types.RouteHandler) (spec []byte, err error) {
+	doc := openAPISpec{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "samp-servers-api", Version: version},
+		Paths:   make(map[string]map[string]openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: make(map[string]*openAPISchema),
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "unknown"
+	}
+
+	for name, handler := range handlers {
+		for _, route := range handler.Routes() {
+			p := pathParameter.ReplaceAllString(path.Join("/", name, route.Path), "{$1}")
+			if doc.Paths[p] == nil {
+				doc.Paths[p] = make(map[string]openAPIOperation)
+			}
+			doc.Paths[p][strings.ToLower(route.Method)] = doc.operation(name, route)
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+func (doc *openAPISpec) operation(group string, route types.Route) (op openAPIOperation) {
+	op = openAPIOperation{
+		OperationID: group + "." + route.Name,
+		Description: route.Description,
+		Responses:   make(map[string]openAPIResponse),
+	}
+
+	for _, match := range pathParameter.FindAllStringSubmatch(route.Path, -1) {
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &openAPISchema{Type: "string"},
+		})
+	}
+	if route.Params != nil {
+		op.Parameters = append(op.Parameters, doc.queryParameters(reflect.TypeOf(types.ServerListParams{}))...)
+	}
+
+	if route.Accepts != nil {
+		op.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content: map[string]openAPIMediaType{
+				"application/json": {Schema: doc.schema(reflect.TypeOf(route.Accepts))},
+			},
+		}
+	}
+
+	response := openAPIResponse{Description: "success"}
+	if route.Returns != nil {
+		response.Content = map[string]openAPIMediaType{
+			"application/json": {Schema: doc.schema(reflect.TypeOf(route.Returns))},
+		}
+	}
+	op.Responses["200"] = response
+
+	if route.Admin || route.KeyRequired {
+		op.Security = []map[string][]string{{"bearer": {}}}
+	}
+	return
+}
+
+// queryParameters describes each field of a struct decoded from a query string, the names are the
+// same ones qstring uses
+func (doc *openAPISpec) queryParameters(t reflect.Type) (params []openAPIParameter) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("qstring"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		params = append(params, openAPIParameter{
+			Name:   name,
+			In:     "query",
+			Schema: doc.schema(field.Type),
+		})
+	}
+	return
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema describes a type as it's encoded to JSON, named structs are added to the components the
+// first time they're seen and referred to from then on
+func (doc *openAPISpec) schema(t reflect.Type) *openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &openAPISchema{Type: "string", Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &openAPISchema{Type: "array", Items: doc.schema(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: doc.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return doc.object(t)
+		}
+		name := t.Name()
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			// reserved before describing the fields so a struct that refers to itself terminates
+			doc.Components.Schemas[name] = &openAPISchema{}
+			*doc.Components.Schemas[name] = *doc.object(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	return &openAPISchema{}
+}
+
+// object describes the fields of a struct in the same way encoding/json encodes them, the fields
+// of embedded structs are promoted and fields without omitempty are required
+func (doc *openAPISpec) object(t reflect.Type) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	doc.fields(t, schema)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (doc *openAPISpec) fields(t reflect.Type, schema *openAPISchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			doc.fields(field.Type, schema)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = doc.schema(field.Type)
+		omitempty := false
+		for _, option := range tag[1:] {
+			if option == "omitempty" {
+				omitempty = true
+			}
+		}
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// OpenAPI serves the OpenAPI spec generated at startup
+func (app *App) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(app.openapi) // nolint:errcheck
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/server/v2"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestApp_OpenAPI(t *testing.T) {
+	handlers := map[string]types.RouteHandler{
+		"v2": v2.Init(storage.NewMemoryStore(), nil, types.Config{}, v2.Deps{}),
+	}
+	spec, err := openAPI("1.2.3", handlers)
+	require.NoError(t, err)
+	app := &App{openapi: spec}
+
+	w := httptest.NewRecorder()
+	app.OpenAPI(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var doc openAPISpec
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+	assert.Equal(t, "1.2.3", doc.Info.Version)
+
+	core := doc.Components.Schemas["ServerCore"]
+	require.NotNil(t, core)
+	assert.Equal(t, &openAPISchema{Type: "string"}, core.Properties["ip"])
+	assert.Equal(t, &openAPISchema{Type: "integer", Format: "int32"}, core.Properties["pc"])
+	assert.Equal(t, &openAPISchema{Type: "boolean"}, core.Properties["pa"])
+	assert.Equal(t, []string{"gm", "hn", "ip", "la", "pa", "pc", "pm", "vn"}, core.Required)
+
+	server := doc.Components.Schemas["Server"]
+	require.NotNil(t, server)
+	assert.Equal(t, &openAPISchema{Ref: "#/components/schemas/ServerCore"}, server.Properties["core"])
+	assert.Equal(t, &openAPISchema{Type: "string", Format: "date-time"}, server.Properties["ls"])
+	assert.Equal(t, &openAPISchema{Type: "object", AdditionalProperties: &openAPISchema{Type: "string"}}, server.Properties["ru"])
+	assert.NotContains(t, server.Required, "ls")
+
+	get := doc.Paths["/v2/server/{address}"]["get"]
+	assert.Equal(t, "v2.serverGet", get.OperationID)
+	assert.Equal(t, []openAPIParameter{{Name: "address", In: "path", Required: true, Schema: &openAPISchema{Type: "string"}}}, get.Parameters)
+	assert.Equal(t, &openAPISchema{Ref: "#/components/schemas/Server"}, get.Responses["200"].Content["application/json"].Schema)
+
+	var names []string
+	for _, param := range doc.Paths["/v2/servers"]["get"].Parameters {
+		names = append(names, param.Name)
+	}
+	assert.Contains(t, names, "pagesize")
+	assert.Contains(t, names, "includeDead")
+
+	post := doc.Paths["/v2/server"]["post"]
+	require.NotNil(t, post.RequestBody)
+	assert.Equal(t, &openAPISchema{Ref: "#/components/schemas/Server"}, post.RequestBody.Content["application/json"].Schema)
+	assert.Empty(t, post.Security)
+
+	feature := doc.Paths["/v2/server/{address}/feature"]["put"]
+	assert.Equal(t, []map[string][]string{{"bearer": {}}}, feature.Security)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// parseFailureLogSize is how many of the most recent parse failures are kept for inspection
+const parseFailureLogSize = 100
+
+// parseFailureLog is a ring buffer of the most recent responses that failed to parse, so the
+// servers behind a rise in the parse failure metrics can be found without trawling the logs.
+type parseFailureLog struct {
+	mu       sync.Mutex
+	failures []types.ParseFailure
+	next     int
+}
+
+func newParseFailureLog(size int) *parseFailureLog {
+	return &parseFailureLog{failures: make([]types.ParseFailure, 0, size)}
+}
+
+// add records a failure, replacing the oldest one once the log is full
+func (l *parseFailureLog) add(failure types.ParseFailure) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.failures) < cap(l.failures) {
+		l.failures = append(l.failures, failure)
+		return
+	}
+	l.failures[l.next] = failure
+	l.next = (l.next + 1) % len(l.failures)
+}
+
+// recent returns the failures in the log, newest first
+func (l *parseFailureLog) recent() (failures []types.ParseFailure) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	failures = make([]types.ParseFailure, 0, len(l.failures))
+	for i := len(l.failures) - 1; i >= 0; i-- {
+		failures = append(failures, l.failures[(l.next+i)%len(l.failures)])
+	}
+	return
+}
+
+// recordParseFailure counts a query error against the parse failure metrics and logs the server it
+// came from if the error was a response that couldn't be parsed, any other error is ignored.
+func (app *App) recordParseFailure(address string, err error) {
+	if partial, ok := err.(query.PartialErrors); ok {
+		for _, err := range partial {
+			app.recordParseFailure(address, err)
+		}
+		return
+	}
+
+	opcode, reason, ok := query.ParseFailure(err)
+	if !ok {
+		return
+	}
+	app.metrics.ParseFailures.WithLabelValues(string(opcode), reason).Inc()
+	app.parseFailures.add(types.ParseFailure{
+		Address: address,
+		Opcode:  string(opcode),
+		Reason:  reason,
+		Error:   err.Error(),
+		Time:    time.Now(),
+	})
+}
+
+// ParseFailures lists the most recent responses that failed to parse, newest first
+func (app *App) ParseFailures(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(app.parseFailures.recent()) // nolint:errcheck
+}
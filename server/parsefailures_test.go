@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestParseFailureLog(t *testing.T) {
+	l := newParseFailureLog(3)
+	assert.Empty(t, l.recent())
+
+	for i := 0; i < 5; i++ {
+		l.add(types.ParseFailure{Address: fmt.Sprintf("s%d.example.com:7777", i)})
+	}
+
+	var addresses []string
+	for _, failure := range l.recent() {
+		addresses = append(addresses, failure.Address)
+	}
+	assert.Equal(t, []string{"s4.example.com:7777", "s3.example.com:7777", "s2.example.com:7777"}, addresses)
+}
+
+func TestApp_recordParseFailure(t *testing.T) {
+	app := NewApp(storage.NewMemoryStore(), zap.NewNop())
+
+	// something other than a SA:MP server answering on the port
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close() // nolint:errcheck
+	go func() {
+		buf := make([]byte, 64)
+		_, from, err := conn.ReadFromUDP(buf)
+		if err == nil {
+			conn.WriteToUDP([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"), from) // nolint:errcheck
+		}
+	}()
+	address := conn.LocalAddr().String()
+
+	_, err = app.queryServer(app.ctx, address)
+	require.Error(t, err)
+	app.recordParseFailure("s1.example.com:7777", errors.New("server did not respond"))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(app.metrics.ParseFailures.WithLabelValues("p", "bad_magic")))
+
+	w := httptest.NewRecorder()
+	app.ParseFailures(w, httptest.NewRequest("GET", "/metrics/parse-failures", nil))
+
+	var failures []types.ParseFailure
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&failures))
+	require.Len(t, failures, 1)
+	assert.Equal(t, address, failures[0].Address)
+	assert.Equal(t, "p", failures[0].Opcode) // the handshake is the first thing sent
+	assert.Equal(t, "bad_magic", failures[0].Reason)
+}
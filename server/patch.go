@@ -0,0 +1,18 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// PatchServer overwrites the fields of a stored server that are present in a partial server object,
+// the patched server has to pass the same checks as a posted one apart from verification. A patch
+// that's rejected is returned as types.Invalid and storage.ErrNotFound if there isn't a server at
+// the address.
+func (app *App) PatchServer(address string, patch map[string]json.RawMessage) error {
+	return storage.PatchServer(app.db, address, patch, func(server types.Server) []error {
+		return server.Core.Allowed(app.config.AllowedGamemodes, app.config.AllowedLanguages)
+	})
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestApp_PatchServer(t *testing.T) {
+	db := storage.NewMemoryStore()
+	require.NoError(t, db.UpsertServer(types.Server{}.Example()))
+	app := NewApp(db, zap.NewNop(), WithConfig(types.Config{AllowedLanguages: []string{"english"}}))
+
+	assert.NoError(t, app.PatchServer("127.0.0.1:7777", map[string]json.RawMessage{"co": json.RawMessage(`"GB"`)}))
+	assert.IsType(t, types.Invalid{}, app.PatchServer("127.0.0.1:7777", map[string]json.RawMessage{"core": json.RawMessage(`{"la":"Russian"}`)}))
+	assert.Equal(t, storage.ErrNotFound, app.PatchServer("127.0.0.2:7777", map[string]json.RawMessage{"co": json.RawMessage(`"GB"`)}))
+
+	server, err := db.GetServer("127.0.0.1:7777")
+	require.NoError(t, err)
+	assert.Equal(t, "GB", server.Country)
+	assert.Equal(t, "English", server.Core.Language)
+}
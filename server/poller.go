@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// defaultPollWorkers is used when PollWorkers is not configured
+const defaultPollWorkers = 16
+
+// StartPoller periodically re-queries every stored server with an info query and updates its player
+// counts and online status. Servers that fail to respond are marked dead until they respond again,
+// see StartReaper, and removed once they've failed MaxPollFailures polls in a row. Blocked servers
+// are skipped. Servers are queried concurrently by a pool of workers, the size of which is
+// controlled by the PollWorkers config field. An interval is skipped if a poll triggered with
+// TriggerPoll is still running when it comes around. StartPoller blocks until the context is
+// cancelled.
+func (app *App) StartPoller(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		select {
+		case app.polling <- struct{}{}:
+		default:
+			app.logger.Debug("skipping scheduled poll while a manual poll is running")
+			continue
+		}
+		app.pollCycle(ctx, "")
+		<-app.polling
+	}
+}
+
+// pollCycle polls every stored server that isn't blocked once, reporting progress to the manual poll
+// job with the id if there is one. The caller must hold app.polling.
+func (app *App) pollCycle(ctx context.Context, jobID string) {
+	started := time.Now()
+	app.pollJobs.update(jobID, func(job *types.PollJob) { job.Started = &started })
+
+	addresses, err := app.db.LoadAllAddresses()
+	if err != nil {
+		app.logger.Error("failed to load addresses for poller",
+			zap.Error(err))
+		return
+	}
+
+	addresses = app.unblocked(addresses)
+	app.pollJobs.update(jobID, func(job *types.PollJob) { job.Total = len(addresses) })
+
+	pollAll(ctx, addresses, app.config.PollWorkers, func(ctx context.Context, address string) {
+		responded := app.pollServer(ctx, address)
+		app.pollJobs.update(jobID, func(job *types.PollJob) {
+			job.Queried++
+			if !responded {
+				job.Failed++
+			}
+		})
+	})
+
+	app.updateIndexMetrics()
+}
+
+// pollServer queries a server and updates it with the result, reporting whether it responded
+func (app *App) pollServer(ctx context.Context, address string) (responded bool) {
+	opts := app.queryOptionsFor(address)
+	opts.Handshake = true // a spoofed response mustn't keep a server that's gone listed as online
+	core, err := query.QueryInfo(ctx, address, opts)
+	if errors.Cause(err) == query.ErrPartialInfo {
+		core, err = app.completeInfo(core, err)
+	}
+	if err != nil {
+		app.recordParseFailure(address, err)
+		app.metrics.Polls.WithLabelValues("failure").Inc()
+		app.logger.Debug("poller failed to query server",
+			zap.Error(err),
+			zap.String("address", address))
+
+		now := time.Now()
+		err = app.db.SetOffline(address)
+		if err != nil {
+			app.logger.Error("failed to mark server offline",
+				zap.Error(err),
+				zap.String("address", address))
+			return false
+		}
+		err = app.db.MarkDead(address, now)
+		if err != nil {
+			app.logger.Error("failed to mark server dead",
+				zap.Error(err),
+				zap.String("address", address))
+		}
+		if app.recordFailure(address) {
+			return false
+		}
+		app.updatePeakPlayers(address, 0, now)
+		app.serverChanged(address, 0, false)
+		return false
+	}
+
+	app.metrics.Polls.WithLabelValues("success").Inc()
+
+	now := time.Now()
+	err = app.db.UpdateServerInfo(core, now)
+	if err != nil {
+		app.logger.Error("failed to update polled server",
+			zap.Error(err),
+			zap.String("address", address))
+		return true
+	}
+
+	err = app.db.AddSample(types.PlayerSample{Address: address, Time: now, Players: float64(core.Players)})
+	if err != nil {
+		app.logger.Error("failed to record player sample",
+			zap.Error(err),
+			zap.String("address", address))
+	}
+	app.updatePeakPlayers(address, core.Players, now)
+
+	app.serverChanged(address, core.Players, true)
+	return true
+}
+
+// recordFailure counts a failed poll of a server and removes it once it's failed MaxPollFailures
+// polls in a row, reporting whether it was removed. Counting polls rather than time means a server
+// isn't expired just because the poller itself was held up. A zero or negative MaxPollFailures
+// leaves dead servers to the reaper.
+func (app *App) recordFailure(address string) (removed bool) {
+	failures, err := app.db.RecordFailure(address)
+	if err != nil {
+		app.logger.Error("failed to record failed poll",
+			zap.Error(err),
+			zap.String("address", address))
+		return false
+	}
+	if app.config.MaxPollFailures <= 0 || failures < app.config.MaxPollFailures {
+		return false
+	}
+
+	err = app.db.RemoveServer(address)
+	if err != nil {
+		app.logger.Error("failed to remove failing server",
+			zap.Error(err),
+			zap.String("address", address))
+		return false
+	}
+	if app.qd != nil {
+		app.qd.Forget(address)
+	}
+	app.logger.Debug("removed server after consecutive failed polls",
+		zap.String("address", address),
+		zap.Int("failures", failures))
+	return true
+}
+
+// completeInfo fills in the strings missing from a partial info response with the stored ones, the
+// server still responded with its player counts so it's online. A server that isn't stored yet has
+// nothing to fill them in with, in which case the error is returned as it was.
+func (app *App) completeInfo(core types.ServerCore, partial error) (types.ServerCore, error) {
+	stored, err := app.db.GetServer(core.Address)
+	if err != nil {
+		return core, partial
+	}
+	if core.Hostname == "" {
+		core.Hostname = stored.Core.Hostname
+		core.HostnameClean = stored.Core.HostnameClean
+	}
+	if core.Gamemode == "" {
+		core.Gamemode = stored.Core.Gamemode
+	}
+	if core.Language == "" {
+		core.Language = stored.Core.Language
+		core.Languages = stored.Core.Languages
+	}
+	return core, nil
+}
+
+// updatePeakPlayers recalculates the 24 hour peak of a server from its samples, which decays as
+// samples fall out of the window so a server that's been offline for a day ends up with zero.
+func (app *App) updatePeakPlayers(address string, players int, now time.Time) {
+	samples, err := app.db.GetSamples(address, now.Add(-time.Hour*24), now)
+	if err != nil {
+		app.logger.Error("failed to load samples for peak players",
+			zap.Error(err),
+			zap.String("address", address))
+		return
+	}
+
+	peak24h := types.PeakOf(samples)
+	if players > peak24h {
+		peak24h = players
+	}
+
+	err = app.db.UpdatePeakPlayers(address, players, peak24h)
+	if err != nil {
+		app.logger.Error("failed to update peak players",
+			zap.Error(err),
+			zap.String("address", address))
+	}
+}
+
+// pollAll calls fn for each address using a fixed number of workers and returns once every address
+// has been handled or the context is cancelled.
+func pollAll(ctx context.Context, addresses []string, workers int, fn func(context.Context, string)) {
+	if workers <= 0 {
+		workers = defaultPollWorkers
+	}
+
+	jobs := make(chan string)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for address := range jobs {
+				fn(ctx, address)
+			}
+		}()
+	}
+
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	for _, address := range addresses {
+		select {
+		case <-ctx.Done():
+			return
+		case jobs <- address:
+		}
+	}
+}
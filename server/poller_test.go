@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestPollAll(t *testing.T) {
+	addresses := []string{"s1.example.com", "s2.example.com", "s3.example.com", "s4.example.com", "s5.example.com"}
+
+	var (
+		mu      sync.Mutex
+		polled  []string
+		running int32
+		maxSeen int32
+	)
+	pollAll(context.Background(), addresses, 2, func(ctx context.Context, address string) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		mu.Lock()
+		polled = append(polled, address)
+		if n > maxSeen {
+			maxSeen = n
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond * 10)
+	})
+
+	sort.Strings(polled)
+	assert.Equal(t, addresses, polled)
+	assert.True(t, maxSeen <= 2, "expected at most 2 concurrent workers, got %d", maxSeen)
+}
+
+func TestPollAll_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int32
+	pollAll(ctx, []string{"s1.example.com", "s2.example.com"}, 1, func(ctx context.Context, address string) {
+		atomic.AddInt32(&calls, 1)
+	})
+
+	assert.True(t, atomic.LoadInt32(&calls) <= 1)
+}
+
+func TestApp_updatePeakPlayers(t *testing.T) {
+	db := storage.NewMemoryStore()
+	require.NoError(t, db.UpsertServer(types.Server{Core: types.ServerCore{Address: "s1.example.com:7777"}}))
+	app := NewApp(db, zap.NewNop())
+
+	start := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		after       time.Duration
+		players     int
+		online      bool
+		wantPeak    int
+		wantPeak24h int
+	}{
+		{"first", 0, 30, true, 30, 30},
+		{"fewer", time.Hour * 2, 10, true, 30, 30},
+		{"offline", time.Hour * 25, 0, false, 30, 10},
+		{"offline for a day", time.Hour * 27, 0, false, 30, 0},
+		{"back", time.Hour * 28, 5, true, 30, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start.Add(tt.after)
+			if tt.online {
+				require.NoError(t, db.AddSample(types.PlayerSample{Address: "s1.example.com:7777", Time: now, Players: float64(tt.players)}))
+			}
+			app.updatePeakPlayers("s1.example.com:7777", tt.players, now)
+
+			server, err := db.GetServer("s1.example.com:7777")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPeak, server.PeakPlayers)
+			assert.Equal(t, tt.wantPeak24h, server.PeakPlayers24h)
+		})
+	}
+
+	// posting the server again doesn't reset the peaks
+	require.NoError(t, db.UpsertServer(types.Server{Core: types.ServerCore{Address: "s1.example.com:7777"}, PeakPlayers: 1000}))
+	server, err := db.GetServer("s1.example.com:7777")
+	require.NoError(t, err)
+	assert.Equal(t, 30, server.PeakPlayers)
+	assert.Equal(t, 5, server.PeakPlayers24h)
+}
+
+func TestApp_completeInfo(t *testing.T) {
+	db := storage.NewMemoryStore()
+	require.NoError(t, db.UpsertServer(types.Server{Core: types.ServerCore{
+		Address:       "s1.example.com:7777",
+		Hostname:      "[SS] Scavenge",
+		HostnameClean: "Scavenge",
+		Gamemode:      "Survival",
+		Language:      "English",
+		Languages:     []string{"English"},
+	}}))
+	app := NewApp(db, zap.NewNop())
+	partial := errors.Wrap(query.ErrPartialInfo, "response ends before the gamemode")
+
+	core, err := app.completeInfo(types.ServerCore{Address: "s1.example.com:7777", Hostname: "New Name", HostnameClean: "New Name", Players: 5, MaxPlayers: 50}, partial)
+	assert.NoError(t, err)
+	assert.Equal(t, types.ServerCore{
+		Address:       "s1.example.com:7777",
+		Hostname:      "New Name",
+		HostnameClean: "New Name",
+		Players:       5,
+		MaxPlayers:    50,
+		Gamemode:      "Survival",
+		Language:      "English",
+		Languages:     []string{"English"},
+	}, core)
+
+	_, err = app.completeInfo(types.ServerCore{Address: "s2.example.com:7777", Players: 5}, partial)
+	assert.Equal(t, partial, err)
+}
+
+func TestApp_recordFailure(t *testing.T) {
+	db := storage.NewMemoryStore()
+	require.NoError(t, db.UpsertServer(types.Server{Core: types.ServerCore{Address: "s1.example.com:7777"}}))
+	app := NewApp(db, zap.NewNop())
+	app.config.MaxPollFailures = 3
+
+	assert.False(t, app.recordFailure("s1.example.com:7777"))
+	assert.False(t, app.recordFailure("s1.example.com:7777"))
+
+	server, err := db.GetServer("s1.example.com:7777")
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.ConsecutiveFailures)
+
+	// a successful poll in between starts the count again
+	require.NoError(t, db.UpdateServerInfo(types.ServerCore{Address: "s1.example.com:7777"}, time.Now()))
+	assert.False(t, app.recordFailure("s1.example.com:7777"))
+	assert.False(t, app.recordFailure("s1.example.com:7777"))
+	assert.True(t, app.recordFailure("s1.example.com:7777"))
+
+	_, err = db.GetServer("s1.example.com:7777")
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestApp_recordFailure_Disabled(t *testing.T) {
+	db := storage.NewMemoryStore()
+	require.NoError(t, db.UpsertServer(types.Server{Core: types.ServerCore{Address: "s1.example.com:7777"}}))
+	app := NewApp(db, zap.NewNop())
+
+	for i := 0; i < 10; i++ {
+		assert.False(t, app.recordFailure("s1.example.com:7777"))
+	}
+
+	server, err := db.GetServer("s1.example.com:7777")
+	require.NoError(t, err)
+	assert.Equal(t, 10, server.ConsecutiveFailures)
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// pollJobLogSize is how many manually triggered polls are kept so their progress can be checked
+const pollJobLogSize = 20
+
+// pollJobs tracks the progress of manually triggered polls. Only one is queued or running at a time,
+// triggering another while it is just returns it again, and the most recent ones are kept after
+// they finish so a client that checks late still finds out how it went.
+type pollJobs struct {
+	mu     sync.Mutex
+	jobs   map[string]*types.PollJob
+	order  []string // oldest first
+	active string
+	nextID int
+}
+
+func newPollJobs() *pollJobs {
+	return &pollJobs{jobs: make(map[string]*types.PollJob)}
+}
+
+// create adds a job unless one is already queued or running, the id of whichever it is is returned
+// along with whether it was created
+func (p *pollJobs) create(now time.Time) (id string, created bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.active != "" {
+		return p.active, false
+	}
+
+	p.nextID++
+	id = strconv.Itoa(p.nextID)
+	p.jobs[id] = &types.PollJob{ID: id, Created: now}
+	p.order = append(p.order, id)
+	if len(p.order) > pollJobLogSize {
+		delete(p.jobs, p.order[0])
+		p.order = p.order[1:]
+	}
+	p.active = id
+	return id, true
+}
+
+// get returns a copy of a job's progress
+func (p *pollJobs) get(id string) (job types.PollJob, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stored, ok := p.jobs[id]
+	if ok {
+		job = *stored
+	}
+	return
+}
+
+// update changes a job's progress, jobs that don't exist are ignored so the scheduled poll can
+// report its progress in the same way with an empty id
+func (p *pollJobs) update(id string, fn func(job *types.PollJob)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if job, ok := p.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// finish marks a job as finished so another can be triggered
+func (p *pollJobs) finish(id string, now time.Time) {
+	p.update(id, func(job *types.PollJob) { job.Finished = &now })
+
+	p.mu.Lock()
+	if p.active == id {
+		p.active = ""
+	}
+	p.mu.Unlock()
+}
+
+// TriggerPoll starts polling every stored server straight away instead of waiting for the poller's
+// next interval and returns the id of the job to check its progress with. The poll waits for a
+// scheduled one that's already running to finish, and the scheduled poll is skipped while a manual
+// one runs, so servers are never polled twice at once. If a manual poll is already queued or
+// running its id is returned and no other is started.
+func (app *App) TriggerPoll() (jobID string) {
+	jobID, created := app.pollJobs.create(time.Now())
+	if !created {
+		return
+	}
+
+	go func() {
+		defer func() { app.pollJobs.finish(jobID, time.Now()) }()
+
+		select {
+		case app.polling <- struct{}{}:
+		case <-app.ctx.Done():
+			return
+		}
+		defer func() { <-app.polling }()
+
+		app.logger.Info("starting manual poll", zap.String("job", jobID))
+		app.pollCycle(app.ctx, jobID)
+	}()
+	return
+}
+
+// PollTrigger handles triggering a manual poll, the response is the new job along with its location
+func (app *App) PollTrigger(w http.ResponseWriter, r *http.Request) {
+	id := app.TriggerPoll()
+	job, _ := app.pollJobs.get(id)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/admin/poll/"+id)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job) // nolint:errcheck
+}
+
+// PollStatus handles checking the progress of a manual poll
+func (app *App) PollStatus(w http.ResponseWriter, r *http.Request) {
+	job, ok := app.pollJobs.get(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, "no poll with that id, only the most recent polls are kept", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job) // nolint:errcheck
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestPollJobs(t *testing.T) {
+	jobs := newPollJobs()
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	id, created := jobs.create(now)
+	assert.True(t, created)
+
+	// another can't be created while the first hasn't finished
+	again, created := jobs.create(now)
+	assert.False(t, created)
+	assert.Equal(t, id, again)
+
+	jobs.update(id, func(job *types.PollJob) { job.Total = 3 })
+	jobs.update("", func(job *types.PollJob) { t.Error("updated a job that doesn't exist") })
+	jobs.finish(id, now)
+
+	job, ok := jobs.get(id)
+	require.True(t, ok)
+	assert.Equal(t, types.PollJob{ID: id, Created: now, Finished: &now, Total: 3}, job)
+
+	next, created := jobs.create(now)
+	assert.True(t, created)
+	assert.NotEqual(t, id, next)
+	jobs.finish(next, now)
+
+	// only the most recent jobs are kept
+	for i := 0; i < pollJobLogSize; i++ {
+		id, _ := jobs.create(now)
+		jobs.finish(id, now)
+	}
+	_, ok = jobs.get(next)
+	assert.False(t, ok)
+}
+
+// waitForPoll waits for a manual poll to finish and returns its final progress
+func waitForPoll(t *testing.T, app *App, id string) types.PollJob {
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		job, ok := app.pollJobs.get(id)
+		require.True(t, ok)
+		if job.Finished != nil {
+			return job
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+	t.Fatalf("poll %s did not finish", id)
+	return types.PollJob{}
+}
+
+func TestApp_TriggerPoll(t *testing.T) {
+	db := storage.NewMemoryStore()
+	app := NewApp(db, zap.NewNop(), WithConfig(types.Config{QueryTimeout: time.Millisecond * 50, QueryRetries: 1}))
+	defer app.cancel()
+
+	online, stop := answerInfo(t, true)
+	defer stop()
+	silent, stop := answerInfo(t, false)
+	defer stop()
+	for _, address := range []string{online, silent} {
+		require.NoError(t, db.UpsertServer(types.Server{Core: types.ServerCore{Address: address, Hostname: "old"}}))
+	}
+
+	// the manual poll waits for the scheduled one that's holding the lock
+	app.polling <- struct{}{}
+	id := app.TriggerPoll()
+	time.Sleep(time.Millisecond * 20)
+	job, ok := app.pollJobs.get(id)
+	require.True(t, ok)
+	assert.Nil(t, job.Started)
+	assert.Equal(t, id, app.TriggerPoll())
+	<-app.polling
+
+	job = waitForPoll(t, app, id)
+	assert.NotNil(t, job.Started)
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, 2, job.Queried)
+	assert.Equal(t, 1, job.Failed)
+
+	server, err := db.GetServer(online)
+	require.NoError(t, err)
+	assert.Equal(t, "Scavenge and Survive", server.Core.Hostname)
+
+	// the lock is released once it's finished
+	select {
+	case app.polling <- struct{}{}:
+		<-app.polling
+	default:
+		t.Error("manual poll still holds the lock")
+	}
+	assert.NotEqual(t, id, app.TriggerPoll())
+}
+
+func TestApp_PollHandlers(t *testing.T) {
+	app := NewApp(storage.NewMemoryStore(), zap.NewNop())
+	defer app.cancel()
+
+	w := httptest.NewRecorder()
+	app.PollTrigger(w, httptest.NewRequest("POST", "/admin/poll", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var job types.PollJob
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, "/admin/poll/"+job.ID, w.Header().Get("Location"))
+
+	waitForPoll(t, app, job.ID)
+
+	status := func(id string) *httptest.ResponseRecorder {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/admin/poll/"+id, nil), map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		app.PollStatus(w, r)
+		return w
+	}
+	w = status(job.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+	assert.NotNil(t, job.Finished)
+	assert.Zero(t, job.Total)
+
+	id, _ := strconv.Atoi(job.ID)
+	assert.Equal(t, http.StatusNotFound, status(strconv.Itoa(id+1)).Code)
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// queryCache holds the results of recent live queries so that a burst of requests for the same
+// server results in a single query. Concurrent requests for an address that isn't cached wait for
+// the one query that's already in flight instead of each sending their own.
+type queryCache struct {
+	ttl   time.Duration
+	query func(address string) (types.Server, error)
+
+	group   singleflight.Group
+	mu      sync.Mutex
+	entries map[string]cachedQuery
+}
+
+type cachedQuery struct {
+	server  types.Server
+	err     error
+	expires time.Time
+}
+
+func newQueryCache(ttl time.Duration, query func(address string) (types.Server, error)) *queryCache {
+	return &queryCache{
+		ttl:     ttl,
+		query:   query,
+		entries: make(map[string]cachedQuery),
+	}
+}
+
+// get returns the cached result for an address if it's still fresh, otherwise it queries the server.
+// The query is shared with every other caller waiting on the same address so it isn't cancelled
+// with ctx, but get stops waiting for it and returns the context's error.
+func (c *queryCache) get(ctx context.Context, address string, now time.Time) (server types.Server, err error) {
+	c.mu.Lock()
+	entry, ok := c.entries[address]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return copyQueried(entry.server), entry.err
+	}
+
+	results := c.group.DoChan(address, func() (interface{}, error) {
+		server, err := c.query(address)
+		c.store(address, server, err, now)
+		return server, err
+	})
+	select {
+	case result := <-results:
+		server, _ = result.Val.(types.Server)
+		return copyQueried(server), result.Err
+	case <-ctx.Done():
+		return types.Server{}, ctx.Err()
+	}
+}
+
+// store caches a query result, failed queries are not cached so the next request tries again but a
+// partial response is since the server did respond. Expired entries are dropped at the same time.
+func (c *queryCache) store(address string, server types.Server, err error, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for cached, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, cached)
+		}
+	}
+
+	if err != nil && !query.IsPartial(err) {
+		return
+	}
+	c.entries[address] = cachedQuery{server: server, err: err, expires: now.Add(c.ttl)}
+}
+
+// copyQueried copies the rules and player list of a queried server so that callers sharing a cached
+// result can't modify each other's copy
+func copyQueried(server types.Server) types.Server {
+	if server.Rules != nil {
+		rules := make(map[string]string, len(server.Rules))
+		for k, v := range server.Rules {
+			rules[k] = v
+		}
+		server.Rules = rules
+	}
+	if server.PlayerList != nil {
+		server.PlayerList = append([]string{}, server.PlayerList...)
+	}
+	return server
+}
+
+// cachedQuery queries the server at an address, or returns the result of a query made within the
+// last LiveCacheTTL. Each query is given LiveTimeout to complete regardless of how many requests are
+// waiting for it, a request that gives up sooner stops waiting without cancelling it for the rest.
+func (app *App) cachedQuery(ctx context.Context, address string) (types.Server, error) {
+	return app.queries.get(ctx, address, time.Now())
+}
+
+// liveQuery sends a full query to a server on behalf of the live query cache, the queries are all
+// sent at once since someone is waiting on the result
+func (app *App) liveQuery(address string) (types.Server, error) {
+	ctx, cancel := context.WithTimeout(app.ctx, app.config.LiveTimeout)
+	defer cancel()
+
+	opts := app.queryOptionsFor(address)
+	opts.Handshake = true // the result is stored, so it has to have come from the server itself
+	server, err := query.QueryAll(ctx, address, opts)
+	server.QueryPort = opts.QueryPort
+	app.recordParseFailure(address, err)
+	return server, err
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestQueryCache(t *testing.T) {
+	var calls int32
+	result := types.Server{Core: types.ServerCore{Address: "127.0.0.1:7777", Players: 10}, Rules: map[string]string{"weather": "10"}}
+	var err error
+	cache := newQueryCache(time.Second*10, func(address string) (types.Server, error) {
+		atomic.AddInt32(&calls, 1)
+		return result, err
+	})
+
+	start := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		after     time.Duration
+		err       error
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"first", 0, nil, 1, false},
+		{"cached", time.Second * 5, nil, 1, false},
+		{"expired and failed", time.Second * 10, errors.New("timeout"), 2, true},
+		{"failure not cached", time.Second * 11, query.PartialError{Opcode: query.Rules, Err: errors.New("truncated")}, 3, true},
+		{"partial cached", time.Second * 12, nil, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err = tt.err
+			got, gotErr := cache.get(context.Background(), "127.0.0.1:7777", start.Add(tt.after))
+			assert.Equal(t, tt.wantCalls, atomic.LoadInt32(&calls))
+			assert.Equal(t, tt.wantErr, gotErr != nil)
+			assert.Equal(t, 10, got.Core.Players)
+
+			// copies are handed out so callers can't change the cached result
+			got.Rules["weather"] = "20"
+		})
+	}
+	assert.Equal(t, "10", result.Rules["weather"])
+}
+
+func TestQueryCache_Concurrent(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	cache := newQueryCache(time.Second*10, func(address string) (types.Server, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return types.Server{Core: types.ServerCore{Address: address}}, nil
+	})
+
+	now := time.Now()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := cache.get(context.Background(), "127.0.0.1:7777", now)
+			assert.NoError(t, err)
+			assert.Equal(t, "127.0.0.1:7777", got.Core.Address)
+		}()
+	}
+	time.Sleep(time.Millisecond * 50) // let every request reach the in-flight query
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestQueryCache_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	cache := newQueryCache(time.Second*10, func(address string) (types.Server, error) {
+		<-release
+		return types.Server{Core: types.ServerCore{Address: address}}, nil
+	})
+	defer close(release)
+
+	// a request that gives up stops waiting without cancelling the query for everyone else
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, err := cache.get(ctx, "127.0.0.1:7777", time.Now())
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := cache.get(context.Background(), "127.0.0.1:7777", time.Now())
+		waiting <- err
+	}()
+	release <- struct{}{}
+	assert.NoError(t, <-waiting)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Southclaws/samp-servers-api/server/realip"
+)
+
+// bucket is a token bucket for a single client, tokens are refilled continuously at the limiter's
+// rate up to the burst size and every request consumes one token.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	rps   float64
+	burst int
+	match func(*http.Request) bool
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastEvict time.Time
+}
+
+// RateLimit returns a middleware that limits POST requests from each client IP to rps requests per
+// second, with bursts of up to burst requests. Requests over the limit are rejected with a 429 and
+// a Retry-After header. Other methods are passed straight through since only POST requests modify
+// the index. Clients are told apart by realip.ClientIP so the limit applies to the real client
+// rather than a reverse proxy in front of the API.
+func RateLimit(rps float64, burst int) func(http.Handler) http.Handler {
+	rl := newRateLimiter(rps, burst)
+	rl.match = postOnly
+	return rl.middleware
+}
+
+// RateLimitAll is the same as RateLimit except every request is limited regardless of its method,
+// it's intended for wrapping individual routes that are expensive to serve.
+func RateLimitAll(rps float64, burst int) func(http.Handler) http.Handler {
+	return newRateLimiter(rps, burst).middleware
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rps:     rps,
+		burst:   burst,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func postOnly(r *http.Request) bool {
+	return r.Method == http.MethodPost
+}
+
+func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rl.match != nil && !rl.match(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		wait, ok := rl.take(realip.ClientIP(r))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// take consumes a token from the client's bucket, if the bucket is empty it returns false along
+// with how long the client must wait until a token is available.
+func (rl *rateLimiter) take(client string) (wait time.Duration, ok bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	if now.Sub(rl.lastEvict) > time.Minute {
+		rl.evict(now)
+		rl.lastEvict = now
+	}
+
+	b, exists := rl.buckets[client]
+	if !exists {
+		b = &bucket{tokens: float64(rl.burst), last: now}
+		rl.buckets[client] = b
+	}
+
+	b.tokens = math.Min(float64(rl.burst), b.tokens+now.Sub(b.last).Seconds()*rl.rps)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rl.rps * float64(time.Second)), false
+	}
+
+	b.tokens--
+	return 0, true
+}
+
+// evict removes buckets that would have refilled completely by now, since they are equivalent to
+// a new bucket and would otherwise accumulate forever.
+func (rl *rateLimiter) evict(now time.Time) {
+	full := time.Duration(float64(rl.burst) / rl.rps * float64(time.Second))
+	for client, b := range rl.buckets {
+		if now.Sub(b.last) > full {
+			delete(rl.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	rl := newRateLimiter(0.5, 2)
+	rl.match = postOnly
+	rl.now = func() time.Time { return now }
+	handler := rl.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(method, remote string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/v2/server", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do("POST", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, do("POST", "10.0.0.1:1234").Code)
+
+	w := do("POST", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	// other clients and other methods are unaffected
+	assert.Equal(t, http.StatusOK, do("POST", "10.0.0.2:1234").Code)
+	assert.Equal(t, http.StatusOK, do("GET", "10.0.0.1:1234").Code)
+
+	now = now.Add(time.Second * 2)
+	assert.Equal(t, http.StatusOK, do("POST", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("POST", "10.0.0.1:1234").Code)
+}
+
+func TestRateLimitAll(t *testing.T) {
+	handler := RateLimitAll(0.5, 1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(method string) int {
+		r := httptest.NewRequest(method, "/v2/server/127.0.0.1:7777/live", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do("GET"))
+	assert.Equal(t, http.StatusTooManyRequests, do("GET"))
+	assert.Equal(t, http.StatusTooManyRequests, do("POST"))
+}
@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Southclaws/samp-servers-api/query"
+)
+
+// RCON runs a console command on the server at an address and returns its output, one line per
+// response from the server. The command is given LiveTimeout to complete. The password is passed
+// straight through to the server and must never be logged.
+func (app *App) RCON(ctx context.Context, address, password, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, app.config.LiveTimeout)
+	defer cancel()
+
+	output, err := query.RCON(ctx, address, password, command, app.queryOptionsFor(address))
+	if err != nil {
+		app.recordParseFailure(address, err)
+		return "", err
+	}
+	return strings.Join(output, "\n"), nil
+}
@@ -0,0 +1,118 @@
+// Package realip works out the IP address of the client that made a request when the API may be
+// running behind reverse proxies, so that rate limiting, logging and host verification all see the
+// same address.
+package realip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type contextKey struct{}
+
+// Proxies is the set of reverse proxies whose forwarding headers are trusted. A request from any
+// other peer is attributed to the peer itself, whatever headers it sent, since they're trivial to
+// forge.
+type Proxies struct {
+	all  bool
+	nets []*net.IPNet
+}
+
+// ParseProxies builds the set of trusted proxies from a list of IP addresses and CIDR ranges. When
+// all is set every peer is trusted as a proxy, which is only safe when nothing can reach the API
+// without going through one.
+func ParseProxies(all bool, proxies []string) (p *Proxies, err error) {
+	p = &Proxies{all: all}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, errors.Errorf("trusted proxy '%s' is not an IP address or CIDR range", proxy)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			proxy += "/" + strconv.Itoa(bits)
+		}
+		_, ipnet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "trusted proxy '%s' is not an IP address or CIDR range", proxy)
+		}
+		p.nets = append(p.nets, ipnet)
+	}
+	return
+}
+
+// Middleware records the client IP of each request so that ClientIP returns it further down the
+// chain, it should wrap everything that needs the client IP.
+func (p *Proxies) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := p.Resolve(r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, ip)))
+	})
+}
+
+// Resolve works out the client IP of a request. If the peer is a trusted proxy, the X-Forwarded-For
+// header is read from the end, skipping the addresses of trusted proxies, and the first address
+// that's left is the client since it was appended by a proxy. Addresses before that were supplied
+// by the client and can't be trusted. When every peer is trusted, only the last address is used
+// since there's no telling the proxies apart from the client. X-Real-IP is used if a trusted proxy
+// didn't set X-Forwarded-For, otherwise the peer address is the client.
+func (p *Proxies) Resolve(r *http.Request) string {
+	peer := remoteHost(r)
+	if p == nil || !(p.all || p.trusted(peer)) {
+		return peer
+	}
+
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if i == 0 || p.all || !p.trusted(hop) {
+				return hop
+			}
+		}
+	}
+	if real := strings.TrimSpace(r.Header.Get("X-Real-IP")); real != "" {
+		return real
+	}
+	return peer
+}
+
+// trusted reports whether an address is in one of the trusted proxy ranges
+func (p *Proxies) trusted(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, ipnet := range p.nets {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP address of the client that made a request, as worked out by Middleware.
+// If the request didn't pass through Middleware, the peer address is used.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(contextKey{}).(string); ok {
+		return ip
+	}
+	return remoteHost(r)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,81 @@
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxies_Resolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		all       bool
+		proxies   []string
+		remote    string
+		forwarded string
+		real      string
+		want      string
+	}{
+		{"remote", false, nil, "10.0.0.1:1234", "", "", "10.0.0.1"},
+		{"forwarded untrusted", false, nil, "10.0.0.1:1234", "192.168.1.2", "", "10.0.0.1"},
+		{"forwarded trust all", true, nil, "10.0.0.1:1234", "192.168.1.2", "", "192.168.1.2"},
+		{"forwarded trust all chain", true, nil, "10.0.0.1:1234", "1.2.3.4, 192.168.1.2", "", "192.168.1.2"},
+		{"no header trust all", true, nil, "10.0.0.1:1234", "", "", "10.0.0.1"},
+		{"trusted proxy", false, []string{"10.0.0.1"}, "10.0.0.1:1234", "1.2.3.4, 5.6.7.8", "", "5.6.7.8"},
+		{"untrusted peer", false, []string{"10.0.0.1"}, "10.0.0.2:1234", "1.2.3.4", "", "10.0.0.2"},
+		{"proxy chain", false, []string{"10.0.0.0/8"}, "10.0.0.1:1234", "1.2.3.4, 5.6.7.8, 10.1.2.3", "", "5.6.7.8"},
+		{"all proxies", false, []string{"10.0.0.0/8"}, "10.0.0.1:1234", "10.1.1.1, 10.2.2.2", "", "10.1.1.1"},
+		{"real ip", false, []string{"10.0.0.1"}, "10.0.0.1:1234", "", "1.2.3.4", "1.2.3.4"},
+		{"real ip untrusted", false, []string{"10.0.0.1"}, "10.0.0.2:1234", "", "1.2.3.4", "10.0.0.2"},
+		{"ipv6", false, []string{"::1"}, "[::1]:1234", "2001:db8::1", "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseProxies(tt.all, tt.proxies)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest("POST", "/v2/server", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.real != "" {
+				r.Header.Set("X-Real-IP", tt.real)
+			}
+			assert.Equal(t, tt.want, p.Resolve(r))
+		})
+	}
+}
+
+func TestParseProxies(t *testing.T) {
+	_, err := ParseProxies(false, []string{"10.0.0.1", "192.168.0.0/16", "::1", " 172.16.0.1 "})
+	assert.NoError(t, err)
+
+	_, err = ParseProxies(false, []string{"proxy.example.com"})
+	assert.EqualError(t, err, "trusted proxy 'proxy.example.com' is not an IP address or CIDR range")
+
+	_, err = ParseProxies(false, []string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestClientIP(t *testing.T) {
+	p, err := ParseProxies(false, []string{"10.0.0.1"})
+	require.NoError(t, err)
+
+	var got string
+	handler := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ClientIP(r)
+	}))
+
+	r := httptest.NewRequest("GET", "/v2/servers", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "1.2.3.4", got)
+
+	// without the middleware the peer is all there is to go on
+	assert.Equal(t, "10.0.0.1", ClientIP(r))
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reapInterval is how often dead servers and expired idempotency keys are checked for removal
+const reapInterval = time.Hour
+
+// StartReaper periodically deletes servers that have been dead for longer than DeadGracePeriod, a
+// server that comes back within the grace period is revived with its history and peaks intact.
+// Stored responses to idempotent requests are deleted once they're older than IdempotencyTTL at the
+// same time. StartReaper blocks until the context is cancelled.
+func (app *App) StartReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		app.reapDead(now)
+		app.reapIdempotencyKeys(now)
+	}
+}
+
+func (app *App) reapDead(now time.Time) {
+	removed, err := app.db.RemoveDead(now.Add(-app.config.DeadGracePeriod))
+	if err != nil {
+		app.logger.Error("failed to remove dead servers",
+			zap.Error(err))
+		return
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	for _, address := range removed {
+		if app.qd != nil {
+			app.qd.Forget(address)
+		}
+		app.logger.Debug("removed dead server",
+			zap.String("address", address))
+	}
+
+	app.updateIndexMetrics()
+}
+
+func (app *App) reapIdempotencyKeys(now time.Time) {
+	err := app.db.RemoveIdempotentResponses(now.Add(-app.config.IdempotencyTTL))
+	if err != nil {
+		app.logger.Error("failed to remove expired idempotency keys",
+			zap.Error(err))
+	}
+}
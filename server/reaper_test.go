@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestApp_reapDead(t *testing.T) {
+	db := storage.NewMemoryStore()
+	for _, address := range []string{"alive.example.com:7777", "down.example.com:7777", "gone.example.com:7777"} {
+		require.NoError(t, db.UpsertServer(types.Server{Core: types.ServerCore{Address: address}}))
+	}
+	app := NewApp(db, zap.NewNop(), WithConfig(types.Config{DeadGracePeriod: time.Hour * 24 * 7}))
+
+	now := time.Date(2018, 1, 10, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, db.MarkDead("down.example.com:7777", now.Add(-time.Hour*24)))
+	require.NoError(t, db.MarkDead("gone.example.com:7777", now.Add(-time.Hour*24*8)))
+
+	app.reapDead(now)
+
+	addresses, err := db.LoadAllAddresses()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alive.example.com:7777", "down.example.com:7777"}, addresses)
+}
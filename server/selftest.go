@@ -0,0 +1,24 @@
+package server
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// SelfTest sends an info query to a server that's known to be up to check queries get out and back
+// at all. A firewall that drops outbound UDP otherwise only shows up as every server being offline.
+// A partial response still passes since the server did answer.
+func (app *App) SelfTest(address string) error {
+	address, err := types.NormalizeAddress(address)
+	if err != nil {
+		return errors.Wrap(err, "invalid self-test address")
+	}
+
+	_, err = query.QueryInfo(app.ctx, address, app.queryOptions())
+	if err != nil && errors.Cause(err) != query.ErrPartialInfo {
+		return errors.Wrapf(err, "failed to query '%s'", address)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// answerInfo answers info queries on a local port with a minimal response, after an echo of the
+// request header like a real server. Nothing is sent if respond is false. The port is picked from
+// outside the ephemeral range since addresses with ephemeral ports aren't valid server addresses.
+func answerInfo(t *testing.T, respond bool) (address string, stop func()) {
+	var (
+		conn *net.UDPConn
+		err  error
+	)
+	for attempt := 0; attempt < 10; attempt++ {
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 20000 + rand.Intn(20000)})
+		if err == nil {
+			break
+		}
+	}
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if !respond {
+				continue
+			}
+			response := append([]byte{}, buf[:n]...)
+			response = append(response, 0, 4, 0, 32, 0) // password, players and max players
+			for _, s := range []string{"Scavenge and Survive", "Survival", "English"} {
+				length := make([]byte, 4)
+				binary.LittleEndian.PutUint32(length, uint32(len(s)))
+				response = append(append(response, length...), s...)
+			}
+			conn.WriteToUDP(response, from) // nolint:errcheck
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() } // nolint:errcheck
+}
+
+func TestApp_SelfTest(t *testing.T) {
+	app := NewApp(storage.NewMemoryStore(), zap.NewNop(), WithConfig(types.Config{QueryTimeout: time.Millisecond * 200}))
+
+	address, stop := answerInfo(t, true)
+	defer stop()
+	assert.NoError(t, app.SelfTest(address))
+
+	silent, stop := answerInfo(t, false)
+	defer stop()
+	assert.Error(t, app.SelfTest(silent))
+
+	assert.Error(t, app.SelfTest("http://example.com"))
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// Timeout returns a middleware that gives handlers d to respond, after which the client gets a 503
+// and the request's context is cancelled so any queries the handler is waiting on stop too. Since
+// the response is buffered until the handler returns, it can't wrap handlers that stream their
+// response. Each route can have its own timeout, see types.Route.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, "request timed out")
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	cancelled := make(chan error, 1)
+	handler := Timeout(time.Millisecond * 50)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fast" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`)) // nolint:errcheck
+			return
+		}
+		<-r.Context().Done()
+		cancelled <- r.Context().Err()
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{}`, w.Body.String())
+
+	start := time.Now()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.True(t, time.Since(start) < time.Second)
+
+	// the handler's context is cancelled so whatever it's waiting on stops too
+	select {
+	case err := <-cancelled:
+		assert.Equal(t, context.DeadlineExceeded, err)
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+}
@@ -0,0 +1,15 @@
+package server
+
+import (
+	"time"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// Trending returns the servers whose player count has grown the most over the window, as a
+// percentage of their player count at the start of it. Servers that started with fewer than
+// TrendingMinPlayers aren't ranked, see storage.Trending.
+func (app *App) Trending(window time.Duration) ([]types.TrendingServer, error) {
+	return storage.Trending(app.db, window, app.config.TrendingMinPlayers, time.Now())
+}
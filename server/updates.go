@@ -0,0 +1,101 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// subscriberBuffer is how many updates can be waiting to be sent to a single subscriber, once it's
+// full any further updates are dropped until the subscriber catches up
+const subscriberBuffer = 64
+
+// subscriber receives updates for either a single address or every server if the address is empty
+type subscriber struct {
+	updates chan types.ServerUpdate
+
+	mu      sync.Mutex
+	address string
+}
+
+func (s *subscriber) subscribe(address string) {
+	s.mu.Lock()
+	s.address = address
+	s.mu.Unlock()
+}
+
+func (s *subscriber) wants(address string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.address == "" || s.address == address
+}
+
+// updateHub fans out server updates to live subscribers. It also remembers whether each server was
+// last seen online so updates can be flagged as online or offline transitions. Servers that haven't
+// been seen since the process started are treated as offline by subscribers but aren't reported as
+// transitions, otherwise every server would appear to come online after a restart.
+type updateHub struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	online      map[string]bool
+}
+
+func newUpdateHub() *updateHub {
+	return &updateHub{
+		subscribers: make(map[*subscriber]struct{}),
+		online:      make(map[string]bool),
+	}
+}
+
+func (h *updateHub) subscribe() *subscriber {
+	s := &subscriber{updates: make(chan types.ServerUpdate, subscriberBuffer)}
+	h.mu.Lock()
+	h.subscribers[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *updateHub) unsubscribe(s *subscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, s)
+	h.mu.Unlock()
+}
+
+// publish sends an update to every interested subscriber without blocking, subscribers that aren't
+// keeping up miss the update rather than holding up the poller. Servers that go offline without
+// having been seen online aren't published since nobody could have been told they were online.
+// transition is true if the server was known to be in the opposite state beforehand.
+func (h *updateHub) publish(address string, players int, online bool) (update types.ServerUpdate, transition bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	was, known := h.online[address]
+	h.online[address] = online
+	transition = known && was != online
+
+	update = types.ServerUpdate{
+		Event:   types.EventUpdate,
+		Address: address,
+		Players: players,
+		Online:  online,
+	}
+	switch {
+	case online && !was:
+		update.Event = types.EventOnline
+	case !online && was:
+		update.Event = types.EventOffline
+	case !online:
+		return update, transition
+	}
+
+	for s := range h.subscribers {
+		if !s.wants(address) {
+			continue
+		}
+		select {
+		case s.updates <- update:
+		default:
+		}
+	}
+	return update, transition
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// drain returns every update waiting to be received by a subscriber
+func drain(s *subscriber) (updates []types.ServerUpdate) {
+	for {
+		select {
+		case update := <-s.updates:
+			updates = append(updates, update)
+		default:
+			return
+		}
+	}
+}
+
+func TestUpdateHub_Transitions(t *testing.T) {
+	h := newUpdateHub()
+	s := h.subscribe()
+
+	h.publish("s1.example.com:7777", 0, false)
+	h.publish("s1.example.com:7777", 4, true)
+	h.publish("s1.example.com:7777", 5, true)
+	h.publish("s1.example.com:7777", 0, false)
+	h.publish("s1.example.com:7777", 0, false)
+
+	assert.Equal(t, []types.ServerUpdate{
+		{Event: types.EventOnline, Address: "s1.example.com:7777", Players: 4, Online: true},
+		{Event: types.EventUpdate, Address: "s1.example.com:7777", Players: 5, Online: true},
+		{Event: types.EventOffline, Address: "s1.example.com:7777", Players: 0, Online: false},
+	}, drain(s))
+}
+
+func TestUpdateHub_Transition(t *testing.T) {
+	h := newUpdateHub()
+
+	tests := []struct {
+		name           string
+		online         bool
+		wantTransition bool
+	}{
+		{"first seen", true, false},
+		{"still online", true, false},
+		{"went offline", false, true},
+		{"still offline", false, false},
+		{"came back", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gotTransition := h.publish("s1.example.com:7777", 0, tt.online)
+			assert.Equal(t, tt.wantTransition, gotTransition)
+		})
+	}
+}
+
+func TestUpdateHub_Subscribe(t *testing.T) {
+	h := newUpdateHub()
+	all := h.subscribe()
+	one := h.subscribe()
+	one.subscribe("s2.example.com:7777")
+	gone := h.subscribe()
+	h.unsubscribe(gone)
+
+	h.publish("s1.example.com:7777", 1, true)
+	h.publish("s2.example.com:7777", 2, true)
+
+	assert.Len(t, drain(all), 2)
+	assert.Equal(t, []types.ServerUpdate{
+		{Event: types.EventOnline, Address: "s2.example.com:7777", Players: 2, Online: true},
+	}, drain(one))
+	assert.Empty(t, drain(gone))
+}
+
+func TestUpdateHub_SlowSubscriber(t *testing.T) {
+	h := newUpdateHub()
+	s := h.subscribe()
+
+	for i := 0; i < subscriberBuffer*2; i++ {
+		h.publish("s1.example.com:7777", i, true)
+	}
+
+	updates := drain(s)
+	assert.Len(t, updates, subscriberBuffer)
+	assert.Equal(t, subscriberBuffer-1, updates[len(updates)-1].Players)
+}
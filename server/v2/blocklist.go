@@ -0,0 +1,73 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// blocklistAdd blocks a server address, IP or range and removes the stored servers it matches
+func (v *V2) blocklistAdd(w http.ResponseWriter, r *http.Request) {
+	var block types.Block
+	status, err := v.decodeBody(w, r, &block)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	errs := block.Validate()
+	if errs != nil {
+		WriteErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+	block.Address, _ = types.NormalizeBlock(block.Address)
+	block.Created = time.Now()
+
+	err = v.Storage.AddBlock(block)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to store block"))
+		return
+	}
+
+	removed, err := storage.RemoveBlocked(v.Storage, block)
+	for _, address := range removed {
+		v.Scraper.Forget(address)
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to remove blocked servers"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, types.BlockResult{Block: block, Removed: len(removed)})
+}
+
+// blocklistRemove removes the block of a server address, IP or range
+func (v *V2) blocklistRemove(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, err := types.NormalizeBlock(address)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = v.Storage.RemoveBlock(address)
+	if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.Errorf("could not find block of '%s'", address))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestBlocklist(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	for _, address := range []string{"203.0.113.1:7777", "203.0.113.2:7777", "198.51.100.1:7777"} {
+		require.NoError(t, store.UpsertServer(types.Server{Core: types.ServerCore{Address: address, Hostname: "server"}}))
+	}
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, jsonRequest(method, url, strings.NewReader(body)))
+		return w
+	}
+
+	w := do("POST", "/blocklist", `{"address": "203.0.113.9/24", "reason": "fake players"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result types.BlockResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.Equal(t, "203.0.113.0/24", result.Address)
+	assert.Equal(t, "fake players", result.Reason)
+	assert.Equal(t, 2, result.Removed)
+
+	addresses, err := store.LoadAllAddresses()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"198.51.100.1:7777"}, addresses)
+
+	// every way of adding a blocked server is rejected
+	assert.Equal(t, http.StatusForbidden, do("POST", "/server/203.0.113.5:7777", "").Code)
+	assert.Equal(t, http.StatusForbidden, do("GET", "/server/203.0.113.5:7777/live", "").Code)
+	posted := types.Server{}.Example()
+	posted.Core.Address = "203.0.113.5:7777"
+	body, err := json.Marshal(posted)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/server", string(body)).Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/validate", string(body)).Code)
+	assert.Equal(t, http.StatusOK, do("POST", "/server/198.51.100.2:7777", "").Code)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do("POST", "/blocklist", `{"address": "not an address:port"}`).Code)
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/blocklist/203.0.113.0/24", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/blocklist/203.0.113.0/24", "").Code)
+	assert.Equal(t, http.StatusOK, do("POST", "/server/203.0.113.5:7777", "").Code)
+}
@@ -0,0 +1,66 @@
+package v2
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxBodySize is used when MaxBodySize is not configured
+const DefaultMaxBodySize = 64 << 10
+
+// decodeBody decodes a JSON request body into v, reading no more than MaxBodySize bytes so a client
+// can't exhaust memory by streaming an endless body. Fields that v doesn't have are rejected so
+// mistyped keys don't go unnoticed, unless the lenient parameter is true for the sake of older
+// clients. Bodies have to be declared as JSON, requests without a body aren't checked so endpoints
+// where the body is optional still work without one. The status to respond with is returned along
+// with any error, 415 if the body wasn't JSON, 413 if it was too large and 400 if it was malformed.
+func (v *V2) decodeBody(w http.ResponseWriter, r *http.Request, into interface{}) (status int, err error) {
+	if r.ContentLength != 0 {
+		err = v.checkContentType(r)
+		if err != nil {
+			return http.StatusUnsupportedMediaType, err
+		}
+	}
+
+	limit := v.Config.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	if r.URL.Query().Get("lenient") != "true" {
+		decoder.DisallowUnknownFields()
+	}
+
+	err = decoder.Decode(into)
+	if err != nil {
+		// MaxBytesReader doesn't have a distinct error type to check for
+		if err.Error() == "http: request body too large" {
+			return http.StatusRequestEntityTooLarge, errors.Errorf("request body exceeds %d bytes", limit)
+		}
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
+
+// checkContentType ensures a request body is declared as application/json, parameters such as the
+// charset are ignored. Some older scanners don't send a Content-Type at all so that can be allowed
+// with AllowNoContentType.
+func (v *V2) checkContentType(r *http.Request) (err error) {
+	header := r.Header.Get("Content-Type")
+	if header == "" {
+		if v.Config.AllowNoContentType {
+			return nil
+		}
+		return errors.New("missing Content-Type, bodies must be application/json")
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil || mediaType != "application/json" {
+		return errors.Errorf("unsupported Content-Type '%s', bodies must be application/json", header)
+	}
+	return nil
+}
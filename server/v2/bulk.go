@@ -0,0 +1,71 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverBulk handles posting an array of server objects, each server is checked and stored in the
+// same way as a single post but a bad server only rejects itself rather than the whole batch.
+func (v *V2) serverBulk(w http.ResponseWriter, r *http.Request) {
+	var servers []types.Server
+	status, err := v.decodeBody(w, r, &servers)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	result := types.BulkResult{Rejected: []types.BulkRejection{}}
+	for i, errs := range v.BulkUpsert(r, servers) {
+		if errs == nil {
+			result.Accepted++
+			continue
+		}
+		rejection := types.BulkRejection{Index: i, Fields: types.ValidationErrors(errs)}
+		for _, err := range errs {
+			rejection.Errors = append(rejection.Errors, err.Error())
+		}
+		result.Rejected = append(result.Rejected, rejection)
+	}
+
+	status = http.StatusOK
+	if len(result.Rejected) > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to encode response"))
+		return
+	}
+}
+
+// BulkUpsert checks and stores each server, returning the errors for each server at the same index
+// as the server. Servers that were stored successfully have a nil entry.
+func (v *V2) BulkUpsert(r *http.Request, servers []types.Server) (results [][]error) {
+	results = make([][]error, len(servers))
+	for i := range servers {
+		server := servers[i]
+
+		_, errs := v.prepareServer(r, &server)
+		if errs != nil {
+			results[i] = errs
+			continue
+		}
+
+		err := v.storeServer(&server)
+		if err != nil {
+			results[i] = []error{errors.Wrap(err, "failed to store server")}
+			continue
+		}
+
+		v.Scraper.Add(server.Core.Address)
+	}
+	return
+}
@@ -0,0 +1,85 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverBulkDelete handles deleting many servers at once, either by address or by when they were
+// last seen. Addresses that are invalid or don't match a server are reported without stopping the
+// rest from being deleted.
+func (v *V2) serverBulkDelete(w http.ResponseWriter, r *http.Request) {
+	var request types.BulkDelete
+	status, err := v.decodeBody(w, r, &request)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	since, errs := request.Validate()
+	if errs != nil {
+		WriteErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	var result types.BulkDeleteResult
+	if request.OfflineSince != "" {
+		var removed []string
+		removed, err = v.Storage.RemoveNotSeenSince(since)
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to remove servers"))
+			return
+		}
+		for _, address := range removed {
+			v.Scraper.Forget(address)
+		}
+		result = types.BulkDeleteResult{Removed: len(removed), NotFound: []string{}, Rejected: []types.BulkRejection{}}
+	} else {
+		result = v.BulkDelete(request.Addresses)
+	}
+
+	status = http.StatusOK
+	if len(result.NotFound) > 0 || len(result.Rejected) > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to encode response"))
+		return
+	}
+}
+
+// BulkDelete deletes the server at each address and stops querying it. Invalid addresses are
+// rejected by their position in the list and addresses that don't match a server are listed as not
+// found, a failure to delete one server is reported as a rejection of its address.
+func (v *V2) BulkDelete(addresses []string) (result types.BulkDeleteResult) {
+	result = types.BulkDeleteResult{NotFound: []string{}, Rejected: []types.BulkRejection{}}
+	for i, address := range addresses {
+		address, err := types.NormalizeAddress(address)
+		if err != nil {
+			result.Rejected = append(result.Rejected, types.BulkRejection{Index: i, Errors: []string{err.Error()}})
+			continue
+		}
+
+		err = v.Storage.RemoveServer(address)
+		if err == storage.ErrNotFound {
+			result.NotFound = append(result.NotFound, address)
+			continue
+		} else if err != nil {
+			result.Rejected = append(result.Rejected, types.BulkRejection{Index: i, Errors: []string{errors.Wrap(err, "failed to remove server").Error()}})
+			continue
+		}
+
+		v.Scraper.Forget(address)
+		result.Removed++
+	}
+	return
+}
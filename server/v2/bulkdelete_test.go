@@ -0,0 +1,97 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerBulkDelete(t *testing.T) {
+	old := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		body          string
+		wantStatus    int
+		wantResult    types.BulkDeleteResult
+		wantRemaining []string
+	}{
+		{
+			"addresses",
+			`{"addresses": ["a.example.com:7777", "b.example.com"]}`,
+			http.StatusOK,
+			types.BulkDeleteResult{Removed: 2, NotFound: []string{}, Rejected: []types.BulkRejection{}},
+			[]string{"c.example.com:7777", "d.example.com:7777"},
+		},
+		{
+			"not found and invalid",
+			`{"addresses": ["a.example.com:7777", "x.example.com:7777", "127.0.0.1:99999"]}`,
+			http.StatusMultiStatus,
+			types.BulkDeleteResult{Removed: 1, NotFound: []string{"x.example.com:7777"}, Rejected: []types.BulkRejection{{Index: 2}}},
+			[]string{"b.example.com:7777", "c.example.com:7777", "d.example.com:7777"},
+		},
+		{
+			"offline since date",
+			`{"offlineSince": "2018-01-01"}`,
+			http.StatusOK,
+			types.BulkDeleteResult{Removed: 2, NotFound: []string{}, Rejected: []types.BulkRejection{}},
+			[]string{"c.example.com:7777", "d.example.com:7777"},
+		},
+		{
+			"offline since time",
+			`{"offlineSince": "2017-01-01T00:00:00Z"}`,
+			http.StatusOK,
+			types.BulkDeleteResult{Removed: 0, NotFound: []string{}, Rejected: []types.BulkRejection{}},
+			[]string{"a.example.com:7777", "b.example.com:7777", "c.example.com:7777", "d.example.com:7777"},
+		},
+		{"empty", `{}`, http.StatusUnprocessableEntity, types.BulkDeleteResult{}, nil},
+		{"both", `{"addresses": ["a.example.com:7777"], "offlineSince": "2018-01-01"}`, http.StatusUnprocessableEntity, types.BulkDeleteResult{}, nil},
+		{"bad date", `{"offlineSince": "yesterday"}`, http.StatusUnprocessableEntity, types.BulkDeleteResult{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			router, cancel := newTestRouter(t, store)
+			defer cancel()
+
+			for address, seen := range map[string]*time.Time{
+				"a.example.com:7777": &old,
+				"b.example.com:7777": &old,
+				"c.example.com:7777": &recent,
+				"d.example.com:7777": nil,
+			} {
+				require.NoError(t, store.UpsertServer(types.Server{Core: types.ServerCore{Address: address}, LastSeen: seen}))
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("POST", "/servers/delete", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusUnprocessableEntity {
+				return
+			}
+
+			var got types.BulkDeleteResult
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			for i := range got.Rejected {
+				assert.NotEmpty(t, got.Rejected[i].Errors)
+				got.Rejected[i].Errors = nil
+			}
+			assert.Equal(t, tt.wantResult, got)
+
+			remaining, err := store.LoadAllAddresses()
+			require.NoError(t, err)
+			sort.Strings(remaining)
+			assert.Equal(t, tt.wantRemaining, remaining)
+		})
+	}
+}
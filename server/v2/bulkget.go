@@ -0,0 +1,70 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverBulkGet handles looking up many servers at once, the response maps each address to its
+// server or to null if there isn't one
+func (v *V2) serverBulkGet(w http.ResponseWriter, r *http.Request) {
+	verbose, err := verboseKeys(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var request types.BulkGet
+	status, err := v.decodeBody(w, r, &request)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	errs := request.Validate()
+	if errs != nil {
+		WriteErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	servers, err := storage.GetMany(v.Storage, request.Addresses)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get servers"))
+		return
+	}
+
+	now := time.Now()
+	for _, server := range servers {
+		if server != nil {
+			server.CheckOnline(now, v.Config.OfflineAfter)
+			server.HidePrivate()
+		}
+	}
+
+	var response interface{} = servers
+	if verbose {
+		verboseServers := make(map[string]*types.VerboseServer, len(servers))
+		for address, server := range servers {
+			if server != nil {
+				verboseServer := server.Verbose()
+				verboseServers[address] = &verboseServer
+			} else {
+				verboseServers[address] = nil
+			}
+		}
+		response = verboseServers
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
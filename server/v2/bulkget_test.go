@@ -0,0 +1,74 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerBulkGet(t *testing.T) {
+	store := storage.NewMemoryStore()
+	for _, server := range []types.Server{
+		{Core: types.ServerCore{Address: "1.2.3.4:7777", Hostname: "first"}, Aliases: []string{"ss.southcla.ws:7777"}},
+		{Core: types.ServerCore{Address: "1.2.3.4:7778", Hostname: "second", Password: true}, PlayerList: []string{"Southclaws"}},
+		{Core: types.ServerCore{Address: "1.2.3.5:7777", Hostname: "archived"}},
+	} {
+		require.NoError(t, store.UpsertServer(server))
+	}
+	require.NoError(t, store.ArchiveServer("1.2.3.5:7777"))
+
+	tooMany := make([]string, types.MaxBulkGet+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`"1.2.3.4:%d"`, 7777+i)
+	}
+
+	tests := []struct {
+		name          string
+		body          string
+		wantStatus    int
+		wantHostnames map[string]string
+	}{
+		{"found", `{"addresses": ["1.2.3.4:7777", "1.2.3.4:7778"]}`, http.StatusOK, map[string]string{"1.2.3.4:7777": "first", "1.2.3.4:7778": "second"}},
+		{"not found", `{"addresses": ["1.2.3.4:7777", "1.2.3.4:7779", "1.2.3.5:7777"]}`, http.StatusOK, map[string]string{"1.2.3.4:7777": "first", "1.2.3.4:7779": "", "1.2.3.5:7777": ""}},
+		{"normalised", `{"addresses": ["1.2.3.4"]}`, http.StatusOK, map[string]string{"1.2.3.4": "first"}},
+		{"alias", `{"addresses": ["ss.southcla.ws:7777", "1.2.3.4:7777"]}`, http.StatusOK, map[string]string{"ss.southcla.ws:7777": "first", "1.2.3.4:7777": "first"}},
+		{"invalid address", `{"addresses": ["1.2.3.4:99999"]}`, http.StatusOK, map[string]string{"1.2.3.4:99999": ""}},
+		{"empty", `{"addresses": []}`, http.StatusUnprocessableEntity, nil},
+		{"too many", `{"addresses": [` + strings.Join(tooMany, ",") + `]}`, http.StatusUnprocessableEntity, nil},
+		{"malformed", `{"addresses": `, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, cancel := newTestRouter(t, store)
+			defer cancel()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("POST", "/servers/get", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got map[string]*types.Server
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			gotHostnames := make(map[string]string)
+			for address, server := range got {
+				gotHostnames[address] = ""
+				if server != nil {
+					gotHostnames[address] = server.Core.Hostname
+					assert.Nil(t, server.PlayerList, "player lists of passworded servers are hidden")
+				}
+			}
+			assert.Equal(t, tt.wantHostnames, gotHostnames)
+		})
+	}
+}
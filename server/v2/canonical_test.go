@@ -0,0 +1,35 @@
+package v2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestStoreServer(t *testing.T) {
+	store := storage.NewMemoryStore()
+	v := Init(store, nil, types.Config{}, Deps{Canonicalize: func(address string) string {
+		if address == "ss.southcla.ws:7777" {
+			return "1.2.3.4:7777"
+		}
+		return address
+	}})
+
+	byIP := types.Server{Core: types.ServerCore{Address: "1.2.3.4:7777", Hostname: "by ip"}}
+	assert.NoError(t, v.storeServer(&byIP))
+	byHost := types.Server{Core: types.ServerCore{Address: "ss.southcla.ws:7777", Hostname: "by hostname"}}
+	assert.NoError(t, v.storeServer(&byHost))
+	assert.Equal(t, "1.2.3.4:7777", byHost.Core.Address)
+
+	addresses, err := store.LoadAllAddresses()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.4:7777"}, addresses)
+
+	server, err := store.GetServer("ss.southcla.ws:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, "by hostname", server.Core.Hostname)
+	assert.Equal(t, []string{"ss.southcla.ws:7777"}, server.Aliases)
+}
@@ -0,0 +1,78 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// queryDiscover is swapped out in tests in the same way as queryServer
+var queryDiscover = query.Discover
+
+// serverDiscover probes a range of ports on an IP for servers and responds with the ones it found,
+// which are also added to the index in the same way as serverAdd if the request asks for it.
+func (v *V2) serverDiscover(w http.ResponseWriter, r *http.Request) {
+	var discover types.Discover
+	status, err := v.decodeBody(w, r, &discover)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	errs := discover.Validate()
+	if errs != nil {
+		WriteErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	servers, err := v.Discover(r.Context(), discover.IP, discover.From, discover.To)
+	if err != nil {
+		if errors.Cause(err) == context.DeadlineExceeded {
+			WriteError(w, http.StatusGatewayTimeout, errors.Wrap(err, "discovery did not finish in time"))
+		} else {
+			WriteError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if discover.Add && v.Scraper != nil {
+		blocklist, err := v.Storage.GetBlocklist()
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to load blocklist"))
+			return
+		}
+		for _, server := range servers {
+			if _, blocked := blocklist.Blocks(server.Core.Address); blocked {
+				continue
+			}
+			v.Scraper.Add(server.Core.Address)
+		}
+	}
+
+	if servers == nil {
+		servers = []types.Server{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(servers)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// discover is the DiscoverFunc used when none is provided
+func (v *V2) discover(ctx context.Context, ip string, from, to int) (servers []types.Server, err error) {
+	ctx, cancel := context.WithTimeout(ctx, v.Config.DiscoverTimeout)
+	defer cancel()
+
+	cores, err := queryDiscover(ctx, ip, from, to, v.QueryOptions(ip))
+	for _, core := range cores {
+		servers = append(servers, types.Server{Core: core})
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerDiscover(t *testing.T) {
+	defer func() { queryDiscover = query.Discover }()
+
+	found := []types.ServerCore{
+		{Address: "203.0.113.10:7777", Hostname: "first"},
+		{Address: "203.0.113.10:7781", Hostname: "second"},
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		cores      []types.ServerCore
+		err        error
+		wantStatus int
+		wantFound  []string
+		wantFields []string
+	}{
+		{"found", `{"ip":"203.0.113.10","from":7777,"to":7800}`, found, nil, http.StatusOK, []string{"203.0.113.10:7777", "203.0.113.10:7781"}, nil},
+		{"add", `{"ip":"203.0.113.10","from":7777,"to":7800,"add":true}`, found, nil, http.StatusOK, []string{"203.0.113.10:7777", "203.0.113.10:7781"}, nil},
+		{"none", `{"ip":"203.0.113.10","from":7777,"to":7777}`, nil, nil, http.StatusOK, []string{}, nil},
+		{"timeout", `{"ip":"203.0.113.10","from":7777,"to":7800}`, found[:1], context.DeadlineExceeded, http.StatusGatewayTimeout, nil, nil},
+		{"too many ports", `{"ip":"203.0.113.10","from":7777,"to":7877}`, nil, nil, http.StatusUnprocessableEntity, nil, []string{"to"}},
+		{"backwards", `{"ip":"203.0.113.10","from":7800,"to":7777}`, nil, nil, http.StatusUnprocessableEntity, nil, []string{"to"}},
+		{"hostname", `{"ip":"ss.southcla.ws","from":7777,"to":7800}`, nil, nil, http.StatusUnprocessableEntity, nil, []string{"ip"}},
+		{"reserved", `{"ip":"203.0.113.10","from":80,"to":7800}`, nil, nil, http.StatusUnprocessableEntity, nil, []string{"from"}},
+		{"loopback", `{"ip":"127.0.0.1","from":7777,"to":7800}`, nil, nil, http.StatusUnprocessableEntity, nil, []string{"ip"}},
+		{"malformed", `{"ip":`, nil, nil, http.StatusBadRequest, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, cancel := newTestRouter(t, storage.NewMemoryStore())
+			defer cancel()
+
+			queryDiscover = func(ctx context.Context, ip string, from, to int, opts query.QueryOptions) ([]types.ServerCore, error) {
+				return tt.cores, tt.err
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("POST", "/discover", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantFields != nil {
+				var got struct {
+					Fields []types.ValidationError `json:"fields"`
+				}
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				fields := []string{}
+				for _, field := range got.Fields {
+					fields = append(fields, field.Field)
+				}
+				assert.Equal(t, tt.wantFields, fields)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []types.Server
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			addresses := []string{}
+			for _, server := range got {
+				addresses = append(addresses, server.Core.Address)
+			}
+			assert.Equal(t, tt.wantFound, addresses)
+		})
+	}
+}
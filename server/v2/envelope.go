@@ -0,0 +1,85 @@
+package v2
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// wantsEnvelope reports whether a request asks for its response to be wrapped in an envelope with
+// envelope=true. Envelopes only apply to JSON, the other formats already have a root element.
+func wantsEnvelope(r *http.Request) (envelope bool, err error) {
+	value := r.URL.Query().Get("envelope")
+	if value == "" {
+		return false, nil
+	}
+	envelope, err = strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Errorf("invalid 'envelope' argument '%s', must be true or false", value)
+	}
+	return
+}
+
+// writeEnvelope writes data wrapped in an envelope along with its meta
+func writeEnvelope(w http.ResponseWriter, data interface{}, meta types.EnvelopeMeta) (err error) {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(types.Envelope{Data: data, Meta: meta})
+}
+
+// envelopeStream writes a JSON array as the data of an envelope one element at a time, the meta is
+// written after the array once it's known how many elements there were
+type envelopeStream struct {
+	w     io.Writer
+	array *arrayStream
+}
+
+func newEnvelopeStream(w io.Writer) listStream {
+	return &envelopeStream{w: w, array: &arrayStream{w: w}}
+}
+
+// Write encodes a single element of the data, the start of the envelope is written before the first
+func (s *envelopeStream) Write(v interface{}) (err error) {
+	if !s.array.Started() {
+		_, err = s.w.Write([]byte(`{"data":`))
+		if err != nil {
+			return
+		}
+	}
+	return s.array.Write(v)
+}
+
+// Started reports whether anything has been written yet
+func (s *envelopeStream) Started() bool {
+	return s.array.Started()
+}
+
+// Close terminates the data and writes the meta, an envelope of an empty array is written if no
+// elements were
+func (s *envelopeStream) Close() (err error) {
+	if !s.array.Started() {
+		_, err = s.w.Write([]byte(`{"data":`))
+		if err != nil {
+			return
+		}
+	}
+	err = s.array.Close()
+	if err != nil {
+		return
+	}
+
+	meta, err := json.Marshal(types.EnvelopeMeta{Count: s.array.count})
+	if err != nil {
+		return
+	}
+	_, err = s.w.Write([]byte(`,"meta":`))
+	if err != nil {
+		return
+	}
+	_, err = s.w.Write(append(meta, '}'))
+	return
+}
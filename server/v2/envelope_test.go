@@ -0,0 +1,73 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestEnvelopeStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := newEnvelopeStream(w)
+	assert.False(t, stream.Started())
+	assert.NoError(t, stream.Write(map[string]int{"pc": 1}))
+	assert.True(t, stream.Started())
+	assert.NoError(t, stream.Write(map[string]int{"pc": 2}))
+	assert.NoError(t, stream.Close())
+	assert.Equal(t, `{"data":[{"pc":1},{"pc":2}],"meta":{"count":2}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.NoError(t, newEnvelopeStream(w).Close())
+	assert.Equal(t, `{"data":[],"meta":{"count":0}}`, w.Body.String())
+}
+
+func TestServerEnvelope(t *testing.T) {
+	store := storage.NewMemoryStore()
+	for _, address := range []string{"a.example.com:7777", "b.example.com:7777"} {
+		require.NoError(t, store.UpsertServer(types.Server{Core: types.ServerCore{Address: address, Hostname: "alpha", Players: 20}}))
+	}
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	next := types.EncodeCursor("a.example.com:7777")
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantBody   string
+	}{
+		{"list", "/servers?envelope=true&fields=ip", http.StatusOK,
+			`{"data":[{"ip":"a.example.com:7777"},{"ip":"b.example.com:7777"}],"meta":{"count":2}}`},
+		{"page", "/servers?envelope=true&fields=ip&limit=1", http.StatusOK,
+			`{"data":[{"ip":"a.example.com:7777"}],"meta":{"count":1,"next":"` + next + `"}}`},
+		{"last page", "/servers?envelope=true&fields=ip&limit=1&cursor=" + next, http.StatusOK,
+			`{"data":[{"ip":"b.example.com:7777"}],"meta":{"count":1}}`},
+		{"bare", "/servers?envelope=false&fields=ip", http.StatusOK,
+			`[{"ip":"a.example.com:7777"},{"ip":"b.example.com:7777"}]`},
+		{"server", "/server/a.example.com:7777?envelope=true&fields=ip,hn", http.StatusOK,
+			`{"data":{"ip":"a.example.com:7777","hn":"alpha"},"meta":{"count":1}}`},
+		{"invalid", "/servers?envelope=yes", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+
+	// other formats already have a root element so they're left as they are
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/servers?envelope=true&format=xml", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "data")
+}
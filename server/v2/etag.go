@@ -0,0 +1,45 @@
+package v2
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverETag returns an entity tag for a server based on its serialised content. The last-seen time
+// and ping are excluded since they change on every query even when nothing a client would display
+// has changed. Each format has its own tag since they're different representations, as does each
+// sparse fieldset of the server and the server wrapped in an envelope.
+func serverETag(server types.Server, format responseFormat, fields []string, envelope bool) string {
+	server.LastSeen = nil
+	server.Ping = 0
+
+	b, err := json.Marshal(server)
+	if err != nil {
+		return ""
+	}
+	if fields != nil {
+		b = append(b, strings.Join(fields, ",")...)
+	}
+	if envelope {
+		b = append(b, "envelope"...)
+	}
+	if format != formatJSON {
+		return fmt.Sprintf(`"%x-%s"`, sha1.Sum(b), format)
+	}
+	return fmt.Sprintf(`"%x"`, sha1.Sum(b))
+}
+
+// etagMatches reports whether an If-None-Match header matches the entity tag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package v2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerETag(t *testing.T) {
+	base := types.Server{}.Example()
+	seen := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	base.LastSeen = &seen
+	base.Online = true
+
+	changed := func(fn func(*types.Server)) types.Server {
+		server := base
+		server.Core = base.Core
+		fn(&server)
+		return server
+	}
+	later := seen.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		server    types.Server
+		wantEqual bool
+	}{
+		{"same", changed(func(s *types.Server) {}), true},
+		{"last seen", changed(func(s *types.Server) { s.LastSeen = &later }), true},
+		{"ping", changed(func(s *types.Server) { s.Ping = 120 }), true},
+		{"players", changed(func(s *types.Server) { s.Core.Players++ }), false},
+		{"hostname", changed(func(s *types.Server) { s.Core.Hostname = "another server" }), false},
+		{"online", changed(func(s *types.Server) { s.Online = false }), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantEqual, serverETag(base, formatJSON, nil, false) == serverETag(tt.server, formatJSON, nil, false))
+		})
+	}
+
+	assert.NotEqual(t, serverETag(base, formatJSON, nil, false), serverETag(base, formatXML, nil, false))
+	assert.NotEqual(t, serverETag(base, formatJSON, nil, false), serverETag(base, formatJSON, []string{"ip", "hn"}, false))
+	assert.NotEqual(t, serverETag(base, formatJSON, nil, false), serverETag(base, formatJSON, nil, true))
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"exact", `"abc"`, true},
+		{"weak", `W/"abc"`, true},
+		{"list", `"xyz", "abc"`, true},
+		{"wildcard", `*`, true},
+		{"different", `"xyz"`, false},
+		{"empty", ``, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, etagMatches(tt.header, `"abc"`))
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package v2
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// exportColumns is the header row of a CSV export
+var exportColumns = []string{"address", "hostname", "players", "maxplayers", "gamemode", "language", "country", "ping", "online"}
+
+// serverExport responds with the server list as a CSV file, the list parameters are the same as
+// for serverList except that cursor pagination isn't supported. Rows are written as each server is
+// read from the database.
+func (v *V2) serverExport(w http.ResponseWriter, r *http.Request) {
+	params, status, err := listParams(r)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+	if params.Paginated() {
+		WriteError(w, http.StatusBadRequest, errors.New("exports do not support 'limit' or 'cursor'"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="servers.csv"`)
+
+	cw := csv.NewWriter(w)
+	err = cw.Write(exportColumns)
+	if err != nil {
+		return
+	}
+
+	// the header row has already been sent so errors can't be reported, they just cut the file short
+	now := time.Now()
+	v.Storage.StreamServers(params, func(server types.Server) error { // nolint:errcheck
+		server.CheckOnline(now, v.Config.OfflineAfter)
+		return cw.Write([]string{
+			server.Core.Address,
+			server.Core.Hostname,
+			strconv.Itoa(server.Core.Players),
+			strconv.Itoa(server.Core.MaxPlayers),
+			server.Core.Gamemode,
+			server.Core.Language,
+			server.Country,
+			strconv.Itoa(server.Ping),
+			strconv.FormatBool(server.Online),
+		})
+	})
+	cw.Flush()
+}
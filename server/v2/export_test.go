@@ -0,0 +1,61 @@
+package v2
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerExport(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	for _, server := range []types.Server{
+		{Core: types.ServerCore{Address: "a.example.com:7777", Hostname: `alpha, "the first"`, Players: 20, MaxPlayers: 50, Gamemode: "tdm", Language: "English", Languages: []string{"English"}}, Country: "GB"},
+		{Core: types.ServerCore{Address: "b.example.com:7777", Hostname: "bravo", Players: 10, MaxPlayers: 100, Gamemode: "rp", Language: "Polish", Languages: []string{"Polish"}}},
+	} {
+		assert.NoError(t, store.UpsertServer(server))
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantRows   [][]string
+	}{
+		{"all", "", http.StatusOK, [][]string{
+			exportColumns,
+			{"a.example.com:7777", `alpha, "the first"`, "20", "50", "tdm", "English", "GB", "0", "false"},
+			{"b.example.com:7777", "bravo", "10", "100", "rp", "Polish", "", "0", "false"},
+		}},
+		{"filtered", "?language=polish", http.StatusOK, [][]string{
+			exportColumns,
+			{"b.example.com:7777", "bravo", "10", "100", "rp", "Polish", "", "0", "false"},
+		}},
+		{"empty", "?gamemode=nothing", http.StatusOK, [][]string{exportColumns}},
+		{"invalid sort", "?sort=mapname", http.StatusBadRequest, nil},
+		{"cursor", "?limit=1", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/servers.csv"+tt.query, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, `attachment; filename="servers.csv"`, w.Header().Get("Content-Disposition"))
+			rows, err := csv.NewReader(w.Body).ReadAll()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRows, rows)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverFeature marks a server as featured
+func (v *V2) serverFeature(w http.ResponseWriter, r *http.Request) {
+	v.setFeatured(w, r, true)
+}
+
+// serverUnfeature removes the featured mark from a server
+func (v *V2) serverUnfeature(w http.ResponseWriter, r *http.Request) {
+	v.setFeatured(w, r, false)
+}
+
+func (v *V2) setFeatured(w http.ResponseWriter, r *http.Request, featured bool) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, err := types.NormalizeAddress(address)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = v.Storage.SetFeatured(address, featured)
+	if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.Errorf("could not find server by address '%s'", address))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
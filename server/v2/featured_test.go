@@ -0,0 +1,61 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerFeatured(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	for _, core := range []types.ServerCore{
+		{Address: "a.example.com:7777", Hostname: "alpha", Players: 30},
+		{Address: "b.example.com:7777", Hostname: "bravo", Players: 20},
+		{Address: "c.example.com:7777", Hostname: "charlie", Players: 10},
+	} {
+		assert.NoError(t, store.UpsertServer(types.Server{Core: core}))
+	}
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+	list := func(query string) (hostnames []string) {
+		w := do("GET", "/servers"+query)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got []types.ServerCore
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		for _, core := range got {
+			hostnames = append(hostnames, core.Hostname)
+		}
+		return
+	}
+
+	assert.Equal(t, http.StatusNoContent, do("PUT", "/server/c.example.com:7777/feature").Code)
+	assert.Equal(t, http.StatusNoContent, do("PUT", "/server/b.example.com:7777/feature").Code)
+	assert.Equal(t, http.StatusNotFound, do("PUT", "/server/d.example.com:7777/feature").Code)
+
+	assert.Equal(t, []string{"alpha", "bravo", "charlie"}, list(""))
+	assert.Equal(t, []string{"bravo", "charlie", "alpha"}, list("?featured=first"))
+	assert.Equal(t, []string{"charlie", "bravo", "alpha"}, list("?featured=first&sort=players"))
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/servers?featured=last").Code)
+
+	// posting the server again doesn't unfeature it
+	assert.NoError(t, store.UpsertServer(types.Server{Core: types.ServerCore{Address: "b.example.com:7777", Hostname: "bravo", Players: 20}}))
+	server, err := store.GetServer("b.example.com:7777")
+	assert.NoError(t, err)
+	assert.True(t, server.Featured)
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/server/b.example.com:7777/feature").Code)
+	assert.Equal(t, []string{"charlie", "alpha", "bravo"}, list("?featured=first"))
+}
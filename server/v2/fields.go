@@ -0,0 +1,67 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// sparseFields returns the fields a request limits servers to with fields=ip,hn,pc, or nil if it
+// doesn't limit them. The parameter may be given more than once, blank names are ignored.
+func sparseFields(r *http.Request) (fields []string) {
+	for _, value := range r.URL.Query()["fields"] {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return
+}
+
+// projectServer returns an object with only the requested fields of a server, keyed by the names
+// they were requested with. Names are the short JSON keys and are looked up among the server's own
+// fields first and then its core fields, so `pi` is the ping and `hn` is the hostname, none of them
+// share a key. Unknown names are ignored and so are fields the full server would omit for being
+// empty. The player list is hidden from passworded servers in the same way as it is from full
+// servers.
+func projectServer(server types.Server, fields []string) map[string]interface{} {
+	server.HidePrivate()
+
+	var top, core map[string]json.RawMessage
+	b, err := json.Marshal(server)
+	if err == nil {
+		err = json.Unmarshal(b, &top)
+	}
+	if err == nil {
+		err = json.Unmarshal(top["core"], &core)
+	}
+	if err != nil {
+		return map[string]interface{}{} // a Server always encodes as an object
+	}
+
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		value, ok := top[field]
+		if !ok {
+			value, ok = core[field]
+		}
+		if ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
+
+// presentFields is present for responses that may be limited to a sparse fieldset. Only JSON can
+// be projected since the other formats have a fixed schema, and the projection always uses the
+// short keys since the names are requested with them, so verbose doesn't apply to it.
+func presentFields(server types.Server, full bool, format responseFormat, fields []string) interface{} {
+	if fields != nil && (format == formatJSON || format == formatVerbose) {
+		return projectServer(server, fields)
+	}
+	return present(server, full, format)
+}
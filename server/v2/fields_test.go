@@ -0,0 +1,107 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestSparseFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"none", "", nil},
+		{"empty", "fields=", nil},
+		{"list", "fields=ip,hn,pc", []string{"ip", "hn", "pc"}},
+		{"spaces and blanks", "fields=ip,%20hn%20,,pc", []string{"ip", "hn", "pc"}},
+		{"repeated", "fields=ip&fields=pi", []string{"ip", "pi"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/servers?"+tt.query, nil)
+			assert.Equal(t, tt.want, sparseFields(r))
+		})
+	}
+}
+
+func TestProjectServer(t *testing.T) {
+	seen := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	server := types.Server{
+		Core: types.ServerCore{
+			Address:   "1.2.3.4:7777",
+			Hostname:  "alpha",
+			Players:   20,
+			Password:  true,
+			Languages: []string{"English"},
+		},
+		PlayerList: []string{"Southclaws"},
+		Ping:       40,
+		LastSeen:   &seen,
+	}
+
+	tests := []struct {
+		name   string
+		server types.Server
+		fields []string
+		want   string
+	}{
+		{"core", server, []string{"ip", "hn", "pc"}, `{"ip":"1.2.3.4:7777","hn":"alpha","pc":20}`},
+		{"server", server, []string{"pi", "description"}, `{"pi":40,"description":""}`},
+		{"last seen", server, []string{"ls"}, `{"ls":"2018-01-01T12:00:00Z"}`},
+		{"languages", server, []string{"lg", "hn"}, `{"lg":["English"],"hn":"alpha"}`},
+		{"unknown", server, []string{"ip", "nope", "core.nope"}, `{"ip":"1.2.3.4:7777"}`},
+		{"empty omitted", types.Server{}, []string{"ls", "pi", "ip"}, `{"ip":""}`},
+		{"private", server, []string{"pl"}, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(projectServer(tt.server, tt.fields))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(b))
+		})
+	}
+}
+
+func TestServerFields(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	assert.NoError(t, store.UpsertServer(types.Server{
+		Core:    types.ServerCore{Address: "a.example.com:7777", Hostname: "alpha", Players: 20, MaxPlayers: 50},
+		Country: "GB",
+	}))
+
+	do := func(url string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return w
+	}
+
+	w := do("/server/a.example.com:7777?fields=ip,hn,co")
+	assert.JSONEq(t, `{"ip":"a.example.com:7777","hn":"alpha","co":"GB"}`, w.Body.String())
+	assert.NotEqual(t, w.Header().Get("ETag"), do("/server/a.example.com:7777").Header().Get("ETag"))
+
+	// the fields are the same whether or not the list is full, and verbose doesn't apply
+	w = do("/servers?fields=hn,pm&verbose=true")
+	assert.JSONEq(t, `[{"hn":"alpha","pm":50}]`, w.Body.String())
+
+	w = do("/servers?limit=10&fields=ip")
+	assert.JSONEq(t, `{"servers":[{"ip":"a.example.com:7777"}],"next":""}`, w.Body.String())
+
+	// formats with a fixed schema ignore it
+	w = do("/servers?fields=ip&format=xml")
+	assert.Contains(t, w.Body.String(), "<hn>alpha</hn>")
+}
@@ -0,0 +1,122 @@
+package v2
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// responseFormat is the encoding of a response body
+type responseFormat string
+
+const (
+	formatJSON     responseFormat = "json"
+	formatXML      responseFormat = "xml"
+	formatProtobuf responseFormat = "protobuf"
+
+	// formatVerbose is JSON with descriptive keys instead of the short ones, it's asked for with the
+	// verbose parameter rather than the format parameter since it's a variant of JSON
+	formatVerbose responseFormat = "verbose"
+)
+
+// negotiateFormat picks the response format from the format query parameter or, if that's not
+// set, the Accept header. XML is only used when it's asked for explicitly, either by the parameter
+// or by an XML media type being accepted without JSON, since JSON is the default. JSON responses
+// use descriptive keys when the request has verbose=true, XML ignores it.
+func negotiateFormat(r *http.Request) (format responseFormat, err error) {
+	format, err = negotiateEncoding(r)
+	if err != nil || format != formatJSON {
+		return
+	}
+	verbose, err := verboseKeys(r)
+	if verbose {
+		format = formatVerbose
+	}
+	return
+}
+
+// negotiateListFormat is negotiateFormat for the server list, which can also be encoded as protocol
+// buffers when it's asked for by the parameter or by accepting application/x-protobuf
+func negotiateListFormat(r *http.Request) (format responseFormat, err error) {
+	if f := responseFormat(r.URL.Query().Get("format")); f == formatProtobuf ||
+		(f == "" && accepts(r, "application/x-protobuf")) {
+		return formatProtobuf, nil
+	}
+	return negotiateFormat(r)
+}
+
+// verboseKeys reports whether a request asks for descriptive JSON keys with verbose=true
+func verboseKeys(r *http.Request) (verbose bool, err error) {
+	value := r.URL.Query().Get("verbose")
+	if value == "" {
+		return false, nil
+	}
+	verbose, err = strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Errorf("invalid 'verbose' argument '%s', must be true or false", value)
+	}
+	return
+}
+
+// present returns what's encoded for a server in a response of the format, either the whole server
+// or only its core fields
+func present(server types.Server, full bool, format responseFormat) interface{} {
+	switch {
+	case full && format == formatVerbose:
+		return server.Verbose()
+	case full:
+		return server
+	case format == formatVerbose:
+		return server.Core.Verbose()
+	default:
+		return server.Core
+	}
+}
+
+// negotiateEncoding picks between JSON and XML, see negotiateFormat
+func negotiateEncoding(r *http.Request) (format responseFormat, err error) {
+	switch f := responseFormat(r.URL.Query().Get("format")); f {
+	case "":
+	case formatJSON, formatXML:
+		return f, nil
+	default:
+		return "", errors.Errorf("invalid 'format' argument '%s', must be one of: %s, %s", f, formatJSON, formatXML)
+	}
+
+	if (accepts(r, "application/xml") || accepts(r, "text/xml")) && !accepts(r, "application/json") {
+		return formatXML, nil
+	}
+	return formatJSON, nil
+}
+
+// accepts reports whether the Accept header of a request lists a media type, parameters such as
+// the quality are ignored
+func accepts(r *http.Request, mediaType string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if strings.TrimSpace(strings.Split(part, ";")[0]) == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// writeXML writes v as an XML document with a root element of the given name
+func writeXML(w http.ResponseWriter, name string, v interface{}) (err error) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	return encodeXML(w, name, v)
+}
+
+// encodeXML writes v to w as an XML document with a root element of the given name
+func encodeXML(w io.Writer, name string, v interface{}) (err error) {
+	_, err = w.Write([]byte(xml.Header))
+	if err != nil {
+		return
+	}
+	return xml.NewEncoder(w).EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+}
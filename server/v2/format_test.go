@@ -0,0 +1,227 @@
+package v2
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		accept  string
+		want    responseFormat
+		wantErr bool
+	}{
+		{"default", "/servers", "", formatJSON, false},
+		{"anything", "/servers", "*/*", formatJSON, false},
+		{"accept xml", "/servers", "application/xml", formatXML, false},
+		{"accept text xml", "/servers", "text/html, text/xml;q=0.9", formatXML, false},
+		{"accept both", "/servers", "application/xml, application/json", formatJSON, false},
+		{"param", "/servers?format=xml", "", formatXML, false},
+		{"param overrides accept", "/servers?format=json", "application/xml", formatJSON, false},
+		{"invalid param", "/servers?format=yaml", "", "", true},
+		{"verbose", "/servers?verbose=true", "", formatVerbose, false},
+		{"not verbose", "/servers?verbose=false", "", formatJSON, false},
+		{"verbose xml", "/servers?format=xml&verbose=true", "", formatXML, false},
+		{"invalid verbose", "/servers?verbose=very", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			got, err := negotiateFormat(r)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNegotiateListFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		want   responseFormat
+	}{
+		{"default", "/servers", "", formatJSON},
+		{"accept protobuf", "/servers", "application/x-protobuf", formatProtobuf},
+		{"param", "/servers?format=protobuf", "", formatProtobuf},
+		{"param overrides accept", "/servers?format=xml", "application/x-protobuf", formatXML},
+		{"verbose", "/servers?verbose=true", "", formatVerbose},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			got, err := negotiateListFormat(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServerListProtobuf(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	servers := []types.Server{
+		{Core: types.ServerCore{Address: "a.example.com:7777", Hostname: "alpha", Players: 20}, Rules: map[string]string{"weather": "10"}},
+		{Core: types.ServerCore{Address: "b.example.com:7777", Hostname: "bravo", Players: 10}},
+	}
+	for _, server := range servers {
+		assert.NoError(t, store.UpsertServer(server))
+	}
+
+	do := func(url string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", url, nil)
+		r.Header.Set("Accept", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	var want []byte
+	for _, server := range servers {
+		want = types.AppendProtoList(want, types.Server{Core: server.Core})
+	}
+	w := do("/servers")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	assert.Equal(t, want, w.Body.Bytes())
+
+	full := do("/servers?full=true")
+	assert.Equal(t, http.StatusOK, full.Code)
+	assert.True(t, full.Body.Len() > w.Body.Len(), "the full list should include the rules")
+
+	want = types.AppendProtoList(nil, types.Server{Core: servers[0].Core})
+	want = types.AppendProtoNext(want, types.EncodeCursor(servers[0].Core.Address))
+	page := do("/servers?limit=1")
+	assert.Equal(t, http.StatusOK, page.Code)
+	assert.Equal(t, want, page.Body.Bytes())
+
+	assert.Empty(t, do("/servers?gamemode=none").Body.Bytes())
+}
+
+func TestServerXML(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	for _, server := range []types.Server{
+		{Core: types.ServerCore{Address: "a.example.com:7777", Hostname: "alpha", Players: 20}, Rules: map[string]string{"weather": "10"}},
+		{Core: types.ServerCore{Address: "b.example.com:7777", Hostname: "bravo", Players: 10}},
+	} {
+		assert.NoError(t, store.UpsertServer(server))
+	}
+
+	do := func(url, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", url, nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	type xmlServer struct {
+		Address string       `xml:"core>ip"`
+		Rules   []types.Rule `xml:"ru>rule"`
+	}
+
+	w := do("/server/a.example.com:7777", "application/xml")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	var server xmlServer
+	assert.NoError(t, xml.NewDecoder(w.Body).Decode(&server))
+	assert.Equal(t, xmlServer{Address: "a.example.com:7777", Rules: []types.Rule{{Name: "weather", Value: "10"}}}, server)
+
+	var list struct {
+		XMLName   xml.Name `xml:"servers"`
+		Hostnames []string `xml:"server>hn"`
+	}
+	w = do("/servers?format=xml", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, xml.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, []string{"alpha", "bravo"}, list.Hostnames)
+
+	w = do("/servers?format=xml&gamemode=nothing", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	list.Hostnames = nil
+	assert.NoError(t, xml.NewDecoder(w.Body).Decode(&list))
+	assert.Empty(t, list.Hostnames)
+
+	var page struct {
+		XMLName   xml.Name `xml:"page"`
+		Addresses []string `xml:"servers>server>ip"`
+		Next      string   `xml:"next"`
+	}
+	w = do("/servers?limit=1", "application/xml")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, xml.NewDecoder(w.Body).Decode(&page))
+	assert.Equal(t, []string{"a.example.com:7777"}, page.Addresses)
+	assert.NotEmpty(t, page.Next)
+
+	assert.Equal(t, http.StatusBadRequest, do("/servers?format=yaml", "").Code)
+}
+
+func TestServerVerbose(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	assert.NoError(t, store.UpsertServer(types.Server{
+		Core:  types.ServerCore{Address: "a.example.com:7777", Hostname: "alpha", Players: 20, MaxPlayers: 50},
+		Rules: map[string]string{"weather": "10"},
+	}))
+
+	do := func(method, url, body string) map[string]interface{} {
+		r := jsonRequest(method, url, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var got map[string]interface{}
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		return got
+	}
+
+	server := do("GET", "/server/a.example.com:7777?verbose=true", "")
+	core, _ := server["core"].(map[string]interface{})
+	assert.Equal(t, "a.example.com:7777", core["address"])
+	assert.Equal(t, "alpha", core["hostname"])
+	assert.Equal(t, float64(50), core["maxPlayers"])
+	assert.Equal(t, map[string]interface{}{"weather": "10"}, server["rules"])
+	assert.NotContains(t, core, "ip")
+
+	server = do("GET", "/server/a.example.com:7777", "")
+	core, _ = server["core"].(map[string]interface{})
+	assert.Equal(t, "a.example.com:7777", core["ip"])
+
+	page := do("GET", "/servers?limit=10&verbose=true", "")
+	servers, _ := page["servers"].([]interface{})
+	if assert.Len(t, servers, 1) {
+		assert.Equal(t, "alpha", servers[0].(map[string]interface{})["hostname"])
+	}
+
+	many := do("POST", "/servers/get?verbose=true", `{"addresses": ["a.example.com", "b.example.com"]}`)
+	assert.Nil(t, many["b.example.com"])
+	core, _ = many["a.example.com"].(map[string]interface{})["core"].(map[string]interface{})
+	assert.Equal(t, "alpha", core["hostname"])
+}
@@ -0,0 +1,118 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+const (
+	defaultHistoryRange    = time.Hour * 24
+	defaultHistoryInterval = time.Minute * 5
+	minHistoryInterval     = time.Minute
+	maxHistoryPoints       = 2000
+)
+
+// serverHistory returns the average player count of a server over each interval between the from
+// and to parameters. A server that exists but has no samples yet has an empty list of points.
+func (v *V2) serverHistory(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, errs := v.addressForStorage(address)
+	if errs != nil {
+		WriteErrors(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	from, to, interval, err := historyParams(r, time.Now())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = v.Storage.GetServer(address)
+	if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.New("server not found"))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	samples, err := v.Storage.GetSamples(address, from, to)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	history := types.History{
+		Address:  address,
+		Interval: interval.String(),
+		Points:   types.BucketSamples(samples, from, interval),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(&history)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// historyParams reads the time range and interval of a history request, to defaults to now and
+// from defaults to a day before to.
+func historyParams(r *http.Request, now time.Time) (from, to time.Time, interval time.Duration, err error) {
+	params := r.URL.Query()
+
+	to = now
+	if raw := params.Get("to"); raw != "" {
+		to, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			err = errors.Wrap(err, "invalid to")
+			return
+		}
+	}
+
+	from = to.Add(-defaultHistoryRange)
+	if raw := params.Get("from"); raw != "" {
+		from, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			err = errors.Wrap(err, "invalid from")
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		err = errors.New("from must be before to")
+		return
+	}
+
+	interval = defaultHistoryInterval
+	if raw := params.Get("interval"); raw != "" {
+		interval, err = time.ParseDuration(raw)
+		if err != nil {
+			err = errors.Wrap(err, "invalid interval")
+			return
+		}
+	}
+
+	if interval < minHistoryInterval {
+		err = errors.Errorf("interval must be at least %v", minHistoryInterval)
+		return
+	}
+	if to.Sub(from)/interval > maxHistoryPoints {
+		err = errors.Errorf("range covers more than %d intervals", maxHistoryPoints)
+		return
+	}
+	return
+}
@@ -0,0 +1,68 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerHistory(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	start := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.UpsertServer(types.Server{Core: types.ServerCore{Address: "127.0.0.1:7777"}}))
+	assert.NoError(t, store.UpsertServer(types.Server{Core: types.ServerCore{Address: "127.0.0.2:7777"}}))
+	for i, players := range []float64{2, 4, 9} {
+		assert.NoError(t, store.AddSample(types.PlayerSample{
+			Address: "127.0.0.1:7777",
+			Time:    start.Add(time.Duration(i) * time.Minute * 3),
+			Players: players,
+		}))
+	}
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantPoints []types.PlayerSample
+	}{
+		{"buckets", "/server/127.0.0.1:7777/history?from=2018-01-01T12:00:00Z&to=2018-01-01T13:00:00Z", http.StatusOK, []types.PlayerSample{
+			{Time: start, Players: 3},
+			{Time: start.Add(time.Minute * 5), Players: 9},
+		}},
+		{"one bucket", "/server/127.0.0.1:7777/history?from=2018-01-01T12:00:00Z&to=2018-01-01T13:00:00Z&interval=1h", http.StatusOK, []types.PlayerSample{
+			{Time: start, Players: 5},
+		}},
+		{"no samples", "/server/127.0.0.2:7777/history?from=2018-01-01T12:00:00Z&to=2018-01-01T13:00:00Z", http.StatusOK, []types.PlayerSample{}},
+		{"not found", "/server/127.0.0.3:7777/history", http.StatusNotFound, nil},
+		{"bad from", "/server/127.0.0.1:7777/history?from=yesterday", http.StatusBadRequest, nil},
+		{"reversed", "/server/127.0.0.1:7777/history?from=2018-01-01T13:00:00Z&to=2018-01-01T12:00:00Z", http.StatusBadRequest, nil},
+		{"short interval", "/server/127.0.0.1:7777/history?interval=10s", http.StatusBadRequest, nil},
+		{"too many points", "/server/127.0.0.1:7777/history?from=2017-01-01T12:00:00Z&to=2018-01-01T13:00:00Z&interval=1m", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var got types.History
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				for i := range got.Points {
+					got.Points[i].Time = got.Points[i].Time.UTC()
+				}
+				assert.Equal(t, tt.wantPoints, got.Points)
+			}
+		})
+	}
+}
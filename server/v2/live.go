@@ -0,0 +1,111 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// queryServer is swapped out in tests so live queries don't need a real server to respond
+var queryServer = query.QueryAll
+
+// serverLive queries a server immediately rather than responding with the stored copy, the result
+// is stored before it's returned. The query is given LiveTimeout to complete in total, if the
+// server hasn't responded by then the response is a 504. Requests for the same server within a
+// short time of each other may share a single query, depending on the Query function in use.
+func (v *V2) serverLive(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, errs := v.addressForStorage(address)
+	if errs != nil {
+		WriteErrors(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	verbose, err := verboseKeys(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	format := formatJSON
+	if verbose {
+		format = formatVerbose
+	}
+
+	status, err := v.checkBlocked(address)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	server, err := v.Query(r.Context(), address)
+	if err != nil {
+		// a partial response still has up to date core information so it's worth storing
+		if !query.IsPartial(err) {
+			switch {
+			case errors.Cause(err) == query.ErrTooManyQueries:
+				WriteError(w, http.StatusServiceUnavailable, errors.Wrap(err, "too busy to query server"))
+			case query.IsTimeout(err):
+				WriteError(w, http.StatusGatewayTimeout, errors.Wrap(err, "server did not respond in time"))
+			default:
+				WriteError(w, http.StatusBadGateway, errors.Wrap(err, "failed to query server"))
+			}
+			return
+		}
+	}
+
+	// posted information isn't part of the query so it's carried over from the stored copy
+	existing, err := v.Storage.GetServer(address)
+	if err == nil {
+		server.Description = existing.Description
+		server.Banner = existing.Banner
+	} else if err != storage.ErrNotFound {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.Active = true
+	server.MarkSeen(time.Now())
+	if v.Locate != nil {
+		server.Country = v.Locate(address)
+	}
+
+	err = v.storeServer(&server)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	v.Scraper.Add(server.Core.Address)
+
+	server.HidePrivate()
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(present(server, true, format))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// liveQuery is the QueryFunc used when none is provided, it queries the server every time
+func (v *V2) liveQuery(ctx context.Context, address string) (types.Server, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.Config.LiveTimeout)
+	defer cancel()
+	opts := v.QueryOptions(address)
+	opts.Handshake = true // the result is stored, so it has to have come from the server itself
+	server, err := queryServer(ctx, address, opts)
+	server.QueryPort = opts.QueryPort
+	return server, err
+}
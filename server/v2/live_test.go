@@ -0,0 +1,136 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerLive(t *testing.T) {
+	defer func() { queryServer = query.QueryAll }()
+
+	queried := types.Server{Core: types.ServerCore{Address: "127.0.0.1:7777", Hostname: "live", Players: 12}}
+	tests := []struct {
+		name            string
+		err             error
+		wantStatus      int
+		wantPlayers     int
+		wantDescription string
+	}{
+		{"ok", nil, http.StatusOK, 12, "posted"},
+		{"partial", query.PartialError{Opcode: query.Players, Err: errors.New("truncated")}, http.StatusOK, 12, "posted"},
+		{"timeout", context.DeadlineExceeded, http.StatusGatewayTimeout, 3, "posted"},
+		{"unreachable", errors.New("connection refused"), http.StatusBadGateway, 3, "posted"},
+		{"busy", query.ErrTooManyQueries, http.StatusServiceUnavailable, 3, "posted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			router, cancel := newTestRouter(t, store)
+			defer cancel()
+
+			assert.NoError(t, store.UpsertServer(types.Server{
+				Core:        types.ServerCore{Address: "127.0.0.1:7777", Hostname: "stored", Players: 3},
+				Description: "posted",
+			}))
+
+			queryServer = func(ctx context.Context, address string, opts query.QueryOptions) (types.Server, error) {
+				return queried, tt.err
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/server/127.0.0.1:7777/live", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var got types.Server
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.wantPlayers, got.Core.Players)
+				assert.True(t, got.Online)
+			}
+
+			stored, err := store.GetServer("127.0.0.1:7777")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPlayers, stored.Core.Players)
+			assert.Equal(t, tt.wantDescription, stored.Description)
+		})
+	}
+}
+
+// spoofingServer answers every query like a real server with no players, except that pings echo
+// the wrong challenge the way someone spoofing the address of a server that isn't there would. It
+// listens outside the ephemeral range since addresses with ephemeral ports aren't valid.
+func spoofingServer(t *testing.T) (address string, stop func()) {
+	var (
+		conn *net.UDPConn
+		err  error
+	)
+	for attempt := 0; attempt < 10; attempt++ {
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 20000 + rand.Intn(20000)})
+		if err == nil {
+			break
+		}
+	}
+	require.NoError(t, err)
+
+	info := new(bytes.Buffer)
+	info.WriteByte(0)
+	binary.Write(info, binary.LittleEndian, []uint16{0, 32}) // nolint:errcheck
+	for _, s := range []string{"Totally Real Roleplay", "Roleplay", "English"} {
+		binary.Write(info, binary.LittleEndian, uint32(len(s))) // nolint:errcheck
+		info.WriteString(s)
+	}
+	responses := map[byte][]byte{'i': info.Bytes(), 'r': {0, 0}, 'c': {0, 0}}
+
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if n < 11 {
+				continue
+			}
+			response := append([]byte{}, buf[:n]...)
+			if buf[10] == 'p' {
+				response[n-1] ^= 0xff
+			} else {
+				response = append(response, responses[buf[10]]...)
+			}
+			conn.WriteToUDP(response, from) // nolint:errcheck
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() } // nolint:errcheck
+}
+
+func TestServerLive_Spoofed(t *testing.T) {
+	address, stop := spoofingServer(t)
+	defer stop()
+
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/server/"+address+"/live", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	_, err := store.GetServer(address)
+	assert.Equal(t, storage.ErrNotFound, err)
+}
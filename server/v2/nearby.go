@@ -0,0 +1,52 @@
+package v2
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/server/realip"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverNearby lists servers ordered by how close they're likely to be to the IP in the `ip`
+// parameter, or to the client if it's not given
+func (v *V2) serverNearby(w http.ResponseWriter, r *http.Request) {
+	ip := r.URL.Query().Get("ip")
+	if ip == "" {
+		ip = realip.ClientIP(r)
+	}
+	if net.ParseIP(ip) == nil {
+		WriteError(w, http.StatusBadRequest, errors.Errorf("'%s' is not an IP address", ip))
+		return
+	}
+
+	servers, err := v.Nearby(ip)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get nearby servers"))
+		return
+	}
+
+	results := make([]types.NearbyServer, len(servers))
+	for i := range servers {
+		results[i] = types.NearbyServer{ServerCore: servers[i].Core, Country: servers[i].Country}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(results)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to encode response"))
+		return
+	}
+}
+
+// nearby lists servers by players alone, for when there's nothing to locate them with
+func (v *V2) nearby(clientIP string) (servers []types.Server, err error) {
+	err = v.Storage.StreamServers(types.ServerListParams{}, func(server types.Server) error {
+		servers = append(servers, server)
+		return nil
+	})
+	return
+}
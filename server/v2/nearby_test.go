@@ -0,0 +1,72 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerNearby(t *testing.T) {
+	store := storage.NewMemoryStore()
+	for _, server := range []types.Server{
+		{Core: types.ServerCore{Address: "a.example.com:7777", Players: 5}, Country: "PL"},
+		{Core: types.ServerCore{Address: "b.example.com:7777", Players: 20}, Country: "US"},
+	} {
+		require.NoError(t, store.UpsertServer(server))
+	}
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	tests := []struct {
+		name          string
+		query         string
+		wantStatus    int
+		wantAddresses []string
+	}{
+		{"ip", "?ip=93.119.25.177", http.StatusOK, []string{"b.example.com:7777", "a.example.com:7777"}},
+		{"client", "", http.StatusOK, []string{"b.example.com:7777", "a.example.com:7777"}},
+		{"invalid ip", "?ip=example.com", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/servers/nearby"+tt.query, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []types.NearbyServer
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			var addresses []string
+			for _, server := range got {
+				addresses = append(addresses, server.Address)
+			}
+			assert.Equal(t, tt.wantAddresses, addresses)
+			assert.Equal(t, "US", got[0].Country)
+		})
+	}
+}
+
+func TestServerNearby_Located(t *testing.T) {
+	var located string
+	v := Init(storage.NewMemoryStore(), nil, types.Config{}, Deps{Nearby: func(clientIP string) ([]types.Server, error) {
+		located = clientIP
+		return []types.Server{{Core: types.ServerCore{Address: "a.example.com:7777"}, Country: "PL"}}, nil
+	}})
+
+	r := httptest.NewRequest("GET", "/servers/nearby", nil)
+	r.RemoteAddr = "93.119.25.177:50000"
+	w := httptest.NewRecorder()
+	v.serverNearby(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "93.119.25.177", located)
+	assert.JSONEq(t, `[{"ip":"a.example.com:7777","hn":"","pc":0,"pm":0,"gm":"","la":"","pa":false,"vn":"","co":"PL"}]`, w.Body.String())
+}
@@ -0,0 +1,55 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverPatch overwrites the fields of a stored server that are present in the body
+func (v *V2) serverPatch(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, err := types.NormalizeAddress(address)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	status, err := v.decodeBody(w, r, &patch)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	err = v.Patch(address, patch)
+	if invalid, ok := errors.Cause(err).(types.Invalid); ok {
+		WriteErrors(w, http.StatusUnprocessableEntity, invalid)
+		return
+	} else if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.Errorf("could not find server by address '%s'", address))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// patch stores a patch directly, with the same restrictions on gamemode and language as posting
+func (v *V2) patch(address string, patch map[string]json.RawMessage) error {
+	return storage.PatchServer(v.Storage, address, patch, func(server types.Server) []error {
+		return server.Core.Allowed(v.Config.AllowedGamemodes, v.Config.AllowedLanguages)
+	})
+}
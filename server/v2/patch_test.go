@@ -0,0 +1,73 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		wantStatus  int
+		wantCountry string
+		wantFields  []string
+	}{
+		{"country", "/server/127.0.0.1:7777", `{"co":"PL"}`, http.StatusNoContent, "PL", nil},
+		{"derived", "/server/127.0.0.1:7777", `{"co":"PL","on":false}`, http.StatusUnprocessableEntity, "GB", []string{"on"}},
+		{"invalid result", "/server/127.0.0.1:7777", `{"core":{"hn":""}}`, http.StatusUnprocessableEntity, "GB", []string{"hostname"}},
+		{"not found", "/server/127.0.0.2:7777", `{"co":"PL"}`, http.StatusNotFound, "GB", nil},
+		{"malformed", "/server/127.0.0.1:7777", `{"co":`, http.StatusBadRequest, "GB", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			server := types.Server{}.Example()
+			server.Country = "GB"
+			require.NoError(t, store.UpsertServer(server))
+			router, cancel := newTestRouter(t, store)
+			defer cancel()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("PATCH", tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantFields != nil {
+				var got errorsResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				var fields []string
+				for _, field := range got.Fields {
+					fields = append(fields, field.Field)
+				}
+				assert.Equal(t, tt.wantFields, fields)
+			}
+
+			stored, err := store.GetServer(server.Core.Address)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCountry, stored.Country)
+		})
+	}
+}
+
+func TestServerPatch_Allowed(t *testing.T) {
+	store := storage.NewMemoryStore()
+	require.NoError(t, store.UpsertServer(types.Server{}.Example()))
+	v := Init(store, nil, types.Config{AllowedGamemodes: []string{"larceny"}}, Deps{})
+
+	assert.NoError(t, v.Patch("127.0.0.1:7777", map[string]json.RawMessage{"core": json.RawMessage(`{"gm":"Grand Larceny 2"}`)}))
+	err := v.Patch("127.0.0.1:7777", map[string]json.RawMessage{"core": json.RawMessage(`{"gm":"Freeroam"}`)})
+	assert.IsType(t, types.Invalid{}, err)
+
+	stored, err := store.GetServer("127.0.0.1:7777")
+	require.NoError(t, err)
+	assert.Equal(t, "Grand Larceny 2", stored.Core.Gamemode)
+}
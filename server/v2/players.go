@@ -0,0 +1,71 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// queryDetailedPlayers is swapped out in tests in the same way as queryServer
+var queryDetailedPlayers = query.QueryDetailedPlayers
+
+// serverPlayers queries a stored server for the ids, names, scores and pings of its players. The
+// list is empty when the server doesn't provide one, either because it's passworded, in which case
+// it's not queried at all, or because it has too many players online.
+func (v *V2) serverPlayers(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, errs := v.addressForStorage(address)
+	if errs != nil {
+		WriteErrors(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	server, err := v.Storage.GetServer(address)
+	if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.New("server not found"))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	players := []types.PlayerDetail{}
+	if !server.Core.Password {
+		ctx, cancel := context.WithTimeout(r.Context(), v.Config.LiveTimeout)
+		defer cancel()
+
+		players, err = queryDetailedPlayers(ctx, server.Core.Address, v.QueryOptions(server.Core.Address))
+		if errors.Cause(err) == query.ErrPlayerListUnavailable {
+			players = []types.PlayerDetail{}
+		} else if err != nil {
+			switch {
+			case errors.Cause(err) == query.ErrTooManyQueries:
+				WriteError(w, http.StatusServiceUnavailable, errors.Wrap(err, "too busy to query server"))
+			case query.IsTimeout(err):
+				WriteError(w, http.StatusGatewayTimeout, errors.Wrap(err, "server did not respond in time"))
+			default:
+				WriteError(w, http.StatusBadGateway, errors.Wrap(err, "failed to query server"))
+			}
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(players)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
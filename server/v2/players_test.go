@@ -0,0 +1,70 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerPlayers(t *testing.T) {
+	defer func() { queryDetailedPlayers = query.QueryDetailedPlayers }()
+
+	players := []types.PlayerDetail{{ID: 2, Name: "Southclaws", Score: 10, Ping: 30}}
+	tests := []struct {
+		name        string
+		address     string
+		stored      bool
+		password    bool
+		err         error
+		wantStatus  int
+		wantPlayers []types.PlayerDetail
+	}{
+		{"ok", "127.0.0.1:7777", true, false, nil, http.StatusOK, players},
+		{"unavailable", "127.0.0.1:7777", true, false, errors.Wrap(query.ErrPlayerListUnavailable, "truncated"), http.StatusOK, []types.PlayerDetail{}},
+		{"passworded", "127.0.0.1:7777", true, true, nil, http.StatusOK, []types.PlayerDetail{}},
+		{"timeout", "127.0.0.1:7777", true, false, context.DeadlineExceeded, http.StatusGatewayTimeout, nil},
+		{"unreachable", "127.0.0.1:7777", true, false, errors.New("connection refused"), http.StatusBadGateway, nil},
+		{"not found", "127.0.0.1:7777", false, false, nil, http.StatusNotFound, nil},
+		{"invalid address", "127.0.0.1:99999", false, false, nil, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			router, cancel := newTestRouter(t, store)
+			defer cancel()
+
+			if tt.stored {
+				assert.NoError(t, store.UpsertServer(types.Server{
+					Core: types.ServerCore{Address: tt.address, Players: 1, Password: tt.password},
+				}))
+			}
+
+			queried := false
+			queryDetailedPlayers = func(ctx context.Context, address string, opts query.QueryOptions) ([]types.PlayerDetail, error) {
+				queried = true
+				return players, tt.err
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/server/"+tt.address+"/players", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.stored && !tt.password, queried)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []types.PlayerDetail
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.wantPlayers, got)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package v2
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// queryRaw is swapped out in tests in the same way as queryServer
+var queryRaw = query.QueryRaw
+
+// serverRaw sends a single query with the opcode parameter to a server and responds with the
+// payload of the response as it was received, for debugging servers whose responses fail to parse.
+// The opcode defaults to 'i'.
+func (v *V2) serverRaw(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, errs := v.addressForStorage(address)
+	if errs != nil {
+		WriteErrors(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	name := r.URL.Query().Get("opcode")
+	if name == "" {
+		name = string(query.Info)
+	}
+	opcode, err := query.ParseOpcode(name)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), v.Config.LiveTimeout)
+	defer cancel()
+
+	payload, err := queryRaw(ctx, address, opcode, v.QueryOptions(address))
+	if err != nil {
+		switch {
+		case errors.Cause(err) == query.ErrTooManyQueries:
+			WriteError(w, http.StatusServiceUnavailable, errors.Wrap(err, "too busy to query server"))
+		case query.IsTimeout(err):
+			WriteError(w, http.StatusGatewayTimeout, errors.Wrap(err, "server did not respond in time"))
+		default:
+			WriteError(w, http.StatusBadGateway, errors.Wrap(err, "failed to query server"))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(types.RawQuery{
+		Address: address,
+		Opcode:  string(opcode),
+		Length:  len(payload),
+		Payload: hex.EncodeToString(payload),
+	})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
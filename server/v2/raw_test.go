@@ -0,0 +1,75 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerRaw(t *testing.T) {
+	defer func() { queryRaw = query.QueryRaw }()
+
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantRaw    types.RawQuery
+	}{
+		{"default opcode", "", nil, http.StatusOK, types.RawQuery{Address: "127.0.0.1:7777", Opcode: "i", Length: 3, Payload: "690102"}},
+		{"rules", "?opcode=r", nil, http.StatusOK, types.RawQuery{Address: "127.0.0.1:7777", Opcode: "r", Length: 3, Payload: "720102"}},
+		{"unknown opcode", "?opcode=x", nil, http.StatusBadRequest, types.RawQuery{}},
+		{"timeout", "?opcode=c", context.DeadlineExceeded, http.StatusGatewayTimeout, types.RawQuery{}},
+		{"unreachable", "?opcode=d", errors.New("connection refused"), http.StatusBadGateway, types.RawQuery{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, cancel := newTestRouter(t, storage.NewMemoryStore())
+			defer cancel()
+
+			queryRaw = func(ctx context.Context, address string, opcode query.Opcode, opts query.QueryOptions) ([]byte, error) {
+				return []byte{byte(opcode), 1, 2}, tt.err
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/server/127.0.0.1:7777/raw"+tt.query, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got types.RawQuery
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.wantRaw, got)
+		})
+	}
+}
+
+func TestServerRaw_QueryOptions(t *testing.T) {
+	defer func() { queryRaw = query.QueryRaw }()
+
+	var got query.QueryOptions
+	queryRaw = func(ctx context.Context, address string, opcode query.Opcode, opts query.QueryOptions) ([]byte, error) {
+		got = opts
+		return []byte{byte(opcode)}, nil
+	}
+	v := Init(storage.NewMemoryStore(), nil, types.Config{}, Deps{QueryOptions: func(address string) query.QueryOptions {
+		return query.QueryOptions{QueryPort: 7778, Transport: query.TransportTCP}
+	}})
+
+	r := mux.SetURLVars(httptest.NewRequest("GET", "/server/127.0.0.1:7777/raw", nil), map[string]string{"address": "127.0.0.1:7777"})
+	w := httptest.NewRecorder()
+	v.serverRaw(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, query.QueryOptions{QueryPort: 7778, Transport: query.TransportTCP}, got)
+}
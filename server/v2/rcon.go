@@ -0,0 +1,80 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// queryRCON is swapped out in tests in the same way as queryServer
+var queryRCON = query.RCON
+
+// serverRCON runs a console command on a server. Since the body contains the server's RCON
+// password, neither the body nor the password are ever logged or included in an error.
+func (v *V2) serverRCON(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, errs := v.addressForStorage(address)
+	if errs != nil {
+		WriteErrors(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	var rcon types.RCONCommand
+	status, err := v.decodeBody(w, r, &rcon)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	errs = rcon.Validate()
+	if errs != nil {
+		WriteErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	output, err := v.RCON(r.Context(), address, rcon.Password, rcon.Command)
+	if err != nil {
+		switch {
+		case err == query.ErrInvalidRCONPassword:
+			WriteError(w, http.StatusForbidden, err)
+		case errors.Cause(err) == query.ErrTooManyQueries:
+			WriteError(w, http.StatusServiceUnavailable, errors.Wrap(err, "too busy to query server"))
+		case query.IsTimeout(err):
+			WriteError(w, http.StatusGatewayTimeout, errors.Wrap(err, "server did not respond in time"))
+		default:
+			WriteError(w, http.StatusBadGateway, errors.Wrap(err, "failed to send command"))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(types.RCONOutput{Output: output})
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// rcon is the RCONFunc used when none is provided
+func (v *V2) rcon(ctx context.Context, address, password, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.Config.LiveTimeout)
+	defer cancel()
+
+	output, err := queryRCON(ctx, address, password, command, v.QueryOptions(address))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(output, "\n"), nil
+}
@@ -0,0 +1,60 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerRCON(t *testing.T) {
+	defer func() { queryRCON = query.RCON }()
+
+	tests := []struct {
+		name       string
+		body       string
+		output     []string
+		err        error
+		wantStatus int
+		wantOutput string
+	}{
+		{"ok", `{"password":"changeme","command":"players"}`, []string{"ID\tName", "0\tSouthclaws"}, nil, http.StatusOK, "ID\tName\n0\tSouthclaws"},
+		{"no output", `{"password":"changeme","command":"gmx"}`, []string{}, nil, http.StatusOK, ""},
+		{"wrong password", `{"password":"wrong","command":"players"}`, nil, query.ErrInvalidRCONPassword, http.StatusForbidden, ""},
+		{"timeout", `{"password":"changeme","command":"players"}`, nil, context.DeadlineExceeded, http.StatusGatewayTimeout, ""},
+		{"unreachable", `{"password":"changeme","command":"players"}`, nil, errors.New("connection refused"), http.StatusBadGateway, ""},
+		{"missing command", `{"password":"changeme"}`, nil, nil, http.StatusUnprocessableEntity, ""},
+		{"malformed", `{"password":`, nil, nil, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, cancel := newTestRouter(t, storage.NewMemoryStore())
+			defer cancel()
+
+			queryRCON = func(ctx context.Context, address, password, command string, opts query.QueryOptions) ([]string, error) {
+				return tt.output, tt.err
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("POST", "/server/127.0.0.1:7777/rcon", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.NotContains(t, w.Body.String(), "changeme")
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got types.RCONOutput
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.wantOutput, got.Output)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// weights applied to each query token found in a server's fields when scoring search results
+const (
+	hostnameWeight = 2
+	gamemodeWeight = 1
+)
+
+// serverSearch returns servers where every whitespace-separated token in the `q` parameter appears
+// in either the hostname or gamemode, ordered by relevance.
+func (v *V2) serverSearch(w http.ResponseWriter, r *http.Request) {
+	tokens := strings.Fields(strings.ToLower(r.URL.Query().Get("q")))
+	if len(tokens) == 0 {
+		WriteError(w, http.StatusBadRequest, errors.New("no search query specified"))
+		return
+	}
+
+	servers, err := v.Storage.SearchServers(tokens)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to search servers"))
+		return
+	}
+
+	results := make([]types.SearchResult, len(servers))
+	for i := range servers {
+		results[i] = types.SearchResult{
+			ServerCore: servers[i].Core,
+			Score:      searchScore(servers[i].Core, tokens),
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Players > results[j].Players
+		}
+		return results[i].Score > results[j].Score
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(results)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to encode response"))
+		return
+	}
+}
+
+// searchScore weights each token found in the hostname higher than those found in the gamemode
+func searchScore(core types.ServerCore, tokens []string) (score int) {
+	hostname := strings.ToLower(core.Hostname)
+	gamemode := strings.ToLower(core.Gamemode)
+	for _, token := range tokens {
+		if strings.Contains(hostname, token) {
+			score += hostnameWeight
+		}
+		if strings.Contains(gamemode, token) {
+			score += gamemodeWeight
+		}
+	}
+	return
+}
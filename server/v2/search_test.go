@@ -0,0 +1,32 @@
+package v2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func Test_searchScore(t *testing.T) {
+	core := types.ServerCore{
+		Hostname: "Los Santos Cops and Robbers",
+		Gamemode: "Cops n Robbers v2",
+	}
+	tests := []struct {
+		name      string
+		tokens    []string
+		wantScore int
+	}{
+		{"both", []string{"cops"}, hostnameWeight + gamemodeWeight},
+		{"hostname", []string{"santos"}, hostnameWeight},
+		{"gamemode", []string{"v2"}, gamemodeWeight},
+		{"multiple", []string{"cops", "santos", "v2"}, hostnameWeight*2 + gamemodeWeight*2},
+		{"none", []string{"roleplay"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantScore, searchScore(core, tt.tokens))
+		})
+	}
+}
@@ -2,66 +2,188 @@ package v2
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/server/realip"
+	"github.com/Southclaws/samp-servers-api/storage"
 	"github.com/Southclaws/samp-servers-api/types"
 )
 
 // serverAdd handles "simple" posts where the only data is the server address which is passed to
-// the QueryDaemon which handles pulling the rest of the information from the legacy query API.
+// the QueryDaemon which handles pulling the rest of the information from the legacy query API. A
+// server object can be posted along with it, in which case it's handled the same as serverPost.
+// The address in the path takes precedence, the body's address must be the same server or empty.
 func (v *V2) serverAdd(w http.ResponseWriter, r *http.Request) {
 	address, ok := mux.Vars(r)["address"]
 	if !ok {
 		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
 	}
 
-	normalised, errs := types.AddressFromString(address)
+	normalised, errs := v.addressForStorage(address)
 	if errs != nil {
 		WriteErrors(w, http.StatusBadRequest, errs)
 		return
 	}
 
-	v.Scraper.Add(normalised)
+	server := types.Server{}
+	status, err := v.decodeBody(w, r, &server)
+	if err == io.EOF {
+		status, err = v.checkBlocked(normalised)
+		if err != nil {
+			WriteError(w, status, err)
+			return
+		}
+		v.Scraper.Add(normalised)
+		return
+	} else if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	if server.Core.Address != "" {
+		posted, errs := v.addressForStorage(server.Core.Address)
+		if errs != nil || posted != normalised {
+			WriteError(w, http.StatusBadRequest,
+				errors.Errorf("address '%s' in the body does not match '%s' in the path", server.Core.Address, address))
+			return
+		}
+	}
+	server.Core.Address = address
+
+	v.postServer(w, r, server)
 }
 
-// serverPost handles posting a server object
-func (v *V2) serverPost(w http.ResponseWriter, r *http.Request) {
-	from := strings.Split(r.RemoteAddr, ":")[0]
+// checkBlocked returns an error if an address is on the blocklist, along with the status code that
+// best describes it
+func (v *V2) checkBlocked(address string) (status int, err error) {
+	blocked, err := storage.IsBlocked(v.Storage, address)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if blocked {
+		return http.StatusForbidden, errors.Errorf("server '%s' is blocked", address)
+	}
+	return http.StatusOK, nil
+}
 
+// addressForStorage validates and normalises an address that's about to be indexed. When StrictIP
+// or ResolveHosts is enabled, hostnames are either rejected or resolved so only IPv4 addresses end
+// up stored. Either way the result is in the same canonical form as types.NormalizeAddress.
+func (v *V2) addressForStorage(address string) (string, []error) {
+	if !v.Config.StrictIP && !v.Config.ResolveHosts {
+		return types.AddressFromString(address)
+	}
+	normalised, others, errs := types.AddressFromStringStrict(address, v.Config.ResolveHosts && !v.Config.StrictIP)
+	if others != nil {
+		v.Logger.Info("hostname has several IPv4 addresses, using the first",
+			zap.String("address", address),
+			zap.String("using", normalised),
+			zap.Strings("others", others))
+	}
+	return normalised, errs
+}
+
+// serverPost handles posting a server object, the address is taken from the body
+func (v *V2) serverPost(w http.ResponseWriter, r *http.Request) {
 	server := types.Server{}
-	err := json.NewDecoder(r.Body).Decode(&server)
+	status, err := v.decodeBody(w, r, &server)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, err)
+		WriteError(w, status, err)
 		return
 	}
 
+	v.postServer(w, r, server)
+}
+
+// postServer checks and stores a posted server then queues it to be queried
+func (v *V2) postServer(w http.ResponseWriter, r *http.Request, server types.Server) {
+	status, errs := v.prepareServer(r, &server)
+	if errs != nil {
+		WriteErrors(w, status, errs)
+		return
+	}
+
+	err := v.storeServer(&server)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	v.Scraper.Add(server.Core.Address)
+}
+
+// prepareServer performs all the checks on a posted server before it's stored and fills in the
+// fields that are derived by the API rather than supplied by the poster. If the server is rejected,
+// the errors are returned along with the status code that best describes them.
+func (v *V2) prepareServer(r *http.Request, server *types.Server) (status int, errs []error) {
 	if v.Config.VerifyByHost {
+		from := realip.ClientIP(r)
 		addressIP := strings.Split(server.Core.Address, ":")[0]
 		if from != addressIP {
-			WriteError(w, http.StatusBadRequest,
-				errors.Errorf("request address '%v' does not match declared server address '%s'", from, addressIP))
-			return
+			return http.StatusBadRequest, []error{
+				errors.Errorf("request address '%v' does not match declared server address '%s'", from, addressIP),
+			}
 		}
 	}
 
-	errs := server.Validate()
+	errs = server.Validate()
 	if errs != nil {
-		WriteErrors(w, http.StatusUnprocessableEntity, errs)
-		return
+		return http.StatusUnprocessableEntity, errs
 	}
 
-	server.Active = true
+	errs = server.Core.Allowed(v.Config.AllowedGamemodes, v.Config.AllowedLanguages)
+	if errs != nil {
+		return http.StatusUnprocessableEntity, errs
+	}
+
+	normalised, errs := v.addressForStorage(server.Core.Address)
+	if errs != nil {
+		return http.StatusUnprocessableEntity, errs
+	}
+	server.Core.Address = normalised
+	server.NormalizeQueryPort()
 
-	err = v.Storage.UpsertServer(server)
+	status, err := v.checkBlocked(server.Core.Address)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, err)
+		return status, []error{err}
 	}
 
-	v.Scraper.Add(server.Core.Address)
+	if !v.Config.SkipVerifyPosted && r.URL.Query().Get("verify") != "false" {
+		opts := v.QueryOptions(server.Core.Address)
+		if server.QueryPort != 0 {
+			opts.QueryPort = server.QueryPort // the posted one, which may not have been stored yet
+		}
+		err := query.VerifyServer(r.Context(), *server, opts)
+		if err != nil {
+			return http.StatusUnprocessableEntity, []error{errors.Wrap(err, "failed to verify server")}
+		}
+	}
+
+	server.Active = true
+	server.LastSeen = nil // only a successful query counts as seeing the server
+	server.Online = false
+	server.Ping = 0
+	server.Aliases = nil // aliases are only recorded when the API merges duplicates
+	server.DeadSince = nil
+	server.ConsecutiveFailures = 0
+	server.SuspectFakePlayers = false // only queries of the server can tell
+	server.Core.HostnameClean = types.CleanHostname(server.Core.Hostname)
+	server.Core.Languages = types.NormalizeLanguage(server.Core.Language)
+	server.OpenMP = types.DetectOpenMP(server.Rules)
+	if v.Locate != nil {
+		server.Country = v.Locate(server.Core.Address)
+	}
+
+	return http.StatusOK, nil
 }
 
 // serverGet handles responding to a request by server address
@@ -69,31 +191,86 @@ func (v *V2) serverGet(w http.ResponseWriter, r *http.Request) {
 	address, ok := mux.Vars(r)["address"]
 	if !ok {
 		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
 	}
 
-	var err error
+	address, err := types.NormalizeAddress(address)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
 
-	_, errs := types.AddressFromString(address)
-	if errs != nil {
-		WriteErrors(w, http.StatusBadRequest, errs)
+	server, err := v.Storage.GetServer(address)
+	if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.Errorf("could not find server by address '%s'", address))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	format, err := negotiateFormat(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	envelope, err := wantsEnvelope(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	envelope = envelope && format != formatXML
+
+	server.CheckOnline(time.Now(), v.Config.OfflineAfter)
+	server.HidePrivate()
+
+	fields := sparseFields(r)
+	etag := serverETag(server, format, fields, envelope)
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Accept")
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	server, found, err := v.Storage.GetServer(address)
+	if format == formatXML {
+		err = writeXML(w, "server", server)
+	} else if envelope {
+		err = writeEnvelope(w, presentFields(server, true, format, fields), types.EnvelopeMeta{Count: 1})
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(presentFields(server, true, format, fields))
+	}
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+}
 
-	if !found {
-		WriteError(w, http.StatusNotFound, errors.Errorf("could not find server by address '%s'", address))
+// serverDelete handles removing a server from the index by address
+func (v *V2) serverDelete(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(&server)
+	address, err := types.NormalizeAddress(address)
 	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = v.Storage.RemoveServer(address)
+	if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.Errorf("could not find server by address '%s'", address))
+		return
+	} else if err != nil {
 		WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+
+	v.Scraper.Forget(address)
+
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,531 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/query"
+	"github.com/Southclaws/samp-servers-api/scraper"
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// newTestRouter builds the v2 routes on top of store and a scraper that never gets a response from
+// anything it queries.
+func newTestRouter(t *testing.T, store storage.Store) (router *mux.Router, cancel context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sc, err := scraper.New(ctx, nil, scraper.Config{
+		QueryInterval: time.Hour,
+		MaxFailed:     1,
+		QueryFunction: func(context.Context, string) (types.Server, error) {
+			return types.Server{}, errors.New("offline")
+		},
+		OnRequestArchive: func(string) {},
+		OnRequestRemove:  func(string) {},
+		OnRequestUpdate:  func(types.Server) {},
+		Registerer:       prometheus.NewRegistry(),
+	})
+	require.NoError(t, err)
+
+	v := Init(store, sc, types.Config{OfflineAfter: time.Minute, LiveTimeout: time.Second, SkipVerifyPosted: true}, Deps{})
+
+	router = mux.NewRouter()
+	for _, route := range v.Routes() {
+		router.Methods(route.Method).Path(route.Path).Name(route.Name).HandlerFunc(route.Handler)
+	}
+	return
+}
+
+// jsonRequest builds a request with a body declared as JSON like every endpoint that accepts a body
+// expects
+func jsonRequest(method, target string, body io.Reader) *http.Request {
+	r := httptest.NewRequest(method, target, body)
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestServerLifecycle(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	server := types.Server{}.Example()
+	payload, err := json.Marshal(server)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, jsonRequest("POST", "/server", bytes.NewReader(payload)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	_, err = store.GetServer(server.Core.Address)
+	assert.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/server/"+server.Core.Address, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got types.Server
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, server.Core, got.Core)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/server/"+server.Core.Address, nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/server/"+server.Core.Address, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/server/"+server.Core.Address, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServerPostDerivedFields(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	body := `{"core":{"ip":"127.0.0.1:7777","hn":"SA:MP Server","pc":1,"pm":32,"gm":"Grand Larceny","la":"English","pa":false,"vn":"0.3.7"},` +
+		`"ls":"2030-01-01T00:00:00Z","on":true,"pi":1,"ds":"2018-01-01T00:00:00Z","cf":3}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, jsonRequest("POST", "/server", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	server, err := store.GetServer("127.0.0.1:7777")
+	require.NoError(t, err)
+	assert.Nil(t, server.LastSeen)
+	assert.False(t, server.Online)
+	assert.Zero(t, server.Ping)
+	assert.Nil(t, server.DeadSince)
+	assert.Zero(t, server.ConsecutiveFailures)
+}
+
+func TestServerPostInvalid(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, jsonRequest("POST", "/server", bytes.NewBufferString(`{"core":{"ip":"test.com:7777"}}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	active, err := store.GetActiveServers()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, active)
+}
+
+func TestServerPostAddress(t *testing.T) {
+	server := func(ip string) string {
+		return `{"core":{"ip":"` + ip + `","hn":"test","pc":4,"pm":32,"gm":"test"},"description":"posted"}`
+	}
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantStored string
+	}{
+		{"body only", "/server", server("127.0.0.1:7777"), http.StatusOK, "127.0.0.1:7777"},
+		{"path and matching body", "/server/127.0.0.1:7777", server("127.0.0.1"), http.StatusOK, "127.0.0.1:7777"},
+		{"path and no address in body", "/server/127.0.0.1:7777", server(""), http.StatusOK, "127.0.0.1:7777"},
+		{"path and different body", "/server/127.0.0.1:7777", server("127.0.0.2:7777"), http.StatusBadRequest, ""},
+		{"path only", "/server/127.0.0.1:7777", "", http.StatusOK, ""},
+		{"no address", "/server", server(""), http.StatusUnprocessableEntity, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			router, cancel := newTestRouter(t, store)
+			defer cancel()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("POST", tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+
+			addresses, err := store.LoadAllAddresses()
+			require.NoError(t, err)
+			if tt.wantStored == "" {
+				assert.Empty(t, addresses)
+				return
+			}
+			assert.Equal(t, []string{tt.wantStored}, addresses)
+			stored, err := store.GetServer(tt.wantStored)
+			require.NoError(t, err)
+			assert.Equal(t, "posted", stored.Description)
+		})
+	}
+}
+
+func TestServerPostAllowed(t *testing.T) {
+	v := Init(storage.NewMemoryStore(), nil, types.Config{
+		AllowedGamemodes: []string{"roleplay", "rp"},
+		AllowedLanguages: []string{"english"},
+		SkipVerifyPosted: true,
+	}, Deps{})
+	tests := []struct {
+		name       string
+		gamemode   string
+		language   string
+		wantStatus int
+		wantFields []string
+	}{
+		{"allowed", "Los Santos Roleplay", "English", http.StatusOK, nil},
+		{"abbreviated", "LS-RP", "English/Polski", http.StatusOK, nil},
+		{"gamemode", "Grand Larceny", "English", http.StatusUnprocessableEntity, []string{"gamemode"}},
+		{"language", "Roleplay", "Russian", http.StatusUnprocessableEntity, []string{"language"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := types.Server{}.Example()
+			server.Core.Gamemode = tt.gamemode
+			server.Core.Language = tt.language
+
+			status, errs := v.prepareServer(httptest.NewRequest("POST", "/server", nil), &server)
+			assert.Equal(t, tt.wantStatus, status)
+			var fields []string
+			for _, err := range types.ValidationErrors(errs) {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+func TestServerPostVerify(t *testing.T) {
+	fast := Deps{QueryOptions: func(string) query.QueryOptions {
+		return query.QueryOptions{Timeout: time.Millisecond * 50, Retries: 1}
+	}}
+	tests := []struct {
+		name       string
+		skip       bool
+		target     string
+		wantStatus int
+	}{
+		{"verified by default", false, "/server", http.StatusUnprocessableEntity},
+		{"skipped by request", false, "/server?verify=false", http.StatusOK},
+		{"skipped by config", true, "/server", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Init(storage.NewMemoryStore(), nil, types.Config{SkipVerifyPosted: tt.skip}, fast)
+			server := types.Server{}.Example()
+			server.Core.Address = "127.0.0.1:7777" // nothing answers there
+
+			status, _ := v.prepareServer(httptest.NewRequest("POST", tt.target, nil), &server)
+			assert.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
+
+func TestServerPostUnknownFields(t *testing.T) {
+	body := `{"core":{"ipaddr":"127.0.0.1:7777","ip":"127.0.0.1:7777","hn":"test","pm":32,"gm":"test"}}`
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantError  string
+	}{
+		{"strict", "", http.StatusBadRequest, `json: unknown field "ipaddr"`},
+		{"lenient", "?lenient=true", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			router, cancel := newTestRouter(t, store)
+			defer cancel()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("POST", "/server"+tt.query, strings.NewReader(body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantError != "" {
+				var got errorResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.wantError, got.Error)
+			}
+		})
+	}
+}
+
+func TestServerPostTooLarge(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	oversized := `{"core":{"ip":"127.0.0.1:7777"},"description":"` + strings.Repeat("a", DefaultMaxBodySize) + `"}`
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"server", "/server", oversized},
+		{"bulk", "/servers", "[" + oversized + "]"},
+		{"webhook", "/webhooks", oversized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("POST", tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+			var got errorResponse
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, "request body exceeds 65536 bytes", got.Error)
+		})
+	}
+
+	active, err := store.GetActiveServers()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, active)
+}
+
+func TestServerPostContentType(t *testing.T) {
+	router, cancel := newTestRouter(t, storage.NewMemoryStore())
+	defer cancel()
+
+	body := `{"core":{"ip":"127.0.0.1:7777","hn":"SA:MP Server","pc":1,"pm":32,"gm":"Grand Larceny","la":"English","pa":false,"vn":"0.3.7"}}`
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"json", "/server", "application/json", body, http.StatusOK},
+		{"charset", "/server", "application/json; charset=utf-8", body, http.StatusOK},
+		{"text", "/server", "text/plain", body, http.StatusUnsupportedMediaType},
+		{"form", "/server", "application/x-www-form-urlencoded", body, http.StatusUnsupportedMediaType},
+		{"missing", "/server", "", body, http.StatusUnsupportedMediaType},
+		{"invalid", "/server", "application/json; charset", body, http.StatusUnsupportedMediaType},
+		{"no body", "/server/127.0.0.1:7777", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestDecodeBody_AllowNoContentType(t *testing.T) {
+	v := Init(storage.NewMemoryStore(), nil, types.Config{AllowNoContentType: true, SkipVerifyPosted: true}, Deps{})
+
+	var into map[string]string
+	status, err := v.decodeBody(httptest.NewRecorder(), httptest.NewRequest("POST", "/server", strings.NewReader(`{"a":"b"}`)), &into)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]string{"a": "b"}, into)
+
+	r := httptest.NewRequest("POST", "/server", strings.NewReader(`{"a":"b"}`))
+	r.Header.Set("Content-Type", "text/plain")
+	status, _ = v.decodeBody(httptest.NewRecorder(), r, &into)
+	assert.Equal(t, http.StatusUnsupportedMediaType, status)
+}
+
+// brokenStore fails every lookup the way a database outage would
+type brokenStore struct {
+	storage.Store
+}
+
+func (brokenStore) GetServer(string) (types.Server, error) {
+	return types.Server{}, errors.New("no reachable servers")
+}
+
+func TestServerGetErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      storage.Store
+		wantStatus int
+	}{
+		{"missing", storage.NewMemoryStore(), http.StatusNotFound},
+		{"broken", brokenStore{}, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, cancel := newTestRouter(t, tt.store)
+			defer cancel()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/server/127.0.0.1:7777", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestServerNoAddress(t *testing.T) {
+	v := Init(storage.NewMemoryStore(), nil, types.Config{}, Deps{})
+	for _, route := range v.Routes() {
+		if !strings.Contains(route.Path, "{address}") {
+			continue
+		}
+		t.Run(route.Name, func(t *testing.T) {
+			// calling the handler directly leaves the route vars empty
+			w := httptest.NewRecorder()
+			route.Handler(w, httptest.NewRequest(route.Method, "/server/", nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var got errorResponse
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, "no address specified", got.Error)
+			assert.False(t, w.Body.Len() > 0, "only the error should be written")
+		})
+	}
+}
+
+func TestServerListSort(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	for _, core := range []types.ServerCore{
+		{Address: "b.example.com:7777", Hostname: "bravo", Players: 10},
+		{Address: "a.example.com:7777", Hostname: "alpha", Players: 10},
+		{Address: "c.example.com:7777", Hostname: "charlie", Players: 20},
+	} {
+		assert.NoError(t, store.UpsertServer(types.Server{Core: core}))
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		wantStatus    int
+		wantHostnames []string
+	}{
+		{"default", "", http.StatusOK, []string{"charlie", "bravo", "alpha"}},
+		{"players", "?sort=players", http.StatusOK, []string{"bravo", "alpha", "charlie"}},
+		{"name", "?sort=name", http.StatusOK, []string{"alpha", "bravo", "charlie"}},
+		{"unknown", "?sort=mapname", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/servers"+tt.query, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []types.ServerCore
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			var gotHostnames []string
+			for _, core := range got {
+				gotHostnames = append(gotHostnames, core.Hostname)
+			}
+			assert.Equal(t, tt.wantHostnames, gotHostnames)
+		})
+	}
+}
+
+func TestServerListPassworded(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	for _, server := range []types.Server{
+		{Core: types.ServerCore{Address: "a.example.com:7777", Players: 2}, PlayerList: []string{"Southclaws", "Dogmeat"}},
+		{Core: types.ServerCore{Address: "b.example.com:7777", Players: 1, Password: true}, PlayerList: []string{"Hidden"}},
+	} {
+		assert.NoError(t, store.UpsertServer(server))
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantLists  map[string][]string
+	}{
+		{"default", "?full=true", http.StatusOK, map[string][]string{
+			"a.example.com:7777": {"Southclaws", "Dogmeat"},
+			"b.example.com:7777": nil,
+		}},
+		{"excluded", "?full=true&includePassworded=false", http.StatusOK, map[string][]string{
+			"a.example.com:7777": {"Southclaws", "Dogmeat"},
+		}},
+		{"password takes precedence", "?full=true&includePassworded=false&password=true", http.StatusOK, map[string][]string{
+			"b.example.com:7777": nil,
+		}},
+		{"invalid", "?includePassworded=maybe", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/servers"+tt.query, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []types.Server
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			gotLists := make(map[string][]string)
+			for _, server := range got {
+				gotLists[server.Core.Address] = server.PlayerList
+			}
+			assert.Equal(t, tt.wantLists, gotLists)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/server/b.example.com:7777", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "Hidden")
+}
+
+func TestServerListDead(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	assert.NoError(t, store.UpsertServer(types.Server{Core: types.ServerCore{Address: "alive.example.com:7777"}}))
+	assert.NoError(t, store.UpsertServer(types.Server{Core: types.ServerCore{Address: "dead.example.com:7777"}}))
+	assert.NoError(t, store.MarkDead("dead.example.com:7777", time.Now()))
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{"default", "", http.StatusOK, []string{"alive.example.com:7777"}},
+		{"excluded", "?includeDead=false", http.StatusOK, []string{"alive.example.com:7777"}},
+		{"included", "?includeDead=true", http.StatusOK, []string{"alive.example.com:7777", "dead.example.com:7777"}},
+		{"invalid", "?includeDead=maybe", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/servers"+tt.query, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got []types.ServerCore
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			var addresses []string
+			for _, core := range got {
+				addresses = append(addresses, core.Address)
+			}
+			assert.Equal(t, tt.want, addresses)
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package v2
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/dyninc/qstring"
@@ -10,23 +9,131 @@ import (
 	"github.com/Southclaws/samp-servers-api/types"
 )
 
-// Servers returns a JSON encoded array of available servers
+// Servers returns a JSON encoded array of available servers. By default only the core fields of
+// each server are listed, `full=true` lists the entire server objects instead. The array is written
+// as each server is read from the database rather than being assembled in memory first. JSON
+// listings can be limited to a sparse fieldset with `fields`, see projectServer, and wrapped in an
+// envelope with `envelope=true`.
 func (v *V2) serverList(w http.ResponseWriter, r *http.Request) {
-	var params types.ServerListParams
-	err := qstring.Unmarshal(r.URL.Query(), &params)
+	params, status, err := listParams(r)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "invalid parameters"))
+		WriteError(w, status, err)
 		return
 	}
+	format, err := negotiateListFormat(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	envelope, err := wantsEnvelope(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	envelope = envelope && (format == formatJSON || format == formatVerbose)
+	w.Header().Add("Vary", "Accept")
+	fields := sparseFields(r)
+
+	if params.Paginated() {
+		v.serverPage(w, params, format, fields, envelope)
+		return
+	}
+
+	w.Header().Set("Content-Type", listCodecs[format].contentType)
+	stream := newListStream(w, format)
+	if envelope {
+		stream = newEnvelopeStream(w)
+	}
+	err = v.Storage.StreamServers(params, func(server types.Server) error {
+		if params.Full {
+			server.HidePrivate()
+		}
+		return stream.Write(presentFields(server, params.Full, format, fields))
+	})
+	if err != nil {
+		if !stream.Started() {
+			WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get servers"))
+		}
+		return
+	}
+
+	stream.Close() // nolint:errcheck
+}
 
-	servers, err := v.Storage.GetServers(params.Page, params.PageSize, params.Sort, params.By, params.Filters)
+// listParams reads the list parameters of a request and checks them before anything is queried so
+// invalid parameters result in a 400 rather than a partially written response
+func listParams(r *http.Request) (params types.ServerListParams, status int, err error) {
+	err = qstring.Unmarshal(r.URL.Query(), &params)
+	if err != nil {
+		return params, http.StatusInternalServerError, errors.Wrap(err, "invalid parameters")
+	}
+
+	_, _, err = params.Order()
+	if err != nil {
+		return params, http.StatusBadRequest, err
+	}
+	_, err = params.PasswordFilter()
+	if err != nil {
+		return params, http.StatusBadRequest, err
+	}
+	_, err = params.FeaturedFirst()
+	if err != nil {
+		return params, http.StatusBadRequest, err
+	}
+	_, err = params.IncludesDead()
+	if err != nil {
+		return params, http.StatusBadRequest, err
+	}
+	_, err = params.ForkFilter()
+	if err != nil {
+		return params, http.StatusBadRequest, err
+	}
+	return params, http.StatusOK, nil
+}
+
+// serverPage responds with a single page of a cursor-based listing. One more server than the limit
+// is requested so the presence of a following page can be determined without a second query.
+func (v *V2) serverPage(w http.ResponseWriter, params types.ServerListParams, format responseFormat, fields []string, envelope bool) {
+	_, err := types.DecodeCursor(params.Cursor)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := params.ClampedLimit()
+	params.Limit = limit + 1
+
+	page := types.ServerPage{Servers: make([]interface{}, 0, limit)}
+	var last string
+	more := false
+	err = v.Storage.StreamServers(params, func(server types.Server) error {
+		if len(page.Servers) == limit {
+			more = true
+			return nil
+		}
+		if params.Full {
+			server.HidePrivate()
+		}
+		page.Servers = append(page.Servers, presentFields(server, params.Full, format, fields))
+		last = server.Core.Address
+		return nil
+	})
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get servers"))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(servers)
+	if more {
+		page.Next = types.EncodeCursor(last)
+	}
+
+	if envelope {
+		err = writeEnvelope(w, page.Servers, types.EnvelopeMeta{Count: len(page.Servers), Next: page.Next})
+	} else {
+		codec := listCodecs[format]
+		w.Header().Set("Content-Type", codec.contentType)
+		err = codec.page(w, page)
+	}
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to encode response"))
 		return
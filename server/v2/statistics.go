@@ -19,10 +19,28 @@ func (v *V2) serverStats(w http.ResponseWriter, r *http.Request) {
 	stats.Servers, err = v.Storage.GetActiveServers()
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get servers"))
+		return
 	}
 	stats.Players, err = v.Storage.GetTotalPlayers()
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get servers"))
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get players"))
+		return
+	}
+	stats.Online, err = v.Storage.GetOnlineServers()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get online servers"))
+		return
+	}
+	stats.Offline = stats.Servers - stats.Online
+	stats.Languages, err = v.Storage.GetServersPerLanguage()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get languages"))
+		return
+	}
+	stats.Gamemodes, err = v.Storage.GetServersPerGamemode()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get gamemodes"))
+		return
 	}
 
 	if stats.Servers > 0 {
@@ -0,0 +1,40 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverStatus responds with whether a server is online and how many players it has from the
+// stored copy, the server is never queried so it's cheap enough to poll
+func (v *V2) serverStatus(w http.ResponseWriter, r *http.Request) {
+	address, ok := mux.Vars(r)["address"]
+	if !ok {
+		WriteError(w, http.StatusBadRequest, errors.New("no address specified"))
+		return
+	}
+
+	address, err := types.NormalizeAddress(address)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	server, err := v.Storage.GetServer(address)
+	if err == storage.ErrNotFound || (err == nil && server.LastSeen == nil) {
+		WriteError(w, http.StatusNotFound, errors.Errorf("server '%s' has never been seen", address))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.CheckOnline(time.Now(), v.Config.OfflineAfter)
+	writeJSON(w, http.StatusOK, server.Status())
+}
@@ -0,0 +1,57 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerStatus(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	recent := time.Now().Add(-time.Second).UTC().Truncate(time.Second)
+	stale := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	for _, server := range []types.Server{
+		{Core: types.ServerCore{Address: "online.example.com:7777", Players: 12}, LastSeen: &recent, Ping: 40},
+		{Core: types.ServerCore{Address: "offline.example.com:7777", Players: 12}, LastSeen: &stale, Ping: 40},
+		{Core: types.ServerCore{Address: "unseen.example.com:7777"}},
+	} {
+		require.NoError(t, store.UpsertServer(server))
+	}
+
+	tests := []struct {
+		name       string
+		address    string
+		wantStatus int
+		want       types.ServerStatus
+	}{
+		{"online", "online.example.com", http.StatusOK, types.ServerStatus{Online: true, Players: 12, Ping: 40, LastSeen: &recent}},
+		{"offline", "offline.example.com:7777", http.StatusOK, types.ServerStatus{LastSeen: &stale}},
+		{"never seen", "unseen.example.com:7777", http.StatusNotFound, types.ServerStatus{}},
+		{"not stored", "missing.example.com:7777", http.StatusNotFound, types.ServerStatus{}},
+		{"invalid", "missing.example.com:80", http.StatusBadRequest, types.ServerStatus{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/server/"+tt.address+"/status", nil))
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got types.ServerStatus
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
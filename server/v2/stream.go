@@ -0,0 +1,206 @@
+package v2
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// listCodec encodes the server list in one of the response formats, either streamed one server at
+// a time or as a page. Supporting another format for the list only takes adding its codec to
+// listCodecs and negotiating it in negotiateListFormat.
+type listCodec struct {
+	contentType string
+	stream      func(w io.Writer) listStream
+	page        func(w io.Writer, page types.ServerPage) error
+}
+
+var listCodecs = map[responseFormat]listCodec{
+	formatJSON:     {"application/json", newArrayStream, encodeJSONPage},
+	formatVerbose:  {"application/json", newArrayStream, encodeJSONPage},
+	formatXML:      {"application/xml; charset=utf-8", newXMLStream, encodeXMLPage},
+	formatProtobuf: {"application/x-protobuf", newProtoStream, encodeProtoPage},
+}
+
+func encodeJSONPage(w io.Writer, page types.ServerPage) error {
+	return json.NewEncoder(w).Encode(page)
+}
+
+func encodeXMLPage(w io.Writer, page types.ServerPage) error {
+	return encodeXML(w, "page", page)
+}
+
+// listStream writes a list of elements one at a time so large listings are never held in memory
+type listStream interface {
+	// Write encodes a single element of the list
+	Write(v interface{}) error
+	// Started reports whether anything has been written yet
+	Started() bool
+	// Close terminates the list, an empty list is written if no elements were
+	Close() error
+}
+
+// newListStream returns a stream for the response format
+func newListStream(w io.Writer, format responseFormat) listStream {
+	return listCodecs[format].stream(w)
+}
+
+func newArrayStream(w io.Writer) listStream { return &arrayStream{w: w} }
+
+func newXMLStream(w io.Writer) listStream { return &xmlStream{w: w} }
+
+func newProtoStream(w io.Writer) listStream { return &protoStream{w: w} }
+
+// arrayStream writes a JSON array to a writer one element at a time
+type arrayStream struct {
+	w     io.Writer
+	count int
+}
+
+// Write encodes a single element of the array, the opening bracket is written before the first
+func (s *arrayStream) Write(v interface{}) (err error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	if s.count == 0 {
+		_, err = s.w.Write([]byte("["))
+	} else {
+		_, err = s.w.Write([]byte(","))
+	}
+	if err != nil {
+		return
+	}
+	s.count++
+
+	_, err = s.w.Write(b)
+	return
+}
+
+// Started reports whether anything has been written yet
+func (s *arrayStream) Started() bool {
+	return s.count > 0
+}
+
+// Close terminates the array, an empty array is written if no elements were
+func (s *arrayStream) Close() (err error) {
+	if s.count == 0 {
+		_, err = s.w.Write([]byte("[]"))
+		return
+	}
+	_, err = s.w.Write([]byte("]"))
+	return
+}
+
+// xmlStream writes a servers element containing a server element for each value written
+type xmlStream struct {
+	w     io.Writer
+	enc   *xml.Encoder
+	count int
+}
+
+var (
+	xmlServers = xml.StartElement{Name: xml.Name{Local: "servers"}}
+	xmlServer  = xml.StartElement{Name: xml.Name{Local: "server"}}
+)
+
+// Write encodes a single server element, the document header and opening tag are written before
+// the first
+func (s *xmlStream) Write(v interface{}) (err error) {
+	if s.count == 0 {
+		err = s.open()
+		if err != nil {
+			return
+		}
+	}
+	s.count++
+	return s.enc.EncodeElement(v, xmlServer)
+}
+
+// Started reports whether anything has been written yet
+func (s *xmlStream) Started() bool {
+	return s.count > 0
+}
+
+// Close terminates the servers element, an empty one is written if no servers were
+func (s *xmlStream) Close() (err error) {
+	if s.count == 0 {
+		err = s.open()
+		if err != nil {
+			return
+		}
+	}
+	err = s.enc.EncodeToken(xmlServers.End())
+	if err != nil {
+		return
+	}
+	return s.enc.Flush()
+}
+
+func (s *xmlStream) open() (err error) {
+	_, err = s.w.Write([]byte(xml.Header))
+	if err != nil {
+		return
+	}
+	s.enc = xml.NewEncoder(s.w)
+	return s.enc.EncodeToken(xmlServers)
+}
+
+// protoStream writes a ServerList protocol buffer message, see types/server.proto. Each server is a
+// field of the message so there's nothing to write around them.
+type protoStream struct {
+	w     io.Writer
+	count int
+}
+
+// Write encodes a single server of the list
+func (s *protoStream) Write(v interface{}) (err error) {
+	server, err := protoServer(v)
+	if err != nil {
+		return
+	}
+	s.count++
+	_, err = s.w.Write(types.AppendProtoList(nil, server))
+	return
+}
+
+// Started reports whether anything has been written yet
+func (s *protoStream) Started() bool {
+	return s.count > 0
+}
+
+// Close does nothing since an empty list is an empty message
+func (s *protoStream) Close() error {
+	return nil
+}
+
+func encodeProtoPage(w io.Writer, page types.ServerPage) (err error) {
+	var b []byte
+	for _, v := range page.Servers {
+		server, err := protoServer(v)
+		if err != nil {
+			return err
+		}
+		b = types.AppendProtoList(b, server)
+	}
+	_, err = w.Write(types.AppendProtoNext(b, page.Next))
+	return
+}
+
+// protoServer returns the server to encode for an element of the list as it's presented, a server
+// that's only listed by its core fields is encoded with nothing else set
+func protoServer(v interface{}) (types.Server, error) {
+	switch v := v.(type) {
+	case types.Server:
+		return v, nil
+	case types.ServerCore:
+		return types.Server{Core: v}, nil
+	default:
+		return types.Server{}, errors.Errorf("can't encode %T as a protocol buffer", v)
+	}
+}
@@ -0,0 +1,54 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+const (
+	defaultTrendingWindow = time.Hour
+	minTrendingWindow     = time.Minute * 10
+	maxTrendingWindow     = time.Hour * 24
+)
+
+// serverTrending lists the servers whose player count has grown the most over the `window`
+// parameter, the last hour by default
+func (v *V2) serverTrending(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < minTrendingWindow || parsed > maxTrendingWindow {
+			WriteError(w, http.StatusBadRequest,
+				errors.Errorf("invalid 'window' argument '%s', must be a duration between %s and %s", value, minTrendingWindow, maxTrendingWindow))
+			return
+		}
+		window = parsed
+	}
+
+	trending, err := v.Trending(window)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to get trending servers"))
+		return
+	}
+	if trending == nil {
+		trending = []types.TrendingServer{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(trending)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, errors.Wrap(err, "failed to encode response"))
+		return
+	}
+}
+
+// trending ranks servers with the configured minimum, for when there's no App to ask
+func (v *V2) trending(window time.Duration) ([]types.TrendingServer, error) {
+	return storage.Trending(v.Storage, window, v.Config.TrendingMinPlayers, time.Now())
+}
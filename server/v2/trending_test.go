@@ -0,0 +1,57 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerTrending(t *testing.T) {
+	var (
+		window time.Duration
+		result []types.TrendingServer
+		fail   error
+	)
+	v := Init(storage.NewMemoryStore(), nil, types.Config{}, Deps{Trending: func(w time.Duration) ([]types.TrendingServer, error) {
+		window = w
+		return result, fail
+	}})
+
+	tests := []struct {
+		name       string
+		query      string
+		result     []types.TrendingServer
+		fail       error
+		wantStatus int
+		wantWindow time.Duration
+		wantBody   string
+	}{
+		{"default", "", nil, nil, http.StatusOK, time.Hour, `[]`},
+		{"window", "?window=6h", []types.TrendingServer{{ServerCore: types.ServerCore{Address: "a.example.com:7777", Players: 30}, Baseline: 20, Growth: 50}}, nil, http.StatusOK, time.Hour * 6,
+			`[{"ip":"a.example.com:7777","hn":"","pc":30,"pm":0,"gm":"","la":"","pa":false,"vn":"","baseline":20,"growth":50}]`},
+		{"too short", "?window=1m", nil, nil, http.StatusBadRequest, 0, ""},
+		{"too long", "?window=48h", nil, nil, http.StatusBadRequest, 0, ""},
+		{"invalid", "?window=week", nil, nil, http.StatusBadRequest, 0, ""},
+		{"failed", "", nil, errors.New("database gone"), http.StatusInternalServerError, time.Hour, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, result, fail = 0, tt.result, tt.fail
+
+			w := httptest.NewRecorder()
+			v.serverTrending(w, httptest.NewRequest("GET", "/servers/trending"+tt.query, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantWindow, window)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -2,8 +2,14 @@
 package v2
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"time"
 
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/query"
 	"github.com/Southclaws/samp-servers-api/scraper"
 	"github.com/Southclaws/samp-servers-api/storage"
 	"github.com/Southclaws/samp-servers-api/types"
@@ -11,18 +17,121 @@ import (
 
 // V2 represents an API endpoint handler
 type V2 struct {
-	Storage *storage.Manager
+	Storage storage.Store
 	Scraper *scraper.Scraper
 	Config  types.Config
+	Deps
+}
+
+// Deps are the optional dependencies of the handlers, anything left nil falls back to doing the
+// work directly in the handler group
+type Deps struct {
+	// Locate finds the country of a server, servers aren't located if it's nil
+	Locate LocateFunc
+	// Canonicalize picks the address to store a server under, servers are stored under the address
+	// they were submitted with if it's nil
+	Canonicalize CanonicalizeFunc
+	// Query sends live queries, they're sent directly without any caching if it's nil
+	Query QueryFunc
+	// RCON runs console commands, they're sent directly if it's nil
+	RCON RCONFunc
+	// Discover probes ranges of ports, probes are sent directly if it's nil
+	Discover DiscoverFunc
+	// Nearby orders servers by distance, they're only sorted by players if it's nil
+	Nearby NearbyFunc
+	// Patch applies patches to servers, they're stored directly if it's nil
+	Patch PatchFunc
+	// Trending ranks servers by growth, storage.Trending is used if it's nil
+	Trending TrendingFunc
+	// QueryOptions picks the options for the other queries the handlers make, the query package's
+	// defaults are used with Querier if it's nil
+	QueryOptions QueryOptionsFunc
+	// Querier sends the other queries the handlers make, each dials its own socket if it's nil
+	Querier *query.Querier
+	// Logger is told about things worth an operator knowing that aren't errors for the client,
+	// nothing is logged if it's nil
+	Logger *zap.Logger
 }
 
-// Init initialises and returns a handler group
-func Init(Storage *storage.Manager, Scraper *scraper.Scraper, Config types.Config) *V2 {
-	return &V2{
+// LocateFunc returns the ISO country code of a server address or an empty string if it's unknown
+type LocateFunc func(address string) string
+
+// CanonicalizeFunc returns the address a server should be stored under, which may differ from the
+// address it was submitted with
+type CanonicalizeFunc func(address string) string
+
+// QueryFunc performs a live query of the server at an address, it may return a recent result rather
+// than querying the server again. A partial error, see query.IsPartial, is returned along with the
+// server if only some of the queries succeeded.
+type QueryFunc func(ctx context.Context, address string) (types.Server, error)
+
+// RCONFunc runs a console command on the server at an address using its RCON password and returns
+// the output of the command
+type RCONFunc func(ctx context.Context, address, password, command string) (string, error)
+
+// DiscoverFunc returns the servers that respond to queries on a range of ports of an IP, ordered by
+// port. The range has already been validated.
+type DiscoverFunc func(ctx context.Context, ip string, from, to int) ([]types.Server, error)
+
+// NearbyFunc returns the listed servers ordered by how close they're likely to be to a client IP,
+// the IP has already been validated
+type NearbyFunc func(clientIP string) ([]types.Server, error)
+
+// PatchFunc overwrites the fields of the stored server at an address that are present in a partial
+// server object, a rejected patch is returned as types.Invalid
+type PatchFunc func(address string, patch map[string]json.RawMessage) error
+
+// QueryOptionsFunc returns the options for querying the server at an address, including the port
+// and transport it's queried with
+type QueryOptionsFunc func(address string) query.QueryOptions
+
+// TrendingFunc returns the servers whose player count has grown over a window, fastest growing
+// first. The window has already been validated.
+type TrendingFunc func(window time.Duration) ([]types.TrendingServer, error)
+
+// Init initialises and returns a handler group, see Deps for what happens to those left nil
+func Init(Storage storage.Store, Scraper *scraper.Scraper, Config types.Config, deps Deps) *V2 {
+	v := &V2{
 		Storage: Storage,
 		Scraper: Scraper,
 		Config:  Config,
+		Deps:    deps,
+	}
+	if v.Query == nil {
+		v.Query = v.liveQuery
+	}
+	if v.RCON == nil {
+		v.RCON = v.rcon
+	}
+	if v.Discover == nil {
+		v.Discover = v.discover
+	}
+	if v.Nearby == nil {
+		v.Nearby = v.nearby
 	}
+	if v.Patch == nil {
+		v.Patch = v.patch
+	}
+	if v.Trending == nil {
+		v.Trending = v.trending
+	}
+	if v.Logger == nil {
+		v.Logger = zap.NewNop()
+	}
+	if v.QueryOptions == nil {
+		v.QueryOptions = func(string) query.QueryOptions { return query.QueryOptions{Querier: v.Querier} }
+	}
+	return v
+}
+
+// storeServer stores a server under its canonical address, the address of the server is updated
+// to the canonical one
+func (v *V2) storeServer(server *types.Server) error {
+	canonical := server.Core.Address
+	if v.Canonicalize != nil {
+		canonical = v.Canonicalize(canonical)
+	}
+	return storage.UpsertCanonical(v.Storage, server, canonical)
 }
 
 // Version returns the route group version name
@@ -31,12 +140,13 @@ func (v *V2) Version() string { return "v2" }
 // Routes returns the version routes
 // nolint:lll
 func (v *V2) Routes() []types.Route {
+	example := types.Server{}.Example()
 	return []types.Route{
 		{
 			Name:        "serverAdd",
 			Path:        "/server/{address}",
 			Method:      "POST",
-			Description: `Add a server to the index using just the IP address. This endpoint requires no body and no additional information. The IP address is added to an internal queue and will be queried periodically for information via the legacy server API. This allows any server to be added with the basic information provided by SA:MP itself.`,
+			Description: `Add a server to the index using just the IP address. This endpoint doesn't need a body or any additional information. The IP address is added to an internal queue and will be queried periodically for information via the legacy server API. This allows any server to be added with the basic information provided by SA:MP itself. A server object can optionally be posted as the body, which is then handled exactly like posting to /server with the address from the path, including being verified against the live server unless the verify=false parameter is given, the ip in the body may be left out but if it's given it must be the same server or the request is rejected with a 400. Servers on the blocklist are rejected with a 403.`,
 			Accepts:     nil,
 			Returns:     nil,
 			Handler:     v.serverAdd,
@@ -45,54 +155,312 @@ func (v *V2) Routes() []types.Route {
 			Name:        "serverPost",
 			Path:        "/server",
 			Method:      "POST",
-			Description: `Provide additional information for a server such as a description and a banner image. This requires a body to be posted which contains information for the server.`,
+			Description: `Provide additional information for a server such as a description and a banner image. This requires a body to be posted which contains information for the server. The hostname must be valid UTF-8 and at most 64 characters long. The player count must not be negative or exceed the maximum players, which must be between 1 and 1000, the most a SA:MP server can hold. The server is queried to verify it and must respond with a hostname and gamemode resembling the posted ones, otherwise it's rejected with a 422. Verification can be skipped with the verify=false parameter, or turned off for the whole index by its operator. Servers that answer queries on a different port to the game port can give it as qp, every query of the server is sent there instead and it's left out when it's the same as the game port. When a server fails the checks the errors are also listed under fields, each with the field it's about and the message, so a form can point out which field needs fixing. Servers posted under a hostname are stored under the IP address it resolves to so each server is only listed once, the hostname is kept in its aliases and the server can still be requested by it. Servers on the blocklist are rejected with a 403. An index can be limited to certain gamemodes and languages, in which case a server whose gamemode or language doesn't contain one of the allowed ones, ignoring case, is rejected with a 422. Bodies larger than 64KB are rejected with a 413, the limit is configurable. Fields the server object doesn't have are rejected with a 400 naming the field unless the lenient=true parameter is given, this applies to every endpoint that accepts a body, as does rejecting bodies sent without a Content-Type of application/json with a 415. Any POST other than running an RCON command can be made safe to retry by sending an Idempotency-Key header with a unique value, a repeat with the same key and body from the same API key, or the same IP without one, within 24 hours gets the original response back with an Idempotent-Replayed header instead of being processed again. Reusing a key for a different request is rejected with a 422.`,
 			Accepts:     types.Server{}.Example(),
 			Returns:     nil,
 			Handler:     v.serverPost,
 		},
+		{
+			Name:        "serverValidate",
+			Path:        "/validate",
+			Method:      "POST",
+			Description: "Checks a server object in exactly the same way as posting it, including verifying it against the live server unless the verify=false parameter is given, but doesn't store it. Responds with `valid` set to `true` if the server would be accepted, otherwise with the same errors and status code that posting it would have.",
+			Accepts:     types.Server{}.Example(),
+			Returns:     types.ValidationResult{}.Example(),
+			Handler:     v.serverValidate,
+		},
+		{
+			Name:        "serverBulk",
+			Path:        "/servers",
+			Method:      "POST",
+			Description: `Provide information for many servers at once. This requires a body containing an array of server objects, each of which is checked in the same way as a single post. Servers that fail the checks are rejected individually without affecting the rest of the batch, if any are rejected the status is 207 and the response lists the index of each rejected server along with the reasons. The size limit applies to the body as a whole so large batches may need splitting.`,
+			Accepts:     []types.Server{types.Server{}.Example()},
+			Returns:     types.BulkResult{}.Example(),
+			Handler:     v.serverBulk,
+		},
+		{
+			Name:        "serverDiscover",
+			Path:        "/discover",
+			Method:      "POST",
+			Description: "Finds the servers running on a range of ports of an IP, which is handy for hosts that run many servers on one machine. Each port from `from` to `to` inclusive is sent a single info query and the servers that respond are returned ordered by port, with their hostname, players and gamemode. The `ip` must be a public IPv4 address, loopback and private ones are rejected with a 422, and the range can cover at most 100 ports. If `add` is `true` every server found is also added to the index in the same way as adding it by address, except for servers on the blocklist. Probes count towards the same limits as every other query, so a discovery that doesn't finish in time fails with a 504.",
+			Accepts:     types.Discover{}.Example(),
+			Returns:     []types.Server{{Core: types.Server{}.Example().Core}},
+			Limited:     true,
+			Timeout:     v.Config.DiscoverTimeout + time.Second, // so the discovery's own deadline passes first
+			Handler:     v.serverDiscover,
+		},
 		{
 			Name:        "serverGet",
 			Path:        "/server/{address}",
 			Method:      "GET",
-			Description: `Returns a full server object using the specified address.`,
+			Description: "Returns a full server object using the specified address. `hc` is the hostname with `{RRGGBB}` colour codes and control characters removed, browsers can display either. `lg` lists the languages named in `la` under their English names, so `EN/RU` is `English` and `Russian`, languages that aren't recognised are listed as they're written. `pk` is the highest player count the server has been seen with and `pk24` is the highest in the last 24 hours, both are updated each time the server is polled. The server is encoded as XML instead of JSON when `format` is `xml` or the `Accept` header asks for `application/xml`, rules are then listed as `rule` elements with `name` and `value` attributes. JSON responses use descriptive keys such as `address` and `hostname` instead of the short ones when `verbose` is `true`, which is handy for reading them in a browser. `fields` limits a JSON response to the keys it lists in the same way as it does for the server list, and `envelope=true` wraps it as the `data` of an envelope in the same way too, with a `meta.count` of 1.",
 			Accepts:     nil,
 			Returns:     types.Server{}.Example(),
 			Handler:     v.serverGet,
 		},
+		{
+			Name:        "serverLive",
+			Path:        "/server/{address}/live",
+			Method:      "GET",
+			Description: `Queries the server immediately instead of returning the stored copy and returns a full server object with the result, which is also stored. If the server does not respond in time the status is 504, or 503 if the API is too busy with other queries to send one. Results are reused for 10 seconds by default so requests for the same server in quick succession don't each send a query. This endpoint is rate limited more strictly than the others since every request sends queries to the server. Servers on the blocklist are rejected with a 403 without being queried. With verbose=true the keys of the server are spelled out in the same way as getting it.`,
+			Accepts:     nil,
+			Returns:     types.Server{}.Example(),
+			Limited:     true,
+			Handler:     v.serverLive,
+		},
+		{
+			Name:        "serverStatus",
+			Path:        "/server/{address}/status",
+			Method:      "GET",
+			Description: "Returns only whether a server is `online`, its `players` and `ping` and when it was `lastSeen`, which makes it a cheap target for browsers polling many servers. It's answered from the stored copy of the server and never queries it, the players and ping are zero while it's offline. Servers that have never responded to a query are a 404.",
+			Accepts:     nil,
+			Returns:     types.ServerStatus{}.Example(),
+			Handler:     v.serverStatus,
+		},
+		{
+			Name:        "serverPlayers",
+			Path:        "/server/{address}/players",
+			Method:      "GET",
+			Description: "Queries the server immediately for the `id`, `name`, `score` and `ping` of each connected player. The list is empty for passworded servers and for servers that don't list their players, which SA:MP servers stop doing once more than 100 players are online. If the server does not respond in time the status is 504, or 503 if the API is too busy with other queries to send one. This endpoint is rate limited in the same way as live queries.",
+			Accepts:     nil,
+			Returns:     []types.PlayerDetail{types.PlayerDetail{}.Example()},
+			Limited:     true,
+			Handler:     v.serverPlayers,
+		},
+		{
+			Name:        "serverRaw",
+			Path:        "/server/{address}/raw",
+			Method:      "GET",
+			Description: "Sends a single query to the server and returns the `payload` of the response hex encoded as it was received, along with its `length` in bytes, for debugging servers whose responses can't be parsed. The header that every response starts with is left out. The `opcode` parameter is one of `i` `r` `c` `d` and defaults to `i`, any other opcode is rejected with a 400. If the server does not respond in time the status is 504, or 503 if the API is too busy with other queries to send one. This endpoint is rate limited in the same way as live queries.",
+			Accepts:     nil,
+			Returns:     types.RawQuery{}.Example(),
+			Limited:     true,
+			Handler:     v.serverRaw,
+		},
+		{
+			Name:        "serverRCON",
+			Path:        "/server/{address}/rcon",
+			Method:      "POST",
+			Description: "Runs a console `command` on the server using its RCON `password` and returns the `output`, with one line for each response the server sent. Commands that have no output return an empty one. If the server rejects the password the status is 403, and like live queries the status is 503 if the API is too busy to send the command. This endpoint always requires an API key in an `Authorization: Bearer` header, even if API keys aren't required for the rest of the API, and requests without a valid key are rejected with a 401. Commands are sent once and never retried, and an Idempotency-Key is ignored so the output is never stored. Passwords are never stored or logged.",
+			Accepts:     types.RCONCommand{}.Example(),
+			Returns:     types.RCONOutput{}.Example(),
+			Limited:     true,
+			KeyRequired: true,
+			Sensitive:   true,
+			Handler:     v.serverRCON,
+		},
+		{
+			Name:        "serverHistory",
+			Path:        "/server/{address}/history",
+			Method:      "GET",
+			Description: "Returns the average player count of a server over each `interval` between `from` and `to`, which are RFC 3339 times. `to` defaults to now, `from` defaults to a day before `to` and `interval` defaults to `5m`. Intervals with no samples are left out and a server with no samples yet has an empty list of `points`. Samples older than a week are kept as hourly averages so shorter intervals than that are not available for older ranges.",
+			Accepts:     nil,
+			Returns:     types.History{}.Example(),
+			Handler:     v.serverHistory,
+		},
+		{
+			Name:        "serverDelete",
+			Path:        "/server/{address}",
+			Method:      "DELETE",
+			Description: `Removes a server from the index using the specified address. Responds with no content on success.`,
+			Accepts:     nil,
+			Returns:     nil,
+			Handler:     v.serverDelete,
+		},
+		{
+			Name:        "serverPatch",
+			Path:        "/server/{address}",
+			Method:      "PATCH",
+			Description: "Corrects some of the fields of a server without posting the whole object again. The body is a partial server object and only the fields it contains are overwritten, `core` is merged field by field so `{\"core\":{\"gm\":\"Roleplay\"}}` only changes the gamemode. The patched server has to pass the same checks as a posted one. Fields the API fills in itself, such as `on`, `ls`, `pk` and the address, can't be patched and are rejected with a 422 naming the field, as are fields the server object doesn't have. Responds with no content on success.",
+			Accepts:     map[string]interface{}{"description": "An awesome server!", "co": "GB", "core": map[string]string{"gm": "Roleplay"}},
+			Returns:     nil,
+			Handler:     v.serverPatch,
+		},
+		{
+			Name:        "serverBulkGet",
+			Path:        "/servers/get",
+			Method:      "POST",
+			Description: "Returns the servers at many addresses at once, such as a list of favourites, which saves a request per server. The body contains `addresses`, a list of up to 200 server addresses. The response is an object with each address as it was given mapped to its server, or to `null` if there's no server at the address or it isn't a valid address. Servers are returned in the same way as getting them individually, so the player list of passworded servers is left out. `verbose=true` spells out the keys of each server in the same way as getting them individually.",
+			Accepts:     types.BulkGet{}.Example(),
+			Returns:     map[string]*types.Server{"127.0.0.1:7777": &example, "ss.southcla.ws:7777": nil},
+			Handler:     v.serverBulkGet,
+		},
+		{
+			Name:        "serverBulkDelete",
+			Path:        "/servers/delete",
+			Method:      "POST",
+			Description: "Removes many servers from the index at once. The body contains either `addresses`, a list of server addresses, or `offlineSince`, a date such as `2018-01-01` or an RFC 3339 time, in which case every server last seen before it is removed, servers that have never been seen successfully are kept. Requires an admin key in the same way as marking a server as featured. The response counts the servers removed and lists the addresses that didn't match a server along with the index of each invalid address, the status is 207 if there are any of either.",
+			Accepts:     types.BulkDelete{}.Example(),
+			Returns:     types.BulkDeleteResult{}.Example(),
+			Admin:       true,
+			Handler:     v.serverBulkDelete,
+		},
+		{
+			Name:        "serverFeature",
+			Path:        "/server/{address}/feature",
+			Method:      "PUT",
+			Description: "Marks a server as featured so it can be listed first with `featured=first`. Requires an admin key in an `Authorization: Bearer` header, requests without a valid key are rejected with a 403. Responds with no content on success.",
+			Accepts:     nil,
+			Returns:     nil,
+			Admin:       true,
+			Handler:     v.serverFeature,
+		},
+		{
+			Name:        "serverUnfeature",
+			Path:        "/server/{address}/feature",
+			Method:      "DELETE",
+			Description: "Removes the featured mark from a server. Requires an admin key in the same way as marking a server as featured. Responds with no content on success.",
+			Accepts:     nil,
+			Returns:     nil,
+			Admin:       true,
+			Handler:     v.serverUnfeature,
+		},
+		{
+			Name:        "blocklistAdd",
+			Path:        "/blocklist",
+			Method:      "POST",
+			Description: "Blocks servers from the index. The `address` is either a server address, which blocks that one server, an IP, which blocks every server on it, or a CIDR range such as `203.0.113.0/24`, which blocks every server in it. Blocked servers are rejected from every endpoint that adds a server with a 403 and are no longer queried, any that are already stored are removed and counted in `removed`. IPs and ranges only match servers stored by IP. Requires an admin key in the same way as marking a server as featured.",
+			Accepts:     types.Block{Address: "203.0.113.0/24", Reason: "fake player counts"},
+			Returns:     types.BlockResult{Block: types.Block{}.Example(), Removed: 3},
+			Admin:       true,
+			Handler:     v.blocklistAdd,
+		},
+		{
+			Name:        "blocklistRemove",
+			Path:        "/blocklist/{address:.+}",
+			Method:      "DELETE",
+			Description: "Removes a block so the servers it matched can be added again, the address is given in the same form as it was blocked with, such as `203.0.113.0/24`. Servers that were removed when it was added aren't restored. Requires an admin key in the same way as marking a server as featured. Responds with no content on success.",
+			Accepts:     nil,
+			Returns:     nil,
+			Admin:       true,
+			Handler:     v.blocklistRemove,
+		},
 		{
 			Name:        "serverList",
 			Path:        "/servers",
 			Method:      "GET",
-			Description: "Returns a list of servers based on the specified query parameters. Supported query parameters are: `page` `sort` `by` `filters`.",
+			Description: "Returns a list of servers based on the specified query parameters. Supported query parameters are: `page` `sort` `by` `filters` `full` `limit` `cursor` `gamemode` `language` `version` `password` `fork` `includePassworded` `includeDead` `featured` `format` `verbose` `fields`. Servers are sorted by `-players` unless `sort` is one of `players` `ping` `name`, each of which is ascending unless prefixed with `-`, servers that are equal keep the order they were added in. `sort` may also be `asc` or `desc` to order by the `by` column. Only the core fields of each server are listed unless `full` is `true`. If `limit` or `cursor` are specified, servers are ordered by address and an object containing a page of `servers` and the `next` cursor is returned instead of an array. `gamemode` matches any part of the field regardless of case, `language` matches servers whose normalised `lg` languages include any of the languages it names so `en` matches `English/Russian`, `version` matches the start of the `vn` version rule so `0.3.7` matches `0.3.7-R2`, `password` matches `true` or `false` exactly and `fork` is `openmp` or `samp` to match servers running open.mp, marked with `om`, or the original SA:MP server, servers must match every filter specified to be listed. Passworded servers are listed unless `includePassworded` is `false`, when `password` is specified it takes precedence and `includePassworded` is ignored. Servers that have stopped responding are marked dead with the time in `ds` and are only listed when `includeDead` is `true`, they're removed entirely if they don't respond again within the grace period, a week by default. When `featured` is `first`, featured servers are listed before the rest, this doesn't apply when paginating by cursor. The player list of passworded servers is never returned. Servers are listed in a `servers` XML element instead of a JSON array when `format` is `xml` or the `Accept` header asks for `application/xml`. When `verbose` is `true` the JSON keys are spelled out, `address` instead of `ip` and so on, for reading the list in a browser. Clients that need to keep responses small can ask for the list as protocol buffers with `format` set to `protobuf` or an `Accept` header of `application/x-protobuf`, the body is then a `ServerList` message as defined in `types/server.proto` in the repository. JSON listings can be cut down to just the fields a client displays with `fields`, a comma separated list of keys such as `ip,hn,pc,pi`. Each server is then an object of only those keys whether or not `full` is set, core fields are named without the `core.` prefix and unknown keys are ignored. `envelope=true` wraps a JSON listing as `{\"data\":[...],\"meta\":{\"count\":10}}`, with the cursor of the following page in `meta.next` when the listing is paged, for clients that would rather parse every response the same way.",
 			Params:      types.ServerListParams{}.Example(),
 			Accepts:     nil,
 			Returns:     []types.ServerCore{types.Server{}.Example().Core, types.Server{}.Example().Core, types.Server{}.Example().Core},
+			Timeout:     -1, // streamed
 			Handler:     v.serverList,
 		},
+		{
+			Name:        "serverExport",
+			Path:        "/servers.csv",
+			Method:      "GET",
+			Description: "Returns the server list as a CSV file with the columns `address` `hostname` `players` `maxplayers` `gamemode` `language` `country` `ping` `online`. The same query parameters as the server list are supported for filtering and ordering the export, except for `limit` and `cursor`.",
+			Accepts:     nil,
+			Returns:     nil,
+			Timeout:     -1, // streamed
+			Handler:     v.serverExport,
+		},
+		{
+			Name:        "serverNearby",
+			Path:        "/servers/nearby",
+			Method:      "GET",
+			Description: "Returns the listed servers ordered by how close they're likely to be to a player, to help with picking a server with a low ping. The player's IP is given with `ip`, or the IP the request came from is used if it's left out. Servers in the same country as the player are listed first, then those on the same continent followed by the rest, each sorted by `players`. Locations only go as far as countries, which are in `co`, so they're a rough guide to distance rather than a measurement of it. If the player can't be located the servers are only sorted by `players`, like the server list.",
+			Accepts:     nil,
+			Returns:     []types.NearbyServer{types.NearbyServer{}.Example()},
+			Handler:     v.serverNearby,
+		},
+		{
+			Name:        "serverTrending",
+			Path:        "/trending",
+			Method:      "GET",
+			Description: "Returns the servers whose player count has grown the most over the last hour, or over the `window` given as a duration such as `30m` or `6h`, up to a day. Each server's growth is the percentage its player count has grown by since the start of the window and is listed in `growth`, along with the player count it started from in `baseline`, fastest growing first. Servers that started with fewer players than the configured minimum, ten by default, aren't ranked so small servers gaining a couple of players don't crowd out the rest, and neither are servers whose history doesn't go back to the first half of the window. Only servers that have grown are listed.",
+			Accepts:     nil,
+			Returns:     []types.TrendingServer{types.TrendingServer{}.Example()},
+			Handler:     v.serverTrending,
+		},
+		{
+			Name:        "serverSearch",
+			Path:        "/search",
+			Method:      "GET",
+			Description: "Returns a list of servers where every word of the `q` query parameter appears in either the hostname or gamemode, regardless of case. Results are ordered by the `_score` field which weighs words found in the hostname higher than those found in the gamemode.",
+			Accepts:     nil,
+			Returns:     []types.SearchResult{types.SearchResult{}.Example()},
+			Handler:     v.serverSearch,
+		},
 		{
 			Name:        "serverStats",
 			Path:        "/stats",
 			Method:      "GET",
-			Description: `Returns a some statistics of the server index.`,
+			Description: `Returns a some statistics of the server index, including online and offline counts and the number of servers per language and per gamemode.`,
 			Accepts:     nil,
 			Returns:     types.Statistics{}.Example(),
 			Handler:     v.serverStats,
 		},
+		{
+			Name:        "webhookAdd",
+			Path:        "/webhooks",
+			Method:      "POST",
+			Description: "Registers a `url` to be notified whenever the server at `address` goes online or offline. Each notification is a POST with a JSON body containing the `event`, `address`, `players`, `online` and `time` fields, failed deliveries are retried with exponential backoff. The response contains a `secret` which is only revealed once, each notification has an `X-Samplist-Signature` header containing `sha256=` followed by the hex encoded HMAC-SHA256 of the body keyed with the secret so receivers can verify it. The URL must point at a public address, hosts that are or resolve to loopback, private or link-local addresses are rejected with a 422 and notifications are never sent to them even if the host is later pointed at one.",
+			Accepts:     types.Webhook{Address: "ss.southcla.ws:7777", URL: "https://example.com/samp-status"},
+			Returns:     types.Webhook{}.Example(),
+			Handler:     v.webhookAdd,
+		},
+		{
+			Name:        "webhookDelete",
+			Path:        "/webhooks/{id}",
+			Method:      "DELETE",
+			Description: "Removes the webhook with the `id` returned when it was registered. The request must have an `X-Samplist-Secret` header containing the webhook's `secret`, otherwise it's rejected with a 403. Responds with no content on success.",
+			Accepts:     nil,
+			Returns:     nil,
+			Handler:     v.webhookDelete,
+		},
 	}
 }
 
 // TODO: replace with handler wrapper
 
+type errorResponse struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+// retryAfter is how many seconds clients are asked to wait before retrying a request that failed
+// because the database was unavailable, long enough for a restarted database to be reconnected to
+const retryAfter = "5"
+
+type errorsResponse struct {
+	Errors []string                `json:"errors"`
+	Fields []types.ValidationError `json:"fields,omitempty"`
+	Status int                     `json:"status"`
+}
+
 // WriteError is a utility function for logging a request error and writing a response all in one.
+// The response is a JSON object containing the error message and the status code. Internal errors
+// caused by the database being unreachable are reported as a 503 instead, with a Retry-After, so
+// clients can tell a request that's worth retrying from one that will never succeed.
 func WriteError(w http.ResponseWriter, status int, err error) {
-	w.WriteHeader(status)
-	w.Write([]byte(err.Error()))
+	if status == http.StatusInternalServerError && storage.IsTransient(err) {
+		status = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", retryAfter)
+	}
+	writeJSON(w, status, errorResponse{
+		Error:  err.Error(),
+		Status: status,
+	})
 }
 
-// WriteErrors does the same but for groups of errors
+// WriteErrors does the same but for groups of errors, any errors that are about a particular field
+// are listed again alongside the field they're about
 func WriteErrors(w http.ResponseWriter, status int, errs []error) {
-	w.WriteHeader(status)
-	for _, err := range errs {
-		w.Write([]byte(err.Error() + ", "))
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
 	}
+	writeJSON(w, status, errorsResponse{
+		Errors: messages,
+		Fields: types.ValidationErrors(errs),
+		Status: status,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) // nolint:errcheck
 }
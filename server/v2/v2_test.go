@@ -0,0 +1,67 @@
+package v2
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteError(w, http.StatusNotFound, errors.New("could not find server"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"could not find server","status":404}`, w.Body.String())
+}
+
+func TestWriteError_Transient(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteError(w, http.StatusInternalServerError, errors.Wrap(io.EOF, "failed to get servers"))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, retryAfter, w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"failed to get servers: EOF","status":503}`, w.Body.String())
+
+	// only internal errors are reported as unavailable
+	w = httptest.NewRecorder()
+	WriteError(w, http.StatusBadRequest, io.EOF)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	WriteError(w, http.StatusInternalServerError, errors.New("index ensure failed"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestWriteErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteErrors(w, http.StatusUnprocessableEntity, []error{
+		errors.New("hostname is empty"),
+		errors.New("gamemode is empty"),
+	})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"errors":["hostname is empty","gamemode is empty"],"status":422}`, w.Body.String())
+}
+
+func TestWriteErrors_Fields(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteErrors(w, http.StatusUnprocessableEntity, []error{
+		types.ValidationError{Field: "hostname", Message: "hostname is empty"},
+		errors.New("failed to query server"),
+	})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{
+		"errors": ["hostname is empty", "failed to query server"],
+		"fields": [{"field": "hostname", "message": "hostname is empty"}],
+		"status": 422
+	}`, w.Body.String())
+}
@@ -0,0 +1,26 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// serverValidate checks a server in exactly the same way as serverPost but doesn't store it, so
+// clients can find out whether a server would be accepted before actually posting it
+func (v *V2) serverValidate(w http.ResponseWriter, r *http.Request) {
+	server := types.Server{}
+	status, err := v.decodeBody(w, r, &server)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	status, errs := v.prepareServer(r, &server)
+	if errs != nil {
+		WriteErrors(w, status, errs)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, types.ValidationResult{Valid: true})
+}
@@ -0,0 +1,55 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestServerValidate(t *testing.T) {
+	valid, err := json.Marshal(types.Server{}.Example())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"valid", string(valid), http.StatusOK, `{"valid":true}`},
+		{"invalid", `{"core":{"ip":"test.com:7777","pm":10}}`, http.StatusUnprocessableEntity, `{
+			"errors": ["hostname is empty", "gamemode is empty"],
+			"fields": [
+				{"field": "hostname", "message": "hostname is empty"},
+				{"field": "gamemode", "message": "gamemode is empty"}
+			],
+			"status": 422
+		}`},
+		{"unknown field", `{"core":{"ip":"test.com:7777"},"colour":"red"}`, http.StatusBadRequest, `{"error":"json: unknown field \"colour\"","status":400}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			router, cancel := newTestRouter(t, store)
+			defer cancel()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, jsonRequest("POST", "/validate", bytes.NewBufferString(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+
+			// nothing is ever stored
+			active, err := store.GetActiveServers()
+			assert.NoError(t, err)
+			assert.Equal(t, 0, active)
+		})
+	}
+}
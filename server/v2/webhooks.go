@@ -0,0 +1,122 @@
+package v2
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// SecretHeader holds the secret of a webhook when removing it, since registering one is anonymous
+// the secret is the only proof of who registered it
+const SecretHeader = "X-Samplist-Secret"
+
+// webhookAdd registers a URL to be notified when a server goes online or offline. The response
+// contains the generated secret, which is the only time it's revealed.
+func (v *V2) webhookAdd(w http.ResponseWriter, r *http.Request) {
+	var hook types.Webhook
+	status, err := v.decodeBody(w, r, &hook)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	errs := hook.Validate()
+	if errs != nil {
+		WriteErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	// the host is checked again when each notification is sent in case it's since been pointed
+	// somewhere else, this just turns away the obvious ones early
+	u, _ := url.Parse(hook.URL) // already known to parse from Validate
+	err = types.CheckPublicHost(u.Hostname())
+	if err != nil {
+		WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	hook.Address, errs = v.addressForStorage(hook.Address)
+	if errs != nil {
+		WriteErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	existing, err := v.Storage.GetWebhooks(hook.Address)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(existing) >= types.MaxWebhooks {
+		WriteError(w, http.StatusUnprocessableEntity,
+			errors.Errorf("server '%s' already has the maximum of %d webhooks", hook.Address, types.MaxWebhooks))
+		return
+	}
+
+	hook.ID, err = randomHex(16)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	hook.Secret, err = randomHex(32)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	hook.Created = time.Now().UTC()
+
+	err = v.Storage.AddWebhook(hook)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, hook)
+}
+
+// webhookDelete removes a webhook, the request must contain the webhook's secret in SecretHeader
+func (v *V2) webhookDelete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	hook, err := v.Storage.GetWebhook(id)
+	if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.Errorf("could not find webhook '%s'", id))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(SecretHeader)), []byte(hook.Secret)) != 1 {
+		WriteError(w, http.StatusForbidden, errors.Errorf("%s does not match the secret of webhook '%s'", SecretHeader, id))
+		return
+	}
+
+	err = v.Storage.RemoveWebhook(id)
+	if err == storage.ErrNotFound {
+		WriteError(w, http.StatusNotFound, errors.Errorf("could not find webhook '%s'", id))
+		return
+	} else if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate random bytes")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,86 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestWebhookAdd(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, jsonRequest("POST", "/webhooks", bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := post(`{"address":"ss.southcla.ws","url":"https://93.184.216.34/hook"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var got types.Webhook
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, "ss.southcla.ws:7777", got.Address)
+	assert.Len(t, got.ID, 32)
+	assert.Len(t, got.Secret, 64)
+
+	hooks, err := store.GetWebhooks("ss.southcla.ws:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Webhook{got}, hooks)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"address":"ss.southcla.ws","url":"gopher://example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`not json`).Code)
+
+	for i := len(hooks); i < types.MaxWebhooks; i++ {
+		assert.Equal(t, http.StatusCreated, post(`{"address":"ss.southcla.ws","url":"https://93.184.216.34/hook"}`).Code)
+	}
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"address":"ss.southcla.ws","url":"https://93.184.216.34/hook"}`).Code)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"address":"other.southcla.ws","url":"http://169.254.169.254/latest/meta-data"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"address":"other.southcla.ws","url":"http://10.0.0.1/hook"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"address":"other.southcla.ws","url":"http://localhost/hook"}`).Code)
+}
+
+func TestWebhookDelete(t *testing.T) {
+	store := storage.NewMemoryStore()
+	router, cancel := newTestRouter(t, store)
+	defer cancel()
+
+	hook := types.Webhook{ID: "abc", Address: "ss.southcla.ws:7777", URL: "https://93.184.216.34/hook", Secret: "secret"}
+	require.NoError(t, store.AddWebhook(hook))
+
+	del := func(id, secret string) int {
+		r := httptest.NewRequest("DELETE", "/webhooks/"+id, nil)
+		if secret != "" {
+			r.Header.Set(SecretHeader, secret)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, del("abc", ""))
+	assert.Equal(t, http.StatusForbidden, del("abc", "wrong"))
+	assert.Equal(t, http.StatusNotFound, del("xyz", "secret"))
+
+	hooks, err := store.GetWebhooks(hook.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Webhook{hook}, hooks)
+
+	assert.Equal(t, http.StatusNoContent, del("abc", "secret"))
+	assert.Equal(t, http.StatusNotFound, del("abc", "secret"))
+
+	hooks, err = store.GetWebhooks(hook.Address)
+	assert.NoError(t, err)
+	assert.Empty(t, hooks)
+}
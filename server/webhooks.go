@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// SignatureHeader holds the hex encoded HMAC-SHA256 of a webhook body, keyed with the secret that
+// was returned when the webhook was registered and prefixed with "sha256="
+const SignatureHeader = "X-Samplist-Signature"
+
+var (
+	// webhookAttempts is how many times a delivery is attempted before it's given up on
+	webhookAttempts = 5
+	// webhookBackoff is the wait after the first failed delivery, it doubles after each failure
+	webhookBackoff = time.Second
+
+	// webhookDestinationAllowed is checked against the IP of every connection made to deliver a
+	// webhook, it's a variable so tests can deliver to local servers
+	webhookDestinationAllowed = types.PublicIP
+
+	// webhookClient only connects to public IPs, since the IP is checked once it's been resolved
+	// a webhook's host can't be pointed at the API's own network after it's been registered
+	webhookClient = &http.Client{
+		Timeout: time.Second * 10,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: time.Second * 5,
+				Control: checkWebhookDestination,
+			}).DialContext,
+			TLSHandshakeTimeout: time.Second * 5,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     time.Second * 90,
+		},
+	}
+)
+
+// checkWebhookDestination refuses connections to IPs that webhookDestinationAllowed rejects
+func checkWebhookDestination(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !webhookDestinationAllowed(ip) {
+		return errors.Errorf("webhook destination %s is not a public address", host)
+	}
+	return nil
+}
+
+// serverChanged publishes a server's status to live update subscribers and notifies its webhooks
+// if it has just gone online or offline
+func (app *App) serverChanged(address string, players int, online bool) {
+	update, transition := app.updates.publish(address, players, online)
+	if transition {
+		go app.notifyWebhooks(update)
+	}
+}
+
+// notifyWebhooks delivers an update to every webhook registered for the server in the background
+func (app *App) notifyWebhooks(update types.ServerUpdate) {
+	hooks, err := app.db.GetWebhooks(update.Address)
+	if err != nil {
+		app.logger.Error("failed to get webhooks",
+			zap.Error(err),
+			zap.String("address", update.Address))
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(types.WebhookEvent{ServerUpdate: update, Time: time.Now().UTC()})
+	if err != nil {
+		app.logger.Error("failed to encode webhook event", zap.Error(err))
+		return
+	}
+
+	for _, hook := range hooks {
+		go app.deliverWebhook(hook, body)
+	}
+}
+
+// deliverWebhook posts the body to the webhook, retrying with exponential backoff until it succeeds,
+// runs out of attempts or the app is shutting down
+func (app *App) deliverWebhook(hook types.Webhook, body []byte) {
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		err := postWebhook(app.ctx, hook, body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookAttempts {
+			app.logger.Warn("giving up on webhook delivery",
+				zap.Error(err),
+				zap.String("id", hook.ID),
+				zap.String("address", hook.Address),
+				zap.Int("attempts", attempt))
+			return
+		}
+
+		app.logger.Debug("webhook delivery failed",
+			zap.Error(err),
+			zap.String("id", hook.ID),
+			zap.Duration("retry_in", backoff))
+
+		select {
+		case <-app.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func postWebhook(ctx context.Context, hook types.Webhook, body []byte) (err error) {
+	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, "sha256="+signWebhook(hook.Secret, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to post webhook")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096)) // nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("webhook responded with %s", resp.Status)
+	}
+	return
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body) // nolint:errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
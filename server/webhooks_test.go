@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/storage"
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// allowLocalWebhooks lets webhooks be delivered to httptest servers, call the returned func to undo it
+func allowLocalWebhooks() func() {
+	webhookDestinationAllowed = func(net.IP) bool { return true }
+	return func() { webhookDestinationAllowed = types.PublicIP }
+}
+
+func TestApp_serverChanged_Webhooks(t *testing.T) {
+	webhookBackoff = time.Millisecond
+	defer allowLocalWebhooks()()
+
+	var (
+		mu       sync.Mutex
+		attempts int
+		events   []types.WebhookEvent
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "sha256="+signWebhook("secret", body), r.Header.Get(SignatureHeader))
+
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var event types.WebhookEvent
+		assert.NoError(t, json.Unmarshal(body, &event))
+		events = append(events, event)
+	}))
+	defer ts.Close()
+
+	db := storage.NewMemoryStore()
+	require.NoError(t, db.AddWebhook(types.Webhook{ID: "1", Address: "s1.example.com:7777", URL: ts.URL, Secret: "secret"}))
+	app := NewApp(db, zap.NewNop())
+	defer app.cancel()
+
+	app.serverChanged("s1.example.com:7777", 4, true) // first sighting, not a transition
+	app.serverChanged("s1.example.com:7777", 5, true)
+	app.serverChanged("s1.example.com:7777", 0, false)
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == 1
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, types.ServerUpdate{Event: types.EventOffline, Address: "s1.example.com:7777", Online: false}, events[0].ServerUpdate)
+}
+
+func TestApp_deliverWebhook_GivesUp(t *testing.T) {
+	webhookBackoff = time.Millisecond
+	defer allowLocalWebhooks()()
+
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	app := NewApp(nil, zap.NewNop())
+	app.deliverWebhook(types.Webhook{URL: ts.URL}, []byte(`{}`))
+
+	assert.Equal(t, webhookAttempts, attempts)
+}
+
+func TestPostWebhook_NonPublic(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+
+	// a hostname that resolves to loopback is refused in the same way, the check is on the IP
+	// that's actually connected to
+	for _, url := range []string{ts.URL, strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)} {
+		err := postWebhook(context.Background(), types.Webhook{URL: url}, []byte(`{}`))
+		if assert.Error(t, err, url) {
+			assert.Contains(t, err.Error(), "is not a public address")
+		}
+	}
+	assert.False(t, called)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+const (
+	wsWriteTimeout = time.Second * 10
+	wsPingInterval = time.Second * 30
+	wsPongTimeout  = time.Second * 60 // must be longer than the ping interval
+	wsReadLimit    = 512
+)
+
+// Updates upgrades the request to a WebSocket and sends a ServerUpdate message each time a server
+// is refreshed by the poller or the scraper. Every server is sent by default, clients can send an
+// UpdateSubscription message at any time to only receive updates for a single address. Updates are
+// dropped for clients that can't keep up rather than slowing down the queries.
+func (app *App) Updates(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: app.checkOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already responded with an error, which ends up in the access log
+		return
+	}
+	defer conn.Close()
+
+	sub := app.updates.subscribe()
+	defer app.updates.unsubscribe(sub)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.readSubscriptions(conn, sub)
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-app.ctx.Done():
+			conn.WriteControl( // nolint:errcheck
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "shutting down"),
+				time.Now().Add(wsWriteTimeout))
+			return
+		case update := <-sub.updates:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)) // nolint:errcheck
+			err = conn.WriteJSON(update)
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readSubscriptions applies subscription messages from the client until the connection is closed
+// or the client stops responding to pings
+func (app *App) readSubscriptions(conn *websocket.Conn, sub *subscriber) {
+	conn.SetReadLimit(wsReadLimit)
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout)) // nolint:errcheck
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		var subscription types.UpdateSubscription
+		err := conn.ReadJSON(&subscription)
+		if err != nil {
+			return
+		}
+
+		address := subscription.Address
+		if address != "" {
+			address, err = types.NormalizeAddress(address)
+			if err != nil {
+				app.logger.Debug("ignoring invalid websocket subscription",
+					zap.Error(err),
+					zap.String("address", subscription.Address))
+				continue
+			}
+		}
+		sub.subscribe(address)
+	}
+}
+
+// checkOrigin applies the same allowed origins as CORS to WebSocket upgrades, requests that don't
+// come from a browser have no origin and are always allowed.
+func (app *App) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range app.config.CorsOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
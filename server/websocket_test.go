@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestApp_Updates(t *testing.T) {
+	app := NewApp(nil, zap.NewNop(), WithConfig(types.Config{CorsOrigins: []string{"*"}}))
+	defer app.cancel()
+
+	ts := httptest.NewServer(http.HandlerFunc(app.Updates))
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// updates published before the subscriber is registered are not sent
+	waitFor(t, func() bool { return subscribers(app.updates) == 1 })
+
+	app.updates.publish("s1.example.com:7777", 4, true)
+
+	var update types.ServerUpdate
+	conn.SetReadDeadline(time.Now().Add(time.Second)) // nolint:errcheck
+	require.NoError(t, conn.ReadJSON(&update))
+	assert.Equal(t, types.ServerUpdate{Event: types.EventOnline, Address: "s1.example.com:7777", Players: 4, Online: true}, update)
+
+	require.NoError(t, conn.WriteJSON(types.UpdateSubscription{Address: "s2.example.com:7777"}))
+	waitFor(t, func() bool {
+		app.updates.mu.Lock()
+		defer app.updates.mu.Unlock()
+		for s := range app.updates.subscribers {
+			return !s.wants("s1.example.com:7777")
+		}
+		return false
+	})
+
+	app.updates.publish("s1.example.com:7777", 5, true)
+	app.updates.publish("s2.example.com:7777", 6, true)
+
+	require.NoError(t, conn.ReadJSON(&update))
+	assert.Equal(t, "s2.example.com:7777", update.Address)
+
+	app.cancel()
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+}
+
+func TestApp_checkOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{"no origin", []string{"https://samp-servers.net"}, "", true},
+		{"wildcard", []string{"*"}, "https://example.com", true},
+		{"allowed", []string{"https://samp-servers.net"}, "https://samp-servers.net", true},
+		{"disallowed", []string{"https://samp-servers.net"}, "https://example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{config: types.Config{CorsOrigins: tt.allowed}}
+			r := httptest.NewRequest("GET", "/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			assert.Equal(t, tt.want, app.checkOrigin(r))
+		})
+	}
+}
+
+func subscribers(h *updateHub) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
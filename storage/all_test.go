@@ -1,25 +1,42 @@
 package storage
 
 import (
+	"fmt"
 	"os"
 	"testing"
+	"time"
 )
 
 var mgr *Manager
 
+// TestMain connects the Manager tests to a local MongoDB. When there isn't one they're skipped so
+// the MemoryStore tests can still run, except on CI where the database is always provided.
 func TestMain(m *testing.M) {
 	var err error
 	mgr, err = New(Config{
-		MongoHost:       "localhost",
-		MongoPort:       "27017",
-		MongoName:       "samplist",
-		MongoUser:       "root",
-		MongoPass:       "",
-		MongoCollection: "servers",
+		MongoHost:            "localhost",
+		MongoPort:            "27017",
+		MongoName:            "samplist",
+		MongoUser:            "root",
+		MongoPass:            "",
+		MongoCollection:      "servers",
+		MongoTimeout:         time.Second * 2,
+		MongoConnectAttempts: 1,
 	})
 	if err != nil {
-		panic(err)
+		if os.Getenv("TRAVIS") != "" {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "skipping MongoDB tests: %v\n", err)
+		mgr = nil
 	}
 
 	os.Exit(m.Run())
 }
+
+// requireMongo skips a test that needs the database when TestMain couldn't connect to one
+func requireMongo(t *testing.T) {
+	if mgr == nil {
+		t.Skip("MongoDB is not available")
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// AddBlock adds a block to the blocklist, replacing any existing block of the same address
+func (mgr *Manager) AddBlock(block types.Block) (err error) {
+	_, err = mgr.blocklist.Upsert(bson.M{"address": block.Address}, block)
+	return
+}
+
+// RemoveBlock removes the block of an address, ErrNotFound is returned if there isn't one
+func (mgr *Manager) RemoveBlock(address string) (err error) {
+	err = mgr.blocklist.Remove(bson.M{"address": address})
+	if err == mgo.ErrNotFound {
+		return ErrNotFound
+	}
+	return
+}
+
+// GetBlocklist returns every block, oldest first
+func (mgr *Manager) GetBlocklist() (blocklist types.Blocklist, err error) {
+	err = mgr.blocklist.Find(nil).Sort("created").All(&blocklist)
+	return
+}
+
+// IsBlocked reports whether a normalised server address is blocked by any block on the blocklist
+func IsBlocked(store Store, address string) (blocked bool, err error) {
+	blocklist, err := store.GetBlocklist()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to load blocklist")
+	}
+	_, blocked = blocklist.Blocks(address)
+	return
+}
+
+// RemoveBlocked deletes every stored server, active or not, that a block matches and returns their
+// addresses. Servers are checked one at a time since a range can't be matched by the store itself.
+func RemoveBlocked(store Store, block types.Block) (removed []string, err error) {
+	addresses, err := store.LoadAllAddresses()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load addresses")
+	}
+	for _, address := range addresses {
+		if !block.Matches(address) {
+			continue
+		}
+		err = store.RemoveServer(address)
+		if err == ErrNotFound {
+			continue // removed since the addresses were loaded
+		} else if err != nil {
+			return removed, errors.Wrapf(err, "failed to remove server '%s'", address)
+		}
+		removed = append(removed, address)
+	}
+	return removed, nil
+}
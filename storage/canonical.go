@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// UpsertCanonical stores a server under its canonical address. If the server was given under a
+// different address, such as a hostname that resolves to the canonical IP, that address is kept as
+// an alias and any record stored under it is removed so the server is only listed once.
+func UpsertCanonical(store Store, server *types.Server, canonical string) (err error) {
+	alias := server.Core.Address
+	if canonical != alias {
+		server.Core.Address = canonical
+		server.AddAliases(alias)
+	}
+
+	err = store.UpsertServer(*server)
+	if err != nil {
+		return
+	}
+
+	if canonical != alias {
+		err = store.RemoveServer(alias)
+		if err != nil && err != ErrNotFound {
+			return errors.Wrapf(err, "failed to remove duplicate of '%s'", canonical)
+		}
+		err = nil
+	}
+	return
+}
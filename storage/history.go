@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// AddSample records the player count of a server
+func (mgr *Manager) AddSample(sample types.PlayerSample) (err error) {
+	return mgr.history.Insert(sample)
+}
+
+// GetSamples returns the samples of a server from the start time up to but not including the end
+// time, ordered by time
+func (mgr *Manager) GetSamples(address string, from, to time.Time) (samples []types.PlayerSample, err error) {
+	err = mgr.history.Find(bson.M{
+		"address": address,
+		"time":    bson.M{"$gte": from, "$lt": to},
+	}).Sort("time").All(&samples)
+	return
+}
+
+// removeBatchSize is how many samples are removed by id at once when downsampling
+const removeBatchSize = 1000
+
+// storedSample is a sample along with its document id so exactly the samples that were averaged can
+// be removed afterwards
+type storedSample struct {
+	ID                 bson.ObjectId `bson:"_id"`
+	types.PlayerSample `bson:",inline"`
+}
+
+// DownsampleSamples replaces the samples recorded before the given time with their averages over
+// each interval, samples that have already been downsampled are left alone. Each server is done in
+// turn and its averages are inserted before the samples they replace are removed by id, so if
+// either fails the samples are left duplicated rather than lost and only one server's samples are
+// ever loaded at once.
+func (mgr *Manager) DownsampleSamples(before time.Time, interval time.Duration) (err error) {
+	query := bson.M{
+		"downsampled": bson.M{"$ne": true},
+		"time":        bson.M{"$lt": before},
+	}
+
+	var addresses []string
+	err = mgr.history.Find(query).Distinct("address", &addresses)
+	if err != nil {
+		return errors.Wrap(err, "failed to find servers with samples to downsample")
+	}
+
+	for _, address := range addresses {
+		err = mgr.downsampleServer(address, before, interval)
+		if err != nil {
+			return errors.Wrapf(err, "failed to downsample samples of '%s'", address)
+		}
+	}
+	return
+}
+
+// downsampleServer downsamples the samples of a single server, see DownsampleSamples
+func (mgr *Manager) downsampleServer(address string, before time.Time, interval time.Duration) (err error) {
+	var stored []storedSample
+	err = mgr.history.Find(bson.M{
+		"address":     address,
+		"downsampled": bson.M{"$ne": true},
+		"time":        bson.M{"$lt": before},
+	}).Sort("time").All(&stored)
+	if err != nil {
+		return errors.Wrap(err, "failed to load samples")
+	}
+	if len(stored) == 0 {
+		return
+	}
+
+	samples := make([]types.PlayerSample, len(stored))
+	ids := make([]bson.ObjectId, len(stored))
+	for i := range stored {
+		samples[i] = stored[i].PlayerSample
+		ids[i] = stored[i].ID
+	}
+
+	averaged := downsample(samples, interval)
+	docs := make([]interface{}, len(averaged))
+	for i := range averaged {
+		docs[i] = averaged[i]
+	}
+	err = mgr.history.Insert(docs...)
+	if err != nil {
+		return errors.Wrap(err, "failed to insert downsampled samples")
+	}
+
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > removeBatchSize {
+			batch = batch[:removeBatchSize]
+		}
+		_, err = mgr.history.RemoveAll(bson.M{"_id": bson.M{"$in": batch}})
+		if err != nil {
+			return errors.Wrap(err, "failed to remove downsampled samples")
+		}
+		ids = ids[len(batch):]
+	}
+	return
+}
+
+// RemoveSamples deletes every sample recorded before the given time
+func (mgr *Manager) RemoveSamples(before time.Time) (err error) {
+	_, err = mgr.history.RemoveAll(bson.M{"time": bson.M{"$lt": before}})
+	return
+}
+
+// downsample averages samples, which must be ordered by address then time, over each interval
+func downsample(samples []types.PlayerSample, interval time.Duration) (averaged []types.PlayerSample) {
+	var (
+		current types.PlayerSample
+		sum     float64
+		n       int
+	)
+	flush := func() {
+		if n > 0 {
+			current.Players = sum / float64(n)
+			averaged = append(averaged, current)
+		}
+	}
+	for _, sample := range samples {
+		bucket := sample.Time.Truncate(interval)
+		if n == 0 || sample.Address != current.Address || !bucket.Equal(current.Time) {
+			flush()
+			current = types.PlayerSample{Address: sample.Address, Time: bucket, Downsampled: true}
+			sum, n = 0, 0
+		}
+		sum += sample.Players
+		n++
+	}
+	flush()
+	return
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestManager_History(t *testing.T) {
+	requireMongo(t)
+
+	start := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int, players float64) types.PlayerSample {
+		return types.PlayerSample{Address: "history.example.com:7777", Time: start.Add(time.Duration(minutes) * time.Minute), Players: players}
+	}
+	for _, sample := range []types.PlayerSample{at(20, 4), at(10, 2), at(70, 6)} {
+		assert.NoError(t, mgr.AddSample(sample))
+	}
+
+	assert.NoError(t, mgr.DownsampleSamples(start.Add(time.Hour), time.Hour))
+
+	samples, err := mgr.GetSamples("history.example.com:7777", start, start.Add(time.Hour*2))
+	assert.NoError(t, err)
+	for i := range samples {
+		samples[i].Time = samples[i].Time.UTC() // mgo decodes times as local
+	}
+	assert.Equal(t, []types.PlayerSample{
+		{Address: "history.example.com:7777", Time: start, Players: 3, Downsampled: true},
+		at(70, 6),
+	}, samples)
+}
+
+// samples are stored as plain PlayerSamples and have to decode along with their id for downsampling
+func TestStoredSample(t *testing.T) {
+	sample := types.PlayerSample{Address: "history.example.com:7777", Time: time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC), Players: 3}
+	doc, err := bson.Marshal(sample)
+	require.NoError(t, err)
+	var raw bson.M
+	require.NoError(t, bson.Unmarshal(doc, &raw))
+	raw["_id"] = bson.NewObjectId()
+	doc, err = bson.Marshal(raw)
+	require.NoError(t, err)
+
+	var stored storedSample
+	require.NoError(t, bson.Unmarshal(doc, &stored))
+	stored.Time = stored.Time.UTC()
+	assert.Equal(t, raw["_id"], stored.ID)
+	assert.Equal(t, sample, stored.PlayerSample)
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// GetIdempotentResponse looks up the response stored for an idempotency key, ErrNotFound is returned
+// if there isn't one
+func (mgr *Manager) GetIdempotentResponse(key string) (response types.IdempotentResponse, err error) {
+	err = mgr.idempotency.Find(bson.M{"key": key}).One(&response)
+	if err == mgo.ErrNotFound {
+		err = ErrNotFound
+	}
+	return
+}
+
+// SetIdempotentResponse stores the response for its idempotency key, replacing any existing one
+func (mgr *Manager) SetIdempotentResponse(response types.IdempotentResponse) (err error) {
+	_, err = mgr.idempotency.Upsert(bson.M{"key": response.Key}, response)
+	return
+}
+
+// RemoveIdempotentResponses deletes every response stored before the given time
+func (mgr *Manager) RemoveIdempotentResponses(before time.Time) (err error) {
+	_, err = mgr.idempotency.RemoveAll(bson.M{"created": bson.M{"$lt": before}})
+	return
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestManager_Idempotency(t *testing.T) {
+	requireMongo(t)
+
+	created := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := types.IdempotentResponse{Key: "idempotency-1", Method: "POST", Path: "/v2/server", Status: 200, ContentType: "application/json", Body: []byte("{}"), Created: created}
+	second := first
+	second.Status = 422
+	second.Created = created.Add(time.Hour * 24)
+	assert.NoError(t, mgr.SetIdempotentResponse(first))
+	assert.NoError(t, mgr.SetIdempotentResponse(second))
+
+	got, err := mgr.GetIdempotentResponse("idempotency-1")
+	assert.NoError(t, err)
+	got.Created = got.Created.UTC() // mgo decodes times as local
+	assert.Equal(t, second, got)
+
+	assert.NoError(t, mgr.RemoveIdempotentResponses(created.Add(time.Hour*25)))
+
+	_, err = mgr.GetIdempotentResponse("idempotency-1")
+	assert.Equal(t, ErrNotFound, err)
+}
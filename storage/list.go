@@ -1,7 +1,10 @@
 package storage
 
 import (
-	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 
 	"github.com/Southclaws/samp-servers-api/types"
@@ -9,71 +12,199 @@ import (
 
 // GetServers returns a slice of Core objects
 func (mgr *Manager) GetServers(pageNum int, pageSize types.PageSize, sort types.SortOrder, by types.SortColumn, filters []types.FilterAttribute) (servers []types.ServerCore, err error) {
-	selected := []types.Server{}
+	err = mgr.StreamServers(types.ServerListParams{
+		Page:     pageNum,
+		PageSize: pageSize,
+		Sort:     sort,
+		By:       by,
+		Filters:  filters,
+	}, func(server types.Server) error {
+		servers = append(servers, server.Core)
+		return nil
+	})
+	return
+}
+
+// StreamServers calls fn for each server that matches the list parameters, in order, without
+// loading the entire result set into memory. Iteration stops at the first error returned by fn.
+func (mgr *Manager) StreamServers(params types.ServerListParams, fn func(types.Server) error) (err error) {
+	if params.Paginated() {
+		return mgr.streamServersAfter(params, fn)
+	}
 
+	pageNum := params.Page
 	if pageNum <= 0 {
 		pageNum = 0
 	} else {
 		pageNum = pageNum - 1 // subtract 1 so 1 becomes 0, "page 1" makes more sense to users
 	}
 
+	pageSize := params.PageSize
 	if pageSize <= 0 {
 		pageSize = types.PageSizeDefault
 	}
 
-	var sortBy types.SortOrder
-
-	if sort == "" {
-		sortBy = "-"
-	} else {
-		switch sort {
-		case types.SortAsc:
-			sortBy = ""
-		case types.SortDesc:
-			sortBy = "-"
-		default:
-			err = errors.Errorf("invalid 'sort' argument '%s'", sort)
-			return
-		}
+	field, desc, err := listOrder(params)
+	if err != nil {
+		return
 	}
-
-	if by == "" {
-		sortBy += "core.players"
-	} else {
-		switch by {
-		case types.ByPlayers:
-			sortBy += "core.players"
-		default:
-			err = errors.Errorf("invalid 'by' argument '%s'", by)
-			return
-		}
+	sortBy := field
+	if desc {
+		sortBy = "-" + sortBy
 	}
 
-	query := bson.M{"active": true}
+	fields := []string{sortBy, "_id"} // ids increase as servers are inserted so ties keep insertion order
+	featuredFirst, err := params.FeaturedFirst()
+	if err != nil {
+		return
+	}
+	if featuredFirst {
+		fields = append([]string{"-featured"}, fields...)
+	}
 
-	if len(filters) > 0 {
-		for _, filter := range filters {
-			switch filter {
-			case types.FilterPassword:
-				query["core.password"] = false
-			case types.FilterEmpty:
-				query["core.players"] = bson.M{"$gt": 0}
-			case types.FilterFull:
-				query["$where"] = "this.core.players < this.core.maxplayers"
-			}
-		}
+	query, err := listQuery(params)
+	if err != nil {
+		return
 	}
 
-	err = mgr.collection.
+	iter := mgr.collection.
 		Find(query).
-		Sort(string(sortBy)).
+		Sort(fields...).
 		Skip(pageNum * int(pageSize)).
 		Limit(int(pageSize)).
-		All(&selected)
-	if err == nil {
-		for i := range selected {
-			servers = append(servers, selected[i].Core)
+		Iter()
+
+	return streamIter(iter, fn)
+}
+
+// streamServersAfter lists servers ordered by address starting after the address in the cursor,
+// the limit is used as-is so callers may request more than the clamped limit to detect a next page.
+func (mgr *Manager) streamServersAfter(params types.ServerListParams, fn func(types.Server) error) (err error) {
+	query, err := listQuery(params)
+	if err != nil {
+		return
+	}
+
+	if params.Cursor != "" {
+		after, err := types.DecodeCursor(params.Cursor)
+		if err != nil {
+			return err
 		}
+		query["core.address"] = bson.M{"$gt": after}
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = types.LimitDefault
+	}
+
+	iter := mgr.collection.
+		Find(query).
+		Sort("core.address").
+		Limit(limit).
+		Iter()
+
+	return streamIter(iter, fn)
+}
+
+// listOrder returns the stored field that a page-based listing is sorted by and whether the order
+// is descending.
+func listOrder(params types.ServerListParams) (field string, desc bool, err error) {
+	by, desc, err := params.Order()
+	if err != nil {
+		return
+	}
+
+	switch by {
+	case types.ByPlayers:
+		field = "core.players"
+	case types.ByPing:
+		field = "ping"
+	case types.ByName:
+		field = "core.hostname"
 	}
 	return
 }
+
+// listQuery builds the database query for the filters in the list parameters, all of the filters
+// must match for a server to be listed.
+func listQuery(params types.ServerListParams) (query bson.M, err error) {
+	conditions := []bson.M{{"active": true}}
+
+	for _, filter := range params.Filters {
+		switch filter {
+		case types.FilterPassword:
+			conditions = append(conditions, bson.M{"core.password": false})
+		case types.FilterEmpty:
+			conditions = append(conditions, bson.M{"core.players": bson.M{"$gt": 0}})
+		case types.FilterFull:
+			conditions = append(conditions, bson.M{"$where": "this.core.players < this.core.maxplayers"})
+		}
+	}
+
+	if params.Gamemode != "" {
+		conditions = append(conditions, bson.M{"core.gamemode": containsInsensitive(params.Gamemode)})
+	}
+	if languages := types.NormalizeLanguage(params.Language); languages != nil {
+		patterns := make([]interface{}, len(languages))
+		for i, language := range languages {
+			patterns[i] = bson.RegEx{Pattern: "^" + regexp.QuoteMeta(language) + "$", Options: "i"}
+		}
+		conditions = append(conditions, bson.M{"core.languages": bson.M{"$in": patterns}})
+	}
+	if version := strings.TrimSpace(params.Version); version != "" {
+		conditions = append(conditions, bson.M{"core.version": bson.RegEx{Pattern: versionPattern(version), Options: "i"}})
+	}
+	password, err := params.PasswordFilter()
+	if err != nil {
+		return
+	}
+	if password != nil {
+		conditions = append(conditions, bson.M{"core.password": *password})
+	}
+	includeDead, err := params.IncludesDead()
+	if err != nil {
+		return
+	}
+	if !includeDead {
+		conditions = append(conditions, bson.M{"deadsince": nil})
+	}
+	openmp, err := params.ForkFilter()
+	if err != nil {
+		return
+	}
+	if openmp != nil {
+		// servers stored before open.mp was detected don't have the field at all
+		conditions = append(conditions, bson.M{"openmp": bson.M{"$ne": !*openmp}})
+	}
+
+	return bson.M{"$and": conditions}, nil
+}
+
+// containsInsensitive matches fields that contain the substring, ignoring case
+func containsInsensitive(substring string) bson.RegEx {
+	return bson.RegEx{Pattern: regexp.QuoteMeta(substring), Options: "i"}
+}
+
+// versionPattern matches versions starting with the prefix, a prefix ending in a digit must not be
+// followed by another digit so that 0.3.7 matches 0.3.7-R2 and 0.3.7.1 but not 0.3.71
+func versionPattern(prefix string) string {
+	pattern := "^" + regexp.QuoteMeta(prefix)
+	if last := prefix[len(prefix)-1]; last >= '0' && last <= '9' {
+		pattern += "($|[^0-9])"
+	}
+	return pattern
+}
+
+func streamIter(iter *mgo.Iter, fn func(types.Server) error) (err error) {
+	var server types.Server
+	for iter.Next(&server) {
+		err = fn(server)
+		if err != nil {
+			iter.Close() // nolint:errcheck
+			return
+		}
+		server = types.Server{}
+	}
+	return iter.Close()
+}
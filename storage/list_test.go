@@ -10,6 +10,8 @@ import (
 
 //nolint
 func TestManager_GetServers(t *testing.T) {
+	requireMongo(t)
+
 	type args struct {
 		page   int
 		size   types.PageSize
@@ -27,10 +29,10 @@ func TestManager_GetServers(t *testing.T) {
 			"v no sort",
 			args{1, 0, "", "", []types.FilterAttribute{}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"s4.example.com", "test server 4", 50, 50, "rivershell", "Polish", true, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
-				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"s4.example.com", "test server 4", 50, 50, "rivershell", "Polish", true, "0.3.7-R2", "", []string{"Polish"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -38,10 +40,10 @@ func TestManager_GetServers(t *testing.T) {
 			"v desc",
 			args{1, 0, "asc", "", []types.FilterAttribute{}},
 			[]types.ServerCore{
-				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
-				{"s4.example.com", "test server 4", 50, 50, "rivershell", "Polish", true, "0.3.7-R2"},
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
+				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"s4.example.com", "test server 4", 50, 50, "rivershell", "Polish", true, "0.3.7-R2", "", []string{"Polish"}},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -49,9 +51,9 @@ func TestManager_GetServers(t *testing.T) {
 			"v pass",
 			args{1, 0, "", "", []types.FilterAttribute{types.FilterPassword}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
-				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -59,9 +61,9 @@ func TestManager_GetServers(t *testing.T) {
 			"v empty",
 			args{1, 0, "", "", []types.FilterAttribute{types.FilterEmpty}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"s4.example.com", "test server 4", 50, 50, "rivershell", "Polish", true, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"s4.example.com", "test server 4", 50, 50, "rivershell", "Polish", true, "0.3.7-R2", "", []string{"Polish"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -69,9 +71,9 @@ func TestManager_GetServers(t *testing.T) {
 			"v full",
 			args{1, 0, "", "", []types.FilterAttribute{types.FilterFull}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
-				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -79,8 +81,8 @@ func TestManager_GetServers(t *testing.T) {
 			"v pass empty",
 			args{1, 0, "", "", []types.FilterAttribute{types.FilterPassword, types.FilterEmpty}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -88,9 +90,9 @@ func TestManager_GetServers(t *testing.T) {
 			"v pass full",
 			args{1, 0, "", "", []types.FilterAttribute{types.FilterPassword, types.FilterFull}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
-				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"s2.example.com", "test server 2", 0, 100, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -98,8 +100,8 @@ func TestManager_GetServers(t *testing.T) {
 			"v empty full",
 			args{1, 0, "", "", []types.FilterAttribute{types.FilterEmpty, types.FilterFull}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -107,7 +109,7 @@ func TestManager_GetServers(t *testing.T) {
 			"limit to 1",
 			args{1, 1, "", "", []types.FilterAttribute{types.FilterPassword, types.FilterFull}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -115,7 +117,7 @@ func TestManager_GetServers(t *testing.T) {
 			"get second page",
 			args{2, 1, "", "", []types.FilterAttribute{types.FilterPassword, types.FilterFull}},
 			[]types.ServerCore{
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -123,8 +125,8 @@ func TestManager_GetServers(t *testing.T) {
 			"get multiple per page",
 			args{1, 2, "", "", []types.FilterAttribute{types.FilterPassword, types.FilterFull}},
 			[]types.ServerCore{
-				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2"},
-				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2"},
+				{"s3.example.com", "test server 3", 948, 1000, "Grand Larceny", "English", false, "0.3.7-R2", "", []string{"English"}},
+				{"ss.southcla.ws", "Scavenge and Survive Official", 4, 32, "Scavenge & Survive by Southclaws", "English", false, "0.3.7-R2", "", []string{"English"}},
 			},
 			false,
 		},
@@ -137,3 +139,97 @@ func TestManager_GetServers(t *testing.T) {
 		})
 	}
 }
+
+func TestManager_StreamServers_Paginated(t *testing.T) {
+	requireMongo(t)
+
+	tests := []struct {
+		name          string
+		params        types.ServerListParams
+		wantAddresses []string
+	}{
+		{"first page", types.ServerListParams{Limit: 2}, []string{"s2.example.com", "s3.example.com"}},
+		{"second page", types.ServerListParams{Limit: 2, Cursor: types.EncodeCursor("s3.example.com")}, []string{"s4.example.com", "ss.southcla.ws"}},
+		{"last page", types.ServerListParams{Limit: 2, Cursor: types.EncodeCursor("ss.southcla.ws")}, nil},
+		{"filtered", types.ServerListParams{Limit: 2, Filters: []types.FilterAttribute{types.FilterEmpty}}, []string{"s3.example.com", "s4.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAddresses []string
+			err := mgr.StreamServers(tt.params, func(server types.Server) error {
+				gotAddresses = append(gotAddresses, server.Core.Address)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAddresses, gotAddresses)
+		})
+	}
+}
+
+func TestManager_StreamServers_Sorted(t *testing.T) {
+	requireMongo(t)
+
+	tests := []struct {
+		name          string
+		params        types.ServerListParams
+		wantAddresses []string
+		wantErr       bool
+	}{
+		{"players", types.ServerListParams{Sort: "players"}, []string{"s2.example.com", "ss.southcla.ws", "s4.example.com", "s3.example.com"}, false},
+		{"-players", types.ServerListParams{Sort: "-players"}, []string{"s3.example.com", "s4.example.com", "ss.southcla.ws", "s2.example.com"}, false},
+		{"name", types.ServerListParams{Sort: "name"}, []string{"ss.southcla.ws", "s2.example.com", "s3.example.com", "s4.example.com"}, false},
+		{"-name", types.ServerListParams{Sort: "-name"}, []string{"s4.example.com", "s3.example.com", "s2.example.com", "ss.southcla.ws"}, false},
+		{"unknown", types.ServerListParams{Sort: "mapname"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAddresses []string
+			err := mgr.StreamServers(tt.params, func(server types.Server) error {
+				gotAddresses = append(gotAddresses, server.Core.Address)
+				return nil
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantAddresses, gotAddresses)
+			}
+		})
+	}
+}
+
+func TestManager_StreamServers_Filtered(t *testing.T) {
+	requireMongo(t)
+
+	tests := []struct {
+		name          string
+		params        types.ServerListParams
+		wantAddresses []string
+		wantErr       bool
+	}{
+		{"gamemode", types.ServerListParams{Gamemode: "grand"}, []string{"s3.example.com", "s2.example.com"}, false},
+		{"language", types.ServerListParams{Language: "POL"}, []string{"s4.example.com"}, false},
+		{"password", types.ServerListParams{Password: "true"}, []string{"s4.example.com"}, false},
+		{"gamemode password", types.ServerListParams{Gamemode: "larceny", Password: "false"}, []string{"s3.example.com", "s2.example.com"}, false},
+		{"exclude passworded", types.ServerListParams{IncludePassworded: "false"}, []string{"s3.example.com", "ss.southcla.ws", "s2.example.com"}, false},
+		{"password overrides exclude", types.ServerListParams{Password: "true", IncludePassworded: "false"}, []string{"s4.example.com"}, false},
+		{"conflicting", types.ServerListParams{Password: "true", Filters: []types.FilterAttribute{types.FilterPassword}}, nil, false},
+		{"regex escaped", types.ServerListParams{Gamemode: ".*"}, nil, false},
+		{"invalid password", types.ServerListParams{Password: "maybe"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAddresses []string
+			err := mgr.StreamServers(tt.params, func(server types.Server) error {
+				gotAddresses = append(gotAddresses, server.Core.Address)
+				return nil
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantAddresses, gotAddresses)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// GetMany looks up the servers at many addresses at once and returns them keyed by each address as
+// it was given, which is nil if there's no server at the address or it's not a valid address. The
+// addresses are normalised before they're looked up so "1.2.3.4" finds the server at "1.2.3.4:7777",
+// and aliases are followed in the same way as GetServer.
+func GetMany(store Store, addresses []string) (servers map[string]*types.Server, err error) {
+	servers = make(map[string]*types.Server, len(addresses))
+	normalised := make(map[string][]string, len(addresses))
+	lookup := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		servers[address] = nil
+		normal, err := types.NormalizeAddress(address)
+		if err != nil {
+			continue
+		}
+		if _, ok := normalised[normal]; !ok {
+			lookup = append(lookup, normal)
+		}
+		normalised[normal] = append(normalised[normal], address)
+	}
+	if len(lookup) == 0 {
+		return
+	}
+
+	found, err := store.GetServersByAddress(lookup)
+	if err != nil {
+		return nil, err
+	}
+	for i := range found {
+		server := &found[i]
+		for _, address := range append([]string{server.Core.Address}, server.Aliases...) {
+			for _, requested := range normalised[address] {
+				servers[requested] = server
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,780 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// MemoryStore is a Store that keeps servers in memory, it's safe for concurrent use. It's intended
+// for tests and small deployments where running MongoDB isn't worth it, nothing is persisted.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	servers     map[string]types.Server
+	inserted    map[string]int // insertion sequence number of each server, used to break ties
+	next        int
+	webhooks    map[string][]types.Webhook
+	blocklist   types.Blocklist
+	samples     map[string][]types.PlayerSample
+	idempotency map[string]types.IdempotentResponse
+}
+
+var _ Store = &MemoryStore{}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		servers:     make(map[string]types.Server),
+		inserted:    make(map[string]int),
+		webhooks:    make(map[string][]types.Webhook),
+		samples:     make(map[string][]types.PlayerSample),
+		idempotency: make(map[string]types.IdempotentResponse),
+	}
+}
+
+// GetServer looks up an active server via the address or one of its aliases
+func (ms *MemoryStore) GetServer(address string) (server types.Server, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	server, ok := ms.servers[address]
+	if ok && server.Active {
+		return copyServer(server), nil
+	}
+	for _, server := range ms.servers {
+		if !server.Active {
+			continue
+		}
+		for _, alias := range server.Aliases {
+			if alias == address {
+				return copyServer(server), nil
+			}
+		}
+	}
+	return types.Server{}, ErrNotFound
+}
+
+// GetServersByAddress returns the active servers at any of the addresses or with any of them as an
+// alias
+func (ms *MemoryStore) GetServersByAddress(addresses []string) (servers []types.Server, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		wanted[address] = true
+	}
+	for _, server := range ms.servers {
+		if !server.Active {
+			continue
+		}
+		match := wanted[server.Core.Address]
+		for _, alias := range server.Aliases {
+			match = match || wanted[alias]
+		}
+		if match {
+			servers = append(servers, copyServer(server))
+		}
+	}
+	return
+}
+
+// GetQueryPort returns the query port of a server, active or not, or ErrNotFound if there isn't one
+func (ms *MemoryStore) GetQueryPort(address string) (port int, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	server, ok := ms.servers[address]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return server.QueryPort, nil
+}
+
+// UpsertServer creates or replaces a server, implicitly sets `Active` to true. The peak player
+// counts, featured status and aliases of an existing server are kept.
+func (ms *MemoryStore) UpsertServer(server types.Server) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	server.Active = true
+	existing, ok := ms.servers[server.Core.Address]
+	if !ok {
+		ms.inserted[server.Core.Address] = ms.next
+		ms.next++
+	}
+	server.PeakPlayers = existing.PeakPlayers
+	server.PeakPlayers24h = existing.PeakPlayers24h
+	server.Featured = existing.Featured
+	added := server.Aliases
+	server.Aliases = append([]string(nil), existing.Aliases...)
+	server.AddAliases(added...)
+	ms.servers[server.Core.Address] = copyServer(server)
+	return
+}
+
+// UpdateServerInfo updates the info query fields of a server and marks it as online, reviving it if
+// it was dead
+func (ms *MemoryStore) UpdateServerInfo(core types.ServerCore, seen time.Time) (err error) {
+	return ms.update(core.Address, func(server *types.Server) {
+		core.Version = server.Core.Version
+		server.Core = core
+		server.ContentHash = ""
+		server.MarkSeen(seen)
+	})
+}
+
+// TouchServer updates when an active server was last seen and its ping if it's stored with the
+// content hash
+func (ms *MemoryStore) TouchServer(address, hash string, seen time.Time, ping int) (touched bool, err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	server, ok := ms.servers[address]
+	if !ok || !server.Active || hash == "" || server.ContentHash != hash {
+		return false, nil
+	}
+	server.MarkSeen(seen)
+	server.Ping = ping
+	ms.servers[address] = server
+	return true, nil
+}
+
+// UpdatePeakPlayers raises the all-time peak of a server to players if it's higher and sets its 24
+// hour peak
+func (ms *MemoryStore) UpdatePeakPlayers(address string, players, peak24h int) (err error) {
+	return ms.update(address, func(server *types.Server) {
+		if players > server.PeakPlayers {
+			server.PeakPlayers = players
+		}
+		server.PeakPlayers24h = peak24h
+	})
+}
+
+// SetFeatured sets whether an active server is featured, ErrNotFound is returned if there isn't one
+func (ms *MemoryStore) SetFeatured(address string, featured bool) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	server, ok := ms.servers[address]
+	if !ok || !server.Active {
+		return ErrNotFound
+	}
+	server.Featured = featured
+	ms.servers[address] = server
+	return
+}
+
+// SetOffline marks a server as offline without archiving it and clears its ping
+func (ms *MemoryStore) SetOffline(address string) (err error) {
+	return ms.update(address, func(server *types.Server) {
+		server.Online = false
+		server.Ping = 0
+	})
+}
+
+// ArchiveServer marks a server as inactive
+func (ms *MemoryStore) ArchiveServer(address string) (err error) {
+	return ms.update(address, func(server *types.Server) {
+		server.Active = false
+	})
+}
+
+// RemoveServer deletes a server, ErrNotFound is returned if it did not exist
+func (ms *MemoryStore) RemoveServer(address string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.servers[address]; !ok {
+		return ErrNotFound
+	}
+	delete(ms.servers, address)
+	delete(ms.inserted, address)
+	return
+}
+
+// MarkDead sets the time a server stopped responding, the time isn't changed if it's already dead
+func (ms *MemoryStore) MarkDead(address string, since time.Time) (err error) {
+	err = ms.update(address, func(server *types.Server) {
+		if server.DeadSince == nil {
+			server.DeadSince = &since
+		}
+	})
+	if err == ErrNotFound {
+		err = nil
+	}
+	return
+}
+
+// RecordFailure increments the consecutive failures of a server and returns the new count
+func (ms *MemoryStore) RecordFailure(address string) (failures int, err error) {
+	err = ms.update(address, func(server *types.Server) {
+		server.ConsecutiveFailures++
+		failures = server.ConsecutiveFailures
+	})
+	return
+}
+
+// RemoveDead deletes every server that has been dead since before the given time and returns their
+// addresses, ordered by address
+func (ms *MemoryStore) RemoveDead(before time.Time) (removed []string, err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for address, server := range ms.servers {
+		if server.DeadSince != nil && server.DeadSince.Before(before) {
+			delete(ms.servers, address)
+			delete(ms.inserted, address)
+			removed = append(removed, address)
+		}
+	}
+	sort.Strings(removed)
+	return
+}
+
+// RemoveNotSeenSince deletes every server that was last seen before the given time and returns their
+// addresses in order, servers that have never been seen are kept
+func (ms *MemoryStore) RemoveNotSeenSince(since time.Time) (removed []string, err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for address, server := range ms.servers {
+		if server.LastSeen != nil && server.LastSeen.Before(since) {
+			delete(ms.servers, address)
+			delete(ms.inserted, address)
+			removed = append(removed, address)
+		}
+	}
+	sort.Strings(removed)
+	return
+}
+
+func (ms *MemoryStore) update(address string, fn func(*types.Server)) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	server, ok := ms.servers[address]
+	if !ok {
+		return ErrNotFound
+	}
+	fn(&server)
+	ms.servers[address] = server
+	return
+}
+
+// StreamServers calls fn for each active server that matches the list parameters, in the same
+// order as the MongoDB implementation. Servers with equal sort keys keep their insertion order.
+func (ms *MemoryStore) StreamServers(params types.ServerListParams, fn func(types.Server) error) (err error) {
+	match, err := listMatcher(params)
+	if err != nil {
+		return
+	}
+
+	servers := ms.sorted(match)
+
+	if params.Paginated() {
+		if params.Cursor != "" {
+			after, err := types.DecodeCursor(params.Cursor)
+			if err != nil {
+				return err
+			}
+			i := sort.Search(len(servers), func(i int) bool { return servers[i].Core.Address > after })
+			servers = servers[i:]
+		}
+
+		limit := params.Limit
+		if limit <= 0 {
+			limit = types.LimitDefault
+		}
+		servers = servers[:minInt(limit, len(servers))]
+	} else {
+		by, desc, err := params.Order()
+		if err != nil {
+			return err
+		}
+		featuredFirst, err := params.FeaturedFirst()
+		if err != nil {
+			return err
+		}
+		ms.insertionOrder(servers)
+		sort.SliceStable(servers, func(i, j int) bool {
+			if featuredFirst && servers[i].Featured != servers[j].Featured {
+				return servers[i].Featured
+			}
+			c := compareBy(servers[i], servers[j], by)
+			if desc {
+				return c > 0
+			}
+			return c < 0
+		})
+
+		pageSize := int(params.PageSize)
+		if pageSize <= 0 {
+			pageSize = int(types.PageSizeDefault)
+		}
+		page := params.Page - 1
+		if page < 0 {
+			page = 0
+		}
+		start := minInt(page*pageSize, len(servers))
+		servers = servers[start:minInt(start+pageSize, len(servers))]
+	}
+
+	for _, server := range servers {
+		err = fn(server)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// SearchServers returns the active servers where every token is contained in either the hostname
+// or the gamemode, regardless of case.
+func (ms *MemoryStore) SearchServers(tokens []string) (servers []types.Server, err error) {
+	servers = ms.sorted(func(server types.Server) bool {
+		if !server.Active {
+			return false
+		}
+		hostname := strings.ToLower(server.Core.Hostname)
+		gamemode := strings.ToLower(server.Core.Gamemode)
+		for _, token := range tokens {
+			token = strings.ToLower(token)
+			if !strings.Contains(hostname, token) && !strings.Contains(gamemode, token) {
+				return false
+			}
+		}
+		return true
+	})
+	return
+}
+
+// LoadAllAddresses returns the address of every stored server
+func (ms *MemoryStore) LoadAllAddresses() (result []string, err error) {
+	for _, server := range ms.sorted(func(types.Server) bool { return true }) {
+		result = append(result, server.Core.Address)
+	}
+	return
+}
+
+// GetActiveServers returns the number of active servers
+func (ms *MemoryStore) GetActiveServers() (servers int, err error) {
+	return ms.count(func(server types.Server) bool { return server.Active }), nil
+}
+
+// GetInactiveServers returns the number of inactive servers
+func (ms *MemoryStore) GetInactiveServers() (servers int, err error) {
+	return ms.count(func(server types.Server) bool { return !server.Active }), nil
+}
+
+// GetOnlineServers returns the number of active servers that responded to their most recent query
+func (ms *MemoryStore) GetOnlineServers() (servers int, err error) {
+	return ms.count(func(server types.Server) bool { return server.Active && server.Online }), nil
+}
+
+// GetTotalPlayers returns the number of total players
+func (ms *MemoryStore) GetTotalPlayers() (players int, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, server := range ms.servers {
+		players += server.Core.Players
+	}
+	return
+}
+
+// GetServersPerLanguage returns the number of active servers for each language
+func (ms *MemoryStore) GetServersPerLanguage() (counts map[string]int, err error) {
+	return ms.countActiveBy(func(server types.Server) string { return server.Core.Language }), nil
+}
+
+// GetServersPerGamemode returns the number of active servers for each gamemode
+func (ms *MemoryStore) GetServersPerGamemode() (counts map[string]int, err error) {
+	return ms.countActiveBy(func(server types.Server) string { return server.Core.Gamemode }), nil
+}
+
+// AddWebhook stores a webhook registration
+func (ms *MemoryStore) AddWebhook(hook types.Webhook) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.webhooks[hook.Address] = append(ms.webhooks[hook.Address], hook)
+	return
+}
+
+// GetWebhooks returns the webhooks registered for a server address, oldest first
+func (ms *MemoryStore) GetWebhooks(address string) (hooks []types.Webhook, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return append([]types.Webhook(nil), ms.webhooks[address]...), nil
+}
+
+// GetWebhook returns a webhook by its ID, ErrNotFound is returned if there isn't one
+func (ms *MemoryStore) GetWebhook(id string) (hook types.Webhook, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, hooks := range ms.webhooks {
+		for _, hook := range hooks {
+			if hook.ID == id {
+				return hook, nil
+			}
+		}
+	}
+	return types.Webhook{}, ErrNotFound
+}
+
+// RemoveWebhook removes a webhook by its ID, ErrNotFound is returned if there isn't one
+func (ms *MemoryStore) RemoveWebhook(id string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for address, hooks := range ms.webhooks {
+		for i, hook := range hooks {
+			if hook.ID == id {
+				ms.webhooks[address] = append(hooks[:i:i], hooks[i+1:]...)
+				return
+			}
+		}
+	}
+	return ErrNotFound
+}
+
+// AddBlock adds a block to the blocklist, replacing any existing block of the same address
+func (ms *MemoryStore) AddBlock(block types.Block) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	blocklist := ms.blocklist[:0:0]
+	for _, existing := range ms.blocklist {
+		if existing.Address != block.Address {
+			blocklist = append(blocklist, existing)
+		}
+	}
+	ms.blocklist = append(blocklist, block)
+	return
+}
+
+// RemoveBlock removes the block of an address, ErrNotFound is returned if there isn't one
+func (ms *MemoryStore) RemoveBlock(address string) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for i, existing := range ms.blocklist {
+		if existing.Address == address {
+			ms.blocklist = append(ms.blocklist[:i:i], ms.blocklist[i+1:]...)
+			return
+		}
+	}
+	return ErrNotFound
+}
+
+// GetBlocklist returns every block, oldest first
+func (ms *MemoryStore) GetBlocklist() (blocklist types.Blocklist, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return append(types.Blocklist(nil), ms.blocklist...), nil
+}
+
+// AddSample records the player count of a server
+func (ms *MemoryStore) AddSample(sample types.PlayerSample) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	// samples almost always arrive in order so this is nearly always an append
+	samples := ms.samples[sample.Address]
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].Time.After(sample.Time) })
+	samples = append(samples, types.PlayerSample{})
+	copy(samples[i+1:], samples[i:])
+	samples[i] = sample
+	ms.samples[sample.Address] = samples
+	return
+}
+
+// GetSamples returns the samples of a server from the start time up to but not including the end
+// time, ordered by time
+func (ms *MemoryStore) GetSamples(address string, from, to time.Time) (samples []types.PlayerSample, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, sample := range ms.samples[address] {
+		if !sample.Time.Before(from) && sample.Time.Before(to) {
+			samples = append(samples, sample)
+		}
+	}
+	return
+}
+
+// DownsampleSamples replaces the samples recorded before the given time with their averages over
+// each interval, samples that have already been downsampled are left alone
+func (ms *MemoryStore) DownsampleSamples(before time.Time, interval time.Duration) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for address, samples := range ms.samples {
+		var old, kept []types.PlayerSample
+		for _, sample := range samples {
+			if !sample.Downsampled && sample.Time.Before(before) {
+				old = append(old, sample)
+			} else {
+				kept = append(kept, sample)
+			}
+		}
+		kept = append(kept, downsample(old, interval)...)
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].Time.Before(kept[j].Time) })
+		ms.samples[address] = kept
+	}
+	return
+}
+
+// RemoveSamples deletes every sample recorded before the given time
+func (ms *MemoryStore) RemoveSamples(before time.Time) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for address, samples := range ms.samples {
+		var kept []types.PlayerSample
+		for _, sample := range samples {
+			if !sample.Time.Before(before) {
+				kept = append(kept, sample)
+			}
+		}
+		if kept == nil {
+			delete(ms.samples, address)
+		} else {
+			ms.samples[address] = kept
+		}
+	}
+	return
+}
+
+// GetIdempotentResponse looks up the response stored for an idempotency key, ErrNotFound is returned
+// if there isn't one
+func (ms *MemoryStore) GetIdempotentResponse(key string) (response types.IdempotentResponse, err error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	response, ok := ms.idempotency[key]
+	if !ok {
+		return response, ErrNotFound
+	}
+	response.Body = append([]byte(nil), response.Body...)
+	return
+}
+
+// SetIdempotentResponse stores the response for its idempotency key, replacing any existing one
+func (ms *MemoryStore) SetIdempotentResponse(response types.IdempotentResponse) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	response.Body = append([]byte(nil), response.Body...)
+	ms.idempotency[response.Key] = response
+	return
+}
+
+// RemoveIdempotentResponses deletes every response stored before the given time
+func (ms *MemoryStore) RemoveIdempotentResponses(before time.Time) (err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for key, response := range ms.idempotency {
+		if response.Created.Before(before) {
+			delete(ms.idempotency, key)
+		}
+	}
+	return
+}
+
+// Ping always succeeds since there's nothing to connect to
+func (ms *MemoryStore) Ping(ctx context.Context) (err error) {
+	return
+}
+
+// Close does nothing, it only exists to satisfy Store
+func (ms *MemoryStore) Close() {}
+
+// sorted returns copies of the servers that match, ordered by address
+func (ms *MemoryStore) sorted(match func(types.Server) bool) (servers []types.Server) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, server := range ms.servers {
+		if match(server) {
+			servers = append(servers, copyServer(server))
+		}
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Core.Address < servers[j].Core.Address })
+	return
+}
+
+// insertionOrder sorts servers by the order they were first stored in
+func (ms *MemoryStore) insertionOrder(servers []types.Server) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	sort.Slice(servers, func(i, j int) bool {
+		return ms.inserted[servers[i].Core.Address] < ms.inserted[servers[j].Core.Address]
+	})
+}
+
+func (ms *MemoryStore) count(match func(types.Server) bool) (n int) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, server := range ms.servers {
+		if match(server) {
+			n++
+		}
+	}
+	return
+}
+
+func (ms *MemoryStore) countActiveBy(key func(types.Server) string) (counts map[string]int) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	counts = make(map[string]int)
+	for _, server := range ms.servers {
+		if server.Active {
+			counts[key(server)]++
+		}
+	}
+	return
+}
+
+// listMatcher is the in-memory equivalent of listQuery
+func listMatcher(params types.ServerListParams) (match func(types.Server) bool, err error) {
+	password, err := params.PasswordFilter()
+	if err != nil {
+		return
+	}
+	includeDead, err := params.IncludesDead()
+	if err != nil {
+		return
+	}
+	openmp, err := params.ForkFilter()
+	if err != nil {
+		return
+	}
+	gamemode := strings.ToLower(params.Gamemode)
+	languages := types.NormalizeLanguage(params.Language)
+	var version *regexp.Regexp
+	if prefix := strings.TrimSpace(params.Version); prefix != "" {
+		version = regexp.MustCompile("(?i)" + versionPattern(prefix))
+	}
+
+	return func(server types.Server) bool {
+		if !server.Active {
+			return false
+		}
+		if !includeDead && server.DeadSince != nil {
+			return false
+		}
+		for _, filter := range params.Filters {
+			switch filter {
+			case types.FilterPassword:
+				if server.Core.Password {
+					return false
+				}
+			case types.FilterEmpty:
+				if server.Core.Players <= 0 {
+					return false
+				}
+			case types.FilterFull:
+				if server.Core.Players >= server.Core.MaxPlayers {
+					return false
+				}
+			}
+		}
+		if gamemode != "" && !strings.Contains(strings.ToLower(server.Core.Gamemode), gamemode) {
+			return false
+		}
+		if languages != nil && !speaksAny(server.Core.Languages, languages) {
+			return false
+		}
+		if version != nil && !version.MatchString(server.Core.Version) {
+			return false
+		}
+		if password != nil && server.Core.Password != *password {
+			return false
+		}
+		if openmp != nil && server.OpenMP != *openmp {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// compareBy compares two servers by a sort column, returning a negative number when a sorts first
+func compareBy(a, b types.Server, by types.SortColumn) int {
+	switch by {
+	case types.ByPing:
+		return a.Ping - b.Ping
+	case types.ByName:
+		return strings.Compare(a.Core.Hostname, b.Core.Hostname)
+	}
+	return a.Core.Players - b.Core.Players
+}
+
+// copyServer copies a server along with its rules, player list and raw strings so callers can't
+// modify what's in the store
+func copyServer(server types.Server) types.Server {
+	if server.Rules != nil {
+		rules := make(map[string]string, len(server.Rules))
+		for k, v := range server.Rules {
+			rules[k] = v
+		}
+		server.Rules = rules
+	}
+	if server.PlayerList != nil {
+		server.PlayerList = append([]string{}, server.PlayerList...)
+	}
+	if server.Aliases != nil {
+		server.Aliases = append([]string{}, server.Aliases...)
+	}
+	if server.LastSeen != nil {
+		seen := *server.LastSeen
+		server.LastSeen = &seen
+	}
+	if server.DeadSince != nil {
+		since := *server.DeadSince
+		server.DeadSince = &since
+	}
+	if server.Raw != nil {
+		raw := *server.Raw
+		if raw.Rules != nil {
+			raw.Rules = make(map[string][]byte, len(server.Raw.Rules))
+			for k, v := range server.Raw.Rules {
+				raw.Rules[k] = v
+			}
+		}
+		server.Raw = &raw
+	}
+	return server
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// speaksAny reports whether any of the languages of a server are one of the wanted languages
+func speaksAny(languages, wanted []string) bool {
+	for _, language := range languages {
+		for _, want := range wanted {
+			if strings.EqualFold(language, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,485 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func memoryFixtures() *MemoryStore {
+	ms := NewMemoryStore()
+	for _, core := range []types.ServerCore{
+		{Address: "s3.example.com", Hostname: "test server 3", Players: 948, MaxPlayers: 1000, Gamemode: "Grand Larceny", Language: "English"},
+		{Address: "s4.example.com", Hostname: "test server 4", Players: 50, MaxPlayers: 50, Gamemode: "rivershell", Language: "Polish", Password: true},
+		{Address: "ss.southcla.ws", Hostname: "Scavenge and Survive Official", Players: 4, MaxPlayers: 32, Gamemode: "Scavenge & Survive by Southclaws", Language: "English"},
+		{Address: "s2.example.com", Hostname: "test server 2", Players: 0, MaxPlayers: 100, Gamemode: "Grand Larceny", Language: "English"},
+	} {
+		ms.UpsertServer(types.Server{Core: core}) // nolint:errcheck
+	}
+	return ms
+}
+
+func TestMemoryStore_StreamServers(t *testing.T) {
+	ms := memoryFixtures()
+	tests := []struct {
+		name          string
+		params        types.ServerListParams
+		wantAddresses []string
+		wantErr       bool
+	}{
+		{"default", types.ServerListParams{}, []string{"s3.example.com", "s4.example.com", "ss.southcla.ws", "s2.example.com"}, false},
+		{"asc", types.ServerListParams{Sort: types.SortAsc}, []string{"s2.example.com", "ss.southcla.ws", "s4.example.com", "s3.example.com"}, false},
+		{"page", types.ServerListParams{Page: 2, PageSize: 3}, []string{"s2.example.com"}, false},
+		{"filters", types.ServerListParams{Filters: []types.FilterAttribute{types.FilterPassword, types.FilterEmpty}}, []string{"s3.example.com", "ss.southcla.ws"}, false},
+		{"full", types.ServerListParams{Filters: []types.FilterAttribute{types.FilterFull}}, []string{"s3.example.com", "ss.southcla.ws", "s2.example.com"}, false},
+		{"gamemode", types.ServerListParams{Gamemode: "grand"}, []string{"s3.example.com", "s2.example.com"}, false},
+		{"cursor first", types.ServerListParams{Limit: 2}, []string{"s2.example.com", "s3.example.com"}, false},
+		{"cursor next", types.ServerListParams{Limit: 2, Cursor: types.EncodeCursor("s3.example.com")}, []string{"s4.example.com", "ss.southcla.ws"}, false},
+		{"name", types.ServerListParams{Sort: "name"}, []string{"ss.southcla.ws", "s2.example.com", "s3.example.com", "s4.example.com"}, false},
+		{"-name", types.ServerListParams{Sort: "-name"}, []string{"s4.example.com", "s3.example.com", "s2.example.com", "ss.southcla.ws"}, false},
+		{"ping ties keep insertion order", types.ServerListParams{Sort: "ping"}, []string{"s3.example.com", "s4.example.com", "ss.southcla.ws", "s2.example.com"}, false},
+		{"-ping ties keep insertion order", types.ServerListParams{Sort: "-ping"}, []string{"s3.example.com", "s4.example.com", "ss.southcla.ws", "s2.example.com"}, false},
+		{"invalid sort", types.ServerListParams{Sort: "sideways"}, nil, true},
+		{"exclude passworded", types.ServerListParams{IncludePassworded: "false"}, []string{"s3.example.com", "ss.southcla.ws", "s2.example.com"}, false},
+		{"password overrides exclude", types.ServerListParams{Password: "true", IncludePassworded: "false"}, []string{"s4.example.com"}, false},
+		{"invalid password", types.ServerListParams{Password: "maybe"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAddresses []string
+			err := ms.StreamServers(tt.params, func(server types.Server) error {
+				gotAddresses = append(gotAddresses, server.Core.Address)
+				return nil
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantAddresses, gotAddresses)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_RemoveServer(t *testing.T) {
+	ms := memoryFixtures()
+
+	assert.NoError(t, ms.RemoveServer("s2.example.com"))
+	assert.Equal(t, ErrNotFound, ms.RemoveServer("s2.example.com"))
+
+	_, err := ms.GetServer("s2.example.com")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestMemoryStore_ArchiveServer(t *testing.T) {
+	ms := memoryFixtures()
+
+	assert.NoError(t, ms.ArchiveServer("s2.example.com"))
+	assert.Equal(t, ErrNotFound, ms.ArchiveServer("s9.example.com"))
+
+	_, err := ms.GetServer("s2.example.com")
+	assert.Equal(t, ErrNotFound, err)
+
+	inactive, err := ms.GetInactiveServers()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, inactive)
+}
+
+func TestMemoryStore_Dead(t *testing.T) {
+	ms := memoryFixtures()
+
+	died := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, ms.MarkDead("s2.example.com", died))
+	assert.NoError(t, ms.MarkDead("s2.example.com", died.Add(time.Hour)))
+	assert.NoError(t, ms.MarkDead("s9.example.com", died))
+
+	server, err := ms.GetServer("s2.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, died, *server.DeadSince)
+
+	var listed []string
+	assert.NoError(t, ms.StreamServers(types.ServerListParams{Limit: 10}, func(server types.Server) error {
+		listed = append(listed, server.Core.Address)
+		return nil
+	}))
+	assert.NotContains(t, listed, "s2.example.com")
+
+	listed = nil
+	assert.NoError(t, ms.StreamServers(types.ServerListParams{Limit: 10, IncludeDead: "true"}, func(server types.Server) error {
+		listed = append(listed, server.Core.Address)
+		return nil
+	}))
+	assert.Contains(t, listed, "s2.example.com")
+
+	// responding again revives a server
+	assert.NoError(t, ms.MarkDead("s3.example.com", died))
+	assert.NoError(t, ms.UpdateServerInfo(types.ServerCore{Address: "s3.example.com"}, died.Add(time.Hour)))
+	server, err = ms.GetServer("s3.example.com")
+	assert.NoError(t, err)
+	assert.Nil(t, server.DeadSince)
+
+	removed, err := ms.RemoveDead(died)
+	assert.NoError(t, err)
+	assert.Empty(t, removed)
+
+	removed, err = ms.RemoveDead(died.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"s2.example.com"}, removed)
+
+	_, err = ms.GetServer("s2.example.com")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestMemoryStore_RecordFailure(t *testing.T) {
+	ms := memoryFixtures()
+
+	_, err := ms.RecordFailure("s9.example.com")
+	assert.Equal(t, ErrNotFound, err)
+
+	for want := 1; want <= 3; want++ {
+		failures, err := ms.RecordFailure("s2.example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, want, failures)
+	}
+
+	// responding again starts the count from zero
+	assert.NoError(t, ms.UpdateServerInfo(types.ServerCore{Address: "s2.example.com"}, time.Now()))
+	server, err := ms.GetServer("s2.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, server.ConsecutiveFailures)
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	ms := NewMemoryStore()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			address := fmt.Sprintf("s%d.example.com", i)
+			ms.UpsertServer(types.Server{Core: types.ServerCore{Address: address, Players: i}}) // nolint:errcheck
+			ms.GetServer(address)                                                               // nolint:errcheck
+			ms.StreamServers(types.ServerListParams{}, func(types.Server) error { return nil }) // nolint:errcheck
+		}(i)
+	}
+	wg.Wait()
+
+	active, err := ms.GetActiveServers()
+	assert.NoError(t, err)
+	assert.Equal(t, 50, active)
+}
+
+func TestMemoryStore_Webhooks(t *testing.T) {
+	ms := NewMemoryStore()
+
+	first := types.Webhook{ID: "1", Address: "s1.example.com:7777", URL: "https://example.com/1"}
+	second := types.Webhook{ID: "2", Address: "s1.example.com:7777", URL: "https://example.com/2"}
+	assert.NoError(t, ms.AddWebhook(first))
+	assert.NoError(t, ms.AddWebhook(second))
+	assert.NoError(t, ms.AddWebhook(types.Webhook{ID: "3", Address: "s2.example.com:7777"}))
+
+	hooks, err := ms.GetWebhooks("s1.example.com:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Webhook{first, second}, hooks)
+
+	hooks, err = ms.GetWebhooks("s9.example.com:7777")
+	assert.NoError(t, err)
+	assert.Empty(t, hooks)
+
+	hook, err := ms.GetWebhook("2")
+	assert.NoError(t, err)
+	assert.Equal(t, second, hook)
+	_, err = ms.GetWebhook("9")
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.NoError(t, ms.RemoveWebhook("1"))
+	assert.Equal(t, ErrNotFound, ms.RemoveWebhook("1"))
+	hooks, err = ms.GetWebhooks("s1.example.com:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Webhook{second}, hooks)
+}
+
+func TestMemoryStore_History(t *testing.T) {
+	ms := NewMemoryStore()
+	start := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(address string, minutes int, players float64) types.PlayerSample {
+		return types.PlayerSample{Address: address, Time: start.Add(time.Duration(minutes) * time.Minute), Players: players}
+	}
+
+	for _, sample := range []types.PlayerSample{
+		at("a:7777", 70, 6), at("a:7777", 10, 2), at("a:7777", 20, 4), at("b:7777", 10, 9), at("a:7777", 130, 1),
+	} {
+		assert.NoError(t, ms.AddSample(sample))
+	}
+
+	samples, err := ms.GetSamples("a:7777", start, start.Add(time.Hour*2))
+	assert.NoError(t, err)
+	assert.Equal(t, []types.PlayerSample{at("a:7777", 10, 2), at("a:7777", 20, 4), at("a:7777", 70, 6)}, samples)
+
+	assert.NoError(t, ms.DownsampleSamples(start.Add(time.Hour*2), time.Hour))
+	samples, err = ms.GetSamples("a:7777", start, start.Add(time.Hour*3))
+	assert.NoError(t, err)
+	assert.Equal(t, []types.PlayerSample{
+		{Address: "a:7777", Time: start, Players: 3, Downsampled: true},
+		{Address: "a:7777", Time: start.Add(time.Hour), Players: 6, Downsampled: true},
+		at("a:7777", 130, 1),
+	}, samples)
+
+	assert.NoError(t, ms.RemoveSamples(start.Add(time.Hour)))
+	samples, err = ms.GetSamples("b:7777", start, start.Add(time.Hour*3))
+	assert.NoError(t, err)
+	assert.Empty(t, samples)
+	samples, err = ms.GetSamples("a:7777", start, start.Add(time.Hour*3))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 2)
+}
+
+func TestUpsertCanonical(t *testing.T) {
+	ms := NewMemoryStore()
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "ss.southcla.ws:7777", Hostname: "by hostname"}}))
+
+	server := types.Server{Core: types.ServerCore{Address: "ss.southcla.ws:7777", Hostname: "merged"}}
+	assert.NoError(t, UpsertCanonical(ms, &server, "1.2.3.4:7777"))
+	assert.Equal(t, "1.2.3.4:7777", server.Core.Address)
+
+	server = types.Server{Core: types.ServerCore{Address: "samp.southcla.ws:7777", Hostname: "merged again"}}
+	assert.NoError(t, UpsertCanonical(ms, &server, "1.2.3.4:7777"))
+
+	addresses, err := ms.LoadAllAddresses()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.4:7777"}, addresses)
+
+	got, err := ms.GetServer("ss.southcla.ws:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, "merged again", got.Core.Hostname)
+	assert.Equal(t, []string{"ss.southcla.ws:7777", "samp.southcla.ws:7777"}, got.Aliases)
+
+	// storing the canonical address directly keeps the aliases
+	assert.NoError(t, UpsertCanonical(ms, &types.Server{Core: types.ServerCore{Address: "1.2.3.4:7777"}}, "1.2.3.4:7777"))
+	got, err = ms.GetServer("1.2.3.4:7777")
+	assert.NoError(t, err)
+	assert.Len(t, got.Aliases, 2)
+}
+
+func TestMemoryStore_Idempotency(t *testing.T) {
+	ms := NewMemoryStore()
+	created := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	old := types.IdempotentResponse{Key: "old", Method: "POST", Path: "/v2/server", Status: 200, Body: []byte("old"), Created: created}
+	recent := types.IdempotentResponse{Key: "recent", Method: "POST", Path: "/v2/server", Status: 422, Body: []byte("recent"), Created: created.Add(time.Hour * 24)}
+	assert.NoError(t, ms.SetIdempotentResponse(old))
+	assert.NoError(t, ms.SetIdempotentResponse(recent))
+
+	got, err := ms.GetIdempotentResponse("recent")
+	assert.NoError(t, err)
+	assert.Equal(t, recent, got)
+
+	assert.NoError(t, ms.RemoveIdempotentResponses(created.Add(time.Hour)))
+
+	_, err = ms.GetIdempotentResponse("old")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = ms.GetIdempotentResponse("recent")
+	assert.NoError(t, err)
+}
+
+func TestMemoryStore_RemoveNotSeenSince(t *testing.T) {
+	ms := NewMemoryStore()
+	since := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	before, after := since.Add(-time.Hour), since.Add(time.Hour)
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "old.example.com:7777"}, LastSeen: &before}))
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "recent.example.com:7777"}, LastSeen: &after}))
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "unseen.example.com:7777"}}))
+	assert.NoError(t, ms.ArchiveServer("old.example.com:7777"))
+
+	removed, err := ms.RemoveNotSeenSince(since)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"old.example.com:7777"}, removed)
+
+	addresses, err := ms.LoadAllAddresses()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"recent.example.com:7777", "unseen.example.com:7777"}, addresses)
+}
+
+func TestMemoryStore_StreamServers_Version(t *testing.T) {
+	ms := NewMemoryStore()
+	for address, version := range map[string]string{
+		"r2.example.com:7777":  "0.3.7-R2",
+		"r4.example.com:7777":  "0.3.7-R4",
+		"dl.example.com:7777":  "0.3.DL-R1",
+		"omp.example.com:7777": "omp 1.1.0.2612",
+		"new.example.com:7777": "0.3.71",
+	} {
+		assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: address, Version: version}}))
+	}
+
+	tests := []struct {
+		version       string
+		wantAddresses []string
+	}{
+		{"0.3.7", []string{"r2.example.com:7777", "r4.example.com:7777"}},
+		{"0.3.7-r", []string{"r2.example.com:7777", "r4.example.com:7777"}},
+		{" 0.3.7-R4 ", []string{"r4.example.com:7777"}},
+		{"0.3.", []string{"dl.example.com:7777", "new.example.com:7777", "r2.example.com:7777", "r4.example.com:7777"}},
+		{"OMP", []string{"omp.example.com:7777"}},
+		{"0.3.7.*", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var addresses []string
+			err := ms.StreamServers(types.ServerListParams{Version: tt.version, By: types.ByName}, func(server types.Server) error {
+				addresses = append(addresses, server.Core.Address)
+				return nil
+			})
+			assert.NoError(t, err)
+			sort.Strings(addresses)
+			assert.Equal(t, tt.wantAddresses, addresses)
+		})
+	}
+}
+
+func TestMemoryStore_StreamServers_Fork(t *testing.T) {
+	ms := NewMemoryStore()
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "samp.example.com:7777"}}))
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "omp.example.com:7777"}, OpenMP: true}))
+
+	for fork, want := range map[string][]string{
+		"":       {"omp.example.com:7777", "samp.example.com:7777"},
+		"openmp": {"omp.example.com:7777"},
+		"samp":   {"samp.example.com:7777"},
+	} {
+		var addresses []string
+		err := ms.StreamServers(types.ServerListParams{Fork: fork}, func(server types.Server) error {
+			addresses = append(addresses, server.Core.Address)
+			return nil
+		})
+		assert.NoError(t, err)
+		sort.Strings(addresses)
+		assert.Equal(t, want, addresses, fork)
+	}
+
+	err := ms.StreamServers(types.ServerListParams{Fork: "omp"}, func(types.Server) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestMemoryStore_GetQueryPort(t *testing.T) {
+	ms := NewMemoryStore()
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "s1.example.com:7777"}, QueryPort: 7778}))
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "s2.example.com:7777"}}))
+	assert.NoError(t, ms.ArchiveServer("s1.example.com:7777"))
+
+	port, err := ms.GetQueryPort("s1.example.com:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, 7778, port)
+
+	port, err = ms.GetQueryPort("s2.example.com:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, port)
+
+	_, err = ms.GetQueryPort("s3.example.com:7777")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestMemoryStore_StreamServers_Language(t *testing.T) {
+	ms := NewMemoryStore()
+	for _, core := range []types.ServerCore{
+		{Address: "s1.example.com", Language: "English", Languages: []string{"English"}},
+		{Address: "s2.example.com", Language: "EN/RU", Languages: []string{"English", "Russian"}},
+		{Address: "s3.example.com", Language: "Polski", Languages: []string{"Polish"}},
+		{Address: "s4.example.com", Language: "Klingon", Languages: []string{"Klingon"}},
+	} {
+		ms.UpsertServer(types.Server{Core: core}) // nolint:errcheck
+	}
+
+	tests := []struct {
+		name          string
+		language      string
+		wantAddresses []string
+	}{
+		{"name", "english", []string{"s1.example.com", "s2.example.com"}},
+		{"code", "ru", []string{"s2.example.com"}},
+		{"native", "polski", []string{"s3.example.com"}},
+		{"several", "RU/PL", []string{"s2.example.com", "s3.example.com"}},
+		{"unknown", "klingon", []string{"s4.example.com"}},
+		{"partial name", "eng", []string{"s1.example.com", "s2.example.com"}},
+		{"no match", "German", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAddresses []string
+			err := ms.StreamServers(types.ServerListParams{Language: tt.language, Limit: 10}, func(server types.Server) error {
+				gotAddresses = append(gotAddresses, server.Core.Address)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAddresses, gotAddresses)
+		})
+	}
+}
+
+func TestMemoryStore_TouchServer(t *testing.T) {
+	ms := NewMemoryStore()
+	server := types.Server{Core: types.ServerCore{Address: "s1.example.com:7777", Players: 4}, Ping: 50}
+	server.ContentHash = server.Hash()
+	assert.NoError(t, ms.UpsertServer(server))
+
+	seen := time.Now().Add(time.Minute)
+	touched, err := ms.TouchServer("s1.example.com:7777", "stale", seen, 60)
+	assert.NoError(t, err)
+	assert.False(t, touched)
+
+	touched, err = ms.TouchServer("s1.example.com:7777", server.ContentHash, seen, 60)
+	assert.NoError(t, err)
+	assert.True(t, touched)
+	got, err := ms.GetServer("s1.example.com:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, seen, *got.LastSeen)
+	assert.True(t, got.Online)
+	assert.Equal(t, 60, got.Ping)
+	assert.Equal(t, 4, got.Core.Players)
+
+	// updating part of the server on its own means the hash no longer describes it
+	assert.NoError(t, ms.UpdateServerInfo(types.ServerCore{Address: "s1.example.com:7777", Players: 5}, seen))
+	touched, err = ms.TouchServer("s1.example.com:7777", server.ContentHash, seen, 60)
+	assert.NoError(t, err)
+	assert.False(t, touched)
+
+	touched, err = ms.TouchServer("s2.example.com:7777", server.ContentHash, seen, 60)
+	assert.NoError(t, err)
+	assert.False(t, touched)
+	touched, err = ms.TouchServer("s2.example.com:7777", "", seen, 60)
+	assert.NoError(t, err)
+	assert.False(t, touched)
+}
+
+func TestMemoryStore_Blocklist(t *testing.T) {
+	ms := NewMemoryStore()
+
+	assert.NoError(t, ms.AddBlock(types.Block{Address: "203.0.113.0/24", Reason: "first"}))
+	assert.NoError(t, ms.AddBlock(types.Block{Address: "198.51.100.1"}))
+	assert.NoError(t, ms.AddBlock(types.Block{Address: "203.0.113.0/24", Reason: "second"}))
+
+	blocklist, err := ms.GetBlocklist()
+	assert.NoError(t, err)
+	assert.Equal(t, types.Blocklist{{Address: "198.51.100.1"}, {Address: "203.0.113.0/24", Reason: "second"}}, blocklist)
+
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "203.0.113.1:7777"}}))
+	assert.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: "198.51.100.2:7777"}}))
+	removed, err := RemoveBlocked(ms, blocklist[1])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"203.0.113.1:7777"}, removed)
+	blocked, err := IsBlocked(ms, "198.51.100.1:7777")
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+
+	assert.NoError(t, ms.RemoveBlock("198.51.100.1"))
+	assert.Equal(t, ErrNotFound, ms.RemoveBlock("198.51.100.1"))
+	blocklist, err = ms.GetBlocklist()
+	assert.NoError(t, err)
+	assert.Len(t, blocklist, 1)
+}
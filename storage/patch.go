@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// PatchServer merges a partial server object onto the stored server at an address, see
+// types.Server.Patch, and stores the result if it passes validation and check. A rejected patch is
+// returned as types.Invalid and ErrNotFound is returned if there isn't a server at the address.
+func PatchServer(store Store, address string, patch map[string]json.RawMessage, check func(types.Server) []error) (err error) {
+	server, err := store.GetServer(address)
+	if err != nil {
+		return
+	}
+
+	errs := server.Patch(patch)
+	if errs == nil {
+		errs = server.Validate()
+	}
+	if errs == nil && check != nil {
+		errs = check(server)
+	}
+	if errs != nil {
+		return types.Invalid(errs)
+	}
+
+	server.ContentHash = "" // the stored server no longer matches it
+	return store.UpsertServer(server)
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// SearchServers returns the active servers where every token is contained in either the hostname
+// or the gamemode, regardless of case.
+func (mgr *Manager) SearchServers(tokens []string) (servers []types.Server, err error) {
+	conditions := []bson.M{{"active": true}}
+	for _, token := range tokens {
+		conditions = append(conditions, bson.M{"$or": []bson.M{
+			{"core.hostname": containsInsensitive(token)},
+			{"core.gamemode": containsInsensitive(token)},
+		}})
+	}
+
+	err = mgr.collection.Find(bson.M{"$and": conditions}).All(&servers)
+	if err != nil {
+		err = errors.Wrap(err, "failed to execute search query on database")
+		return
+	}
+	return
+}
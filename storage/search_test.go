@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_SearchServers(t *testing.T) {
+	requireMongo(t)
+
+	tests := []struct {
+		name          string
+		tokens        []string
+		wantAddresses []string
+	}{
+		{"hostname", []string{"official"}, []string{"ss.southcla.ws"}},
+		{"gamemode", []string{"rivershell"}, []string{"s4.example.com"}},
+		{"case", []string{"GRAND"}, []string{"s2.example.com", "s3.example.com"}},
+		{"all tokens", []string{"test", "larceny"}, []string{"s2.example.com", "s3.example.com"}},
+		{"missing token", []string{"test", "survive"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotServers, err := mgr.SearchServers(tt.tokens)
+			assert.NoError(t, err)
+
+			var gotAddresses []string
+			for _, server := range gotServers {
+				gotAddresses = append(gotAddresses, server.Core.Address)
+			}
+			assert.ElementsMatch(t, tt.wantAddresses, gotAddresses)
+		})
+	}
+}
@@ -1,40 +1,217 @@
 package storage
 
 import (
+	"time"
+
+	"github.com/pkg/errors"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 
 	"github.com/Southclaws/samp-servers-api/types"
 )
 
-// GetServer looks up a server via the address
-func (mgr *Manager) GetServer(address string) (server types.Server, found bool, err error) {
-	err = mgr.collection.Find(bson.M{"core.address": address, "active": true}).One(&server)
+// GetServer looks up a server via the address or one of its aliases
+func (mgr *Manager) GetServer(address string) (server types.Server, err error) {
+	err = mgr.collection.Find(bson.M{
+		"$or":    []bson.M{{"core.address": address}, {"aliases": address}},
+		"active": true,
+	}).One(&server)
 	if err == mgo.ErrNotFound {
-		found = false
-		err = nil // the caller does not need to interpret this as an "error"
-	} else if err != nil {
-		return
-	} else {
-		found = true
+		err = ErrNotFound
 	}
+	return
+}
 
+// GetServersByAddress looks up the servers at any of the addresses, or with any of them as an alias,
+// in a single query
+func (mgr *Manager) GetServersByAddress(addresses []string) (servers []types.Server, err error) {
+	err = mgr.collection.Find(bson.M{
+		"$or":    []bson.M{{"core.address": bson.M{"$in": addresses}}, {"aliases": bson.M{"$in": addresses}}},
+		"active": true,
+	}).All(&servers)
 	return
 }
 
-// UpsertServer creates or updates a server object in the database, implicitly sets `Active` to true
+// GetQueryPort returns the port a server answers queries on if it's not the game port, active or
+// not, or ErrNotFound if there isn't a server at the address.
+func (mgr *Manager) GetQueryPort(address string) (port int, err error) {
+	server := types.Server{}
+	err = mgr.collection.Find(bson.M{"core.address": address}).
+		Select(bson.M{"queryport": 1}).
+		One(&server)
+	if err == mgo.ErrNotFound {
+		err = ErrNotFound
+	}
+	return server.QueryPort, err
+}
+
+// UpsertServer creates or updates a server object in the database, implicitly sets `Active` to true.
+// The peak player counts and featured status are left as they are since they're maintained by the
+// API rather than whoever provided the server, and aliases are added to the existing ones.
 func (mgr *Manager) UpsertServer(server types.Server) (err error) {
 	server.Active = true
+
+	existing := types.Server{}
+	err = mgr.collection.Find(bson.M{"core.address": server.Core.Address}).
+		Select(bson.M{"peakplayers": 1, "peakplayers24h": 1, "featured": 1, "aliases": 1}).
+		One(&existing)
+	if err != nil && err != mgo.ErrNotFound {
+		return errors.Wrap(err, "failed to load existing server")
+	}
+	server.PeakPlayers = existing.PeakPlayers
+	server.PeakPlayers24h = existing.PeakPlayers24h
+	server.Featured = existing.Featured
+	added := server.Aliases
+	server.Aliases = append([]string(nil), existing.Aliases...)
+	server.AddAliases(added...)
+
 	_, err = mgr.collection.Upsert(bson.M{"core.address": server.Core.Address}, server)
 	return
 }
 
+// UpdateServerInfo updates the fields of a server that are returned by an info query and marks it
+// as online, reviving it if it was dead. The rest of the server such as rules and the version are left untouched.
+// The content hash is cleared since the stored server no longer matches it.
+func (mgr *Manager) UpdateServerInfo(core types.ServerCore, seen time.Time) (err error) {
+	return mgr.collection.Update(bson.M{"core.address": core.Address}, bson.M{"$set": bson.M{
+		"core.hostname":       core.Hostname,
+		"core.hostnameclean":  core.HostnameClean,
+		"core.players":        core.Players,
+		"core.maxplayers":     core.MaxPlayers,
+		"core.gamemode":       core.Gamemode,
+		"core.language":       core.Language,
+		"core.languages":      core.Languages,
+		"core.password":       core.Password,
+		"contenthash":         "",
+		"lastseen":            seen,
+		"online":              true,
+		"deadsince":           nil,
+		"consecutivefailures": 0,
+	}})
+}
+
+// TouchServer updates when an active server was last seen and its ping if it's stored with the
+// content hash, the match and the update are a single query so there's nothing to load first
+func (mgr *Manager) TouchServer(address, hash string, seen time.Time, ping int) (touched bool, err error) {
+	if hash == "" {
+		return false, nil
+	}
+	err = mgr.collection.Update(
+		bson.M{"core.address": address, "active": true, "contenthash": hash},
+		bson.M{"$set": bson.M{"lastseen": seen, "online": true, "deadsince": nil, "consecutivefailures": 0, "ping": ping}})
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// UpdatePeakPlayers raises the all-time peak player count of a server to players if it's higher and
+// sets the 24 hour peak
+func (mgr *Manager) UpdatePeakPlayers(address string, players, peak24h int) (err error) {
+	return mgr.collection.Update(bson.M{"core.address": address}, bson.M{
+		"$max": bson.M{"peakplayers": players},
+		"$set": bson.M{"peakplayers24h": peak24h},
+	})
+}
+
+// SetFeatured sets whether an active server is featured, ErrNotFound is returned if there isn't one
+func (mgr *Manager) SetFeatured(address string, featured bool) (err error) {
+	err = mgr.collection.Update(bson.M{"core.address": address, "active": true}, bson.M{"$set": bson.M{"featured": featured}})
+	if err == mgo.ErrNotFound {
+		err = ErrNotFound
+	}
+	return
+}
+
+// SetOffline marks a server as offline without archiving it and clears its ping
+func (mgr *Manager) SetOffline(address string) (err error) {
+	return mgr.collection.Update(bson.M{"core.address": address}, bson.M{"$set": bson.M{"online": false, "ping": 0}})
+}
+
 // ArchiveServer marks a server as inactive by setting the `Active` field to false
 func (mgr *Manager) ArchiveServer(address string) (err error) {
 	return mgr.collection.Update(bson.M{"core.address": address}, bson.M{"$set": bson.M{"active": false}})
 }
 
-// RemoveServer deletes a server from the database
+// RemoveServer deletes a server from the database, ErrNotFound is returned if it did not exist
 func (mgr *Manager) RemoveServer(address string) (err error) {
-	return mgr.collection.Remove(bson.M{"core.address": address})
+	err = mgr.collection.Remove(bson.M{"core.address": address})
+	if err == mgo.ErrNotFound {
+		return ErrNotFound
+	}
+	return
+}
+
+// MarkDead sets the time a server stopped responding, the time isn't changed if it's already dead
+// so the grace period runs from when it was first found dead
+func (mgr *Manager) MarkDead(address string, since time.Time) (err error) {
+	err = mgr.collection.Update(bson.M{"core.address": address, "deadsince": nil}, bson.M{"$set": bson.M{"deadsince": since}})
+	if err == mgo.ErrNotFound {
+		err = nil
+	}
+	return
+}
+
+// RecordFailure increments the consecutive failures of a server and returns the new count, the
+// increment and the read are a single query so concurrent polls can't lose a failure
+func (mgr *Manager) RecordFailure(address string) (failures int, err error) {
+	server := types.Server{}
+	_, err = mgr.collection.Find(bson.M{"core.address": address}).
+		Select(bson.M{"consecutivefailures": 1}).
+		Apply(mgo.Change{Update: bson.M{"$inc": bson.M{"consecutivefailures": 1}}, ReturnNew: true}, &server)
+	if err == mgo.ErrNotFound {
+		err = ErrNotFound
+	}
+	return server.ConsecutiveFailures, err
+}
+
+// RemoveDead deletes every server that has been dead since before the given time and returns the
+// addresses of the servers that were removed
+func (mgr *Manager) RemoveDead(before time.Time) (removed []string, err error) {
+	query := bson.M{"deadsince": bson.M{"$lt": before}}
+
+	var servers []types.Server
+	err = mgr.collection.Find(query).Select(bson.M{"core.address": 1}).All(&servers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find dead servers")
+	}
+	if len(servers) == 0 {
+		return
+	}
+
+	_, err = mgr.collection.RemoveAll(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to remove dead servers")
+	}
+
+	for _, server := range servers {
+		removed = append(removed, server.Core.Address)
+	}
+	return
+}
+
+// RemoveNotSeenSince deletes every server, active or not, that was last seen before the given time
+// and returns their addresses. Servers that have never been seen are kept since they may not have
+// been queried yet.
+func (mgr *Manager) RemoveNotSeenSince(since time.Time) (removed []string, err error) {
+	query := bson.M{"lastseen": bson.M{"$lt": since}}
+
+	var servers []types.Server
+	err = mgr.collection.Find(query).Select(bson.M{"core.address": 1}).All(&servers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find servers not seen since the time")
+	}
+	if len(servers) == 0 {
+		return
+	}
+
+	_, err = mgr.collection.RemoveAll(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to remove servers not seen since the time")
+	}
+
+	for _, server := range servers {
+		removed = append(removed, server.Core.Address)
+	}
+	return
 }
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -9,6 +10,8 @@ import (
 )
 
 func TestManager_UpsertServer(t *testing.T) {
+	requireMongo(t)
+
 	type args struct {
 		server types.Server
 	}
@@ -42,6 +45,8 @@ func TestManager_UpsertServer(t *testing.T) {
 }
 
 func TestManager_GetServer(t *testing.T) {
+	requireMongo(t)
+
 	type args struct {
 		address string
 	}
@@ -49,8 +54,7 @@ func TestManager_GetServer(t *testing.T) {
 		name       string
 		args       args
 		wantServer types.Server
-		wantFound  bool
-		wantErr    bool
+		wantErr    error
 	}{
 		{"valid", args{"ss.southcla.ws"},
 			types.Server{
@@ -68,16 +72,103 @@ func TestManager_GetServer(t *testing.T) {
 				Banner:      "https://i.imgur.com/o13jh8h",
 				Active:      true,
 			},
-			true,
-			false,
+			nil,
 		},
+		{"missing", args{"s9.example.com"}, types.Server{}, ErrNotFound},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotServer, gotFound, err := mgr.GetServer(tt.args.address)
-			assert.NoError(t, err)
+			gotServer, err := mgr.GetServer(tt.args.address)
+			assert.Equal(t, tt.wantErr, err)
 			assert.Equal(t, tt.wantServer, gotServer)
-			assert.Equal(t, tt.wantFound, gotFound)
 		})
 	}
 }
+
+func TestManager_UpdatePeakPlayers(t *testing.T) {
+	requireMongo(t)
+
+	server := types.Server{Core: types.ServerCore{Address: "peaks.example.com:7777", Hostname: "peaks"}}
+	assert.NoError(t, mgr.UpsertServer(server))
+
+	assert.NoError(t, mgr.UpdatePeakPlayers("peaks.example.com:7777", 20, 20))
+	assert.NoError(t, mgr.UpdatePeakPlayers("peaks.example.com:7777", 5, 12))
+
+	server.PeakPlayers = 1000
+	assert.NoError(t, mgr.UpsertServer(server))
+
+	got, err := mgr.GetServer("peaks.example.com:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, 20, got.PeakPlayers)
+	assert.Equal(t, 12, got.PeakPlayers24h)
+}
+
+func TestManager_Dead(t *testing.T) {
+	requireMongo(t)
+
+	server := types.Server{Core: types.ServerCore{Address: "dead.example.com:7777", Hostname: "dead"}}
+	assert.NoError(t, mgr.UpsertServer(server))
+
+	died := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, mgr.MarkDead("dead.example.com:7777", died))
+	assert.NoError(t, mgr.MarkDead("dead.example.com:7777", died.Add(time.Hour)))
+
+	got, err := mgr.GetServer("dead.example.com:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, died, got.DeadSince.UTC())
+
+	removed, err := mgr.RemoveDead(died.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dead.example.com:7777"}, removed)
+
+	_, err = mgr.GetServer("dead.example.com:7777")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestManager_RemoveNotSeenSince(t *testing.T) {
+	requireMongo(t)
+
+	// far enough in the past that no other fixtures are affected
+	since := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	seen := since.Add(-time.Hour)
+	server := types.Server{Core: types.ServerCore{Address: "unseen.example.com:7777", Hostname: "unseen"}, LastSeen: &seen}
+	assert.NoError(t, mgr.UpsertServer(server))
+
+	removed, err := mgr.RemoveNotSeenSince(since)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"unseen.example.com:7777"}, removed)
+
+	_, err = mgr.GetServer("unseen.example.com:7777")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestManager_SetFeatured(t *testing.T) {
+	requireMongo(t)
+
+	server := types.Server{Core: types.ServerCore{Address: "featured.example.com:7777", Hostname: "featured"}}
+	assert.NoError(t, mgr.UpsertServer(server))
+
+	assert.NoError(t, mgr.SetFeatured("featured.example.com:7777", true))
+	assert.NoError(t, mgr.UpsertServer(server))
+
+	got, err := mgr.GetServer("featured.example.com:7777")
+	assert.NoError(t, err)
+	assert.True(t, got.Featured)
+
+	assert.Equal(t, ErrNotFound, mgr.SetFeatured("unfeatured.example.com:7777", true))
+}
+
+func TestManager_Aliases(t *testing.T) {
+	requireMongo(t)
+
+	server := types.Server{Core: types.ServerCore{Address: "5.6.7.8:7777", Hostname: "aliased"}, Aliases: []string{"aliased.example.com:7777"}}
+	assert.NoError(t, mgr.UpsertServer(server))
+
+	server.Aliases = []string{"other.example.com:7777"}
+	assert.NoError(t, mgr.UpsertServer(server))
+
+	got, err := mgr.GetServer("aliased.example.com:7777")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.6.7.8:7777", got.Core.Address)
+	assert.Equal(t, []string{"aliased.example.com:7777", "other.example.com:7777"}, got.Aliases)
+}
@@ -47,3 +47,48 @@ func (mgr *Manager) GetTotalPlayers() (players int, err error) {
 	players = tmp["players"].(int)
 	return
 }
+
+// GetOnlineServers returns the number of active servers that responded to their most recent query
+func (mgr *Manager) GetOnlineServers() (servers int, err error) {
+	servers, err = mgr.collection.Find(bson.M{"active": true, "online": true}).Count()
+	if err != nil {
+		err = errors.Wrap(err, "failed to execute find query on database")
+		return
+	}
+	return
+}
+
+// GetServersPerLanguage returns the number of active servers for each language
+func (mgr *Manager) GetServersPerLanguage() (counts map[string]int, err error) {
+	return mgr.countActiveBy("core.language")
+}
+
+// GetServersPerGamemode returns the number of active servers for each gamemode
+func (mgr *Manager) GetServersPerGamemode() (counts map[string]int, err error) {
+	return mgr.countActiveBy("core.gamemode")
+}
+
+// countActiveBy groups active servers by the value of a field and counts each group, this is done
+// with an aggregation so only the counts are sent back instead of every server document.
+func (mgr *Manager) countActiveBy(field string) (counts map[string]int, err error) {
+	pipe := mgr.collection.Pipe([]bson.M{
+		{"$match": bson.M{"active": true}},
+		{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+	})
+
+	var groups []struct {
+		Name  string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	err = pipe.All(&groups)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to count servers by %s", field)
+		return
+	}
+
+	counts = make(map[string]int, len(groups))
+	for _, group := range groups {
+		counts[group.Name] = group.Count
+	}
+	return
+}
@@ -9,6 +9,8 @@ import (
 )
 
 func TestManager_GetStatistics(t *testing.T) {
+	requireMongo(t)
+
 	wantStatistics := types.Statistics{
 		Servers:          4,
 		Players:          1002,
@@ -30,3 +32,23 @@ func TestManager_GetStatistics(t *testing.T) {
 
 	assert.Equal(t, wantStatistics, gotStatistics)
 }
+
+func TestManager_GetServersPerLanguage(t *testing.T) {
+	requireMongo(t)
+
+	gotCounts, err := mgr.GetServersPerLanguage()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"English": 3, "Polish": 1}, gotCounts)
+}
+
+func TestManager_GetServersPerGamemode(t *testing.T) {
+	requireMongo(t)
+
+	gotCounts, err := mgr.GetServersPerGamemode()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"Grand Larceny":                    2,
+		"rivershell":                       1,
+		"Scavenge & Survive by Southclaws": 1,
+	}, gotCounts)
+}
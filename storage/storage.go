@@ -1,28 +1,54 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"gopkg.in/mgo.v2"
 )
 
-// Config describes db connection information
+// Config describes db connection information. MongoURI is a mongodb:// connection string that's
+// used instead of MongoHost and MongoPort when it's set, it may list several servers of a replica
+// set along with any of the options mgo understands. MongoTimeout applies to connecting and to each
+// operation and MongoPoolSize caps the connections open to each server, mgo's defaults are kept
+// for either when it's zero. Connecting is attempted MongoConnectAttempts times with exponential
+// backoff so the API can be started alongside the database.
 type Config struct {
-	MongoHost       string `split_words:"true" required:"true"`
-	MongoPort       string `split_words:"true" required:"true"`
-	MongoName       string `split_words:"true" required:"true"`
-	MongoUser       string `split_words:"true" required:"true"`
-	MongoPass       string `split_words:"true" required:"false"`
-	MongoCollection string `split_words:"true" required:"true"`
+	MongoHost            string        `split_words:"true" required:"true"`
+	MongoPort            string        `split_words:"true" required:"true"`
+	MongoURI             string        `envconfig:"MONGO_URI" required:"false"`
+	MongoName            string        `split_words:"true" required:"true"`
+	MongoUser            string        `split_words:"true" required:"true"`
+	MongoPass            string        `split_words:"true" required:"false"`
+	MongoCollection      string        `split_words:"true" required:"true"`
+	MongoTimeout         time.Duration `split_words:"true" required:"false"`
+	MongoPoolSize        int           `split_words:"true" required:"false"`
+	MongoConnectAttempts int           `split_words:"true" required:"false"`
 }
 
+// defaultDialTimeout is how long connecting waits for a server when MongoTimeout isn't set, the
+// same as mgo.Dial
+const defaultDialTimeout = time.Second * 10
+
+// connectBackoff is the delay before the second attempt to connect and connectMaxBackoff is the
+// longest delay between attempts
+var (
+	connectBackoff    = time.Second
+	connectMaxBackoff = time.Second * 30
+)
+
 // Manager provides access to collections and predefined CRUD functionality.
 type Manager struct {
-	config     Config
-	session    *mgo.Session
-	db         *mgo.Database
-	collection *mgo.Collection
+	config      Config
+	session     *mgo.Session
+	db          *mgo.Database
+	collection  *mgo.Collection
+	webhooks    *mgo.Collection
+	blocklist   *mgo.Collection
+	history     *mgo.Collection
+	idempotency *mgo.Collection
 }
 
 // New sets up a MongoDB connection and ensures it is ready to use
@@ -31,7 +57,7 @@ func New(config Config) (mgr *Manager, err error) {
 		config: config,
 	}
 
-	mgr.session, err = mgo.Dial(fmt.Sprintf("%s:%s", config.MongoHost, config.MongoPort))
+	mgr.session, err = dial(config)
 	if err != nil {
 		return
 	}
@@ -58,5 +84,120 @@ func New(config Config) (mgr *Manager, err error) {
 		return nil, errors.Wrap(err, "index ensure failed")
 	}
 
+	err = mgr.collection.EnsureIndexKey("aliases")
+	if err != nil {
+		return nil, errors.Wrap(err, "alias index ensure failed")
+	}
+
+	mgr.webhooks = mgr.session.DB(config.MongoName).C(config.MongoCollection + "_webhooks")
+
+	err = mgr.webhooks.EnsureIndexKey("address")
+	if err != nil {
+		return nil, errors.Wrap(err, "webhook index ensure failed")
+	}
+	err = mgr.webhooks.EnsureIndex(mgo.Index{Key: []string{"id"}, Unique: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "webhook id index ensure failed")
+	}
+
+	mgr.blocklist = mgr.session.DB(config.MongoName).C(config.MongoCollection + "_blocklist")
+
+	err = mgr.blocklist.EnsureIndex(mgo.Index{
+		Key:    []string{"address"},
+		Unique: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "blocklist index ensure failed")
+	}
+
+	mgr.history = mgr.session.DB(config.MongoName).C(config.MongoCollection + "_history")
+
+	err = mgr.history.EnsureIndexKey("address", "time")
+	if err != nil {
+		return nil, errors.Wrap(err, "history index ensure failed")
+	}
+
+	mgr.idempotency = mgr.session.DB(config.MongoName).C(config.MongoCollection + "_idempotency")
+
+	err = mgr.idempotency.EnsureIndex(mgo.Index{
+		Key:    []string{"key"},
+		Unique: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "idempotency index ensure failed")
+	}
+
+	err = mgr.idempotency.EnsureIndexKey("created")
+	if err != nil {
+		return nil, errors.Wrap(err, "idempotency created index ensure failed")
+	}
+
 	return
 }
+
+// dial connects to the database, retrying with exponential backoff in case it isn't up yet
+func dial(config Config) (session *mgo.Session, err error) {
+	info := &mgo.DialInfo{Addrs: []string{fmt.Sprintf("%s:%s", config.MongoHost, config.MongoPort)}}
+	if config.MongoURI != "" {
+		info, err = mgo.ParseURL(config.MongoURI)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid mongodb uri")
+		}
+	}
+	if config.MongoTimeout > 0 {
+		info.Timeout = config.MongoTimeout
+	} else if info.Timeout == 0 {
+		info.Timeout = defaultDialTimeout
+	}
+	if config.MongoPoolSize > 0 {
+		info.PoolLimit = config.MongoPoolSize
+	}
+
+	attempts := config.MongoConnectAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	err = withBackoff(attempts, connectBackoff, connectMaxBackoff, time.Sleep, func() (err error) {
+		session, err = mgo.DialWithInfo(info)
+		return
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to mongodb after %d attempts", attempts)
+	}
+
+	if config.MongoTimeout > 0 {
+		session.SetSocketTimeout(config.MongoTimeout)
+		session.SetSyncTimeout(config.MongoTimeout)
+	}
+	return
+}
+
+// Ping checks the database is reachable. mgo has no way to cancel a ping so it's run in the
+// background on a clone of the session and abandoned if the context is done first. The clone shares
+// the connection the rest of the operations use, which mgo doesn't replace by itself once it's been
+// broken by the database restarting, so the session is refreshed when the ping fails in order for
+// operations to reconnect as soon as the database is back.
+func (mgr *Manager) Ping(ctx context.Context) (err error) {
+	session := mgr.session.Clone()
+	result := make(chan error, 1)
+	go func() {
+		defer session.Close()
+		result <- session.Ping()
+	}()
+
+	select {
+	case err = <-result:
+		if err != nil {
+			mgr.session.Refresh()
+			err = errors.Wrap(err, "failed to ping mongodb")
+		}
+	case <-ctx.Done():
+		err = errors.Wrap(ctx.Err(), "failed to ping mongodb")
+	}
+	return
+}
+
+// Close ends the database session
+func (mgr *Manager) Close() {
+	mgr.session.Close()
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// ErrNotFound is returned when an operation targets a server that is not stored
+var ErrNotFound = errors.New("server not found")
+
+// Store is the set of operations the API needs from a server database. Manager implements it with
+// MongoDB but handlers and the app only depend on this interface so other backends can be used.
+type Store interface {
+	// GetServer looks up an active server by its address or one of its aliases, ErrNotFound is
+	// returned if there isn't one
+	GetServer(address string) (server types.Server, err error)
+	// GetServersByAddress returns every active server stored under one of the addresses or with
+	// one of them as an alias, in no particular order. Addresses without a server are left out.
+	GetServersByAddress(addresses []string) (servers []types.Server, err error)
+	// GetQueryPort returns the query port of a server, active or not, which is zero if it answers
+	// queries on its game port. ErrNotFound is returned if there isn't one.
+	GetQueryPort(address string) (port int, err error)
+	// UpsertServer creates or replaces a server, implicitly marking it active. Fields maintained by
+	// the API, such as the peaks and aliases, are kept.
+	UpsertServer(server types.Server) (err error)
+	// UpdateServerInfo updates only the info query fields of a server and marks it online
+	UpdateServerInfo(core types.ServerCore, seen time.Time) (err error)
+	// TouchServer marks an active server as seen at a time with a new ping without rewriting the
+	// rest of it, but only if its content hash is still hash. touched is false if there's no such
+	// server or it has changed since it was stored with the hash.
+	TouchServer(address, hash string, seen time.Time, ping int) (touched bool, err error)
+	// UpdatePeakPlayers raises the all-time peak of a server to players if it's higher and sets its
+	// 24 hour peak
+	UpdatePeakPlayers(address string, players, peak24h int) (err error)
+	// SetFeatured sets whether an active server is featured, ErrNotFound is returned if there isn't one
+	SetFeatured(address string, featured bool) (err error)
+	// SetOffline marks a server as offline without archiving it
+	SetOffline(address string) (err error)
+	// ArchiveServer marks a server as inactive
+	ArchiveServer(address string) (err error)
+	// RemoveServer deletes a server, ErrNotFound is returned if it did not exist
+	RemoveServer(address string) (err error)
+	// MarkDead records that a server stopped responding at a time, unless it's already dead
+	MarkDead(address string, since time.Time) (err error)
+	// RecordFailure counts a failed poll of a server and returns how many it has failed in a row,
+	// ErrNotFound is returned if there isn't one
+	RecordFailure(address string) (failures int, err error)
+	// RemoveDead deletes every server that has been dead since before a time and returns their
+	// addresses
+	RemoveDead(before time.Time) (removed []string, err error)
+	// RemoveNotSeenSince deletes every server, active or not, that was last seen before a time and
+	// returns their addresses, servers that have never been seen are kept
+	RemoveNotSeenSince(since time.Time) (removed []string, err error)
+
+	// StreamServers calls fn for each active server matching the list parameters, dead servers are
+	// only included when the parameters ask for them
+	StreamServers(params types.ServerListParams, fn func(types.Server) error) (err error)
+	// SearchServers returns active servers where every token matches the hostname or gamemode
+	SearchServers(tokens []string) (servers []types.Server, err error)
+	// LoadAllAddresses returns the address of every stored server, active or not
+	LoadAllAddresses() (result []string, err error)
+
+	// GetActiveServers returns the number of active servers
+	GetActiveServers() (servers int, err error)
+	// GetInactiveServers returns the number of inactive servers
+	GetInactiveServers() (servers int, err error)
+	// GetOnlineServers returns the number of active servers that are online
+	GetOnlineServers() (servers int, err error)
+	// GetTotalPlayers returns the sum of players across all servers
+	GetTotalPlayers() (players int, err error)
+	// GetServersPerLanguage returns the number of active servers for each language
+	GetServersPerLanguage() (counts map[string]int, err error)
+	// GetServersPerGamemode returns the number of active servers for each gamemode
+	GetServersPerGamemode() (counts map[string]int, err error)
+
+	// AddWebhook stores a webhook registration
+	AddWebhook(hook types.Webhook) (err error)
+	// GetWebhooks returns the webhooks registered for a server address
+	GetWebhooks(address string) (hooks []types.Webhook, err error)
+	// GetWebhook returns a webhook by its ID, ErrNotFound is returned if there isn't one
+	GetWebhook(id string) (hook types.Webhook, err error)
+	// RemoveWebhook removes a webhook by its ID, ErrNotFound is returned if there isn't one
+	RemoveWebhook(id string) (err error)
+
+	// AddBlock adds a block to the blocklist, replacing any existing block of the same address
+	AddBlock(block types.Block) (err error)
+	// RemoveBlock removes the block of an address, ErrNotFound is returned if there isn't one
+	RemoveBlock(address string) (err error)
+	// GetBlocklist returns every block, oldest first
+	GetBlocklist() (blocklist types.Blocklist, err error)
+
+	// AddSample records the player count of a server
+	AddSample(sample types.PlayerSample) (err error)
+	// GetSamples returns the samples of a server in the time range, ordered by time
+	GetSamples(address string, from, to time.Time) (samples []types.PlayerSample, err error)
+	// DownsampleSamples replaces samples recorded before a time with their averages per interval
+	DownsampleSamples(before time.Time, interval time.Duration) (err error)
+	// RemoveSamples deletes every sample recorded before a time
+	RemoveSamples(before time.Time) (err error)
+
+	// GetIdempotentResponse looks up the response stored for an idempotency key, ErrNotFound is
+	// returned if there isn't one
+	GetIdempotentResponse(key string) (response types.IdempotentResponse, err error)
+	// SetIdempotentResponse stores the response for its idempotency key, replacing any existing one
+	SetIdempotentResponse(response types.IdempotentResponse) (err error)
+	// RemoveIdempotentResponses deletes every response stored before a time
+	RemoveIdempotentResponses(before time.Time) (err error)
+
+	// Ping checks the store is reachable, giving up when the context is done
+	Ping(ctx context.Context) (err error)
+	// Close releases any resources held by the store
+	Close()
+}
+
+var _ Store = &Manager{}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+)
+
+// transientCodes are the MongoDB error codes of operations that failed because the server was
+// shutting down or stepping down as primary, none of them say anything about the operation itself
+var transientCodes = map[int]bool{
+	91:    true, // ShutdownInProgress
+	189:   true, // PrimarySteppedDown
+	10107: true, // NotMaster
+	11600: true, // InterruptedAtShutdown
+	11602: true, // InterruptedDueToReplStateChange
+	13435: true, // NotMasterNoSlaveOk
+}
+
+// IsTransient reports whether a storage error was caused by the database being unreachable or
+// restarting rather than by the operation, so the same operation is worth trying again shortly.
+// ErrNotFound is never transient.
+func IsTransient(err error) bool {
+	switch cause := errors.Cause(err).(type) {
+	case nil:
+		return false
+	case net.Error:
+		return true
+	case *mgo.QueryError:
+		return transientCodes[cause.Code]
+	case *mgo.LastError:
+		return transientCodes[cause.Code]
+	default:
+		if cause == ErrNotFound || cause == mgo.ErrNotFound {
+			return false
+		}
+		// mgo reports a dropped connection as the read failing with EOF and a cluster it can't find
+		// a server in with an error that isn't exported
+		return cause == io.EOF || cause == io.ErrUnexpectedEOF || cause.Error() == "no reachable servers"
+	}
+}
+
+// withBackoff calls fn until it succeeds or has been called attempts times, at least once, sleeping
+// between calls for initial and then twice as long each time up to max. The error of the last call
+// is returned.
+func withBackoff(attempts int, initial, max time.Duration, sleep func(time.Duration), fn func() error) (err error) {
+	delay := initial
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= attempts {
+			return
+		}
+		sleep(delay)
+		delay *= 2
+		if delay > max {
+			delay = max
+		}
+	}
+}
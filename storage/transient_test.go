@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"not found", ErrNotFound, false},
+		{"mgo not found", errors.Wrap(mgo.ErrNotFound, "failed to get server"), false},
+		{"dropped connection", errors.Wrap(io.EOF, "failed to get server"), true},
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"no servers", errors.New("no reachable servers"), true},
+		{"stepped down", &mgo.QueryError{Code: 189, Message: "primary stepped down"}, true},
+		{"shutting down", &mgo.LastError{Code: 11600, Err: "interrupted at shutdown"}, true},
+		{"duplicate key", &mgo.LastError{Code: 11000, Err: "duplicate key"}, false},
+		{"bad query", &mgo.QueryError{Code: 2, Message: "bad value"}, false},
+		{"other", errors.New("index ensure failed"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestWithBackoff(t *testing.T) {
+	var slept []time.Duration
+	sleep := func(d time.Duration) { slept = append(slept, d) }
+
+	calls := 0
+	err := withBackoff(6, time.Second, time.Second*5, sleep, func() error {
+		calls++
+		return errors.Errorf("attempt %d failed", calls)
+	})
+	assert.EqualError(t, err, "attempt 6 failed")
+	assert.Equal(t, []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5}, slept)
+
+	slept, calls = nil, 0
+	err = withBackoff(6, time.Second, time.Second*5, sleep, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Len(t, slept, 2)
+
+	// it's always called at least once
+	calls = 0
+	assert.NoError(t, withBackoff(0, time.Second, time.Second, sleep, func() error { calls++; return nil }))
+	assert.Equal(t, 1, calls)
+}
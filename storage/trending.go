@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// Trending returns the listed servers whose player count has grown over the window before now,
+// ordered by their growth as a percentage of the players they had at the start of it. Servers that
+// started with fewer than minPlayers are left out so a server going from one player to three doesn't
+// outrank a busy one, as are servers that weren't being sampled until the second half of the window
+// since they'd be compared over only part of it. Only servers that could have grown past minPlayers
+// have their samples loaded.
+func Trending(store Store, window time.Duration, minPlayers int, now time.Time) (trending []types.TrendingServer, err error) {
+	if minPlayers < 1 {
+		minPlayers = 1
+	}
+
+	var candidates []types.ServerCore
+	err = store.StreamServers(types.ServerListParams{}, func(server types.Server) error {
+		if server.Core.Players > minPlayers {
+			candidates = append(candidates, server.Core)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load servers")
+	}
+
+	from := now.Add(-window)
+	for _, core := range candidates {
+		samples, err := store.GetSamples(core.Address, from, now)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get samples of '%s'", core.Address)
+		}
+		if len(samples) == 0 || samples[0].Time.After(from.Add(window/2)) {
+			continue
+		}
+
+		baseline := samples[0].Players
+		if baseline < float64(minPlayers) {
+			continue
+		}
+		growth := (float64(core.Players) - baseline) / baseline * 100
+		if growth <= 0 {
+			continue
+		}
+		trending = append(trending, types.TrendingServer{
+			ServerCore: core,
+			Baseline:   baseline,
+			Growth:     math.Round(growth*10) / 10,
+		})
+	}
+
+	sort.SliceStable(trending, func(i, j int) bool {
+		if trending[i].Growth != trending[j].Growth {
+			return trending[i].Growth > trending[j].Growth
+		}
+		return trending[i].Players > trending[j].Players
+	})
+	return trending, nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestTrending(t *testing.T) {
+	ms := NewMemoryStore()
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		address string
+		players int
+		first   time.Duration // how long before now the first sample was taken
+		started float64
+	}{
+		{"doubled:7777", 40, time.Minute * 50, 20},
+		{"grown:7777", 30, time.Minute * 55, 20},
+		{"grown-busier:7777", 60, time.Minute * 55, 40},
+		{"shrunk:7777", 10, time.Minute * 50, 20},
+		{"small:7777", 12, time.Minute * 50, 2},
+		{"new:7777", 40, time.Minute * 20, 10},
+		{"unsampled:7777", 40, 0, 0},
+	} {
+		require.NoError(t, ms.UpsertServer(types.Server{Core: types.ServerCore{Address: tt.address, Players: tt.players}}))
+		if tt.first == 0 {
+			continue
+		}
+		require.NoError(t, ms.AddSample(types.PlayerSample{Address: tt.address, Time: now.Add(-tt.first), Players: tt.started}))
+		require.NoError(t, ms.AddSample(types.PlayerSample{Address: tt.address, Time: now.Add(-time.Minute), Players: float64(tt.players)}))
+	}
+
+	trending, err := Trending(ms, time.Hour, 10, now)
+	require.NoError(t, err)
+
+	var got []string
+	for _, server := range trending {
+		got = append(got, server.Address)
+	}
+	assert.Equal(t, []string{"doubled:7777", "grown-busier:7777", "grown:7777"}, got)
+	assert.Equal(t, 20.0, trending[0].Baseline)
+	assert.Equal(t, 100.0, trending[0].Growth)
+	assert.Equal(t, 50.0, trending[1].Growth)
+
+	// over a shorter window the newer server has enough history and the others have none
+	trending, err = Trending(ms, time.Minute*30, 10, now)
+	require.NoError(t, err)
+	require.Len(t, trending, 1)
+	assert.Equal(t, "new:7777", trending[0].Address)
+	assert.Equal(t, 300.0, trending[0].Growth)
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+// AddWebhook stores a webhook registration
+func (mgr *Manager) AddWebhook(hook types.Webhook) (err error) {
+	return mgr.webhooks.Insert(hook)
+}
+
+// GetWebhooks returns the webhooks registered for a server address, oldest first
+func (mgr *Manager) GetWebhooks(address string) (hooks []types.Webhook, err error) {
+	err = mgr.webhooks.Find(bson.M{"address": address}).Sort("created").All(&hooks)
+	return
+}
+
+// GetWebhook returns a webhook by its ID, ErrNotFound is returned if there isn't one
+func (mgr *Manager) GetWebhook(id string) (hook types.Webhook, err error) {
+	err = mgr.webhooks.Find(bson.M{"id": id}).One(&hook)
+	if err == mgo.ErrNotFound {
+		err = ErrNotFound
+	}
+	return
+}
+
+// RemoveWebhook removes a webhook by its ID, ErrNotFound is returned if there isn't one
+func (mgr *Manager) RemoveWebhook(id string) (err error) {
+	err = mgr.webhooks.Remove(bson.M{"id": id})
+	if err == mgo.ErrNotFound {
+		return ErrNotFound
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Southclaws/samp-servers-api/types"
+)
+
+func TestManager_Webhooks(t *testing.T) {
+	requireMongo(t)
+
+	created := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := types.Webhook{ID: "1", Address: "hooks.example.com:7777", URL: "https://example.com/1", Secret: "a", Created: created}
+	second := types.Webhook{ID: "2", Address: "hooks.example.com:7777", URL: "https://example.com/2", Secret: "b", Created: created.Add(time.Hour)}
+	assert.NoError(t, mgr.AddWebhook(second))
+	assert.NoError(t, mgr.AddWebhook(first))
+
+	hooks, err := mgr.GetWebhooks("hooks.example.com:7777")
+	assert.NoError(t, err)
+	for i := range hooks {
+		hooks[i].Created = hooks[i].Created.UTC() // mgo decodes times as local
+	}
+	assert.Equal(t, []types.Webhook{first, second}, hooks)
+
+	hooks, err = mgr.GetWebhooks("nohooks.example.com:7777")
+	assert.NoError(t, err)
+	assert.Empty(t, hooks)
+
+	hook, err := mgr.GetWebhook("2")
+	assert.NoError(t, err)
+	hook.Created = hook.Created.UTC()
+	assert.Equal(t, second, hook)
+	_, err = mgr.GetWebhook("9")
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.NoError(t, mgr.RemoveWebhook("1"))
+	assert.Equal(t, ErrNotFound, mgr.RemoveWebhook("1"))
+	assert.NoError(t, mgr.RemoveWebhook("2"))
+}
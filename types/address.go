@@ -1,8 +1,7 @@
 package types
 
 import (
-	"fmt"
-	"net/url"
+	"net"
 	"strconv"
 	"strings"
 
@@ -11,51 +10,162 @@ import (
 
 // AddressFromString validates an address field for a server and ensures it contains the correct
 // combination of host:port with either "samp://" or an empty scheme. returns an address with the
-// :7777 port if absent (this is the default SA:MP port) and strips the "samp:// protocol".
+// :7777 port if absent (this is the default SA:MP port) and strips the "samp:// protocol". See
+// ParseAddress for exactly what's accepted.
 func AddressFromString(input string) (output string, errs []error) {
-	if len(input) < 1 {
-		errs = append(errs, errors.New("address is empty"))
+	host, port, err := ParseAddress(input)
+	if err != nil {
+		return "", []error{err}
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// defaultPort is the port SA:MP servers listen on unless they're configured otherwise
+const defaultPort = 7777
+
+// ParseAddress splits an address into its host and port. The scheme, if there is one, is checked
+// before anything else is parsed so that something like "1.2.3.4:80://x" is rejected for its scheme
+// rather than confusing the rest of the parsing. The only scheme allowed is "samp://", in any case.
+// A trailing slash is ignored but any other path, query or fragment is rejected, as is a
+// user:password component. Hostnames are lowercased since they're case insensitive and the result
+// is used as a key. The port is 7777 if it's absent and must be outside the reserved and ephemeral
+// ranges.
+func ParseAddress(address string) (host string, port int, err error) {
+	if address == "" {
+		return "", 0, errors.New("address is empty")
+	}
+
+	rest := address
+	if i := strings.Index(address, "://"); i >= 0 {
+		if scheme := address[:i]; !strings.EqualFold(scheme, "samp") {
+			return "", 0, errors.Errorf("address contains invalid scheme '%s', must be either empty or 'samp://'", scheme)
+		}
+		rest = address[i+len("://"):]
+	}
+
+	if strings.Contains(rest, "@") {
+		return "", 0, errors.New("address contains a user:password component")
+	}
+	rest = strings.TrimRight(rest, "/")
+	if strings.ContainsAny(rest, "/?#") {
+		return "", 0, errors.New("address contains a path, query or fragment")
+	}
+
+	host, portStr := rest, ""
+	switch {
+	case strings.HasPrefix(rest, "[") && strings.HasSuffix(rest, "]"):
+		host = rest[1 : len(rest)-1]
+	case strings.HasPrefix(rest, "["), strings.Count(rest, ":") == 1:
+		host, portStr, err = net.SplitHostPort(rest)
+		if err != nil {
+			return "", 0, errors.Wrap(err, "invalid address")
+		}
 	}
 
-	var withScheme string
-	if !strings.Contains(input, "://") {
-		withScheme = fmt.Sprintf("samp://%s", input)
+	if host == "" {
+		return "", 0, errors.New("address has no host")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	} else if strings.Contains(host, ":") {
+		return "", 0, errors.Errorf("address host '%s' is not a valid IP address", host)
 	} else {
-		withScheme = input
+		for _, r := range host {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_') {
+				return "", 0, errors.Errorf("address host '%s' contains invalid characters", host)
+			}
+		}
+		host = strings.ToLower(host)
 	}
 
-	u, err := url.Parse(withScheme)
+	if portStr == "" {
+		return host, defaultPort, nil
+	}
+	port, err = strconv.Atoi(portStr)
 	if err != nil {
-		errs = append(errs, err)
-		return
+		return "", 0, errors.Errorf("invalid port '%s' specified", portStr)
+	}
+	if !validPort(port) {
+		return "", 0, errors.Errorf("port %d falls within reserved or ephemeral range", port)
 	}
+	return host, port, nil
+}
+
+// validPort reports whether a port is outside of the reserved and ephemeral ranges, which no SA:MP
+// server should be using
+func validPort(port int) bool {
+	return port >= 1024 && port <= 49152
+}
 
-	if u.User != nil {
-		errs = append(errs, errors.New("address contains a user:password component"))
+// NormalizeAddress returns the canonical host:port form of an address, this is the form used as
+// the storage key so all handlers must pass addresses through it before touching storage. For
+// example, "samp://1.2.3.4", "1.2.3.4" and "1.2.3.4:7777" all normalise to "1.2.3.4:7777".
+func NormalizeAddress(address string) (string, error) {
+	normalised, errs := AddressFromString(address)
+	if errs != nil {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return "", errors.New(strings.Join(messages, ", "))
 	}
+	return normalised, nil
+}
+
+// lookupIP is used to resolve hostnames to IP addresses, it's a variable so tests can stub it
+var lookupIP = net.LookupIP
 
-	if u.Scheme != "samp" {
-		errs = append(errs, errors.Errorf("address contains invalid scheme '%s', must be either empty or 'samp://'", u.Scheme))
+// AddressFromStringStrict performs the same validation as AddressFromString but also ensures the
+// host is an IPv4 address, since the SA:MP query protocol can only address servers by IPv4. IPv6
+// hosts are always rejected. Hostnames are rejected unless resolve is true, in which case they are
+// looked up and the first A record is used. If a hostname has multiple A records there's no way to
+// tell which of them is actually running the server, so the addresses of the rest are returned in
+// others for the caller to note that the hostname was pinned to the first.
+func AddressFromStringStrict(input string, resolve bool) (output string, others []string, errs []error) {
+	output, errs = AddressFromString(input)
+	if errs != nil {
+		return
 	}
 
-	portStr := u.Port()
+	host, port, err := net.SplitHostPort(output)
+	if err != nil {
+		errs = append(errs, err)
+		return
+	}
 
-	if portStr != "" {
-		port, err := strconv.Atoi(u.Port())
-		if err != nil {
-			errs = append(errs, errors.Errorf("invalid port '%s' specified", u.Port()))
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() == nil {
+			errs = append(errs, errors.Errorf("address '%s' is IPv6, only IPv4 addresses are supported", host))
 			return
 		}
+		output = net.JoinHostPort(ip.To4().String(), port)
+		return
+	}
 
-		if port < 1024 || port > 49152 {
-			errs = append(errs, errors.Errorf("port %d falls within reserved or ephemeral range", port))
-			return
-		}
+	if !resolve {
+		errs = append(errs, errors.Errorf("address host '%s' is not an IPv4 address", host))
+		return
+	}
 
-		output = u.Host
-	} else {
-		output = u.Host + ":7777"
+	ips, err := lookupIP(host)
+	if err != nil {
+		errs = append(errs, errors.Wrapf(err, "failed to resolve host '%s'", host))
+		return
 	}
 
+	var addresses []string
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			addresses = append(addresses, net.JoinHostPort(ip4.String(), port))
+		}
+	}
+	if addresses == nil {
+		errs = append(errs, errors.Errorf("host '%s' has no IPv4 address records", host))
+		return
+	}
+	output = addresses[0]
+	if len(addresses) > 1 {
+		others = addresses[1:]
+	}
 	return
 }
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"net"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -35,3 +36,125 @@ func TestAddressFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		wantHost string
+		wantPort int
+		wantErr  string
+	}{
+		{"valid", "1.2.3.4", "1.2.3.4", 7777, ""},
+		{"valid.port", "1.2.3.4:8888", "1.2.3.4", 8888, ""},
+		{"valid.scheme", "samp://1.2.3.4:8888", "1.2.3.4", 8888, ""},
+		{"valid.scheme.uppercase", "SAMP://1.2.3.4", "1.2.3.4", 7777, ""},
+		{"valid.trailing.slash", "samp://1.2.3.4:8888/", "1.2.3.4", 8888, ""},
+		{"valid.hostname.uppercase", "SS.Southcla.ws", "ss.southcla.ws", 7777, ""},
+		{"valid.ipv6", "[2001:db8::1]:8888", "2001:db8::1", 8888, ""},
+		{"valid.ipv6.no.port", "[2001:db8::1]", "2001:db8::1", 7777, ""},
+		{"invalid.empty", "", "", 0, "address is empty"},
+		{"invalid.scheme", "http://1.2.3.4:7777", "", 0, "address contains invalid scheme 'http', must be either empty or 'samp://'"},
+		{"invalid.scheme.after.port", "1.2.3.4:80://weird", "", 0, "address contains invalid scheme '1.2.3.4:80', must be either empty or 'samp://'"},
+		{"invalid.user", "user:pass@1.2.3.4", "", 0, "address contains a user:password component"},
+		{"invalid.user.scheme", "samp://user@1.2.3.4:7777", "", 0, "address contains a user:password component"},
+		{"invalid.path", "1.2.3.4:7777/servers", "", 0, "address contains a path, query or fragment"},
+		{"invalid.query", "1.2.3.4:7777?x=1", "", 0, "address contains a path, query or fragment"},
+		{"invalid.no.host", "samp://:7777", "", 0, "address has no host"},
+		{"invalid.host", "my server:7777", "", 0, "address host 'my server' contains invalid characters"},
+		{"invalid.port", "1.2.3.4:port", "", 0, "invalid port 'port' specified"},
+		{"invalid.port.reserved", "1.2.3.4:80", "", 0, "port 80 falls within reserved or ephemeral range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, err := ParseAddress(tt.address)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantHost, host)
+			assert.Equal(t, tt.wantPort, port)
+		})
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		wantAddr string
+		wantErr  bool
+	}{
+		{"valid", "1.2.3.4", "1.2.3.4:7777", false},
+		{"valid.port", "1.2.3.4:7777", "1.2.3.4:7777", false},
+		{"valid.scheme", "samp://1.2.3.4", "1.2.3.4:7777", false},
+		{"valid.scheme.port", "samp://1.2.3.4:8888", "1.2.3.4:8888", false},
+		{"valid.hostname", "samp.example.com", "samp.example.com:7777", false},
+		{"valid.hostname.case", "SAMP://Samp.Example.com/", "samp.example.com:7777", false},
+		{"valid.ipv6", "[2001:DB8::1]", "[2001:db8::1]:7777", false},
+		{"invalid.empty", "", "", true},
+		{"invalid.port", "1.2.3.4:port", "", true},
+		{"invalid.scheme", "http://1.2.3.4", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAddr, err := NormalizeAddress(tt.address)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantAddr, gotAddr)
+			}
+		})
+	}
+}
+
+func TestAddressFromStringStrict(t *testing.T) {
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "samp.example.com":
+			return []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.168.1.2"), net.ParseIP("192.168.1.3")}, nil
+		case "single.example.com":
+			return []net.IP{net.ParseIP("192.168.1.4")}, nil
+		case "v6.example.com":
+			return []net.IP{net.ParseIP("2001:db8::1")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	defer func() { lookupIP = net.LookupIP }()
+
+	tests := []struct {
+		name       string
+		address    string
+		resolve    bool
+		wantAddr   string
+		wantOthers []string
+		wantErrs   []string
+	}{
+		{"valid", "192.168.1.2", false, "192.168.1.2:7777", nil, nil},
+		{"valid.port", "samp://192.168.1.2:8888", false, "192.168.1.2:8888", nil, nil},
+		{"valid.resolve", "samp.example.com", true, "192.168.1.2:7777", []string{"192.168.1.3:7777"}, nil},
+		{"valid.resolve.port", "samp.example.com:8888", true, "192.168.1.2:8888", []string{"192.168.1.3:8888"}, nil},
+		{"valid.resolve.single", "single.example.com", true, "192.168.1.4:7777", nil, nil},
+		{"invalid.hostname", "samp.example.com", false, "", nil, []string{"address host 'samp.example.com' is not an IPv4 address"}},
+		{"invalid.ipv6", "[2001:db8::1]:7777", true, "", nil, []string{"address '2001:db8::1' is IPv6, only IPv4 addresses are supported"}},
+		{"invalid.resolve.ipv6", "v6.example.com", true, "", nil, []string{"host 'v6.example.com' has no IPv4 address records"}},
+		{"invalid.resolve", "nothing.example.com", true, "", nil, []string{"no such host"}},
+		{"invalid.scheme", "http://192.168.1.2", false, "", nil, []string{"address contains invalid scheme 'http', must be either empty or 'samp://'"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAddr, gotOthers, gotErrs := AddressFromStringStrict(tt.address, tt.resolve)
+
+			assert.Len(t, gotErrs, len(tt.wantErrs))
+			for i := range gotErrs {
+				assert.Equal(t, tt.wantErrs[i], errors.Cause(gotErrs[i]).Error())
+			}
+			if tt.wantErrs == nil {
+				assert.Equal(t, tt.wantAddr, gotAddr)
+				assert.Equal(t, tt.wantOthers, gotOthers)
+			}
+		})
+	}
+}
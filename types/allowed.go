@@ -0,0 +1,39 @@
+package types
+
+import (
+	"strings"
+)
+
+// Allowed checks the gamemode and language of a server against the ones an index is restricted to,
+// for listings that only cover a certain kind of server such as roleplay. A value is allowed if it
+// contains any of the allowed values, ignoring case, and an empty list allows anything. Each error
+// is a ValidationError naming the field it's about.
+func (core ServerCore) Allowed(gamemodes, languages []string) (errs []error) {
+	if !containsAny(core.Gamemode, gamemodes) {
+		errs = append(errs, invalidField("gamemode", "gamemode '%s' is not allowed, it must contain one of: %s",
+			core.Gamemode, strings.Join(gamemodes, ", ")))
+	}
+	if !containsAny(core.Language, languages) {
+		errs = append(errs, invalidField("language", "language '%s' is not allowed, it must contain one of: %s",
+			core.Language, strings.Join(languages, ", ")))
+	}
+	return
+}
+
+// containsAny reports whether value contains any of the substrings ignoring case, blank substrings
+// are skipped so a stray comma in the config doesn't allow everything
+func containsAny(value string, substrings []string) bool {
+	value = strings.ToLower(value)
+	restricted := false
+	for _, substring := range substrings {
+		substring = strings.ToLower(strings.TrimSpace(substring))
+		if substring == "" {
+			continue
+		}
+		restricted = true
+		if strings.Contains(value, substring) {
+			return true
+		}
+	}
+	return !restricted
+}
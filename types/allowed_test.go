@@ -0,0 +1,37 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerCore_Allowed(t *testing.T) {
+	tests := []struct {
+		name       string
+		gamemode   string
+		language   string
+		gamemodes  []string
+		languages  []string
+		wantFields []string
+	}{
+		{"unrestricted", "Freeroam", "English", nil, nil, nil},
+		{"blank entries", "Freeroam", "English", []string{" ", ""}, []string{""}, nil},
+		{"substring", "Los Santos Roleplay v2", "English", []string{"rp", "roleplay"}, nil, nil},
+		{"case", "LS-RP", "English", []string{"rp"}, nil, nil},
+		{"gamemode", "Freeroam", "English", []string{"roleplay"}, nil, []string{"gamemode"}},
+		{"language", "Roleplay", "Russian", []string{"roleplay"}, []string{"english", "polski"}, []string{"language"}},
+		{"both", "Freeroam", "", []string{"roleplay"}, []string{"english"}, []string{"gamemode", "language"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ServerCore{Gamemode: tt.gamemode, Language: tt.language}.Allowed(tt.gamemodes, tt.languages)
+			var fields []string
+			for _, err := range ValidationErrors(errs) {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+			assert.Len(t, errs, len(tt.wantFields))
+		})
+	}
+}
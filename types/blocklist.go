@@ -0,0 +1,87 @@
+package types
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// Block excludes servers from the index. The address is either a server address such as
+// 1.2.3.4:7777 which blocks that one server, an IP which blocks every server on it or a CIDR range
+// such as 1.2.3.0/24 which blocks every server in an entire hosting range.
+type Block struct {
+	Address string    `json:"address"`
+	Reason  string    `json:"reason,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// BlockResult is the response to adding a block, Removed counts the stored servers it matched
+// which were removed from the index
+type BlockResult struct {
+	Block
+	Removed int `json:"removed"`
+}
+
+// Blocklist is every block in place
+type Blocklist []Block
+
+// NormalizeBlock returns the canonical form of a block address so the same block can't be added
+// twice in different forms. Ranges are reduced to their network address, IPs are left as they are
+// and anything else is normalised as a server address, with the default port if it's absent.
+func NormalizeBlock(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if _, network, err := net.ParseCIDR(address); err == nil {
+		return network.String(), nil
+	}
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.String(), nil
+	}
+	return NormalizeAddress(address)
+}
+
+// Validate checks the address of a block is a server address, an IP or a CIDR range. Each error is
+// a ValidationError naming the field it's about.
+func (b Block) Validate() (errs []error) {
+	if _, err := NormalizeBlock(b.Address); err != nil {
+		errs = append(errs, invalidField("address", "address '%s' is not a server address, IP or CIDR range: %v", b.Address, err))
+	}
+	return
+}
+
+// Matches reports whether a normalised server address is blocked by the block. IPs and ranges only
+// match servers stored by IP since there's no knowing what a hostname resolves to without looking
+// it up.
+func (b Block) Matches(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	if _, network, err := net.ParseCIDR(b.Address); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+	if blocked := net.ParseIP(b.Address); blocked != nil {
+		return blocked.Equal(net.ParseIP(host))
+	}
+	return address == b.Address
+}
+
+// Blocks returns the first block that matches a normalised server address, if there is one
+func (l Blocklist) Blocks(address string) (block Block, blocked bool) {
+	for _, block := range l {
+		if block.Matches(address) {
+			return block, true
+		}
+	}
+	return
+}
+
+// Example returns an example of Block
+func (b Block) Example() Block {
+	return Block{
+		Address: "203.0.113.0/24",
+		Reason:  "fake player counts",
+		Created: time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
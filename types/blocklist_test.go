@@ -0,0 +1,73 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    string
+		wantErr bool
+	}{
+		{"address", "1.2.3.4:7777", "1.2.3.4:7777", false},
+		{"address default port", "samp://ss.southcla.ws", "ss.southcla.ws:7777", false},
+		{"ip", " 1.2.3.4 ", "1.2.3.4", false},
+		{"range", "1.2.3.4/24", "1.2.3.0/24", false},
+		{"reserved port", "1.2.3.4:80", "", true},
+		{"empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeBlock(tt.address)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Len(t, Block{Address: tt.address}.Validate(), 1)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Empty(t, Block{Address: tt.address}.Validate())
+		})
+	}
+}
+
+func TestBlock_Matches(t *testing.T) {
+	tests := []struct {
+		name    string
+		block   string
+		address string
+		want    bool
+	}{
+		{"same address", "1.2.3.4:7777", "1.2.3.4:7777", true},
+		{"other port", "1.2.3.4:7777", "1.2.3.4:7778", false},
+		{"ip any port", "1.2.3.4", "1.2.3.4:7778", true},
+		{"other ip", "1.2.3.4", "1.2.3.5:7777", false},
+		{"in range", "1.2.3.0/24", "1.2.3.200:7777", true},
+		{"outside range", "1.2.3.0/24", "1.2.4.1:7777", false},
+		{"hostname not in range", "1.2.3.0/24", "ss.southcla.ws:7777", false},
+		{"hostname", "ss.southcla.ws:7777", "ss.southcla.ws:7777", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Block{Address: tt.block}.Matches(tt.address))
+		})
+	}
+}
+
+func TestBlocklist_Blocks(t *testing.T) {
+	list := Blocklist{{Address: "1.2.3.4:7777"}, {Address: "5.6.0.0/16", Reason: "hosting range"}}
+
+	block, blocked := list.Blocks("5.6.7.8:7777")
+	assert.True(t, blocked)
+	assert.Equal(t, "hosting range", block.Reason)
+
+	_, blocked = list.Blocks("1.2.3.4:7778")
+	assert.False(t, blocked)
+
+	_, blocked = Blocklist(nil).Blocks("1.2.3.4:7777")
+	assert.False(t, blocked)
+}
@@ -0,0 +1,122 @@
+package types
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BulkResult is the response to a bulk server submission, it contains the amount of servers that
+// were stored and the position of each rejected server in the submitted array alongside the reasons
+// it was rejected.
+type BulkResult struct {
+	Accepted int             `json:"accepted"`
+	Rejected []BulkRejection `json:"rejected"`
+}
+
+// BulkRejection describes a single server that was rejected from a bulk submission
+type BulkRejection struct {
+	Index  int               `json:"index"`
+	Errors []string          `json:"errors"`
+	Fields []ValidationError `json:"fields,omitempty"`
+}
+
+// Example returns an example of BulkResult
+func (b BulkResult) Example() BulkResult {
+	return BulkResult{
+		Accepted: 12,
+		Rejected: []BulkRejection{
+			{
+				Index:  3,
+				Errors: []string{"hostname is empty", "gamemode is empty"},
+				Fields: []ValidationError{
+					{Field: "hostname", Message: "hostname is empty"},
+					{Field: "gamemode", Message: "gamemode is empty"},
+				},
+			},
+		},
+	}
+}
+
+// MaxBulkGet is the most addresses that can be looked up at once
+var MaxBulkGet = 200
+
+// BulkGet is a request for the servers at many addresses at once, such as a browser's favourites
+type BulkGet struct {
+	Addresses []string `json:"addresses"`
+}
+
+// Validate checks that there's at least one address and no more than MaxBulkGet. The addresses
+// themselves aren't checked, ones that aren't valid simply don't match a server.
+func (b BulkGet) Validate() (errs []error) {
+	switch {
+	case len(b.Addresses) == 0:
+		errs = append(errs, invalidField("addresses", "no addresses specified"))
+	case len(b.Addresses) > MaxBulkGet:
+		errs = append(errs, invalidField("addresses", "%d addresses exceeds the maximum of %d", len(b.Addresses), MaxBulkGet))
+	}
+	return
+}
+
+// Example returns an example of BulkGet
+func (b BulkGet) Example() BulkGet {
+	return BulkGet{
+		Addresses: []string{"127.0.0.1:7777", "ss.southcla.ws:7777"},
+	}
+}
+
+// BulkDelete is a request to delete many servers at once, either the servers at a list of addresses
+// or every server that hasn't been seen since a date
+type BulkDelete struct {
+	Addresses    []string `json:"addresses,omitempty"`
+	OfflineSince string   `json:"offlineSince,omitempty"`
+}
+
+// BulkDeleteResult is the response to a bulk deletion, it contains the amount of servers that were
+// deleted, the addresses that didn't match a server and the position of each address that was
+// rejected alongside the reasons.
+type BulkDeleteResult struct {
+	Removed  int             `json:"removed"`
+	NotFound []string        `json:"notFound"`
+	Rejected []BulkRejection `json:"rejected"`
+}
+
+// Validate checks that exactly one of the addresses or date is given and returns the date, which
+// is either a plain date such as 2018-01-01 or an RFC 3339 time. The addresses are checked
+// individually so that a bad one doesn't fail the rest.
+func (b BulkDelete) Validate() (since time.Time, errs []error) {
+	if len(b.Addresses) == 0 && b.OfflineSince == "" {
+		return since, []error{errors.New("either addresses or offlineSince must be specified")}
+	}
+	if len(b.Addresses) > 0 && b.OfflineSince != "" {
+		return since, []error{errors.New("addresses and offlineSince cannot both be specified")}
+	}
+	if b.OfflineSince == "" {
+		return
+	}
+
+	since, err := time.Parse("2006-01-02", b.OfflineSince)
+	if err != nil {
+		since, err = time.Parse(time.RFC3339, b.OfflineSince)
+	}
+	if err != nil {
+		errs = append(errs, errors.Errorf("offlineSince '%s' is not a date or RFC 3339 time", b.OfflineSince))
+	}
+	return
+}
+
+// Example returns an example of BulkDelete
+func (b BulkDelete) Example() BulkDelete {
+	return BulkDelete{
+		Addresses: []string{"ss.southcla.ws:7777", "127.0.0.1:7777"},
+	}
+}
+
+// Example returns an example of BulkDeleteResult
+func (b BulkDeleteResult) Example() BulkDeleteResult {
+	return BulkDeleteResult{
+		Removed:  1,
+		NotFound: []string{"127.0.0.1:7777"},
+		Rejected: []BulkRejection{},
+	}
+}
@@ -4,16 +4,79 @@ import "time"
 
 // Config stores app global configuration
 type Config struct {
-	Version         string
-	Bind            string        `split_words:"true" required:"true"`
-	MongoHost       string        `split_words:"true" required:"true"`
-	MongoPort       string        `split_words:"true" required:"true"`
-	MongoName       string        `split_words:"true" required:"true"`
-	MongoUser       string        `split_words:"true" required:"true"`
-	MongoPass       string        `split_words:"true" required:"false"`
-	MongoCollection string        `split_words:"true" required:"true"`
-	QueryInterval   time.Duration `split_words:"true" required:"true"`
-	MaxFailedQuery  int           `split_words:"true" required:"true"`
-	VerifyByHost    bool          `split_words:"true" required:"true"`
-	LegacyList      bool          `split_words:"true" required:"true"`
+	Version              string
+	Bind                 string            `split_words:"true" required:"true"`
+	ShutdownTimeout      time.Duration     `split_words:"true" required:"false"`
+	CorsOrigins          []string          `split_words:"true" required:"false"`
+	GzipMinLength        int               `split_words:"true" required:"false"`
+	MaxBodySize          int64             `split_words:"true" required:"false"`
+	Storage              string            `split_words:"true" required:"false"`
+	MongoHost            string            `split_words:"true" required:"false"`
+	MongoPort            string            `split_words:"true" required:"false"`
+	MongoURI             string            `envconfig:"MONGO_URI" required:"false"`
+	MongoName            string            `split_words:"true" required:"false"`
+	MongoUser            string            `split_words:"true" required:"false"`
+	MongoPass            string            `split_words:"true" required:"false"`
+	MongoCollection      string            `split_words:"true" required:"false"`
+	MongoTimeout         time.Duration     `split_words:"true" required:"false"`
+	MongoPoolSize        int               `split_words:"true" required:"false"`
+	MongoConnectAttempts int               `split_words:"true" required:"false"`
+	QueryInterval        time.Duration     `split_words:"true" required:"true"`
+	QueryTimeout         time.Duration     `split_words:"true" required:"false"`
+	QueryRetries         int               `split_words:"true" required:"false"`
+	QueryEncoding        string            `split_words:"true" required:"false"`
+	QueryHostRate        float64           `split_words:"true" required:"false"`
+	MaxConcurrentQueries int               `split_words:"true" required:"false"`
+	QuerySockets         int               `split_words:"true" required:"false"`
+	TCPQueryServers      []string          `envconfig:"TCP_QUERY_SERVERS" required:"false"`
+	OfflineAfter         time.Duration     `split_words:"true" required:"false"`
+	PollInterval         time.Duration     `split_words:"true" required:"false"`
+	PollWorkers          int               `split_words:"true" required:"false"`
+	HistoryRawRetention  time.Duration     `split_words:"true" required:"false"`
+	HistoryRetention     time.Duration     `split_words:"true" required:"false"`
+	DeadGracePeriod      time.Duration     `split_words:"true" required:"false"`
+	MaxPollFailures      int               `split_words:"true" required:"false"`
+	IdempotencyTTL       time.Duration     `envconfig:"IDEMPOTENCY_TTL" required:"false"`
+	MaxFailedQuery       int               `split_words:"true" required:"true"`
+	VerifyByHost         bool              `split_words:"true" required:"true"`
+	SkipVerifyPosted     bool              `split_words:"true" required:"false"`
+	AllowedGamemodes     []string          `split_words:"true" required:"false"`
+	AllowedLanguages     []string          `split_words:"true" required:"false"`
+	RateLimit            float64           `split_words:"true" required:"false"`
+	RateLimitBurst       int               `split_words:"true" required:"false"`
+	LiveRateLimit        float64           `split_words:"true" required:"false"`
+	LiveRateBurst        int               `split_words:"true" required:"false"`
+	LiveTimeout          time.Duration     `split_words:"true" required:"false"`
+	LiveCacheTTL         time.Duration     `envconfig:"LIVE_CACHE_TTL" required:"false"`
+	DiscoverTimeout      time.Duration     `split_words:"true" required:"false"`
+	TrendingMinPlayers   int               `split_words:"true" required:"false"`
+	SelfTestAddress      string            `split_words:"true" required:"false"`
+	RequestTimeout       time.Duration     `split_words:"true" required:"false"`
+	TrustProxy           bool              `split_words:"true" required:"false"`
+	TrustedProxies       []string          `split_words:"true" required:"false"`
+	StrictIP             bool              `split_words:"true" required:"false"`
+	ResolveHosts         bool              `split_words:"true" required:"false"`
+	AllowNoContentType   bool              `split_words:"true" required:"false"`
+	LegacyList           bool              `split_words:"true" required:"true"`
+	GeoipDatabase        string            `split_words:"true" required:"false"`
+	APIKeys              []string          `envconfig:"API_KEYS" required:"false"`
+	AdminKeys            []string          `split_words:"true" required:"false"`
+	AccessLogLevels      map[string]string `split_words:"true" required:"false"`
+}
+
+// Redacted returns a copy of the config with the database password and API keys replaced so it can
+// be logged, the number of keys is kept since that's useful to know
+func (c Config) Redacted() Config {
+	redact := func(secrets []string) (redacted []string) {
+		for range secrets {
+			redacted = append(redacted, "[redacted]")
+		}
+		return
+	}
+	if c.MongoPass != "" {
+		c.MongoPass = "[redacted]"
+	}
+	c.APIKeys = redact(c.APIKeys)
+	c.AdminKeys = redact(c.AdminKeys)
+	return c
 }
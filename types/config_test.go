@@ -0,0 +1,20 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Redacted(t *testing.T) {
+	config := Config{Bind: "0.0.0.0:80", MongoPass: "hunter2", APIKeys: []string{"a", "b"}, AdminKeys: []string{"c"}}
+
+	assert.Equal(t, Config{
+		Bind:      "0.0.0.0:80",
+		MongoPass: "[redacted]",
+		APIKeys:   []string{"[redacted]", "[redacted]"},
+		AdminKeys: []string{"[redacted]"},
+	}, config.Redacted())
+	assert.Equal(t, []string{"a", "b"}, config.APIKeys, "the original is left alone")
+	assert.Equal(t, Config{Bind: "0.0.0.0:80"}, Config{Bind: "0.0.0.0:80"}.Redacted())
+}
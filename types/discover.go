@@ -0,0 +1,55 @@
+package types
+
+import (
+	"net"
+)
+
+// MaxDiscoverPorts is the most ports a single discovery may probe
+var MaxDiscoverPorts = 100
+
+// Discover is a request to find the servers running on a range of ports of an IP, which is how
+// hosting providers tend to run many servers at once. From and To are inclusive.
+type Discover struct {
+	IP   string `json:"ip"`
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Add  bool   `json:"add,omitempty"`
+}
+
+// Validate checks the IP is an IPv4 address, since that's all the query protocol can address, and
+// that it's public so discovery can't be used to scan the API's own network. The range has to be
+// in order, outside of the reserved and ephemeral ports and no larger than MaxDiscoverPorts. Each
+// error is a ValidationError naming the field it's about.
+func (d Discover) Validate() (errs []error) {
+	if ip := net.ParseIP(d.IP); ip == nil || ip.To4() == nil {
+		errs = append(errs, invalidField("ip", "ip '%s' is not an IPv4 address", d.IP))
+	} else if !PublicIP(ip) {
+		errs = append(errs, invalidField("ip", "ip '%s' is not a public address", d.IP))
+	}
+
+	fromValid, toValid := validPort(d.From), validPort(d.To)
+	if !fromValid {
+		errs = append(errs, invalidField("from", "from %d falls within reserved or ephemeral range", d.From))
+	}
+	if !toValid {
+		errs = append(errs, invalidField("to", "to %d falls within reserved or ephemeral range", d.To))
+	}
+	if fromValid && toValid {
+		if d.To < d.From {
+			errs = append(errs, invalidField("to", "to %d is before from %d", d.To, d.From))
+		} else if d.To-d.From+1 > MaxDiscoverPorts {
+			errs = append(errs, invalidField("to", "range of %d ports exceeds the maximum of %d", d.To-d.From+1, MaxDiscoverPorts))
+		}
+	}
+	return
+}
+
+// Example returns an example of Discover
+func (d Discover) Example() Discover {
+	return Discover{
+		IP:   "203.0.113.10",
+		From: 7777,
+		To:   7800,
+		Add:  true,
+	}
+}
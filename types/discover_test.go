@@ -0,0 +1,36 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscover_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		discover   Discover
+		wantFields []string
+	}{
+		{"valid", Discover{IP: "1.2.3.4", From: 7777, To: 7876}, nil},
+		{"single port", Discover{IP: "1.2.3.4", From: 7777, To: 7777}, nil},
+		{"too many ports", Discover{IP: "1.2.3.4", From: 7777, To: 7877}, []string{"to"}},
+		{"backwards", Discover{IP: "1.2.3.4", From: 7778, To: 7777}, []string{"to"}},
+		{"ipv6", Discover{IP: "::1", From: 7777, To: 7800}, []string{"ip"}},
+		{"empty", Discover{}, []string{"ip", "from", "to"}},
+		{"ephemeral", Discover{IP: "1.2.3.4", From: 7777, To: 50000}, []string{"to"}},
+		{"loopback", Discover{IP: "127.0.0.1", From: 7777, To: 7800}, []string{"ip"}},
+		{"private", Discover{IP: "192.168.1.2", From: 7777, To: 7800}, []string{"ip"}},
+		{"private 10", Discover{IP: "10.0.0.1", From: 7777, To: 7800}, []string{"ip"}},
+		{"metadata", Discover{IP: "169.254.169.254", From: 7777, To: 7800}, []string{"ip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, err := range tt.discover.Validate() {
+				fields = append(fields, err.(ValidationError).Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
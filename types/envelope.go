@@ -0,0 +1,15 @@
+package types
+
+// Envelope wraps a response for clients that would rather parse every response the same way, a
+// list and a single object alike are the Data of an Envelope
+type Envelope struct {
+	Data interface{}  `json:"data"`
+	Meta EnvelopeMeta `json:"meta"`
+}
+
+// EnvelopeMeta describes the Data of an Envelope. Count is the number of items, one for a single
+// object, and Next is the cursor of the following page of a paged listing if there is one.
+type EnvelopeMeta struct {
+	Count int    `json:"count"`
+	Next  string `json:"next,omitempty"`
+}
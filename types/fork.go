@@ -0,0 +1,40 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ForkOpenMP and ForkSAMP are the accepted values of the fork parameter
+const (
+	ForkOpenMP = "openmp"
+	ForkSAMP   = "samp"
+)
+
+// DetectOpenMP reports whether a server is running open.mp from its rules. open.mp servers report a
+// version starting with "omp" and add an allowed_clients rule listing the client versions they
+// accept, neither of which the original SA:MP server does. It's a variable so the heuristics can be
+// replaced as the fork evolves, it's called with the rules of every queried and posted server.
+var DetectOpenMP = func(rules map[string]string) bool {
+	if _, ok := rules["allowed_clients"]; ok {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(rules["version"])), "omp")
+}
+
+// ForkFilter returns whether listed servers must be running open.mp, or nil if servers are listed
+// whichever they're running
+func (slp ServerListParams) ForkFilter() (openmp *bool, err error) {
+	switch slp.Fork {
+	case "":
+		return nil, nil
+	case ForkOpenMP:
+		openmp = new(bool)
+		*openmp = true
+		return openmp, nil
+	case ForkSAMP:
+		return new(bool), nil
+	}
+	return nil, errors.Errorf("invalid 'fork' argument '%s', must be %s or %s", slp.Fork, ForkOpenMP, ForkSAMP)
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectOpenMP(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules map[string]string
+		want  bool
+	}{
+		{"samp", map[string]string{"version": "0.3.7-R2", "mapname": "San Andreas"}, false},
+		{"omp version", map[string]string{"version": "omp 1.1.0.2612"}, true},
+		{"allowed clients", map[string]string{"version": "0.3.7-R2", "allowed_clients": "0.3.7, 0.3.DL"}, true},
+		{"no rules", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectOpenMP(tt.rules))
+		})
+	}
+}
+
+func TestServerListParams_ForkFilter(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		fork    string
+		want    *bool
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"openmp", &yes, false},
+		{"samp", &no, false},
+		{"omp", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fork, func(t *testing.T) {
+			got, err := ServerListParams{Fork: tt.fork}.ForkFilter()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"time"
+)
+
+// PlayerSample is the player count of a server at a point in time, it's recorded each time the
+// poller queries a server. Samples older than the raw retention period are merged into hourly
+// averages and marked as downsampled.
+type PlayerSample struct {
+	Address     string    `json:"-"`
+	Time        time.Time `json:"time"`
+	Players     float64   `json:"players"`
+	Downsampled bool      `json:"-"`
+}
+
+// History is a series of average player counts for a server, each point covers Interval starting
+// at its time. Intervals without any samples are left out.
+type History struct {
+	Address  string         `json:"address"`
+	Interval string         `json:"interval"`
+	Points   []PlayerSample `json:"points"`
+}
+
+// BucketSamples averages samples into consecutive intervals starting at from, the samples must be
+// ordered by time and not be before from.
+func BucketSamples(samples []PlayerSample, from time.Time, interval time.Duration) (points []PlayerSample) {
+	points = []PlayerSample{}
+	var (
+		current time.Time
+		sum     float64
+		n       int
+	)
+	flush := func() {
+		if n > 0 {
+			points = append(points, PlayerSample{Time: current, Players: sum / float64(n)})
+		}
+	}
+	for _, sample := range samples {
+		bucket := from.Add(sample.Time.Sub(from) / interval * interval)
+		if n == 0 || !bucket.Equal(current) {
+			flush()
+			current, sum, n = bucket, 0, 0
+		}
+		sum += sample.Players
+		n++
+	}
+	flush()
+	return
+}
+
+// Example returns an example of History
+func (h History) Example() History {
+	start := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	return History{
+		Address:  "ss.southcla.ws:7777",
+		Interval: "5m0s",
+		Points: []PlayerSample{
+			{Time: start, Players: 4},
+			{Time: start.Add(time.Minute * 5), Players: 5.5},
+			{Time: start.Add(time.Minute * 10), Players: 7},
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketSamples(t *testing.T) {
+	from := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int, players float64) PlayerSample {
+		return PlayerSample{Time: from.Add(time.Duration(minutes) * time.Minute), Players: players}
+	}
+	tests := []struct {
+		name     string
+		samples  []PlayerSample
+		interval time.Duration
+		want     []PlayerSample
+	}{
+		{"none", nil, time.Minute * 5, []PlayerSample{}},
+		{"one bucket", []PlayerSample{at(0, 4), at(1, 6), at(4, 8)}, time.Minute * 5, []PlayerSample{at(0, 6)}},
+		{"gap", []PlayerSample{at(1, 2), at(11, 3), at(14, 5)}, time.Minute * 5, []PlayerSample{at(0, 2), at(10, 4)}},
+		{"boundary", []PlayerSample{at(4, 1), at(5, 9)}, time.Minute * 5, []PlayerSample{at(0, 1), at(5, 9)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, BucketSamples(tt.samples, from, tt.interval))
+		})
+	}
+}
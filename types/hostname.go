@@ -0,0 +1,31 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// colourEmbed matches the {RRGGBB} colour codes SA:MP renders in hostnames and chat
+var colourEmbed = regexp.MustCompile(`\{[0-9A-Fa-f]{6}\}`)
+
+// CleanHostname strips colour embeds and control characters from a hostname and collapses the
+// whitespace left behind into single spaces, anything else including non-ASCII text is kept as it
+// is. Embeds are removed until none are left so that one hidden inside another such as
+// {{FFFFFF}FF0000} doesn't survive, which also means cleaning a clean hostname changes nothing.
+func CleanHostname(s string) string {
+	for {
+		stripped := colourEmbed.ReplaceAllString(s, "")
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
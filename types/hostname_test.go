@@ -0,0 +1,31 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanHostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostname string
+		want     string
+	}{
+		{"plain", "Scavenge and Survive", "Scavenge and Survive"},
+		{"colours", "{FF0000}Scavenge {ffffff}and {00FF00}Survive", "Scavenge and Survive"},
+		{"whitespace", "  [NGRP]  {FF0000} Roleplay ", "[NGRP] Roleplay"},
+		{"control characters", "Scavenge\x00 and\tSurvive\n", "Scavenge and Survive"},
+		{"nested", "{{FF0000}FFFFFF}Scavenge", "Scavenge"},
+		{"not a colour", "{GGGGGG}Scavenge {FFF}", "{GGGGGG}Scavenge {FFF}"},
+		{"non-ascii", "{FF0000}Привет RP ★ 日本", "Привет RP ★ 日本"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CleanHostname(tt.hostname)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, got, CleanHostname(got))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package types
+
+import (
+	"time"
+)
+
+// IdempotentResponse is the response to a POST request that was sent with an Idempotency-Key header,
+// it's kept so that a retry of the request with the same key gets the same response instead of being
+// processed again.
+type IdempotentResponse struct {
+	Key         string
+	Method      string
+	Path        string
+	BodyHash    string // hex encoded SHA-256 of the request body
+	Status      int
+	ContentType string
+	Body        []byte
+	Created     time.Time
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+)
+
+// languageSeparator matches the ways hosts list several languages in one field, such as
+// "English/Russian", "EN, RU", "[EN][RU]" or "English & Polish"
+var languageSeparator = regexp.MustCompile(`\s*(?:[/\\,;|&+]|\]\s*\[|\s-\s|\s+and\s+)\s*`)
+
+// languageNames maps the codes, abbreviations and native names commonly found in language fields
+// to the English name of the language, keys are lower case
+var languageNames = map[string]string{
+	"en": "English", "eng": "English", "english": "English",
+	"ru": "Russian", "rus": "Russian", "russian": "Russian", "русский": "Russian",
+	"pl": "Polish", "pol": "Polish", "polish": "Polish", "polski": "Polish",
+	"es": "Spanish", "esp": "Spanish", "spa": "Spanish", "spanish": "Spanish", "español": "Spanish", "espanol": "Spanish",
+	"pt": "Portuguese", "br": "Portuguese", "pt-br": "Portuguese", "por": "Portuguese", "portuguese": "Portuguese", "português": "Portuguese", "portugues": "Portuguese",
+	"de": "German", "ger": "German", "deu": "German", "german": "German", "deutsch": "German",
+	"fr": "French", "fra": "French", "fre": "French", "french": "French", "français": "French", "francais": "French",
+	"it": "Italian", "ita": "Italian", "italian": "Italian", "italiano": "Italian",
+	"ro": "Romanian", "rom": "Romanian", "romanian": "Romanian", "română": "Romanian", "romana": "Romanian",
+	"tr": "Turkish", "tur": "Turkish", "turkish": "Turkish", "türkçe": "Turkish", "turkce": "Turkish",
+	"hu": "Hungarian", "hun": "Hungarian", "hungarian": "Hungarian", "magyar": "Hungarian",
+	"nl": "Dutch", "dut": "Dutch", "nld": "Dutch", "dutch": "Dutch", "nederlands": "Dutch",
+	"sr": "Serbian", "srb": "Serbian", "serbian": "Serbian", "srpski": "Serbian",
+	"hr": "Croatian", "cro": "Croatian", "croatian": "Croatian", "hrvatski": "Croatian",
+	"bg": "Bulgarian", "bul": "Bulgarian", "bulgarian": "Bulgarian", "български": "Bulgarian",
+	"lt": "Lithuanian", "lit": "Lithuanian", "lithuanian": "Lithuanian", "lietuvių": "Lithuanian", "lietuviu": "Lithuanian",
+	"lv": "Latvian", "lat": "Latvian", "latvian": "Latvian", "latviešu": "Latvian",
+	"ua": "Ukrainian", "uk": "Ukrainian", "ukr": "Ukrainian", "ukrainian": "Ukrainian", "українська": "Ukrainian",
+	"cz": "Czech", "cs": "Czech", "cze": "Czech", "czech": "Czech", "čeština": "Czech", "cestina": "Czech",
+	"el": "Greek", "gr": "Greek", "greek": "Greek", "ελληνικά": "Greek",
+	"ar": "Arabic", "ara": "Arabic", "arabic": "Arabic", "العربية": "Arabic",
+	"id": "Indonesian", "ind": "Indonesian", "indonesian": "Indonesian", "bahasa indonesia": "Indonesian",
+	"vi": "Vietnamese", "vn": "Vietnamese", "vie": "Vietnamese", "vietnamese": "Vietnamese", "tiếng việt": "Vietnamese",
+	"zh": "Chinese", "cn": "Chinese", "chi": "Chinese", "chinese": "Chinese", "中文": "Chinese",
+	"he": "Hebrew", "heb": "Hebrew", "hebrew": "Hebrew", "עברית": "Hebrew",
+}
+
+// NormalizeLanguage splits a free text language field such as "EN/RU" into the languages it lists
+// and maps the ones it recognises to their English name, so "English", "EN" and "eng" are all
+// "English". Languages it doesn't recognise are kept as they were written, without the brackets and
+// whitespace around them, and each language is only listed once in the order it first appeared.
+func NormalizeLanguage(s string) (languages []string) {
+	seen := make(map[string]bool)
+	for _, part := range languageSeparator.Split(s, -1) {
+		part = strings.Join(strings.Fields(strings.Trim(part, " \t[](){}.-_")), " ")
+		if part == "" {
+			continue
+		}
+		if name, ok := languageNames[strings.ToLower(part)]; ok {
+			part = name
+		}
+		if key := strings.ToLower(part); !seen[key] {
+			seen[key] = true
+			languages = append(languages, part)
+		}
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{"name", "English", []string{"English"}},
+		{"code", "EN", []string{"English"}},
+		{"abbreviation", "eng", []string{"English"}},
+		{"native", "Русский", []string{"Russian"}},
+		{"slash", "English/Russian", []string{"English", "Russian"}},
+		{"comma", "EN, RU, PL", []string{"English", "Russian", "Polish"}},
+		{"ampersand", "English & Polish", []string{"English", "Polish"}},
+		{"and", "Spanish and English", []string{"Spanish", "English"}},
+		{"dash", "PT - EN", []string{"Portuguese", "English"}},
+		{"hyphenated code", "pt-br", []string{"Portuguese"}},
+		{"brackets", "[EN] [RU]", []string{"English", "Russian"}},
+		{"bracketed", "[EN]/[RU]", []string{"English", "Russian"}},
+		{"duplicates", "English/EN/eng", []string{"English"}},
+		{"unknown", "Klingon", []string{"Klingon"}},
+		{"unknown mixed", "English / Klingon", []string{"English", "Klingon"}},
+		{"unknown duplicates", "Klingon/klingon", []string{"Klingon"}},
+		{"whitespace", "  Brazilian   Portuguese ", []string{"Brazilian Portuguese"}},
+		{"empty", "", nil},
+		{"separators", " / , ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeLanguage(tt.s))
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package types
+
+// NearbyServer is a server listed by how close it's likely to be to a player, along with the
+// country it's in
+type NearbyServer struct {
+	ServerCore
+	Country string `json:"co,omitempty"`
+}
+
+// Example returns an example of NearbyServer
+func (ns NearbyServer) Example() NearbyServer {
+	return NearbyServer{
+		ServerCore: Server{}.Example().Core,
+		Country:    "GB",
+	}
+}
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// derivedFields are the keys of fields the API fills in itself, from queries of the server or by
+// keeping track of it, so they can't be patched
+var derivedFields = map[string]bool{
+	"active": true, "ls": true, "on": true, "pi": true, "ds": true, "raw": true, "pk": true,
+	"pk24": true, "featured": true, "om": true, "fake": true, "cf": true, "aliases": true, "pl": true,
+	"core.ip": true, "core.hc": true, "core.lg": true,
+}
+
+// Invalid is a list of problems with a submitted object, usually ValidationErrors, as a single error
+type Invalid []error
+
+func (errs Invalid) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Patch merges a partial server object onto the server, only the fields present in the patch are
+// overwritten. The core is patched field by field too, so {"core":{"gm":"Roleplay"}} leaves the rest
+// of it as it was. Fields that are derived by the API such as on and ls are rejected along with keys
+// the server doesn't have, nothing is changed if any key is rejected. The fields derived from the
+// patched ones, like the clean hostname, are updated but the result isn't validated.
+func (server *Server) Patch(patch map[string]json.RawMessage) (errs []error) {
+	patched := *server
+	if _, ok := patch["ru"]; ok {
+		patched.Rules = nil // replaced rather than merged into, and left alone if the patch is rejected
+	}
+
+	fields := map[string]interface{}{
+		"description": &patched.Description,
+		"banner":      &patched.Banner,
+		"co":          &patched.Country,
+		"ru":          &patched.Rules,
+		"qp":          &patched.QueryPort,
+	}
+	errs = applyPatch("", patch, fields)
+
+	if core, ok := patch["core"]; ok {
+		var corePatch map[string]json.RawMessage
+		if err := json.Unmarshal(core, &corePatch); err != nil {
+			errs = append(errs, invalidField("core", "core must be an object: %v", err))
+		} else {
+			errs = append(errs, applyPatch("core.", corePatch, map[string]interface{}{
+				"hn": &patched.Core.Hostname,
+				"pc": &patched.Core.Players,
+				"pm": &patched.Core.MaxPlayers,
+				"gm": &patched.Core.Gamemode,
+				"la": &patched.Core.Language,
+				"pa": &patched.Core.Password,
+				"vn": &patched.Core.Version,
+			})...)
+		}
+	}
+	if errs != nil {
+		return
+	}
+
+	patched.Core.HostnameClean = CleanHostname(patched.Core.Hostname)
+	patched.Core.Languages = NormalizeLanguage(patched.Core.Language)
+	patched.OpenMP = DetectOpenMP(patched.Rules)
+	patched.NormalizeQueryPort()
+	*server = patched
+	return nil
+}
+
+// applyPatch decodes each value of a patch into the field with its key, the prefix is prepended to
+// keys in errors to say where they're nested. Keys are handled in order so errors are too.
+func applyPatch(prefix string, patch map[string]json.RawMessage, fields map[string]interface{}) (errs []error) {
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := prefix + key
+		if prefix == "" && key == "core" {
+			continue
+		}
+		if derivedFields[name] {
+			errs = append(errs, invalidField(name, "%s is derived by the API and can't be patched", name))
+			continue
+		}
+		field, ok := fields[key]
+		if !ok {
+			errs = append(errs, invalidField(name, "unknown field %s", name))
+			continue
+		}
+		if err := json.Unmarshal(patch[key], field); err != nil {
+			errs = append(errs, invalidField(name, "invalid value for %s: %v", name, err))
+		}
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Patch(t *testing.T) {
+	tests := []struct {
+		name       string
+		patch      string
+		want       func(*Server)
+		wantFields []string
+	}{
+		{"country", `{"co":"PL"}`, func(s *Server) { s.Country = "PL" }, nil},
+		{"description and banner", `{"description":"New","banner":""}`, func(s *Server) {
+			s.Description = "New"
+			s.Banner = ""
+		}, nil},
+		{"core field", `{"core":{"hn":"{FF0000}Red Server","la":"Polski"}}`, func(s *Server) {
+			s.Core.Hostname = "{FF0000}Red Server"
+			s.Core.HostnameClean = "Red Server"
+			s.Core.Language = "Polski"
+			s.Core.Languages = NormalizeLanguage("Polski")
+		}, nil},
+		{"rules replaced", `{"ru":{"weather":"5"}}`, func(s *Server) { s.Rules = map[string]string{"weather": "5"} }, nil},
+		{"query port", `{"qp":7777}`, func(s *Server) { s.QueryPort = 0 }, nil},
+		{"derived", `{"on":true,"ls":"2018-01-01T00:00:00Z"}`, nil, []string{"ls", "on"}},
+		{"derived core", `{"core":{"ip":"1.2.3.4:7777","hc":"x"}}`, nil, []string{"core.hc", "core.ip"}},
+		{"unknown", `{"colour":"red","core":{"map":"LS"}}`, nil, []string{"colour", "core.map"}},
+		{"wrong type", `{"co":5}`, nil, []string{"co"}},
+		{"core not an object", `{"core":"1.2.3.4"}`, nil, []string{"core"}},
+		{"partly derived", `{"co":"PL","pk":500}`, nil, []string{"pk"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch map[string]json.RawMessage
+			assert.NoError(t, json.Unmarshal([]byte(tt.patch), &patch))
+
+			server := Server{}.Example()
+			server.QueryPort = 7778
+			errs := server.Patch(patch)
+
+			want := Server{}.Example()
+			want.QueryPort = 7778
+			if tt.want != nil {
+				tt.want(&want)
+			}
+			assert.Equal(t, want, server)
+
+			var fields []string
+			for _, err := range ValidationErrors(errs) {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
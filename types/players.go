@@ -0,0 +1,67 @@
+package types
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// MaxPlayerErrors is the maximum number of invalid nicknames reported for a single server
+	MaxPlayerErrors = 10
+
+	nicknamePattern = regexp.MustCompile(`^[0-9a-zA-Z_$=()\[\]. ]{1,24}$`)
+)
+
+// PlayerDetail is a connected player as listed by the detailed players query, the ID is the player's
+// slot on the server and the ping is in milliseconds.
+type PlayerDetail struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	Ping  int    `json:"ping"`
+}
+
+// Example returns an example of PlayerDetail
+func (player PlayerDetail) Example() PlayerDetail {
+	return PlayerDetail{
+		ID:    0,
+		Name:  "Southclaws",
+		Score: 1337,
+		Ping:  48,
+	}
+}
+
+// maxPlayerSlots is the most players a SA:MP server can be configured to hold
+const maxPlayerSlots = 1000
+
+// validatePlayers checks the player list is no longer than the server could actually hold and that
+// each nickname uses only the characters SA:MP allows.
+func validatePlayers(players []string, maxPlayers int) (errs []error) {
+	limit := maxPlayers
+	if limit > maxPlayerSlots {
+		limit = maxPlayerSlots
+	} else if limit < 0 {
+		limit = 0
+	}
+	if len(players) > limit {
+		return []error{errors.Errorf("player list has %d players, the maximum is %d", len(players), limit)}
+	}
+
+	invalid := 0
+	for i, name := range players {
+		if nicknamePattern.MatchString(name) {
+			continue
+		}
+		invalid++
+		if invalid > MaxPlayerErrors {
+			continue
+		}
+		errs = append(errs, errors.Errorf("player %d nickname %q is invalid", i, name))
+	}
+	if invalid > MaxPlayerErrors {
+		errs = append(errs, errors.Errorf("%d more invalid nicknames", invalid-MaxPlayerErrors))
+	}
+
+	return
+}
@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// PollJob is the progress of a poll cycle that was triggered manually. Started is empty while the
+// job waits for a scheduled poll to finish and Finished is set once every server has been polled,
+// Queried counts the servers polled so far and Failed the ones among them that didn't respond.
+type PollJob struct {
+	ID       string     `json:"id"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Total    int        `json:"total"`
+	Queried  int        `json:"queried"`
+	Failed   int        `json:"failed"`
+}
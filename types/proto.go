@@ -0,0 +1,132 @@
+package types
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// protobuf wire types, see https://developers.google.com/protocol-buffers/docs/encoding
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// MarshalProto encodes the server as a Server message, see server.proto. A server with nothing but
+// its core set encodes to a message with only the core, which is how the list is encoded when it's
+// not full.
+func (server Server) MarshalProto() []byte {
+	b := appendMessage(nil, 1, server.Core.MarshalProto())
+	keys := make([]string, 0, len(server.Rules))
+	for key := range server.Rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // so equal servers always encode the same
+	for _, key := range keys {
+		entry := appendString(nil, 1, key)
+		entry = appendString(entry, 2, server.Rules[key])
+		b = appendMessage(b, 2, entry)
+	}
+	for _, player := range server.PlayerList {
+		b = appendRepeatedString(b, 3, player)
+	}
+	b = appendString(b, 4, server.Description)
+	b = appendString(b, 5, server.Banner)
+	b = appendBool(b, 6, server.Active)
+	b = appendTime(b, 7, server.LastSeen)
+	b = appendBool(b, 8, server.Online)
+	b = appendString(b, 9, server.Country)
+	b = appendInt(b, 10, server.Ping)
+	b = appendTime(b, 11, server.DeadSince)
+	b = appendInt(b, 12, server.PeakPlayers)
+	b = appendInt(b, 13, server.PeakPlayers24h)
+	b = appendBool(b, 14, server.Featured)
+	b = appendBool(b, 15, server.OpenMP)
+	b = appendBool(b, 16, server.SuspectFakePlayers)
+	b = appendInt(b, 17, server.ConsecutiveFailures)
+	b = appendInt(b, 18, server.QueryPort)
+	for _, alias := range server.Aliases {
+		b = appendRepeatedString(b, 19, alias)
+	}
+	return b
+}
+
+// MarshalProto encodes the core fields as a ServerCore message, see server.proto
+func (core ServerCore) MarshalProto() []byte {
+	b := appendString(nil, 1, core.Address)
+	b = appendString(b, 2, core.Hostname)
+	b = appendInt(b, 3, core.Players)
+	b = appendInt(b, 4, core.MaxPlayers)
+	b = appendString(b, 5, core.Gamemode)
+	b = appendString(b, 6, core.Language)
+	b = appendBool(b, 7, core.Password)
+	b = appendString(b, 8, core.Version)
+	b = appendString(b, 9, core.HostnameClean)
+	for _, language := range core.Languages {
+		b = appendRepeatedString(b, 10, language)
+	}
+	return b
+}
+
+// AppendProtoList appends a server to an encoded ServerList message. The servers of a list are
+// each a field of the message so a list can be written out one server at a time.
+func AppendProtoList(b []byte, server Server) []byte {
+	return appendMessage(b, 1, server.MarshalProto())
+}
+
+// AppendProtoNext appends the cursor of the next page to an encoded ServerList message
+func AppendProtoNext(b []byte, next string) []byte {
+	return appendString(b, 2, next)
+}
+
+// fields with the default value are left out as they are in proto3
+
+func appendKey(b []byte, field, wire int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendInt(b []byte, field int, v int) []byte {
+	if v == 0 {
+		return b
+	}
+	// negative int32 and int64 values are sign extended to ten bytes
+	return appendVarint(appendKey(b, field, wireVarint), uint64(int64(v)))
+}
+
+func appendBool(b []byte, field int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendVarint(appendKey(b, field, wireVarint), 1)
+}
+
+func appendTime(b []byte, field int, t *time.Time) []byte {
+	if t == nil || t.Unix() == 0 {
+		return b
+	}
+	return appendVarint(appendKey(b, field, wireVarint), uint64(t.Unix()))
+}
+
+func appendString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendRepeatedString(b, field, s)
+}
+
+// appendRepeatedString appends an element of a repeated string field, which unlike a single string
+// is kept even when it's empty
+func appendRepeatedString(b []byte, field int, s string) []byte {
+	b = appendVarint(appendKey(b, field, wireBytes), uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendMessage(b []byte, field int, message []byte) []byte {
+	b = appendVarint(appendKey(b, field, wireBytes), uint64(len(message)))
+	return append(b, message...)
+}
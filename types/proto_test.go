@@ -0,0 +1,127 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the messages of server.proto as protoc-gen-go would generate them, so the hand written encoding
+// is checked against the protobuf library's decoding
+
+type pbServerList struct {
+	Servers []*pbServer `protobuf:"bytes,1,rep,name=servers,proto3"`
+	Next    string      `protobuf:"bytes,2,opt,name=next,proto3"`
+}
+
+type pbServerCore struct {
+	Ip string   `protobuf:"bytes,1,opt,name=ip,proto3"`
+	Hn string   `protobuf:"bytes,2,opt,name=hn,proto3"`
+	Pc int32    `protobuf:"varint,3,opt,name=pc,proto3"`
+	Pm int32    `protobuf:"varint,4,opt,name=pm,proto3"`
+	Gm string   `protobuf:"bytes,5,opt,name=gm,proto3"`
+	La string   `protobuf:"bytes,6,opt,name=la,proto3"`
+	Pa bool     `protobuf:"varint,7,opt,name=pa,proto3"`
+	Vn string   `protobuf:"bytes,8,opt,name=vn,proto3"`
+	Hc string   `protobuf:"bytes,9,opt,name=hc,proto3"`
+	Ls []string `protobuf:"bytes,10,rep,name=ls,proto3"`
+}
+
+type pbServer struct {
+	Core        *pbServerCore     `protobuf:"bytes,1,opt,name=core,proto3"`
+	Ru          map[string]string `protobuf:"bytes,2,rep,name=ru,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Pl          []string          `protobuf:"bytes,3,rep,name=pl,proto3"`
+	Description string            `protobuf:"bytes,4,opt,name=description,proto3"`
+	Banner      string            `protobuf:"bytes,5,opt,name=banner,proto3"`
+	Active      bool              `protobuf:"varint,6,opt,name=active,proto3"`
+	Ls          int64             `protobuf:"varint,7,opt,name=ls,proto3"`
+	On          bool              `protobuf:"varint,8,opt,name=on,proto3"`
+	Co          string            `protobuf:"bytes,9,opt,name=co,proto3"`
+	Pi          int32             `protobuf:"varint,10,opt,name=pi,proto3"`
+	Ds          int64             `protobuf:"varint,11,opt,name=ds,proto3"`
+	Pk          int32             `protobuf:"varint,12,opt,name=pk,proto3"`
+	Pk24        int32             `protobuf:"varint,13,opt,name=pk24,proto3"`
+	Featured    bool              `protobuf:"varint,14,opt,name=featured,proto3"`
+	Om          bool              `protobuf:"varint,15,opt,name=om,proto3"`
+	Fake        bool              `protobuf:"varint,16,opt,name=fake,proto3"`
+	Cf          int32             `protobuf:"varint,17,opt,name=cf,proto3"`
+	Qp          int32             `protobuf:"varint,18,opt,name=qp,proto3"`
+	Aliases     []string          `protobuf:"bytes,19,rep,name=aliases,proto3"`
+}
+
+func (m *pbServerList) Reset()         { *m = pbServerList{} }
+func (m *pbServerList) String() string { return proto.CompactTextString(m) }
+func (*pbServerList) ProtoMessage()    {}
+func (m *pbServerCore) Reset()         { *m = pbServerCore{} }
+func (m *pbServerCore) String() string { return proto.CompactTextString(m) }
+func (*pbServerCore) ProtoMessage()    {}
+func (m *pbServer) Reset()             { *m = pbServer{} }
+func (m *pbServer) String() string     { return proto.CompactTextString(m) }
+func (*pbServer) ProtoMessage()        {}
+
+func TestServer_MarshalProto(t *testing.T) {
+	seen := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	server := Server{}.Example()
+	server.LastSeen = &seen
+	server.Online = true
+	server.Country = "GB"
+	server.Ping = 40
+	server.Active = true
+	server.PeakPlayers = 100
+	server.ConsecutiveFailures = 2
+	server.QueryPort = 7778
+	server.PlayerList = []string{"Southclaws", ""}
+	server.Aliases = []string{"ss.southcla.ws:7777"}
+
+	var got pbServer
+	require.NoError(t, proto.Unmarshal(server.MarshalProto(), &got))
+	assert.Equal(t, pbServer{
+		Core: &pbServerCore{
+			Ip: server.Core.Address,
+			Hn: server.Core.Hostname,
+			Pc: int32(server.Core.Players),
+			Pm: int32(server.Core.MaxPlayers),
+			Gm: server.Core.Gamemode,
+			La: server.Core.Language,
+			Vn: server.Core.Version,
+			Hc: server.Core.HostnameClean,
+			Ls: server.Core.Languages,
+		},
+		Ru:          server.Rules,
+		Pl:          []string{"Southclaws", ""},
+		Description: server.Description,
+		Banner:      server.Banner,
+		Active:      true,
+		Ls:          seen.Unix(),
+		On:          true,
+		Co:          "GB",
+		Pi:          40,
+		Pk:          100,
+		Pk24:        int32(server.PeakPlayers24h),
+		Cf:          2,
+		Qp:          7778,
+		Aliases:     []string{"ss.southcla.ws:7777"},
+	}, got)
+}
+
+func TestAppendProtoList(t *testing.T) {
+	var b []byte
+	b = AppendProtoList(b, Server{Core: ServerCore{Address: "a.example.com:7777", Players: 10}})
+	b = AppendProtoList(b, Server{Core: ServerCore{Address: "b.example.com:7777", Password: true}})
+	b = AppendProtoNext(b, "cursor")
+
+	var got pbServerList
+	require.NoError(t, proto.Unmarshal(b, &got))
+	assert.Equal(t, pbServerList{
+		Servers: []*pbServer{
+			{Core: &pbServerCore{Ip: "a.example.com:7777", Pc: 10}},
+			{Core: &pbServerCore{Ip: "b.example.com:7777", Pa: true}},
+		},
+		Next: "cursor",
+	}, got)
+
+	assert.Empty(t, AppendProtoNext(nil, ""))
+}
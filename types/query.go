@@ -1,9 +1,13 @@
 package types
 
 import (
+	"encoding/base64"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/dyninc/qstring"
+	"github.com/pkg/errors"
 )
 
 // -
@@ -16,6 +20,27 @@ type PageSize int
 // PageSizeDefault controls the default page size of listings
 const PageSizeDefault PageSize = 5000
 
+// LimitDefault controls the default page size of cursor-based listings
+const LimitDefault = 50
+
+// LimitMax is the largest page size of cursor-based listings, larger limits are clamped to this
+const LimitMax = 500
+
+// EncodeCursor creates an opaque cursor that points to the position after the given address
+func EncodeCursor(address string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(address))
+}
+
+// DecodeCursor returns the address that a cursor created by EncodeCursor points after
+func DecodeCursor(cursor string) (address string, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		err = errors.Wrap(err, "invalid cursor")
+		return
+	}
+	return string(b), nil
+}
+
 // -
 // Sorting
 // -
@@ -35,6 +60,58 @@ const SortDesc SortOrder = "desc"
 // ByPlayers means the list will use the amount of players as a sort key
 const ByPlayers SortColumn = "player"
 
+// ByPing means the list will use the most recently measured ping as a sort key
+const ByPing SortColumn = "ping"
+
+// ByName means the list will use the hostname as a sort key
+const ByName SortColumn = "name"
+
+// SortKeys lists the keys accepted by the sort parameter in addition to the asc and desc orders,
+// each of them sorts ascending unless it's prefixed with "-"
+var SortKeys = []string{"players", "ping", "name"}
+
+var sortKeyColumns = map[string]SortColumn{
+	"players": ByPlayers,
+	"ping":    ByPing,
+	"name":    ByName,
+}
+
+// Order returns the column a page-based listing is sorted by and whether the order is descending.
+// The sort parameter is either a sort key such as "-players", in which case By is ignored, or one
+// of the asc and desc orders which are applied to By. Listings are sorted by players, descending,
+// by default.
+func (slp ServerListParams) Order() (by SortColumn, desc bool, err error) {
+	switch slp.Sort {
+	case "", SortDesc:
+		desc = true
+	case SortAsc:
+		desc = false
+	default:
+		key := strings.TrimPrefix(string(slp.Sort), "-")
+		by, ok := sortKeyColumns[key]
+		if !ok {
+			options := make([]string, 0, len(SortKeys)*2+2)
+			for _, key := range SortKeys {
+				options = append(options, key, "-"+key)
+			}
+			options = append(options, string(SortAsc), string(SortDesc))
+			err = errors.Errorf("invalid 'sort' argument '%s', must be one of: %s", slp.Sort, strings.Join(options, ", "))
+			return "", false, err
+		}
+		return by, key != string(slp.Sort), nil
+	}
+
+	switch slp.By {
+	case "", ByPlayers:
+		by = ByPlayers
+	case ByPing, ByName:
+		by = slp.By
+	default:
+		err = errors.Errorf("invalid 'by' argument '%s', must be one of: %s, %s, %s", slp.By, ByPlayers, ByPing, ByName)
+	}
+	return
+}
+
 // -
 // Filtering
 // -
@@ -55,13 +132,115 @@ const FilterFull FilterAttribute = "full"
 // URL Query
 // -
 
-// ServerListParams represents the URL query parameters for server listing
+// ServerListParams represents the URL query parameters for server listing. When either Limit or
+// Cursor are set, the listing is paginated by cursor and ordered by address instead of by page.
+//
+// Gamemode matches servers containing the value regardless of case. Language is normalised in the
+// same way as the language of each server, see NormalizeLanguage, and matches servers that list any
+// of the languages it names so "en", "eng" and "English" all match "English/Russian". Version matches
+// servers whose version starts with the value so 0.3.7 matches 0.3.7-R2 but not 0.3.71, and
+// Password, when "true" or "false", matches servers with or without a password. Fork set to
+// "openmp" or "samp" matches servers running open.mp or the original SA:MP server. Empty values are ignored. When
+// combined with each other and with Filters, a server must match all of them to be listed.
+//
+// IncludePassworded defaults to "true", setting it to "false" hides passworded servers. It's only a
+// default for browsers, so when Password is set it takes precedence and IncludePassworded is ignored.
+//
+// Dead servers, ones that have stopped responding and are waiting to be removed, are only listed
+// when IncludeDead is "true".
+//
+// Featured set to "first" lists featured servers before the rest, each group in the usual order. It
+// only applies to page-based listings since cursor-based ones are always ordered by address.
 type ServerListParams struct {
 	Page     int
 	PageSize PageSize
 	Sort     SortOrder
 	By       SortColumn
 	Filters  []FilterAttribute
+	Full     bool
+	Limit    int
+	Cursor   string
+	Gamemode string
+	Language string
+	Version  string
+	Password string
+	Fork     string
+
+	IncludePassworded string `qstring:"includePassworded"`
+	IncludeDead       string `qstring:"includeDead"`
+	Featured          string
+}
+
+// IncludesDead returns whether dead servers are listed
+func (slp ServerListParams) IncludesDead() (include bool, err error) {
+	if slp.IncludeDead == "" {
+		return false, nil
+	}
+	include, err = strconv.ParseBool(slp.IncludeDead)
+	if err != nil {
+		return false, errors.Errorf("invalid 'includeDead' argument '%s'", slp.IncludeDead)
+	}
+	return
+}
+
+// FeaturedOrder is the only accepted value of the featured parameter
+const FeaturedOrder = "first"
+
+// FeaturedFirst returns whether featured servers are listed before the rest
+func (slp ServerListParams) FeaturedFirst() (first bool, err error) {
+	switch slp.Featured {
+	case "":
+		return false, nil
+	case FeaturedOrder:
+		return true, nil
+	}
+	return false, errors.Errorf("invalid 'featured' argument '%s', must be %s", slp.Featured, FeaturedOrder)
+}
+
+// PasswordFilter returns whether listed servers must have a password, or nil if servers are listed
+// either way. The exact Password parameter takes precedence over IncludePassworded.
+func (slp ServerListParams) PasswordFilter() (password *bool, err error) {
+	if slp.Password != "" {
+		p, err := strconv.ParseBool(slp.Password)
+		if err != nil {
+			return nil, errors.Errorf("invalid 'password' argument '%s'", slp.Password)
+		}
+		return &p, nil
+	}
+
+	if slp.IncludePassworded != "" {
+		include, err := strconv.ParseBool(slp.IncludePassworded)
+		if err != nil {
+			return nil, errors.Errorf("invalid 'includePassworded' argument '%s'", slp.IncludePassworded)
+		}
+		if !include {
+			password = new(bool)
+		}
+	}
+	return
+}
+
+// Paginated returns true if the listing uses cursor-based pagination
+func (slp ServerListParams) Paginated() bool {
+	return slp.Limit != 0 || slp.Cursor != ""
+}
+
+// ClampedLimit returns the cursor page size with the default and maximum applied
+func (slp ServerListParams) ClampedLimit() int {
+	if slp.Limit <= 0 {
+		return LimitDefault
+	}
+	if slp.Limit > LimitMax {
+		return LimitMax
+	}
+	return slp.Limit
+}
+
+// ServerPage represents a single page of a cursor-based server listing, Next is empty on the last
+// page and otherwise contains the cursor to pass to retrieve the following page.
+type ServerPage struct {
+	Servers []interface{} `json:"servers" xml:"servers>server"`
+	Next    string        `json:"next" xml:"next"`
 }
 
 // Example returns an example of ServerListParams in url.Values format
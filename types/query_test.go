@@ -0,0 +1,135 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+	}{
+		{"ip", "192.168.1.2:7777"},
+		{"hostname", "ss.southcla.ws"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAddress, err := DecodeCursor(EncodeCursor(tt.address))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.address, gotAddress)
+		})
+	}
+
+	_, err := DecodeCursor("not base64!")
+	assert.Error(t, err)
+}
+
+func TestServerListParams_ClampedLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{"default", 0, LimitDefault},
+		{"negative", -1, LimitDefault},
+		{"within", 100, 100},
+		{"max", LimitMax, LimitMax},
+		{"clamped", LimitMax + 1, LimitMax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ServerListParams{Limit: tt.limit}.ClampedLimit())
+		})
+	}
+}
+
+func TestServerListParams_Order(t *testing.T) {
+	tests := []struct {
+		name     string
+		sort     SortOrder
+		by       SortColumn
+		wantBy   SortColumn
+		wantDesc bool
+		wantErr  bool
+	}{
+		{"default", "", "", ByPlayers, true, false},
+		{"asc", SortAsc, "", ByPlayers, false, false},
+		{"desc by name", SortDesc, ByName, ByName, true, false},
+		{"players", "players", "", ByPlayers, false, false},
+		{"-players", "-players", "", ByPlayers, true, false},
+		{"ping", "ping", "", ByPing, false, false},
+		{"-name", "-name", "", ByName, true, false},
+		{"key ignores by", "name", ByPing, ByName, false, false},
+		{"unknown key", "-mapname", "", "", false, true},
+		{"unknown by", SortAsc, "mapname", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBy, gotDesc, err := ServerListParams{Sort: tt.sort, By: tt.by}.Order()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBy, gotBy)
+			assert.Equal(t, tt.wantDesc, gotDesc)
+		})
+	}
+}
+
+func TestServerListParams_PasswordFilter(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name              string
+		password          string
+		includePassworded string
+		want              *bool
+		wantErr           bool
+	}{
+		{"default", "", "", nil, false},
+		{"include", "", "true", nil, false},
+		{"exclude", "", "false", &no, false},
+		{"password", "true", "", &yes, false},
+		{"password takes precedence", "true", "false", &yes, false},
+		{"invalid password", "maybe", "", nil, true},
+		{"invalid include", "", "maybe", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ServerListParams{Password: tt.password, IncludePassworded: tt.includePassworded}.PasswordFilter()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServerListParams_FeaturedFirst(t *testing.T) {
+	tests := []struct {
+		name     string
+		featured string
+		want     bool
+		wantErr  bool
+	}{
+		{"default", "", false, false},
+		{"first", "first", true, false},
+		{"invalid", "last", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ServerListParams{Featured: tt.featured}.FeaturedFirst()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
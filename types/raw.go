@@ -0,0 +1,32 @@
+package types
+
+import "time"
+
+// RawQuery is the payload of a server's response to a single query packet, with the header that
+// echoes the request stripped off. The payload is hex encoded so it can be inspected byte by byte.
+type RawQuery struct {
+	Address string `json:"address"`
+	Opcode  string `json:"opcode"`
+	Length  int    `json:"length"`
+	Payload string `json:"payload"`
+}
+
+// Example returns an example of RawQuery
+func (raw RawQuery) Example() RawQuery {
+	return RawQuery{
+		Address: "ss.southcla.ws:7777",
+		Opcode:  "c",
+		Length:  17,
+		Payload: "01000a536f757468636c61777339050000",
+	}
+}
+
+// ParseFailure is a response from a server that couldn't be parsed, Reason is one of bad_magic,
+// truncated_string or length_overflow and Error describes exactly what was wrong with it.
+type ParseFailure struct {
+	Address string    `json:"address"`
+	Opcode  string    `json:"opcode"`
+	Reason  string    `json:"reason"`
+	Error   string    `json:"error"`
+	Time    time.Time `json:"time"`
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"github.com/pkg/errors"
+)
+
+// RCONCommand is a console command to run on a server along with the server's RCON password
+type RCONCommand struct {
+	Password string `json:"password"`
+	Command  string `json:"command"`
+}
+
+// RCONOutput is the output of an RCON command, with a line for each response the server sent
+type RCONOutput struct {
+	Output string `json:"output"`
+}
+
+// Validate checks the password and command are both present
+func (rcon RCONCommand) Validate() (errs []error) {
+	if rcon.Password == "" {
+		errs = append(errs, errors.New("password is empty"))
+	}
+	if rcon.Command == "" {
+		errs = append(errs, errors.New("command is empty"))
+	}
+	return
+}
+
+// Example returns an example of RCONCommand
+func (rcon RCONCommand) Example() RCONCommand {
+	return RCONCommand{
+		Password: "changeme",
+		Command:  "players",
+	}
+}
+
+// Example returns an example of RCONOutput
+func (rcon RCONOutput) Example() RCONOutput {
+	return RCONOutput{
+		Output: "ID\tName\tPing\tIP\n0\tSouthclaws\t48\t127.0.0.1",
+	}
+}
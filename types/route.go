@@ -3,6 +3,7 @@ package types
 import (
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // Route represents an API route and its associated handler function
@@ -14,7 +15,15 @@ type Route struct {
 	Params      url.Values       `json:"params"`
 	Accepts     interface{}      `json:"accepts"`
 	Returns     interface{}      `json:"returns"`
+	Limited     bool             `json:"limited"`     // rate limited for every method since requests are costly
+	Admin       bool             `json:"admin"`       // requires one of the configured admin keys
+	KeyRequired bool             `json:"keyRequired"` // requires an API key even if the rest of the API doesn't
+	Sensitive   bool             `json:"-"`           // responds with something that mustn't be stored, so it's never replayed
 	Handler     http.HandlerFunc `json:"-"`
+
+	// Timeout overrides the configured request timeout for routes that need longer, a negative
+	// timeout disables it for routes that stream their response
+	Timeout time.Duration `json:"-"`
 }
 
 // RouteHandler represents an version group of API endpoints
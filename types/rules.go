@@ -0,0 +1,51 @@
+package types
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// MaxRules is the maximum number of rules a posted server may have
+	MaxRules = 50
+	// MaxRuleLength is the maximum length in bytes of a rule name or value
+	MaxRuleLength = 256
+)
+
+// validateRules checks the size of a rules map and the length of each rule, rules are returned in
+// name order so the errors are always in the same order for the same input.
+func validateRules(rules map[string]string) (errs []error) {
+	if len(rules) > MaxRules {
+		return []error{errors.Errorf("server has %d rules, the maximum is %d", len(rules), MaxRules)}
+	}
+
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if len(name) > MaxRuleLength {
+			errs = append(errs, errors.Errorf("rule name %q exceeds %d bytes", name[:32]+"...", MaxRuleLength))
+		}
+		if len(rules[name]) > MaxRuleLength {
+			errs = append(errs, errors.Errorf("rule %q value exceeds %d bytes", name, MaxRuleLength))
+		}
+	}
+
+	return
+}
+
+// stripControl removes ASCII control characters from a string, these have no meaning in SA:MP rules
+// and are only ever present in data that didn't come from a real server.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,15 @@
+package types
+
+// SearchResult represents a server that matched a search query along with its relevance score
+type SearchResult struct {
+	ServerCore
+	Score int `json:"_score"`
+}
+
+// Example returns an example of SearchResult
+func (sr SearchResult) Example() SearchResult {
+	return SearchResult{
+		ServerCore: Server{}.Example().Core,
+		Score:      3,
+	}
+}
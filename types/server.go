@@ -1,34 +1,210 @@
 package types
 
 import (
-	"github.com/pkg/errors"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net"
+	"strconv"
+	"time"
+	"unicode/utf8"
 )
 
+// MaxHostnameLength is the maximum length in characters of a posted hostname, SA:MP servers cap
+// their hostnames at around 50 characters so this leaves a little headroom
+var MaxHostnameLength = 64
+
 // Server contains all the information associated with a game server including the core information, the standard SA:MP
 // "rules" and "players" lists as well as any additional fields to enhance the server browsing experience.
 type Server struct {
-	Core        ServerCore        `json:"core"`
-	Rules       map[string]string `json:"ru,omitempty"`
-	Description string            `json:"description"`
-	Banner      string            `json:"banner"`
-	Active      bool              `json:"active"`
+	Core        ServerCore        `json:"core" xml:"core"`
+	Rules       map[string]string `json:"ru,omitempty" xml:"-"`
+	PlayerList  []string          `json:"pl,omitempty" xml:"-"`
+	Description string            `json:"description" xml:"description"`
+	Banner      string            `json:"banner" xml:"banner"`
+	Active      bool              `json:"active" xml:"active"`
+	LastSeen    *time.Time        `json:"ls,omitempty" xml:"ls,omitempty"`
+	Online      bool              `json:"on,omitempty" xml:"on,omitempty"`
+	Country     string            `json:"co,omitempty" xml:"co,omitempty"`
+	Ping        int               `json:"pi,omitempty" xml:"pi,omitempty"`
+	DeadSince   *time.Time        `json:"ds,omitempty" xml:"ds,omitempty"`
+	Raw         *RawStrings       `json:"raw,omitempty" xml:"-"`
+
+	// PeakPlayers and PeakPlayers24h are maintained by the poller from the recorded player samples.
+	PeakPlayers    int `json:"pk,omitempty" xml:"pk,omitempty"`
+	PeakPlayers24h int `json:"pk24,omitempty" xml:"pk24,omitempty"`
+
+	// Featured is set by admins to promote a server, like the peaks it's never taken from a posted
+	// server.
+	Featured bool `json:"featured,omitempty" xml:"featured,omitempty"`
+
+	// OpenMP is set when the server is running open.mp rather than the original SA:MP server, it's
+	// inferred from the rules by DetectOpenMP.
+	OpenMP bool `json:"om,omitempty" xml:"om,omitempty"`
+
+	// SuspectFakePlayers is set when the player list of the server, as it was last queried, had
+	// duplicate names in it, which is a sign of bots inflating the player count. The duplicates are
+	// removed from the player list.
+	SuspectFakePlayers bool `json:"fake,omitempty" xml:"fake,omitempty"`
+
+	// ConsecutiveFailures counts the polls in a row the server failed to respond to, it's reset by
+	// the next successful query. The poller removes a server once it reaches MaxPollFailures.
+	ConsecutiveFailures int `json:"cf,omitempty" xml:"cf,omitempty"`
+
+	// QueryPort is the port the server answers queries on when it's not the game port in its
+	// address, which some hosts do. It's left zero otherwise, see NormalizeQueryPort.
+	QueryPort int `json:"qp,omitempty" xml:"qp,omitempty"`
+
+	// Aliases are the other addresses the server has been submitted under, each of which resolves
+	// to the address in Core.
+	Aliases []string `json:"aliases,omitempty" xml:"-"`
+
+	// ContentHash is the Hash of the server when it was last stored in full, it's cleared whenever
+	// part of the server is updated on its own so that it's only set while it's accurate.
+	ContentHash string `json:"-" xml:"-"`
+}
+
+// RawStrings holds the bytes of any strings in a server's query responses that weren't valid UTF-8,
+// exactly as they were received, so that their conversion to UTF-8 can be audited. Strings that
+// were already valid are left out. They're only part of JSON responses since XML can't hold
+// arbitrary bytes.
+type RawStrings struct {
+	Hostname []byte            `json:"hostname,omitempty"`
+	Gamemode []byte            `json:"gamemode,omitempty"`
+	Language []byte            `json:"language,omitempty"`
+	Rules    map[string][]byte `json:"rules,omitempty"`
 }
 
-// Validate checks the contents of a Server object to ensure all the required fields are valid.
+// Hash returns a hash of everything about the server except when it was last seen, its ping and
+// the fields the API maintains itself such as the peaks and featured status, so two servers with
+// the same hash only differ by things that change on every query or that storing doesn't replace.
+func (server Server) Hash() string {
+	server.LastSeen = nil
+	server.Online = false
+	server.Ping = 0
+	server.DeadSince = nil
+	server.ConsecutiveFailures = 0
+	server.Active = false
+	server.PeakPlayers = 0
+	server.PeakPlayers24h = 0
+	server.Featured = false
+	server.ContentHash = ""
+
+	// maps are encoded with sorted keys so the encoding of equal servers is always the same
+	content, _ := json.Marshal(server) // nolint:errcheck
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// MarkSeen records a successful query of the server at the given time, a dead server is revived
+func (server *Server) MarkSeen(now time.Time) {
+	server.LastSeen = &now
+	server.Online = true
+	server.DeadSince = nil
+	server.ConsecutiveFailures = 0
+}
+
+// CheckOnline marks the server as offline if it has not been successfully queried within threshold,
+// the ping of an offline server is meaningless so it's cleared too.
+func (server *Server) CheckOnline(now time.Time, threshold time.Duration) {
+	server.Online = server.LastSeen != nil && now.Sub(*server.LastSeen) <= threshold
+	if !server.Online {
+		server.Ping = 0
+	}
+}
+
+// PeakOf returns the highest player count of any sample, or zero if there are none. Downsampled
+// samples are averages so they're rounded to the nearest player.
+func PeakOf(samples []PlayerSample) (peak int) {
+	for _, sample := range samples {
+		if players := int(math.Round(sample.Players)); players > peak {
+			peak = players
+		}
+	}
+	return
+}
+
+// NormalizeQueryPort clears the query port if it's the same as the game port so that it's only
+// stored and listed when the two differ, the address must already be normalised.
+func (server *Server) NormalizeQueryPort() {
+	_, port, err := net.SplitHostPort(server.Core.Address)
+	if err == nil && port == strconv.Itoa(server.QueryPort) {
+		server.QueryPort = 0
+	}
+}
+
+// AddAliases records other addresses of the server, addresses that are already known or are the
+// server's own address are ignored
+func (server *Server) AddAliases(aliases ...string) {
+	for _, alias := range aliases {
+		if alias == server.Core.Address {
+			continue
+		}
+		known := false
+		for _, existing := range server.Aliases {
+			if existing == alias {
+				known = true
+				break
+			}
+		}
+		if !known {
+			server.Aliases = append(server.Aliases, alias)
+		}
+	}
+}
+
+// HidePrivate clears the player list of passworded servers, SA:MP doesn't list the players of a
+// passworded server to outsiders and neither should the API, regardless of what was posted.
+func (server *Server) HidePrivate() {
+	if server.Core.Password {
+		server.PlayerList = nil
+	}
+}
+
+// Validate checks the contents of a Server object to ensure all the required fields are valid, the
+// player counts and hostname length must be within what SA:MP allows. Rule values also have any control characters
+// stripped out. Each error is a ValidationError naming the field it's about.
 func (server *Server) Validate() (errs []error) {
 	_, addrErrs := AddressFromString(server.Core.Address)
-	errs = append(errs, addrErrs...)
+	errs = append(errs, fieldErrors("address", addrErrs)...)
+
+	switch {
+	case len(server.Core.Hostname) < 1:
+		errs = append(errs, invalidField("hostname", "hostname is empty"))
+	case !utf8.ValidString(server.Core.Hostname):
+		errs = append(errs, invalidField("hostname", "hostname is not valid UTF-8"))
+	case utf8.RuneCountInString(server.Core.Hostname) > MaxHostnameLength:
+		errs = append(errs, invalidField("hostname", "hostname exceeds %d characters", MaxHostnameLength))
+	}
 
-	if len(server.Core.Hostname) < 1 {
-		errs = append(errs, errors.New("hostname is empty"))
+	switch {
+	case server.Core.MaxPlayers == 0:
+		errs = append(errs, invalidField("maxplayers", "maxplayers is empty"))
+	case server.Core.MaxPlayers < 0:
+		errs = append(errs, invalidField("maxplayers", "maxplayers %d is negative", server.Core.MaxPlayers))
+	case server.Core.MaxPlayers > maxPlayerSlots:
+		errs = append(errs, invalidField("maxplayers", "maxplayers %d exceeds the maximum of %d", server.Core.MaxPlayers, maxPlayerSlots))
 	}
 
-	if server.Core.MaxPlayers == 0 {
-		errs = append(errs, errors.New("maxplayers is empty"))
+	if server.Core.Players < 0 {
+		errs = append(errs, invalidField("players", "players %d is negative", server.Core.Players))
+	} else if server.Core.MaxPlayers > 0 && server.Core.Players > server.Core.MaxPlayers {
+		errs = append(errs, invalidField("players", "players %d exceeds maxplayers %d", server.Core.Players, server.Core.MaxPlayers))
 	}
 
 	if len(server.Core.Gamemode) < 1 {
-		errs = append(errs, errors.New("gamemode is empty"))
+		errs = append(errs, invalidField("gamemode", "gamemode is empty"))
+	}
+
+	if server.QueryPort != 0 && !validPort(server.QueryPort) {
+		errs = append(errs, invalidField("queryport", "queryport %d falls within reserved or ephemeral range", server.QueryPort))
+	}
+
+	errs = append(errs, fieldErrors("rules", validateRules(server.Rules))...)
+	errs = append(errs, fieldErrors("playerlist", validatePlayers(server.PlayerList, server.Core.MaxPlayers))...)
+	for name, value := range server.Rules {
+		server.Rules[name] = stripControl(value)
 	}
 
 	return
@@ -39,13 +215,16 @@ func (server Server) Example() Server {
 	return Server{
 		Core: ServerCore{
 			Address:    "127.0.0.1:7777",
-			Hostname:   "SA-MP SERVER CLAN tdm [NGRP] [GF EDIT] [Y_INI] [RUS] [BASIC] [GODFATHER] [REFUNDING] [STRCMP]",
+			Hostname:   "SA-MP SERVER CLAN tdm [NGRP] [GF EDIT] [Y_INI] [RUS]",
 			Players:    32,
 			MaxPlayers: 128,
 			Gamemode:   "Grand Larceny",
 			Language:   "English",
 			Password:   false,
 			Version:    "0.3.7-R2",
+
+			HostnameClean: "SA-MP SERVER CLAN tdm [NGRP] [GF EDIT] [Y_INI] [RUS]",
+			Languages:     []string{"English"},
 		},
 		Rules: map[string]string{
 			"lagcomp":   "On",
@@ -55,8 +234,16 @@ func (server Server) Example() Server {
 			"weburl":    "www.sa-mp.com",
 			"worldtime": "10:00",
 		},
+		PlayerList: []string{
+			"Southclaws",
+			"Y_Less",
+			"Zeex",
+		},
 		Description: "An awesome server! Come and play with us.",
 		Banner:      "https://i.imgur.com/Juaezhv.jpg",
 		Active:      true,
+
+		PeakPlayers:    97,
+		PeakPlayers24h: 45,
 	}
 }
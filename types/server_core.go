@@ -3,12 +3,20 @@ package types
 // ServerCore stores the standard SA:MP 'info' query fields necessary for server lists. The json keys are short to cut down on
 // network traffic since these are the objects returned to a listing request which could contain hundreds of objects.
 type ServerCore struct {
-	Address    string `json:"ip"`
-	Hostname   string `json:"hn"`
-	Players    int    `json:"pc"`
-	MaxPlayers int    `json:"pm"`
-	Gamemode   string `json:"gm"`
-	Language   string `json:"la"`
-	Password   bool   `json:"pa"`
-	Version    string `json:"vn"`
+	Address    string `json:"ip" xml:"ip"`
+	Hostname   string `json:"hn" xml:"hn"`
+	Players    int    `json:"pc" xml:"pc"`
+	MaxPlayers int    `json:"pm" xml:"pm"`
+	Gamemode   string `json:"gm" xml:"gm"`
+	Language   string `json:"la" xml:"la"`
+	Password   bool   `json:"pa" xml:"pa"`
+	Version    string `json:"vn" xml:"vn"`
+
+	// HostnameClean is the hostname without colour codes or control characters, from CleanHostname,
+	// for browsers that would rather not display them.
+	HostnameClean string `json:"hc,omitempty" xml:"hc,omitempty"`
+
+	// Languages are the languages listed in Language, from NormalizeLanguage, for browsers that
+	// offer a language filter.
+	Languages []string `json:"lg,omitempty" xml:"lg,omitempty"`
 }
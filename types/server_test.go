@@ -0,0 +1,318 @@
+package types
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_CheckOnline(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Minute)
+	stale := now.Add(-time.Hour)
+	tests := []struct {
+		name       string
+		lastSeen   *time.Time
+		wantOnline bool
+		wantPing   int
+	}{
+		{"recent", &recent, true, 40},
+		{"stale", &stale, false, 0},
+		{"never seen", nil, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := Server{LastSeen: tt.lastSeen, Online: true, Ping: 40}
+			server.CheckOnline(now, time.Minute*30)
+			assert.Equal(t, tt.wantOnline, server.Online)
+			assert.Equal(t, tt.wantPing, server.Ping)
+		})
+	}
+}
+
+func TestServer_HidePrivate(t *testing.T) {
+	tests := []struct {
+		name           string
+		password       bool
+		wantPlayerList []string
+	}{
+		{"public", false, []string{"Southclaws"}},
+		{"passworded", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := Server{Core: ServerCore{Password: tt.password}, PlayerList: []string{"Southclaws"}}
+			server.HidePrivate()
+			assert.Equal(t, tt.wantPlayerList, server.PlayerList)
+		})
+	}
+}
+
+func TestPeakOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []PlayerSample
+		want    int
+	}{
+		{"none", nil, 0},
+		{"raw", []PlayerSample{{Players: 4}, {Players: 12}, {Players: 7}}, 12},
+		{"averaged", []PlayerSample{{Players: 3.4}, {Players: 5.6}}, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PeakOf(tt.samples))
+		})
+	}
+}
+
+func TestServer_Validate(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxRules; i++ {
+		tooMany[strings.Repeat("r", i+1)] = "value"
+	}
+	tests := []struct {
+		name      string
+		rules     map[string]string
+		wantRules map[string]string
+		wantErrs  []string
+	}{
+		{"valid", map[string]string{"mapname": "San Andreas", "weather": "10"}, map[string]string{"mapname": "San Andreas", "weather": "10"}, nil},
+		{"valid control characters", map[string]string{"mapname": "San\x00 Andreas\n"}, map[string]string{"mapname": "San Andreas"}, nil},
+		{"invalid too many", tooMany, nil, []string{"server has 51 rules, the maximum is 50"}},
+		{"invalid value", map[string]string{"weburl": strings.Repeat("a", 257)}, nil, []string{`rule "weburl" value exceeds 256 bytes`}},
+		{"invalid name", map[string]string{strings.Repeat("a", 257): "value"}, nil, []string{`rule name "` + strings.Repeat("a", 32) + `..." exceeds 256 bytes`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := Server{}.Example()
+			server.Rules = tt.rules
+
+			var gotErrs []string
+			for _, err := range server.Validate() {
+				gotErrs = append(gotErrs, err.Error())
+			}
+			assert.Equal(t, tt.wantErrs, gotErrs)
+			if tt.wantErrs == nil {
+				assert.Equal(t, tt.wantRules, server.Rules)
+			}
+		})
+	}
+}
+
+func TestServer_Validate_PlayerList(t *testing.T) {
+	tooMany := make([]string, 129)
+	for i := range tooMany {
+		tooMany[i] = "Player"
+	}
+	manyInvalid := make([]string, 12)
+	var cappedErrs []string
+	for i := range manyInvalid {
+		manyInvalid[i] = "bad-name"
+		if i < MaxPlayerErrors {
+			cappedErrs = append(cappedErrs, fmt.Sprintf(`player %d nickname "bad-name" is invalid`, i))
+		}
+	}
+	cappedErrs = append(cappedErrs, "2 more invalid nicknames")
+	tests := []struct {
+		name     string
+		players  []string
+		wantErrs []string
+	}{
+		{"valid", []string{"Southclaws", "Y_Less", "[HLF]Southclaw", "Mr.Bean", "$money=(1)"}, nil},
+		{"valid empty", nil, nil},
+		{"invalid too many", tooMany, []string{"player list has 129 players, the maximum is 128"}},
+		{"invalid charset", []string{"Southclaws", "bad-name"}, []string{`player 1 nickname "bad-name" is invalid`}},
+		{"invalid length", []string{strings.Repeat("a", 25)}, []string{`player 0 nickname "` + strings.Repeat("a", 25) + `" is invalid`}},
+		{"invalid empty", []string{""}, []string{`player 0 nickname "" is invalid`}},
+		{"invalid capped", manyInvalid, cappedErrs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := Server{}.Example()
+			server.PlayerList = tt.players
+
+			var gotErrs []string
+			for _, err := range server.Validate() {
+				gotErrs = append(gotErrs, err.Error())
+			}
+			assert.Equal(t, tt.wantErrs, gotErrs)
+		})
+	}
+}
+
+func TestServer_Validate_PlayerCounts(t *testing.T) {
+	tests := []struct {
+		name       string
+		players    int
+		maxPlayers int
+		wantErrs   []string
+	}{
+		{"valid", 32, 128, nil},
+		{"valid full", 128, 128, nil},
+		{"valid empty", 0, 1000, nil},
+		{"invalid too many players", 1050, 500, []string{"players 1050 exceeds maxplayers 500"}},
+		{"invalid negative players", -1, 128, []string{"players -1 is negative"}},
+		{"invalid negative maxplayers", 0, -5, []string{"maxplayers -5 is negative"}},
+		{"invalid maxplayers above cap", 10, 1001, []string{"maxplayers 1001 exceeds the maximum of 1000"}},
+		{"invalid missing maxplayers", 10, 0, []string{"maxplayers is empty"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := Server{}.Example()
+			server.PlayerList = nil
+			server.Core.Players = tt.players
+			server.Core.MaxPlayers = tt.maxPlayers
+
+			var gotErrs []string
+			for _, err := range server.Validate() {
+				gotErrs = append(gotErrs, err.Error())
+			}
+			assert.Equal(t, tt.wantErrs, gotErrs)
+		})
+	}
+}
+
+func TestServer_MarshalXML(t *testing.T) {
+	server := Server{
+		Core:       ServerCore{Address: "127.0.0.1:7777", Hostname: "<test>", Players: 2},
+		Rules:      map[string]string{"weather": "10", "mapname": "San Andreas"},
+		PlayerList: []string{"Southclaws", "Y_Less"},
+		Raw:        &RawStrings{Hostname: []byte{0xcf}},
+	}
+
+	b, err := xml.Marshal(server)
+	assert.NoError(t, err)
+	assert.Equal(t, `<Server>`+
+		`<core><ip>127.0.0.1:7777</ip><hn>&lt;test&gt;</hn><pc>2</pc><pm>0</pm><gm></gm><la></la><pa>false</pa><vn></vn></core>`+
+		`<description></description><banner></banner><active>false</active>`+
+		`<ru><rule name="mapname" value="San Andreas"></rule><rule name="weather" value="10"></rule></ru>`+
+		`<pl><player>Southclaws</player><player>Y_Less</player></pl>`+
+		`</Server>`, string(b))
+
+	b, err = xml.Marshal(Server{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "<ru>")
+	assert.NotContains(t, string(b), "<pl>")
+}
+
+func TestServer_AddAliases(t *testing.T) {
+	server := Server{Core: ServerCore{Address: "1.2.3.4:7777"}, Aliases: []string{"a.example.com:7777"}}
+	server.AddAliases("b.example.com:7777", "1.2.3.4:7777", "a.example.com:7777", "b.example.com:7777")
+	assert.Equal(t, []string{"a.example.com:7777", "b.example.com:7777"}, server.Aliases)
+}
+
+func TestServer_Validate_Fields(t *testing.T) {
+	server := Server{}.Example()
+	server.Core.Address = ""
+	server.Core.Hostname = ""
+	server.Core.MaxPlayers = 10
+	server.Core.Players = 11
+	server.Rules = map[string]string{"weburl": strings.Repeat("a", 257)}
+
+	assert.Equal(t, []ValidationError{
+		{Field: "address", Message: "address is empty"},
+		{Field: "hostname", Message: "hostname is empty"},
+		{Field: "players", Message: "players 11 exceeds maxplayers 10"},
+		{Field: "rules", Message: `rule "weburl" value exceeds 256 bytes`},
+	}, ValidationErrors(server.Validate()))
+}
+
+func TestServer_Validate_Hostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostname string
+		want     []ValidationError
+	}{
+		{"valid", "My Server", nil},
+		{"multibyte at the limit", strings.Repeat("ж", MaxHostnameLength), nil},
+		{"empty", "", []ValidationError{{Field: "hostname", Message: "hostname is empty"}}},
+		{"too long", strings.Repeat("a", 200), []ValidationError{{Field: "hostname", Message: "hostname exceeds 64 characters"}}},
+		{"invalid encoding", "My \xff\xfe Server", []ValidationError{{Field: "hostname", Message: "hostname is not valid UTF-8"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := Server{}.Example()
+			server.Core.Hostname = tt.hostname
+			assert.Equal(t, tt.want, ValidationErrors(server.Validate()))
+		})
+	}
+}
+
+func TestServer_NormalizeQueryPort(t *testing.T) {
+	tests := []struct {
+		name      string
+		address   string
+		queryPort int
+		want      int
+	}{
+		{"unset", "1.2.3.4:7777", 0, 0},
+		{"same as game port", "1.2.3.4:7777", 7777, 0},
+		{"different", "1.2.3.4:7777", 7778, 7778},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := Server{Core: ServerCore{Address: tt.address}, QueryPort: tt.queryPort}
+			server.NormalizeQueryPort()
+			assert.Equal(t, tt.want, server.QueryPort)
+		})
+	}
+}
+
+func TestServer_Validate_QueryPort(t *testing.T) {
+	server := Server{}.Example()
+	server.QueryPort = 7778
+	assert.Empty(t, server.Validate())
+
+	server.QueryPort = 80
+	assert.Equal(t, []ValidationError{
+		{Field: "queryport", Message: "queryport 80 falls within reserved or ephemeral range"},
+	}, ValidationErrors(server.Validate()))
+}
+
+func TestServer_Hash(t *testing.T) {
+	server := Server{}.Example()
+	hash := server.Hash()
+	assert.Len(t, hash, 64)
+
+	seen := time.Now()
+	fresh := server
+	fresh.MarkSeen(seen)
+	fresh.Ping = 120
+	fresh.PeakPlayers = 64
+	fresh.Featured = true
+	fresh.ContentHash = hash
+	assert.Equal(t, hash, fresh.Hash(), "freshness and API maintained fields don't change the hash")
+
+	players := server
+	players.Core.Players++
+	assert.NotEqual(t, hash, players.Hash())
+
+	rules := server
+	rules.Rules = map[string]string{"weather": "11"}
+	assert.NotEqual(t, hash, rules.Hash())
+
+	description := server
+	description.Description = "changed"
+	assert.NotEqual(t, hash, description.Hash())
+}
+
+// the short keys of a server and its core fields are both used unprefixed, so they mustn't overlap
+func TestServer_DistinctKeys(t *testing.T) {
+	keys := func(v interface{}) map[string]bool {
+		typ := reflect.TypeOf(v)
+		keys := make(map[string]bool, typ.NumField())
+		for i := 0; i < typ.NumField(); i++ {
+			keys[strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]] = true
+		}
+		return keys
+	}
+	server := keys(Server{})
+	for key := range keys(ServerCore{}) {
+		assert.False(t, server[key], "core key '%s' is also a server key", key)
+	}
+}
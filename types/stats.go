@@ -2,9 +2,13 @@ package types
 
 // Statistics represents a set of simple metrics for the entire listing database
 type Statistics struct {
-	Servers          int     `json:"servers"`
-	Players          int     `json:"players"`
-	PlayersPerServer float32 `json:"players_per_server"`
+	Servers          int            `json:"servers"`
+	Players          int            `json:"players"`
+	PlayersPerServer float32        `json:"players_per_server"`
+	Online           int            `json:"online"`
+	Offline          int            `json:"offline"`
+	Languages        map[string]int `json:"languages"`
+	Gamemodes        map[string]int `json:"gamemodes"`
 }
 
 // Example returns an example of Statistics
@@ -13,5 +17,9 @@ func (s Statistics) Example() Statistics {
 		Servers:          1000,
 		Players:          10000,
 		PlayersPerServer: 10,
+		Online:           950,
+		Offline:          50,
+		Languages:        map[string]int{"English": 600, "Russian": 250, "Polish": 150},
+		Gamemodes:        map[string]int{"Grand Larceny": 700, "rivershell": 300},
 	}
 }
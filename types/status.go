@@ -0,0 +1,31 @@
+package types
+
+import (
+	"time"
+)
+
+// ServerStatus is the least a browser needs to show whether a server is up, for polling many
+// servers without fetching each of them in full
+type ServerStatus struct {
+	Online   bool       `json:"online"`
+	Players  int        `json:"players"`
+	Ping     int        `json:"ping"`
+	LastSeen *time.Time `json:"lastSeen"`
+}
+
+// Status returns the status of a server, the player count and ping are zero while it's offline.
+// The server should already have been marked online or offline with CheckOnline.
+func (server Server) Status() (status ServerStatus) {
+	status = ServerStatus{Online: server.Online, LastSeen: server.LastSeen}
+	if server.Online {
+		status.Players = server.Core.Players
+		status.Ping = server.Ping
+	}
+	return
+}
+
+// Example returns an example of ServerStatus
+func (status ServerStatus) Example() ServerStatus {
+	seen := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	return ServerStatus{Online: true, Players: 32, Ping: 48, LastSeen: &seen}
+}
@@ -0,0 +1,20 @@
+package types
+
+// TrendingServer is a server listed by how quickly its player count is growing, Growth is the
+// percentage its players have grown by since Baseline, the players it had at the start of the window
+type TrendingServer struct {
+	ServerCore
+	Baseline float64 `json:"baseline"`
+	Growth   float64 `json:"growth"`
+}
+
+// Example returns an example of TrendingServer
+func (ts TrendingServer) Example() TrendingServer {
+	core := Server{}.Example().Core
+	core.Players = 30
+	return TrendingServer{
+		ServerCore: core,
+		Baseline:   20,
+		Growth:     50,
+	}
+}
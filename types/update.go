@@ -0,0 +1,27 @@
+package types
+
+// UpdateEvent describes what happened to a server in a ServerUpdate
+type UpdateEvent string
+
+const (
+	// EventUpdate means a server was queried and is still online
+	EventUpdate UpdateEvent = "update"
+	// EventOnline means a server responded to a query after being offline or unknown
+	EventOnline UpdateEvent = "online"
+	// EventOffline means a server that was online stopped responding to queries
+	EventOffline UpdateEvent = "offline"
+)
+
+// ServerUpdate is pushed to live update subscribers each time a server is refreshed
+type ServerUpdate struct {
+	Event   UpdateEvent `json:"event"`
+	Address string      `json:"address"`
+	Players int         `json:"players"`
+	Online  bool        `json:"online"`
+}
+
+// UpdateSubscription is sent by live update subscribers to receive updates for a single server
+// rather than all of them, an empty address goes back to receiving everything.
+type UpdateSubscription struct {
+	Address string `json:"address"`
+}
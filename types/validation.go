@@ -0,0 +1,51 @@
+package types
+
+import (
+	"fmt"
+)
+
+// ValidationError is a problem with a single field of a submitted object. The message is the same
+// one the error has always had, the field is there so that clients can point out which one needs
+// fixing without having to parse the message.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (err ValidationError) Error() string {
+	return err.Message
+}
+
+// invalidField returns a ValidationError for a field from a formatted message
+func invalidField(field, format string, args ...interface{}) ValidationError {
+	return ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// fieldErrors turns errors about a field into ValidationErrors for it
+func fieldErrors(field string, errs []error) (fieldErrs []error) {
+	for _, err := range errs {
+		fieldErrs = append(fieldErrs, ValidationError{Field: field, Message: err.Error()})
+	}
+	return
+}
+
+// ValidationErrors picks out the errors that are about a particular field
+func ValidationErrors(errs []error) (fields []ValidationError) {
+	for _, err := range errs {
+		if field, ok := err.(ValidationError); ok {
+			fields = append(fields, field)
+		}
+	}
+	return
+}
+
+// ValidationResult is the response to a server that passed validation without being stored, servers
+// that fail are rejected with the validation errors instead
+type ValidationResult struct {
+	Valid bool `json:"valid"`
+}
+
+// Example returns an example of ValidationResult
+func (result ValidationResult) Example() ValidationResult {
+	return ValidationResult{Valid: true}
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"time"
+)
+
+// VerboseCore is ServerCore with descriptive json keys in place of the short ones, for developers
+// reading responses in a browser. The fields are the same as ServerCore's, in the same order, so
+// one converts directly to the other.
+type VerboseCore struct {
+	Address    string `json:"address"`
+	Hostname   string `json:"hostname"`
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"maxPlayers"`
+	Gamemode   string `json:"gamemode"`
+	Language   string `json:"language"`
+	Password   bool   `json:"password"`
+	Version    string `json:"version"`
+
+	HostnameClean string   `json:"hostnameClean,omitempty"`
+	Languages     []string `json:"languages,omitempty"`
+}
+
+// VerboseServer is Server with descriptive json keys in place of the short ones, see Verbose
+type VerboseServer struct {
+	Core                VerboseCore       `json:"core"`
+	Rules               map[string]string `json:"rules,omitempty"`
+	PlayerList          []string          `json:"players,omitempty"`
+	Description         string            `json:"description"`
+	Banner              string            `json:"banner"`
+	Active              bool              `json:"active"`
+	LastSeen            *time.Time        `json:"lastSeen,omitempty"`
+	Online              bool              `json:"online,omitempty"`
+	Country             string            `json:"country,omitempty"`
+	Ping                int               `json:"ping,omitempty"`
+	DeadSince           *time.Time        `json:"deadSince,omitempty"`
+	Raw                 *RawStrings       `json:"raw,omitempty"`
+	PeakPlayers         int               `json:"peakPlayers,omitempty"`
+	PeakPlayers24h      int               `json:"peakPlayers24h,omitempty"`
+	Featured            bool              `json:"featured,omitempty"`
+	OpenMP              bool              `json:"openMP,omitempty"`
+	SuspectFakePlayers  bool              `json:"suspectFakePlayers,omitempty"`
+	ConsecutiveFailures int               `json:"consecutiveFailures,omitempty"`
+	QueryPort           int               `json:"queryPort,omitempty"`
+	Aliases             []string          `json:"aliases,omitempty"`
+}
+
+// Verbose returns the core fields with descriptive json keys
+func (core ServerCore) Verbose() VerboseCore {
+	return VerboseCore(core)
+}
+
+// Verbose returns the server with descriptive json keys, the data is exactly the same
+func (server Server) Verbose() VerboseServer {
+	return VerboseServer{
+		Core:                server.Core.Verbose(),
+		Rules:               server.Rules,
+		PlayerList:          server.PlayerList,
+		Description:         server.Description,
+		Banner:              server.Banner,
+		Active:              server.Active,
+		LastSeen:            server.LastSeen,
+		Online:              server.Online,
+		Country:             server.Country,
+		Ping:                server.Ping,
+		DeadSince:           server.DeadSince,
+		Raw:                 server.Raw,
+		PeakPlayers:         server.PeakPlayers,
+		PeakPlayers24h:      server.PeakPlayers24h,
+		Featured:            server.Featured,
+		OpenMP:              server.OpenMP,
+		SuspectFakePlayers:  server.SuspectFakePlayers,
+		ConsecutiveFailures: server.ConsecutiveFailures,
+		QueryPort:           server.QueryPort,
+		Aliases:             server.Aliases,
+	}
+}
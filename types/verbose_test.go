@@ -0,0 +1,28 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Verbose(t *testing.T) {
+	// every field of a server that's part of its JSON has a verbose counterpart
+	serverType, verboseType := reflect.TypeOf(Server{}), reflect.TypeOf(VerboseServer{})
+	for i := 0; i < serverType.NumField(); i++ {
+		field := serverType.Field(i)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+		_, ok := verboseType.FieldByName(field.Name)
+		assert.True(t, ok, "%s has no verbose field", field.Name)
+	}
+
+	server := Server{}.Example()
+	verbose := server.Verbose()
+	assert.Equal(t, server.Core.Address, verbose.Core.Address)
+	assert.Equal(t, server.Core.Languages, verbose.Core.Languages)
+	assert.Equal(t, server.Rules, verbose.Rules)
+	assert.Equal(t, server.PlayerList, verbose.PlayerList)
+}
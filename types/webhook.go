@@ -0,0 +1,122 @@
+package types
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MaxWebhooks is the most webhooks that can be registered for a single server
+var MaxWebhooks = 10
+
+// Webhook is a registration to be sent a WebhookEvent whenever a server goes online or offline. The
+// secret is used to sign each delivery so receivers can check it came from the API, it's generated
+// on registration and only returned in the response to it.
+type Webhook struct {
+	ID      string    `json:"id"`
+	Address string    `json:"address"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// WebhookEvent is the body posted to a webhook URL
+type WebhookEvent struct {
+	ServerUpdate
+	Time time.Time `json:"time"`
+}
+
+// nonPublicNetworks are the ranges PublicIP rejects on top of the loopback, link-local, multicast
+// and unspecified addresses the net package already knows about
+var nonPublicNetworks = func() (networks []*net.IPNet) {
+	for _, cidr := range []string{
+		"0.0.0.0/8",      // this network
+		"10.0.0.0/8",     // private
+		"100.64.0.0/10",  // carrier-grade NAT
+		"172.16.0.0/12",  // private
+		"192.0.0.0/24",   // protocol assignments
+		"192.168.0.0/16", // private
+		"198.18.0.0/15",  // benchmarking
+		"240.0.0.0/4",    // reserved, including broadcast
+		"fc00::/7",       // unique local
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return
+}()
+
+// PublicIP reports whether an IP is reachable on the public internet, webhooks may only be sent to
+// public IPs so they can't be used to reach the API's own network, such as the cloud metadata
+// service at 169.254.169.254
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPublicHost resolves a host and returns an error unless every IP it resolves to is public
+func CheckPublicHost(host string) error {
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		var err error
+		ips, err = lookupIP(host)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve host '%s'", host)
+		}
+	}
+	for _, ip := range ips {
+		if !PublicIP(ip) {
+			return errors.Errorf("host '%s' is not a public address", host)
+		}
+	}
+	return nil
+}
+
+// Validate checks the address and URL of a webhook registration. Hosts that are obviously not
+// public are rejected, hostnames have to be checked with CheckPublicHost as well.
+func (hook Webhook) Validate() (errs []error) {
+	_, addrErrs := AddressFromString(hook.Address)
+	errs = append(errs, addrErrs...)
+
+	if hook.URL == "" {
+		return append(errs, errors.New("url is empty"))
+	}
+	u, err := url.Parse(hook.URL)
+	if err != nil {
+		return append(errs, errors.Wrap(err, "invalid url"))
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		errs = append(errs, errors.Errorf("url scheme must be http or https, not '%s'", u.Scheme))
+	}
+	host := u.Hostname()
+	if host == "" {
+		errs = append(errs, errors.New("url has no host"))
+	} else if ip := net.ParseIP(host); (ip != nil && !PublicIP(ip)) || strings.EqualFold(host, "localhost") {
+		errs = append(errs, errors.Errorf("url host '%s' is not a public address", host))
+	}
+	return
+}
+
+// Example returns an example of Webhook
+func (hook Webhook) Example() Webhook {
+	return Webhook{
+		ID:      "5f0c3f6be07c4b8c9e4a2d7f1b3a6c90",
+		Address: "ss.southcla.ws:7777",
+		URL:     "https://example.com/samp-status",
+		Secret:  "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0",
+		Created: time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
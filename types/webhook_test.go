@@ -0,0 +1,80 @@
+package types
+
+import (
+	"net"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		hook     Webhook
+		wantErrs int
+	}{
+		{"valid", Webhook{Address: "ss.southcla.ws:7777", URL: "https://example.com/hook"}, 0},
+		{"valid http", Webhook{Address: "127.0.0.1", URL: "http://example.com:8080/hook"}, 0},
+		{"no address", Webhook{URL: "https://example.com/hook"}, 1},
+		{"no url", Webhook{Address: "ss.southcla.ws"}, 1},
+		{"bad scheme", Webhook{Address: "ss.southcla.ws", URL: "ftp://example.com/hook"}, 1},
+		{"relative", Webhook{Address: "ss.southcla.ws", URL: "/hook"}, 2},
+		{"nothing", Webhook{}, 2},
+		{"loopback", Webhook{Address: "ss.southcla.ws", URL: "http://127.0.0.1:8080/hook"}, 1},
+		{"localhost", Webhook{Address: "ss.southcla.ws", URL: "http://LocalHost/hook"}, 1},
+		{"private", Webhook{Address: "ss.southcla.ws", URL: "http://192.168.1.2/hook"}, 1},
+		{"metadata", Webhook{Address: "ss.southcla.ws", URL: "http://169.254.169.254/latest/meta-data"}, 1},
+		{"ipv6 loopback", Webhook{Address: "ss.southcla.ws", URL: "http://[::1]/hook"}, 1},
+		{"public ip", Webhook{Address: "ss.southcla.ws", URL: "http://93.184.216.34/hook"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, tt.hook.Validate(), tt.wantErrs)
+		})
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"93.184.216.34":   true,
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"172.32.0.1":      true,
+		"192.168.0.1":     false,
+		"100.64.0.1":      false,
+		"169.254.169.254": false,
+		"0.0.0.0":         false,
+		"255.255.255.255": false,
+		"224.0.0.1":       false,
+		"::1":             false,
+		"::":              false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		assert.Equal(t, want, PublicIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestCheckPublicHost(t *testing.T) {
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "public.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		case "rebind.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("127.0.0.1")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	defer func() { lookupIP = net.LookupIP }()
+
+	assert.NoError(t, CheckPublicHost("public.example.com"))
+	assert.NoError(t, CheckPublicHost("93.184.216.34"))
+	assert.EqualError(t, CheckPublicHost("rebind.example.com"), "host 'rebind.example.com' is not a public address")
+	assert.EqualError(t, CheckPublicHost("10.0.0.1"), "host '10.0.0.1' is not a public address")
+	assert.EqualError(t, CheckPublicHost("nothing.example.com"), "failed to resolve host 'nothing.example.com': no such host")
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// Rule is a single server rule as it's encoded in XML, maps can't be encoded as XML so the rules of
+// a server are written as a list of these instead
+type Rule struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// xmlRules and xmlPlayers wrap the lists so they can be left out entirely when they're empty, which
+// encoding/xml doesn't do for parent>child tags
+type xmlRules struct {
+	Rules []Rule `xml:"rule"`
+}
+
+type xmlPlayers struct {
+	Players []string `xml:"player"`
+}
+
+type xmlAliases struct {
+	Aliases []string `xml:"alias"`
+}
+
+// MarshalXML encodes a server the same way as its struct tags describe except for the rules, which
+// are written as repeated rule elements ordered by name, and the player list, which is written as
+// repeated player elements. Aliases are written as repeated alias elements in the same way.
+func (server Server) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain Server // drops the methods so encoding it doesn't recurse into MarshalXML
+
+	var rules *xmlRules
+	if len(server.Rules) > 0 {
+		rules = &xmlRules{Rules: make([]Rule, 0, len(server.Rules))}
+		for name, value := range server.Rules {
+			rules.Rules = append(rules.Rules, Rule{Name: name, Value: value})
+		}
+		sort.Slice(rules.Rules, func(i, j int) bool { return rules.Rules[i].Name < rules.Rules[j].Name })
+	}
+
+	var players *xmlPlayers
+	if len(server.PlayerList) > 0 {
+		players = &xmlPlayers{Players: server.PlayerList}
+	}
+
+	var aliases *xmlAliases
+	if len(server.Aliases) > 0 {
+		aliases = &xmlAliases{Aliases: server.Aliases}
+	}
+
+	return e.EncodeElement(struct {
+		plain
+		Rules   *xmlRules   `xml:"ru,omitempty"`
+		Players *xmlPlayers `xml:"pl,omitempty"`
+		Aliases *xmlAliases `xml:"aliases,omitempty"`
+	}{plain(server), rules, players, aliases}, start)
+}